@@ -9,7 +9,6 @@ import (
 	"fmt"
 	iofs "io/fs"
 	"net"
-	"net/url"
 	"os"
 	"path/filepath"
 	"time"
@@ -117,21 +116,22 @@ func CreateApp(appoptions *options.App) (*App, error) {
 
 		ctx = context.WithValue(ctx, "frontenddevserverurl", frontendDevServerURL)
 
-		externalURL, err := url.Parse(frontendDevServerURL)
+		// Multiple URLs can be given separated by commas, they are tried in order
+		externalURLs, err := assetserver.ParseFrontendDevServerURLs(frontendDevServerURL)
 		if err != nil {
 			return nil, err
 		}
 
-		if externalURL.Host == "" {
-			return nil, fmt.Errorf("Invalid frontend:dev:serverUrl missing protocol scheme?")
+		hosts := make([]string, len(externalURLs))
+		for i, externalURL := range externalURLs {
+			hosts[i] = externalURL.Host
 		}
-
-		waitCb := func() { myLogger.Debug("Waiting for frontend DevServer '%s' to be ready", externalURL) }
-		if !checkPortIsOpen(externalURL.Host, time.Minute, waitCb) {
+		waitCb := func() { myLogger.Debug("Waiting for frontend DevServer '%s' to be ready", frontendDevServerURL) }
+		if !checkPortIsOpen(hosts, time.Minute, waitCb) {
 			myLogger.Error("Timeout waiting for frontend DevServer")
 		}
 
-		handler := assetserver.NewExternalAssetsHandler(myLogger, assetConfig, externalURL)
+		handler := assetserver.NewExternalAssetsHandler(myLogger, assetConfig, externalURLs...)
 		assetConfig.Assets = nil
 		assetConfig.Handler = handler
 		assetConfig.Middleware = nil
@@ -210,10 +210,17 @@ func CreateApp(appoptions *options.App) (*App, error) {
 		appoptions.OnBeforeClose,
 	}
 	appBindings := binding.NewBindings(myLogger, appoptions.Bind, bindingExemptions, false, appoptions.EnumBind)
+	if err := appBindings.SetCallTimeouts(appoptions.CallTimeouts); err != nil {
+		return nil, err
+	}
+	ctx = context.WithValue(ctx, "bindings", appBindings)
 
 	eventHandler := runtime.NewEvents(myLogger)
 	ctx = context.WithValue(ctx, "events", eventHandler)
 	messageDispatcher := dispatcher.NewDispatcher(ctx, myLogger, appBindings, eventHandler, appoptions.ErrorFormatter)
+	messageDispatcher.UseInterceptors(appoptions.Interceptors)
+	messageDispatcher.UseTelemetry(appoptions.Telemetry)
+	messageDispatcher.UseCallLimits(appoptions.CallLimits)
 
 	// Create the frontends and register to event handler
 	desktopFrontend := desktop.NewFrontend(ctx, appoptions, myLogger, appBindings, messageDispatcher)
@@ -268,17 +275,20 @@ func tryInferAssetDirFromFS(assets iofs.FS) (string, error) {
 	return path, nil
 }
 
-func checkPortIsOpen(host string, timeout time.Duration, waitCB func()) (ret bool) {
+// checkPortIsOpen waits until one of the hosts accepts connections
+func checkPortIsOpen(hosts []string, timeout time.Duration, waitCB func()) (ret bool) {
 	if timeout == 0 {
 		timeout = time.Minute
 	}
 
 	deadline := time.Now().Add(timeout)
 	for time.Now().Before(deadline) {
-		conn, _ := net.DialTimeout("tcp", host, 2*time.Second)
-		if conn != nil {
-			conn.Close()
-			return true
+		for _, host := range hosts {
+			conn, _ := net.DialTimeout("tcp", host, 2*time.Second)
+			if conn != nil {
+				conn.Close()
+				return true
+			}
 		}
 
 		waitCB()
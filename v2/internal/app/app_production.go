@@ -6,7 +6,9 @@ import (
 	"context"
 
 	"github.com/wailsapp/wails/v2/internal/binding"
+	"github.com/wailsapp/wails/v2/internal/frontend"
 	"github.com/wailsapp/wails/v2/internal/frontend/desktop"
+	"github.com/wailsapp/wails/v2/internal/frontend/devserver"
 	"github.com/wailsapp/wails/v2/internal/frontend/dispatcher"
 	"github.com/wailsapp/wails/v2/internal/frontend/runtime"
 	"github.com/wailsapp/wails/v2/internal/logger"
@@ -73,6 +75,10 @@ func CreateApp(appoptions *options.App) (*App, error) {
 		appoptions.OnBeforeClose,
 	}
 	appBindings := binding.NewBindings(myLogger, appoptions.Bind, bindingExemptions, IsObfuscated(), appoptions.EnumBind)
+	if err := appBindings.SetCallTimeouts(appoptions.CallTimeouts); err != nil {
+		return nil, err
+	}
+	ctx = context.WithValue(ctx, "bindings", appBindings)
 	eventHandler := runtime.NewEvents(myLogger)
 	ctx = context.WithValue(ctx, "events", eventHandler)
 	// Attach logger to context
@@ -83,8 +89,22 @@ func CreateApp(appoptions *options.App) (*App, error) {
 	}
 
 	messageDispatcher := dispatcher.NewDispatcher(ctx, myLogger, appBindings, eventHandler, appoptions.ErrorFormatter)
-	appFrontend := desktop.NewFrontend(ctx, appoptions, myLogger, appBindings, messageDispatcher)
-	eventHandler.AddFrontend(appFrontend)
+	messageDispatcher.UseInterceptors(appoptions.Interceptors)
+	messageDispatcher.UseTelemetry(appoptions.Telemetry)
+	messageDispatcher.UseCallLimits(appoptions.CallLimits)
+	desktopFrontend := desktop.NewFrontend(ctx, appoptions, myLogger, appBindings, messageDispatcher)
+	var appFrontend frontend.Frontend = desktopFrontend
+	if appoptions.WebServer.Enabled {
+		// Serve the UI and the websocket IPC to browsers, next to the desktop window
+		addr := appoptions.WebServer.Addr
+		if addr == "" {
+			addr = "localhost:34115"
+		}
+		ctx = context.WithValue(ctx, "devserver", addr)
+		appFrontend = devserver.NewFrontend(ctx, appoptions, myLogger, appBindings, messageDispatcher, menuManager, desktopFrontend)
+		eventHandler.AddFrontend(appFrontend)
+	}
+	eventHandler.AddFrontend(desktopFrontend)
 
 	ctx = context.WithValue(ctx, "frontend", appFrontend)
 	result := &App{
@@ -10,11 +10,14 @@ import (
 	"runtime"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/wailsapp/wails/v2/internal/typescriptify"
 
 	"github.com/leaanthony/slicer"
 	"github.com/wailsapp/wails/v2/internal/logger"
+	"github.com/wailsapp/wails/v2/pkg/options"
 )
 
 type Bindings struct {
@@ -28,6 +31,13 @@ type Bindings struct {
 	tsSuffix            string
 	tsInterface         bool
 	obfuscate           bool
+
+	// defaultTimeout is the timeout of the methods bound after startup, see SetCallTimeouts
+	defaultTimeout time.Duration
+
+	// interfaces are the implementations of the bound interfaces by their name, see SetImplementation
+	interfacesLock sync.RWMutex
+	interfaces     map[string]*implementation
 }
 
 // NewBindings returns a new Bindings object
@@ -38,6 +48,7 @@ func NewBindings(logger *logger.Logger, structPointersToBind []interface{}, exem
 		structsToGenerateTS: make(map[string]map[string]interface{}),
 		enumsToGenerateTS:   make(map[string]map[string]interface{}),
 		obfuscate:           obfuscate,
+		interfaces:          make(map[string]*implementation),
 	}
 
 	for _, exemption := range exemptions {
@@ -65,13 +76,38 @@ func NewBindings(logger *logger.Logger, structPointersToBind []interface{}, exem
 	return result
 }
 
-// Add the given struct methods to the Bindings
+// Add the given struct methods to the Bindings, under its namespace if it is an options.NamespacedBinding,
+// or the methods of the interface of an options.InterfaceBinding.
+// It fails if another struct exposes a method with the same qualified name.
 func (b *Bindings) Add(structPtr interface{}) error {
-	methods, err := b.getMethods(structPtr)
+	namespace := ""
+	if namespaced, ok := structPtr.(options.NamespacedBinding); ok {
+		parts := strings.Split(namespaced.Namespace, ".")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("cannot bind value to app: the namespace '%s' is not of the form 'group.Name'", namespaced.Namespace)
+		}
+		namespace, structPtr = namespaced.Namespace, namespaced.Struct
+	}
+	var methods []*BoundMethod
+	var err error
+	if binding, ok := structPtr.(options.InterfaceBinding); ok {
+		methods, err = b.getInterfaceMethods(binding)
+	} else {
+		methods, err = b.getMethods(structPtr)
+	}
 	if err != nil {
 		return fmt.Errorf("cannot bind value to app: %s", err.Error())
 	}
 
+	for _, method := range methods {
+		if namespace != "" {
+			method.Name = namespace + method.Name[strings.LastIndex(method.Name, "."):]
+		}
+		if existing := b.db.GetMethod(method.Name); existing != nil && existing.origin != method.origin {
+			return fmt.Errorf("cannot bind value to app: '%s' is exposed by both %s and %s, bind one of them with options.BindAs", method.Name, existing.origin, method.origin)
+		}
+	}
+
 	for _, method := range methods {
 		splitName := strings.Split(method.Name, ".")
 		packageName := splitName[0]
@@ -80,6 +116,11 @@ func (b *Bindings) Add(structPtr interface{}) error {
 
 		// Add it as a regular method
 		b.db.AddMethod(packageName, structName, methodName, method)
+		if method.implementation != nil {
+			b.interfacesLock.Lock()
+			b.interfaces[packageName+"."+structName] = method.implementation
+			b.interfacesLock.Unlock()
+		}
 	}
 	return nil
 }
@@ -268,7 +309,7 @@ func (b *Bindings) AddStructToGenerateTS(packageName string, structName string,
 
 	for i := 0; i < structType.NumField(); i++ {
 		field := structType.Field(i)
-		if field.Anonymous {
+		if field.Anonymous || field.Tag.Get("json") == "-" {
 			continue
 		}
 		kind := field.Type.Kind()
@@ -276,7 +317,7 @@ func (b *Bindings) AddStructToGenerateTS(packageName string, structName string,
 			if !field.IsExported() {
 				continue
 			}
-			fqname := field.Type.String()
+			fqname := typescriptify.GenericTypeName(field.Type.String())
 			sNameSplit := strings.Split(fqname, ".")
 			if len(sNameSplit) < 2 {
 				continue
@@ -292,7 +333,7 @@ func (b *Bindings) AddStructToGenerateTS(packageName string, structName string,
 			if !field.IsExported() {
 				continue
 			}
-			fqname := field.Type.Elem().String()
+			fqname := typescriptify.GenericTypeName(field.Type.Elem().String())
 			sNameSplit := strings.Split(fqname, ".")
 			if len(sNameSplit) < 2 {
 				continue
@@ -346,26 +387,14 @@ func (b *Bindings) getAllEnumNames() *slicer.StringSlicer {
 	return &result
 }
 
+// hasExportedJSONFields returns true if the struct has a field with a json tag which is not ignored with `json:"-"`
 func (b *Bindings) hasExportedJSONFields(typeOf reflect.Type) bool {
 	for i := 0; i < typeOf.NumField(); i++ {
-		jsonFieldName := ""
-		f := typeOf.Field(i)
-		jsonTag := f.Tag.Get("json")
-		if len(jsonTag) == 0 {
+		jsonTag := typeOf.Field(i).Tag.Get("json")
+		if len(jsonTag) == 0 || jsonTag == "-" {
 			continue
 		}
-		jsonTagParts := strings.Split(jsonTag, ",")
-		if len(jsonTagParts) > 0 {
-			jsonFieldName = jsonTagParts[0]
-		}
-		for _, t := range jsonTagParts {
-			if t == "-" {
-				continue
-			}
-		}
-		if jsonFieldName != "" {
-			return true
-		}
+		return true
 	}
 	return false
 }
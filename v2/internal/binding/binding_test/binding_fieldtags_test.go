@@ -0,0 +1,45 @@
+package binding_test
+
+type FieldTagsCredentials struct {
+	Token string `json:"token"`
+}
+
+type FieldTags struct {
+	ID          string                `json:"id" wails:"readonly"`
+	Name        string                `json:"displayName,omitempty"`
+	Count       int                   `json:",omitempty"`
+	Password    string                `json:"-"`
+	Credentials *FieldTagsCredentials `json:"-"`
+	internal    string
+}
+
+func (s FieldTags) Get() FieldTags {
+	return s
+}
+
+var FieldTagsTest = BindingTest{
+	name: "FieldTags",
+	structs: []interface{}{
+		&FieldTags{},
+	},
+	exemptions:  nil,
+	shouldError: false,
+	want: `
+export namespace binding_test {
+	export class FieldTags {
+		readonly id: string;
+		displayName?: string;
+		Count?: number;
+		static createFrom(source: any = {}) {
+			return new FieldTags(source);
+		}
+		constructor(source: any = {}) {
+			if ('string' === typeof source) source = JSON.parse(source);
+			this.id = source["id"];
+			this.displayName = source["displayName"];
+			this.Count = source["Count"];
+		}
+	}
+}
+`,
+}
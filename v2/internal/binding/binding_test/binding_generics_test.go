@@ -0,0 +1,106 @@
+package binding_test
+
+import (
+	"io/fs"
+	"os"
+	"testing"
+
+	"github.com/wailsapp/wails/v2/internal/binding"
+	"github.com/wailsapp/wails/v2/internal/logger"
+)
+
+type GenericItem struct {
+	Name string `json:"name"`
+}
+
+type GenericPage[T any] struct {
+	Items []T  `json:"items"`
+	Next  *int `json:"next"`
+}
+
+type GenericPair[K comparable, V any] struct {
+	Key   K `json:"key"`
+	Value V `json:"value"`
+}
+
+type GenericService struct{}
+
+func (s *GenericService) List() GenericPage[GenericItem] {
+	return GenericPage[GenericItem]{}
+}
+
+func (s *GenericService) Pair(p GenericPair[string, int]) *GenericPair[string, GenericItem] {
+	return nil
+}
+
+type GenericRepository[T any] struct {
+	items []T
+}
+
+func (r *GenericRepository[T]) All() []T {
+	return r.items
+}
+
+var GenericsTest = BindingTest{
+	name: "Generics",
+	structs: []interface{}{
+		&GenericService{},
+	},
+	exemptions:  nil,
+	shouldError: false,
+	TsGenerationOptionsTest: TsGenerationOptionsTest{
+		TsOutputType: "interfaces",
+	},
+	want: `
+export namespace binding_test {
+	export interface GenericItem {
+		name: string;
+	}
+	export interface GenericPage_GenericItem {
+		items: GenericItem[];
+		next?: number;
+	}
+	export interface GenericPair_string_GenericItem {
+		key: string;
+		value: GenericItem;
+	}
+	export interface GenericPair_string_int {
+		key: string;
+		value: number;
+	}
+}
+`,
+}
+
+const expectedGenericBindings = `// Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
+// This file is automatically generated. DO NOT EDIT
+import {binding_test} from '../models';
+
+export function List():Promise<binding_test.GenericPage_GenericItem>;
+
+export function Pair(arg1:binding_test.GenericPair_string_int):Promise<binding_test.GenericPair_string_GenericItem>;
+`
+
+func TestGenericBindings(t *testing.T) {
+	generationDir := t.TempDir()
+	testLogger := &logger.Logger{}
+	b := binding.NewBindings(testLogger, []interface{}{&GenericService{}, &GenericRepository[GenericItem]{}}, nil, false, nil)
+
+	// The instantiated generic structs are bound under their monomorphized name
+	if b.DB().GetMethod("binding_test.GenericRepository_GenericItem.All") == nil {
+		t.Fatalf("the method of the generic struct is not bound")
+	}
+
+	err := b.GenerateGoBindings(generationDir)
+	if err != nil {
+		t.Fatalf("could not generate the Go bindings: %v", err)
+	}
+	rawGeneratedBindings, err := fs.ReadFile(os.DirFS(generationDir), "binding_test/GenericService.d.ts")
+	if err != nil {
+		t.Fatalf("could not read the generated bindings: %v", err)
+	}
+	generatedBindings := string(rawGeneratedBindings)
+	if generatedBindings != expectedGenericBindings {
+		t.Fatalf("the generated bindings does not match the expected ones.\nWanted:\n%s\n\nGot:\n%s", expectedGenericBindings, generatedBindings)
+	}
+}
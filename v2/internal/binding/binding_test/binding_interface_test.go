@@ -0,0 +1,75 @@
+package binding_test
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/wailsapp/wails/v2/internal/binding"
+	"github.com/wailsapp/wails/v2/internal/logger"
+	"github.com/wailsapp/wails/v2/pkg/options"
+)
+
+type Greeter interface {
+	Greet(ctx context.Context, name string) (string, error)
+}
+
+type realGreeter struct{}
+
+func (g *realGreeter) Greet(_ context.Context, name string) (string, error) {
+	return "Hello " + name, nil
+}
+
+// Unrelated is not a method of the interface, it is not bound
+func (g *realGreeter) Unrelated() {}
+
+type mockGreeter struct{}
+
+func (g mockGreeter) Greet(_ context.Context, name string) (string, error) {
+	return "mock " + name, nil
+}
+
+func TestInterfaceBindings(t *testing.T) {
+	testLogger := &logger.Logger{}
+	b := binding.NewBindings(testLogger, []interface{}{options.BindInterface((*Greeter)(nil), &realGreeter{})}, nil, false, nil)
+
+	// The methods are those of the interface, named after it
+	require.Nil(t, b.DB().GetMethod("binding_test.realGreeter.Greet"))
+	require.Nil(t, b.DB().GetMethod("binding_test.Greeter.Unrelated"))
+	method := b.DB().GetMethod("binding_test.Greeter.Greet")
+	require.NotNil(t, method)
+	result, err := method.Call(context.Background(), []interface{}{"Wails"})
+	require.NoError(t, err)
+	require.Equal(t, "Hello Wails", result)
+
+	// The implementation is replaced while the method stays bound
+	require.NoError(t, b.SetImplementation("binding_test.Greeter", mockGreeter{}))
+	result, err = method.Call(context.Background(), []interface{}{"Wails"})
+	require.NoError(t, err)
+	require.Equal(t, "mock Wails", result)
+
+	require.EqualError(t, b.SetImplementation("binding_test.Greeter", &Service{}), "cannot set the implementation of 'binding_test.Greeter': *binding_test.Service does not implement binding_test.Greeter")
+	require.EqualError(t, b.SetImplementation("binding_test.Service", mockGreeter{}), "cannot set the implementation of 'binding_test.Service': no such bound interface")
+
+	// The bindings of the frontend are generated from the interface
+	generationDir := t.TempDir()
+	require.NoError(t, b.GenerateGoBindings(generationDir))
+	generatedBindings, err := fs.ReadFile(os.DirFS(generationDir), "binding_test/Greeter.d.ts")
+	require.NoError(t, err)
+	require.Contains(t, string(generatedBindings), "export function Greet(arg1:string):Promise<string>;")
+
+	// The implementation must implement the interface, which must be given as a pointer
+	require.Error(t, b.Add(options.BindInterface((*Greeter)(nil), &Service{})))
+	require.Error(t, b.Add(options.BindInterface(Greeter(nil), &realGreeter{})))
+	require.Error(t, b.Add(options.BindInterface(&realGreeter{}, &realGreeter{})))
+
+	// An interface can be bound under a namespace
+	require.NoError(t, b.Add(options.BindAs("services.Greeter", options.BindInterface((*Greeter)(nil), mockGreeter{}))))
+	result, err = b.DB().GetMethod("services.Greeter.Greet").Call(context.Background(), []interface{}{"Wails"})
+	require.NoError(t, err)
+	require.Equal(t, "mock Wails", result)
+	require.NoError(t, b.SetImplementation("services.Greeter", &realGreeter{}))
+}
@@ -0,0 +1,46 @@
+package binding_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/wailsapp/wails/v2/internal/binding"
+	other "github.com/wailsapp/wails/v2/internal/binding/binding_test/binding_test_import/collision_package"
+	"github.com/wailsapp/wails/v2/internal/logger"
+	"github.com/wailsapp/wails/v2/pkg/options"
+)
+
+type Service struct{}
+
+func (s *Service) Ping() string {
+	return "pong"
+}
+
+func TestNamespacedBindings(t *testing.T) {
+	testLogger := &logger.Logger{}
+
+	// Two structs exposing the same qualified name collide
+	b := binding.NewBindings(testLogger, []interface{}{&Service{}}, nil, false, nil)
+	err := b.Add(&other.Service{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "'binding_test.Service.Ping' is exposed by both")
+
+	// Binding the same struct again is not a collision
+	require.NoError(t, b.Add(&Service{}))
+
+	// A struct bound under a namespace is available with its name
+	require.NoError(t, b.Add(options.BindAs("plugins.Other", &other.Service{})))
+	require.NotNil(t, b.DB().GetMethod("binding_test.Service.Ping"))
+	require.NotNil(t, b.DB().GetMethod("plugins.Other.Ping"))
+	result, err := b.DB().GetMethod("plugins.Other.Ping").Call(nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, "other pong", result)
+	bindingsJSON, err := b.ToJSON()
+	require.NoError(t, err)
+	require.Contains(t, bindingsJSON, `"plugins":{"Other":{"Ping":`)
+
+	// The namespace must have two parts
+	require.Error(t, b.Add(options.BindAs("plugins", &other.Service{})))
+	require.Error(t, b.Add(options.BindAs("plugins.Other", &Service{})))
+}
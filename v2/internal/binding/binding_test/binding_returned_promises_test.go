@@ -29,6 +29,8 @@ export function SingleReturnStructSlice(arg1:any):Promise<Array<binding_test.Pro
 
 export function SingleReturnWithError(arg1:number):Promise<string>;
 
+export function StreamReturn(arg1:number):Promise<AsyncIterableIterator<binding_test.PromisesTestReturnStruct>>;
+
 export function TwoReturn(arg1:any):Promise<string|number>;
 `
 
@@ -52,6 +54,9 @@ func (h *PromisesTest) SingleReturnStructPointerSlice(_ interface{}) []*Promises
 }
 func (h *PromisesTest) SingleReturnWithError(_ int) (string, error) { return "", nil }
 func (h *PromisesTest) TwoReturn(_ interface{}) (string, int)       { return "", 0 }
+func (h *PromisesTest) StreamReturn(_ int) <-chan PromisesTestReturnStruct {
+	return nil
+}
 
 func TestPromises(t *testing.T) {
 	// given
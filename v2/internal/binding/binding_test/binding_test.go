@@ -30,6 +30,8 @@ func TestBindings_GenerateModels(t *testing.T) {
 
 	tests := []BindingTest{
 		EscapedNameTest,
+		FieldTagsTest,
+		GenericsTest,
 		ImportedStructTest,
 		ImportedSliceTest,
 		ImportedMapTest,
@@ -0,0 +1,9 @@
+// Package binding_test has the same name as the package of the binding tests, to test the collisions of the
+// qualified names of bound methods
+package binding_test
+
+type Service struct{}
+
+func (s *Service) Ping() string {
+	return "other pong"
+}
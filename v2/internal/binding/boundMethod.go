@@ -1,9 +1,11 @@
 package binding
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"time"
 )
 
 // BoundMethod defines all the data related to a Go method that is
@@ -14,6 +16,36 @@ type BoundMethod struct {
 	Outputs  []*Parameter  `json:"outputs,omitempty"`
 	Comments string        `json:"comments,omitempty"`
 	Method   reflect.Value `json:"-"`
+
+	// needsContext is set if the first parameter of the method is a context.Context, which is not
+	// one of the Inputs but passed by Call
+	needsContext bool
+
+	// needsProgress is set if the method takes a *Progress after the context, which is passed by Call too
+	needsProgress bool
+
+	// decoders convert the arguments of the frontend to the types of the Inputs, see ParseArgs
+	decoders []argumentDecoder
+
+	// function and receiver call a method of a struct without the method value of Method, which is slower to
+	// call. The function is invalid for the functions bound at runtime.
+	function reflect.Value
+	receiver reflect.Value
+
+	// implementation is the implementation of the bound interface of the method, nil for the methods of structs.
+	// The method is the method with the index of the current implementation, see SetImplementation.
+	implementation *implementation
+	index          int
+
+	// streams is set if the first output of the method is a stream, see StreamReader
+	streams bool
+
+	// timeout is the deadline of the calls, zero for none, see SetCallTimeouts
+	timeout time.Duration
+
+	// origin is the import path and the name of the struct of the method, e.g. "example.com/app/store.Store",
+	// to tell apart the structs exposing the same qualified name
+	origin string
 }
 
 // InputCount returns the number of inputs this bound method has
@@ -26,6 +58,11 @@ func (b *BoundMethod) OutputCount() int {
 	return len(b.Outputs)
 }
 
+// Streams returns true if the result of the method is a stream, which Call returns as a StreamReader
+func (b *BoundMethod) Streams() bool {
+	return b.streams
+}
+
 // ParseArgs method converts the input json into the types expected by the method
 func (b *BoundMethod) ParseArgs(args []json.RawMessage) ([]interface{}, error) {
 	result := make([]interface{}, b.InputCount())
@@ -33,23 +70,40 @@ func (b *BoundMethod) ParseArgs(args []json.RawMessage) ([]interface{}, error) {
 		return nil, fmt.Errorf("received %d arguments to method '%s', expected %d", len(args), b.Name, b.InputCount())
 	}
 	for index, arg := range args {
-		typ := b.Inputs[index].reflectType
-		inputValue := reflect.New(typ).Interface()
-		err := json.Unmarshal(arg, inputValue)
+		value, err := b.decoders[index](arg)
 		if err != nil {
 			return nil, err
 		}
-		if inputValue == nil {
-			result[index] = reflect.Zero(typ).Interface()
-		} else {
-			result[index] = reflect.ValueOf(inputValue).Elem().Interface()
-		}
+		result[index] = value
 	}
 	return result, nil
 }
 
-// Call will attempt to call this bound method with the given args
-func (b *BoundMethod) Call(args []interface{}) (interface{}, error) {
+// Call will attempt to call this bound method with the given args. If the method takes a
+// context.Context as its first parameter, it gets ctx. A *Progress parameter reports to the client
+// of ctx, see WithProgress. The result of a method which streams is a
+// StreamReader, the context of the method is cancelled when the stream ends. A call exceeding the
+// timeout of the method fails with a TimeoutError.
+func (b *BoundMethod) Call(ctx context.Context, args []interface{}) (interface{}, error) {
+	if !b.streams {
+		if b.timeout > 0 {
+			return b.callWithTimeout(ctx, args)
+		}
+		return b.call(ctx, args)
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	result, err := b.call(ctx, args)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return newStreamReader(result, cancel), nil
+}
+
+func (b *BoundMethod) call(ctx context.Context, args []interface{}) (interface{}, error) {
 	// Check inputs
 	expectedInputLength := len(b.Inputs)
 	actualInputLength := len(args)
@@ -60,16 +114,38 @@ func (b *BoundMethod) Call(args []interface{}) (interface{}, error) {
 	/** Convert inputs to reflect values **/
 
 	// Create slice for the input arguments to the method call
-	callArgs := make([]reflect.Value, expectedInputLength)
+	callArgs := make([]reflect.Value, 0, b.Method.Type().NumIn()+1)
+	if b.function.IsValid() {
+		callArgs = append(callArgs, b.receiver)
+	}
+	if b.needsContext {
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		callArgs = append(callArgs, reflect.ValueOf(ctx))
+	}
+	if b.needsProgress {
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		callArgs = append(callArgs, reflect.ValueOf(progressOf(ctx)))
+	}
 
 	// Iterate over given arguments
-	for index, arg := range args {
+	for _, arg := range args {
 		// Save the converted argument
-		callArgs[index] = reflect.ValueOf(arg)
+		callArgs = append(callArgs, reflect.ValueOf(arg))
 	}
 
 	// Do the call
-	callResults := b.Method.Call(callArgs)
+	var callResults []reflect.Value
+	if b.function.IsValid() {
+		callResults = b.function.Call(callArgs)
+	} else if b.implementation != nil {
+		callResults = b.implementation.method(b.index).Call(callArgs)
+	} else {
+		callResults = b.Method.Call(callArgs)
+	}
 
 	//** Check results **//
 	var returnValue interface{}
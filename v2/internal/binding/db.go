@@ -2,6 +2,7 @@ package binding
 
 import (
 	"encoding/json"
+	"strings"
 	"sync"
 	"unsafe"
 )
@@ -107,6 +108,37 @@ func (d *DB) AddMethod(packageName string, structName string, methodName string,
 	d.obfuscatedMethodArray = append(d.obfuscatedMethodArray, &ObfuscatedMethod{method: methodDefinition, methodName: key})
 }
 
+// RemoveMethods removes the method with the qualified name "packageName.structName.methodName", or all the
+// methods of "packageName.structName". The IDs of the other obfuscated methods are kept.
+func (d *DB) RemoveMethods(name string) bool {
+	// Lock the db whilst processing and unlock on return
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	removed := false
+	for key, method := range d.methodMap {
+		if key != name && !strings.HasPrefix(key, name+".") {
+			continue
+		}
+		parts := strings.Split(key, ".")
+		delete(d.store[parts[0]][parts[1]], parts[2])
+		if len(d.store[parts[0]][parts[1]]) == 0 {
+			delete(d.store[parts[0]], parts[1])
+		}
+		if len(d.store[parts[0]]) == 0 {
+			delete(d.store, parts[0])
+		}
+		delete(d.methodMap, key)
+		for _, obfuscated := range d.obfuscatedMethodArray {
+			if obfuscated.method == method {
+				obfuscated.method = nil
+			}
+		}
+		removed = true
+	}
+	return removed
+}
+
 // ToJSON converts the method map to JSON
 func (d *DB) ToJSON() (string, error) {
 	// Lock the db whilst processing and unlock on return
@@ -127,6 +159,10 @@ func (d *DB) UpdateObfuscatedCallMap() map[string]int {
 	mappings := make(map[string]int)
 
 	for id, k := range d.obfuscatedMethodArray {
+		if k.method == nil {
+			// The method has been removed
+			continue
+		}
 		mappings[k.methodName] = id
 	}
 
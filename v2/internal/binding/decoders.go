@@ -0,0 +1,146 @@
+package binding
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"unicode/utf8"
+)
+
+// argumentDecoder converts the JSON of an argument to a value of the type of the parameter
+type argumentDecoder func(arg json.RawMessage) (interface{}, error)
+
+var (
+	boolType    = reflect.TypeOf(false)
+	stringType  = reflect.TypeOf("")
+	intType     = reflect.TypeOf(0)
+	int64Type   = reflect.TypeOf(int64(0))
+	float64Type = reflect.TypeOf(float64(0))
+)
+
+// newArgumentDecoder returns the decoder of the arguments of the type, it is created once when the method is
+// bound. The arguments of the basic types are parsed directly if they are in their plain form, which saves the
+// overhead of encoding/json for the frequent calls with a few small arguments. The others, e.g. strings with
+// escapes, are decoded by encoding/json, with the same results and errors.
+func newArgumentDecoder(typ reflect.Type) argumentDecoder {
+	decode := func(arg json.RawMessage) (interface{}, error) {
+		value := reflect.New(typ)
+		if err := json.Unmarshal(arg, value.Interface()); err != nil {
+			return nil, err
+		}
+		return value.Elem().Interface(), nil
+	}
+	switch typ {
+	case boolType:
+		return func(arg json.RawMessage) (interface{}, error) {
+			switch string(arg) {
+			case "true":
+				return true, nil
+			case "false":
+				return false, nil
+			}
+			return decode(arg)
+		}
+	case stringType:
+		return func(arg json.RawMessage) (interface{}, error) {
+			if isPlainString(arg) {
+				return string(arg[1 : len(arg)-1]), nil
+			}
+			return decode(arg)
+		}
+	case intType, int64Type:
+		return func(arg json.RawMessage) (interface{}, error) {
+			if isPlainInteger(arg) {
+				if value, err := strconv.ParseInt(string(arg), 10, typ.Bits()); err == nil {
+					if typ == intType {
+						return int(value), nil
+					}
+					return value, nil
+				}
+			}
+			return decode(arg)
+		}
+	case float64Type:
+		return func(arg json.RawMessage) (interface{}, error) {
+			if isNumber(arg) {
+				if value, err := strconv.ParseFloat(string(arg), 64); err == nil {
+					return value, nil
+				}
+			}
+			return decode(arg)
+		}
+	}
+	return decode
+}
+
+// isPlainString reports whether the JSON is a string without escapes, which is its own value
+func isPlainString(arg []byte) bool {
+	if len(arg) < 2 || arg[0] != '"' || arg[len(arg)-1] != '"' {
+		return false
+	}
+	content := arg[1 : len(arg)-1]
+	if bytes.IndexByte(content, '\\') >= 0 || bytes.IndexByte(content, '"') >= 0 {
+		return false
+	}
+	for _, c := range content {
+		if c < ' ' {
+			return false
+		}
+	}
+	return utf8.Valid(content)
+}
+
+// isPlainInteger reports whether the JSON is an integer number, without a fraction or an exponent
+func isPlainInteger(arg []byte) bool {
+	digits := arg
+	if len(digits) > 0 && digits[0] == '-' {
+		digits = digits[1:]
+	}
+	if len(digits) == 0 || (digits[0] == '0' && len(digits) > 1) {
+		return false
+	}
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// isNumber reports whether the JSON is a number
+func isNumber(arg []byte) bool {
+	if len(arg) > 0 && arg[0] == '-' {
+		arg = arg[1:]
+	}
+	digits := func() int {
+		count := 0
+		for count < len(arg) && arg[count] >= '0' && arg[count] <= '9' {
+			count++
+		}
+		arg = arg[count:]
+		return count
+	}
+	if len(arg) > 1 && arg[0] == '0' && arg[1] >= '0' && arg[1] <= '9' {
+		return false
+	}
+	if digits() == 0 {
+		return false
+	}
+	if len(arg) > 0 && arg[0] == '.' {
+		arg = arg[1:]
+		if digits() == 0 {
+			return false
+		}
+	}
+	if len(arg) > 0 && (arg[0] == 'e' || arg[0] == 'E') {
+		arg = arg[1:]
+		if len(arg) > 0 && (arg[0] == '+' || arg[0] == '-') {
+			arg = arg[1:]
+		}
+		if digits() == 0 {
+			return false
+		}
+	}
+	return len(arg) == 0
+}
@@ -0,0 +1,41 @@
+package binding
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_newArgumentDecoder(t *testing.T) {
+	type name string
+	tests := []struct {
+		typ  reflect.Type
+		args []string
+	}{
+		{boolType, []string{`true`, `false`, `null`, `1`, `"true"`}},
+		{stringType, []string{`"wails"`, `""`, `"a\"b"`, `"été"`, `"\u00e9t\u00e9"`, "\"\xff\"", `null`, `1`}},
+		{reflect.TypeOf(name("")), []string{`"wails"`}},
+		{intType, []string{`0`, `-7`, `42`, `9223372036854775807`, `9223372036854775808`, `01`, `-`, `1.0`, `1e3`, `null`, `"1"`}},
+		{int64Type, []string{`-9223372036854775808`, `12`, `1.5`}},
+		{float64Type, []string{`0`, `-0.5`, `1e3`, `1E-3`, `2.5e+10`, `1e400`, `.5`, `1.`, `01`, `-`, `null`, `"1"`}},
+	}
+	for _, tt := range tests {
+		decode := newArgumentDecoder(tt.typ)
+		for _, arg := range tt.args {
+			t.Run(tt.typ.String()+" "+arg, func(t *testing.T) {
+				// The arguments are decoded like encoding/json does
+				expected := reflect.New(tt.typ)
+				expectedErr := json.Unmarshal([]byte(arg), expected.Interface())
+				value, err := decode(json.RawMessage(arg))
+				if expectedErr != nil {
+					assert.EqualError(t, err, expectedErr.Error())
+					return
+				}
+				assert.NoError(t, err)
+				assert.Equal(t, expected.Elem().Interface(), value)
+			})
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package binding
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Bind binds a function or the methods of a struct pointer after startup, e.g. for plugins loaded at runtime.
+// A function is bound with a qualified name of the form "package.Struct.Method", the methods of a struct
+// pointer with a name of the form "package.Struct". The methods get the default timeout of SetCallTimeouts.
+func (b *Bindings) Bind(name string, value interface{}) error {
+	parts := strings.Split(name, ".")
+	for _, part := range parts {
+		if part == "" {
+			return fmt.Errorf("cannot bind '%s': invalid name", name)
+		}
+	}
+
+	var methods []*BoundMethod
+	switch {
+	case isFunction(value):
+		if len(parts) != 3 {
+			return fmt.Errorf("cannot bind '%s': the name of a function must be of the form 'package.Struct.Method'", name)
+		}
+		methods = append(methods, b.newBoundMethod(name, reflect.ValueOf(value)))
+	case isStructPtr(value):
+		if len(parts) != 2 {
+			return fmt.Errorf("cannot bind '%s': the name of a struct must be of the form 'package.Struct'", name)
+		}
+		structMethods, err := b.getMethods(value)
+		if err != nil {
+			return fmt.Errorf("cannot bind '%s': %s", name, err.Error())
+		}
+		for _, method := range structMethods {
+			method.Name = name + "." + method.Name[strings.LastIndex(method.Name, ".")+1:]
+			methods = append(methods, method)
+		}
+	default:
+		return fmt.Errorf("cannot bind '%s': not a function or a pointer to a struct", name)
+	}
+
+	for _, method := range methods {
+		if b.db.GetMethod(method.Name) != nil {
+			return fmt.Errorf("cannot bind '%s': '%s' is already bound", name, method.Name)
+		}
+	}
+	for _, method := range methods {
+		method.timeout = b.defaultTimeout
+		parts := strings.Split(method.Name, ".")
+		b.db.AddMethod(parts[0], parts[1], parts[2], method)
+	}
+	return nil
+}
+
+// Unbind removes the bound method with the qualified name "package.Struct.Method", or all the methods of
+// "package.Struct". The calls in progress are not affected.
+func (b *Bindings) Unbind(name string) error {
+	if !b.db.RemoveMethods(name) {
+		return fmt.Errorf("cannot unbind '%s': not bound", name)
+	}
+	b.interfacesLock.Lock()
+	delete(b.interfaces, name)
+	b.interfacesLock.Unlock()
+	return nil
+}
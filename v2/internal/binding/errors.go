@@ -0,0 +1,35 @@
+package binding
+
+import "errors"
+
+// CodedError is implemented by the errors of bound methods which are passed to the frontend as an object with
+// a code, the message and data, instead of only their message. The frontend rejects the promise of the call
+// with the object.
+type CodedError interface {
+	error
+	// ErrorCode identifies the kind of the error, e.g. "not_found"
+	ErrorCode() string
+	// ErrorData holds the details of the error, it may be nil
+	ErrorData() map[string]interface{}
+}
+
+// ErrorEnvelope is the object the frontend gets for a CodedError
+type ErrorEnvelope struct {
+	Code    string                 `json:"code"`
+	Message string                 `json:"message"`
+	Data    map[string]interface{} `json:"data,omitempty"`
+}
+
+// ErrorValue returns the error of a call passed to the frontend: the ErrorEnvelope of a CodedError, which
+// may be wrapped, or the message of the other errors
+func ErrorValue(err error) interface{} {
+	var coded CodedError
+	if errors.As(err, &coded) {
+		return &ErrorEnvelope{
+			Code:    coded.ErrorCode(),
+			Message: err.Error(),
+			Data:    coded.ErrorData(),
+		}
+	}
+	return err.Error()
+}
@@ -6,11 +6,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"regexp"
 	"sort"
 	"strings"
 
 	"github.com/wailsapp/wails/v2/internal/fs"
+	"github.com/wailsapp/wails/v2/internal/typescriptify"
 
 	"github.com/leaanthony/slicer"
 )
@@ -106,6 +108,10 @@ func (b *Bindings) GenerateGoBindings(baseDir string) error {
 					returnType = "Promise<void>"
 				} else if methodDetails.OutputCount() == 1 && methodDetails.Outputs[0].TypeName == "error" {
 					returnType = "Promise<void>"
+				} else if methodDetails.Streams() {
+					// Streams resolve to an async iterator of their values
+					outputTypeName := entityFullReturnType(streamValueType(methodDetails.Outputs[0]), b.tsPrefix, b.tsSuffix, &importNamespaces)
+					returnType = "Promise<AsyncIterableIterator<" + goTypeToTypescriptType(outputTypeName, &importNamespaces) + ">>"
 				} else {
 					outputTypeName := entityFullReturnType(methodDetails.Outputs[0].TypeName, b.tsPrefix, b.tsSuffix, &importNamespaces)
 					firstType := goTypeToTypescriptType(outputTypeName, &importNamespaces)
@@ -145,6 +151,15 @@ func (b *Bindings) GenerateGoBindings(baseDir string) error {
 	return nil
 }
 
+// streamValueType returns the type of the values of a streamed output, the values of a runtime.Stream can be of
+// any type
+func streamValueType(output *Parameter) string {
+	if output.reflectType.Kind() == reflect.Chan {
+		return output.reflectType.Elem().String()
+	}
+	return "interface{}"
+}
+
 func fullyQualifiedName(packageName string, typeName string) string {
 	if len(packageName) > 0 {
 		return packageName + "." + typeName
@@ -228,6 +243,7 @@ func goTypeToTypescriptType(input string, importNamespaces *slicer.StringSlicer)
 }
 
 func entityFullReturnType(input, prefix, suffix string, importNamespaces *slicer.StringSlicer) string {
+	input = typescriptify.GenericTypeName(input)
 	if strings.ContainsRune(input, '.') {
 		nameSpace, returnType := getSplitReturn(input)
 		return nameSpace + "." + prefix + returnType + suffix
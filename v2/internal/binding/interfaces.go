@@ -0,0 +1,88 @@
+package binding
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/wailsapp/wails/v2/internal/typescriptify"
+	"github.com/wailsapp/wails/v2/pkg/options"
+)
+
+// implementation is the value implementing a bound interface, shared by the bound methods of the interface.
+// It can be replaced while the app is running, the calls in progress complete with the previous one.
+type implementation struct {
+	interfaceType reflect.Type
+
+	lock  sync.RWMutex
+	value reflect.Value
+}
+
+// method returns the method of the current implementation with the index of the method of the interface
+func (i *implementation) method(index int) reflect.Value {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+	return i.value.Method(index)
+}
+
+// set replaces the implementation, it fails if the value does not implement the interface
+func (i *implementation) set(value interface{}) error {
+	if value == nil || !reflect.TypeOf(value).Implements(i.interfaceType) {
+		return fmt.Errorf("%T does not implement %s", value, i.interfaceType)
+	}
+	// The value is stored as the interface, so its methods have the indexes of the methods of the interface
+	interfaceValue := reflect.New(i.interfaceType).Elem()
+	interfaceValue.Set(reflect.ValueOf(value))
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	i.value = interfaceValue
+	return nil
+}
+
+// getInterfaceMethods returns the methods of the bound interface, named after the interface, e.g.
+// "store.Store.Method". They call the methods of the current implementation, see SetImplementation.
+func (b *Bindings) getInterfaceMethods(binding options.InterfaceBinding) ([]*BoundMethod, error) {
+	interfaceType := reflect.TypeOf(binding.Interface)
+	if interfaceType == nil || interfaceType.Kind() != reflect.Ptr || interfaceType.Elem().Kind() != reflect.Interface {
+		return nil, fmt.Errorf("%T is not a pointer to an interface, e.g. (*store.Store)(nil)", binding.Interface)
+	}
+	interfaceType = interfaceType.Elem()
+	if interfaceType.Name() == "" {
+		return nil, fmt.Errorf("%s is not a named interface", interfaceType)
+	}
+	current := &implementation{interfaceType: interfaceType}
+	if err := current.set(binding.Implementation); err != nil {
+		return nil, err
+	}
+	baseName := typescriptify.GenericTypeName(interfaceType.String())
+
+	var result []*BoundMethod
+	for index := 0; index < interfaceType.NumMethod(); index++ {
+		methodDef := interfaceType.Method(index)
+		if !methodDef.IsExported() {
+			continue
+		}
+		boundMethod := b.newBoundMethod(baseName+"."+methodDef.Name, current.method(index))
+		boundMethod.origin = interfaceType.PkgPath() + "." + interfaceType.Name()
+		boundMethod.implementation = current
+		boundMethod.index = index
+		result = append(result, boundMethod)
+	}
+	return result, nil
+}
+
+// SetImplementation replaces the implementation of the interface bound with the name, of the form
+// "package.Interface", e.g. to switch between a mock and the real service. The frontend is not affected,
+// the calls in progress complete with the previous implementation.
+func (b *Bindings) SetImplementation(name string, value interface{}) error {
+	b.interfacesLock.RLock()
+	current, ok := b.interfaces[name]
+	b.interfacesLock.RUnlock()
+	if !ok {
+		return fmt.Errorf("cannot set the implementation of '%s': no such bound interface", name)
+	}
+	if err := current.set(value); err != nil {
+		return fmt.Errorf("cannot set the implementation of '%s': %s", name, err.Error())
+	}
+	return nil
+}
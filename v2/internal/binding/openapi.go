@@ -0,0 +1,215 @@
+package binding
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/wailsapp/wails/v2/internal/typescriptify"
+)
+
+var (
+	errorType  = reflect.TypeOf((*error)(nil)).Elem()
+	readerType = reflect.TypeOf((*io.Reader)(nil)).Elem()
+	timeType   = reflect.TypeOf(time.Time{})
+)
+
+// ToOpenAPI returns the OpenAPI 3.1 document of the bound methods, as served over HTTP at
+// POST /wails/call/{package}/{struct}/{method}. The request body of a call is the JSON array of its arguments,
+// the reply holds the "result" or the "error" of the method. The structs of the parameters are JSON Schemas in
+// the components of the document. The methods returning a stream can't be called over HTTP and are left out.
+func (b *Bindings) ToOpenAPI() (string, error) {
+	schemas := &openAPISchemas{components: map[string]interface{}{}}
+	paths := map[string]interface{}{}
+
+	b.db.lock.RLock()
+	for packageName, structs := range b.db.store {
+		for structName, methods := range structs {
+			for methodName, method := range methods {
+				if method.Streams() {
+					continue
+				}
+				path := "/wails/call/" + packageName + "/" + structName + "/" + methodName
+				paths[path] = map[string]interface{}{"post": schemas.operation(method)}
+			}
+		}
+	}
+	b.db.lock.RUnlock()
+
+	document := map[string]interface{}{
+		"openapi": "3.1.0",
+		"info": map[string]interface{}{
+			"title":   "Wails bindings",
+			"version": "1.0.0",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": schemas.components,
+		},
+	}
+	result, err := json.Marshal(document)
+	return string(result), err
+}
+
+// openAPISchemas builds the JSON Schemas of the Go types, the structs are added to the components
+type openAPISchemas struct {
+	components map[string]interface{}
+}
+
+// operation returns the operation calling the method
+func (s *openAPISchemas) operation(method *BoundMethod) map[string]interface{} {
+	args := make([]interface{}, 0, len(method.Inputs))
+	for _, input := range method.Inputs {
+		args = append(args, s.schema(input.reflectType))
+	}
+	arguments := map[string]interface{}{
+		"type":     "array",
+		"minItems": len(args),
+		"maxItems": len(args),
+	}
+	if len(args) > 0 {
+		arguments["prefixItems"] = args
+	}
+
+	var result interface{} = map[string]interface{}{"type": "null"}
+	if len(method.Outputs) > 0 && method.Outputs[0].reflectType != errorType {
+		result = s.schema(method.Outputs[0].reflectType)
+	}
+
+	reply := func(description string, properties map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"description": description,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{
+					"schema": map[string]interface{}{
+						"type":       "object",
+						"properties": properties,
+					},
+				},
+			},
+		}
+	}
+	// The errors are messages, or objects with a code, the message and data
+	errorSchema := map[string]interface{}{}
+
+	operation := map[string]interface{}{
+		"operationId": method.Name,
+		"requestBody": map[string]interface{}{
+			"required": len(args) > 0,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": arguments},
+			},
+		},
+		"responses": map[string]interface{}{
+			"200":     reply("The result of the method", map[string]interface{}{"result": result}),
+			"default": reply("The error of the call", map[string]interface{}{"error": errorSchema}),
+		},
+	}
+	if method.Comments != "" {
+		operation["description"] = method.Comments
+	}
+	return operation
+}
+
+// schema returns the JSON Schema of the values of the type in the JSON encoding
+func (s *openAPISchemas) schema(typ reflect.Type) interface{} {
+	switch {
+	case typ == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case typ.Implements(readerType):
+		// The result is the URL the reader is streamed from
+		return map[string]interface{}{"type": "string", "format": "uri-reference"}
+	}
+
+	switch typ.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Ptr:
+		return s.schema(typ.Elem())
+	case reflect.Slice, reflect.Array:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			// Byte slices are encoded in base64
+			return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]interface{}{"type": "array", "items": s.schema(typ.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": s.schema(typ.Elem())}
+	case reflect.Struct:
+		if typ.Name() == "" {
+			return s.structSchema(typ)
+		}
+		name := typescriptify.GenericTypeName(typ.String())
+		if _, exists := s.components[name]; !exists {
+			// The name is taken first for the structs referencing themselves
+			s.components[name] = nil
+			s.components[name] = s.structSchema(typ)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	default:
+		// Any value, e.g. an interface{}
+		return map[string]interface{}{}
+	}
+}
+
+// structSchema returns the schema of the struct, with the fields of encoding/json
+func (s *openAPISchemas) structSchema(typ reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	var required []string
+	s.addFields(typ, properties, &required)
+	sort.Strings(required)
+	result := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		result["required"] = required
+	}
+	return result
+}
+
+func (s *openAPISchemas) addFields(typ reflect.Type, properties map[string]interface{}, required *[]string) {
+	for index := 0; index < typ.NumField(); index++ {
+		field := typ.Field(index)
+		jsonTag := field.Tag.Get("json")
+		if jsonTag == "-" {
+			continue
+		}
+		parts := strings.Split(jsonTag, ",")
+		name := parts[0]
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			// The fields of embedded structs are promoted
+			s.addFields(fieldType, properties, required)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = s.schema(field.Type)
+		optional := field.Type.Kind() == reflect.Ptr
+		for _, option := range parts[1:] {
+			if option == "omitempty" {
+				optional = true
+			}
+		}
+		if !optional {
+			*required = append(*required, name)
+		}
+	}
+}
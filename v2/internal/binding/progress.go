@@ -0,0 +1,61 @@
+package binding
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// progressInterval is the minimum time between the progress updates sent to the client, the updates in between
+// are dropped except the one completing the progress
+const progressInterval = 50 * time.Millisecond
+
+// ProgressUpdate is the progress of a call, Current out of Total. Total is zero if it is unknown.
+type ProgressUpdate struct {
+	Current int64  `json:"current"`
+	Total   int64  `json:"total"`
+	Message string `json:"message,omitempty"`
+}
+
+// Progress reports the progress of a long-running bound method to the client calling it. A method gets it by
+// taking a *runtime.Progress parameter, after the context.Context if it has one, which is not one of the
+// arguments of the frontend. The reports are dropped if the client can't receive them, e.g. in the desktop window.
+type Progress struct {
+	report func(ProgressUpdate)
+
+	lock sync.Mutex
+	last time.Time
+}
+
+var progressType = reflect.TypeOf((*Progress)(nil))
+
+type progressKey struct{}
+
+// WithProgress returns the context of a call whose progress is reported to the client with report
+func WithProgress(ctx context.Context, report func(ProgressUpdate)) context.Context {
+	return context.WithValue(ctx, progressKey{}, report)
+}
+
+// progressOf returns the progress of the call of the context
+func progressOf(ctx context.Context) *Progress {
+	report, _ := ctx.Value(progressKey{}).(func(ProgressUpdate))
+	return &Progress{report: report}
+}
+
+// Report reports that current out of total units of work are done, total is zero if it is unknown.
+// The updates are sent at most every 50ms, except the one reaching the total.
+func (p *Progress) Report(current, total int64, message string) {
+	if p == nil || p.report == nil {
+		return
+	}
+	p.lock.Lock()
+	now := time.Now()
+	if now.Sub(p.last) < progressInterval && (total == 0 || current < total) {
+		p.lock.Unlock()
+		return
+	}
+	p.last = now
+	p.lock.Unlock()
+	p.report(ProgressUpdate{Current: current, Total: total, Message: message})
+}
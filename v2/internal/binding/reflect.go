@@ -1,10 +1,13 @@
 package binding
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"runtime"
 	"strings"
+
+	"github.com/wailsapp/wails/v2/internal/typescriptify"
 )
 
 // isStructPtr returns true if the value given is a
@@ -24,6 +27,8 @@ func isStruct(value interface{}) bool {
 	return reflect.ValueOf(value).Kind() == reflect.Struct
 }
 
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
 func (b *Bindings) getMethods(value interface{}) ([]*BoundMethod, error) {
 	// Create result placeholder
 	var result []*BoundMethod
@@ -48,7 +53,7 @@ func (b *Bindings) getMethods(value interface{}) ([]*BoundMethod, error) {
 	structType := reflect.TypeOf(value)
 	structValue := reflect.ValueOf(value)
 	structTypeString := structType.String()
-	baseName := structTypeString[1:]
+	baseName := typescriptify.GenericTypeName(structTypeString[1:])
 
 	// Process Methods
 	for i := 0; i < structType.NumMethod(); i++ {
@@ -62,100 +67,124 @@ func (b *Bindings) getMethods(value interface{}) ([]*BoundMethod, error) {
 			continue
 		}
 
-		// Create new method
-		boundMethod := &BoundMethod{
-			Name:     fullMethodName,
-			Inputs:   nil,
-			Outputs:  nil,
-			Comments: "",
-			Method:   method,
-		}
+		// Save method in result
+		boundMethod := b.newBoundMethod(fullMethodName, method)
+		boundMethod.origin = structType.Elem().PkgPath() + "." + structType.Elem().Name()
+		boundMethod.function = methodDef.Func
+		boundMethod.receiver = structValue
+		result = append(result, boundMethod)
+	}
+	return result, nil
+}
 
-		// Iterate inputs
-		methodType := method.Type()
-		inputParamCount := methodType.NumIn()
-		var inputs []*Parameter
-		for inputIndex := 0; inputIndex < inputParamCount; inputIndex++ {
-			input := methodType.In(inputIndex)
-			thisParam := newParameter("", input)
+// newBoundMethod returns the bound method of the function with the qualified name, the structs of its
+// parameters are added to the TS models
+func (b *Bindings) newBoundMethod(fullMethodName string, method reflect.Value) *BoundMethod {
+	// Create new method
+	boundMethod := &BoundMethod{
+		Name:     fullMethodName,
+		Inputs:   nil,
+		Outputs:  nil,
+		Comments: "",
+		Method:   method,
+	}
 
-			thisInput := input
+	// Iterate inputs
+	methodType := method.Type()
+	inputParamCount := methodType.NumIn()
+	var inputs []*Parameter
+	firstInput := 0
+	if inputParamCount > 0 && methodType.In(0) == contextType {
+		// The context is passed by the dispatcher, it is not an input of the frontend
+		boundMethod.needsContext = true
+		firstInput = 1
+	}
+	if inputParamCount > firstInput && methodType.In(firstInput) == progressType {
+		// The progress reports to the client of the call, it is not an input of the frontend either
+		boundMethod.needsProgress = true
+		firstInput++
+	}
+	for inputIndex := firstInput; inputIndex < inputParamCount; inputIndex++ {
+		input := methodType.In(inputIndex)
+		thisParam := newParameter("", input)
 
-			if thisInput.Kind() == reflect.Slice {
-				thisInput = thisInput.Elem()
-			}
+		thisInput := input
 
-			// Process struct pointer params
-			if thisInput.Kind() == reflect.Ptr {
-				if thisInput.Elem().Kind() == reflect.Struct {
-					typ := thisInput.Elem()
-					a := reflect.New(typ)
-					s := reflect.Indirect(a).Interface()
-					name := typ.Name()
-					packageName := getPackageName(thisInput.String())
-					b.AddStructToGenerateTS(packageName, name, s)
-				}
-			}
+		if thisInput.Kind() == reflect.Slice {
+			thisInput = thisInput.Elem()
+		}
 
-			// Process struct params
-			if thisInput.Kind() == reflect.Struct {
-				a := reflect.New(thisInput)
+		// Process struct pointer params
+		if thisInput.Kind() == reflect.Ptr {
+			if thisInput.Elem().Kind() == reflect.Struct {
+				typ := thisInput.Elem()
+				a := reflect.New(typ)
 				s := reflect.Indirect(a).Interface()
-				name := thisInput.Name()
+				name := typescriptify.GenericTypeName(typ.Name())
 				packageName := getPackageName(thisInput.String())
 				b.AddStructToGenerateTS(packageName, name, s)
 			}
+		}
 
-			inputs = append(inputs, thisParam)
+		// Process struct params
+		if thisInput.Kind() == reflect.Struct {
+			a := reflect.New(thisInput)
+			s := reflect.Indirect(a).Interface()
+			name := typescriptify.GenericTypeName(thisInput.Name())
+			packageName := getPackageName(thisInput.String())
+			b.AddStructToGenerateTS(packageName, name, s)
 		}
 
-		boundMethod.Inputs = inputs
+		inputs = append(inputs, thisParam)
+		boundMethod.decoders = append(boundMethod.decoders, newArgumentDecoder(input))
+	}
 
-		// Iterate outputs
-		// TODO: Determine what to do about limiting return types
-		//       especially around errors.
-		outputParamCount := methodType.NumOut()
-		var outputs []*Parameter
-		for outputIndex := 0; outputIndex < outputParamCount; outputIndex++ {
-			output := methodType.Out(outputIndex)
-			thisParam := newParameter("", output)
+	boundMethod.Inputs = inputs
 
-			thisOutput := output
+	// Iterate outputs
+	// TODO: Determine what to do about limiting return types
+	//       especially around errors.
+	outputParamCount := methodType.NumOut()
+	var outputs []*Parameter
+	for outputIndex := 0; outputIndex < outputParamCount; outputIndex++ {
+		output := methodType.Out(outputIndex)
+		thisParam := newParameter("", output)
 
-			if thisOutput.Kind() == reflect.Slice {
-				thisOutput = thisOutput.Elem()
-			}
+		thisOutput := output
 
-			// Process struct pointer params
-			if thisOutput.Kind() == reflect.Ptr {
-				if thisOutput.Elem().Kind() == reflect.Struct {
-					typ := thisOutput.Elem()
-					a := reflect.New(typ)
-					s := reflect.Indirect(a).Interface()
-					name := typ.Name()
-					packageName := getPackageName(thisOutput.String())
-					b.AddStructToGenerateTS(packageName, name, s)
-				}
-			}
+		if outputIndex == 0 && isStreamType(output) {
+			boundMethod.streams = true
+		}
+		if thisOutput.Kind() == reflect.Slice || thisOutput.Kind() == reflect.Chan {
+			thisOutput = thisOutput.Elem()
+		}
 
-			// Process struct params
-			if thisOutput.Kind() == reflect.Struct {
-				a := reflect.New(thisOutput)
+		// Process struct pointer params
+		if thisOutput.Kind() == reflect.Ptr {
+			if thisOutput.Elem().Kind() == reflect.Struct {
+				typ := thisOutput.Elem()
+				a := reflect.New(typ)
 				s := reflect.Indirect(a).Interface()
-				name := thisOutput.Name()
+				name := typescriptify.GenericTypeName(typ.Name())
 				packageName := getPackageName(thisOutput.String())
 				b.AddStructToGenerateTS(packageName, name, s)
 			}
-
-			outputs = append(outputs, thisParam)
 		}
-		boundMethod.Outputs = outputs
 
-		// Save method in result
-		result = append(result, boundMethod)
+		// Process struct params
+		if thisOutput.Kind() == reflect.Struct {
+			a := reflect.New(thisOutput)
+			s := reflect.Indirect(a).Interface()
+			name := typescriptify.GenericTypeName(thisOutput.Name())
+			packageName := getPackageName(thisOutput.String())
+			b.AddStructToGenerateTS(packageName, name, s)
+		}
 
+		outputs = append(outputs, thisParam)
 	}
-	return result, nil
+	boundMethod.Outputs = outputs
+
+	return boundMethod
 }
 
 func getPackageName(in string) string {
@@ -0,0 +1,97 @@
+package binding
+
+import (
+	"context"
+	"io"
+	"reflect"
+)
+
+// StreamReader reads the values of a streamed result one by one. The results of the bound methods returning a
+// receive channel or a runtime.Stream are streamed, the frontends which support it forward the values to the
+// client as they are read.
+type StreamReader interface {
+	// Recv returns the next value of the stream. It returns io.EOF after the last value and the error of the
+	// stream if it failed.
+	Recv(ctx context.Context) (interface{}, error)
+	// Cancel stops the stream, e.g. when the client stops iterating it
+	Cancel()
+}
+
+var streamReaderType = reflect.TypeOf((*StreamReader)(nil)).Elem()
+
+// isStreamType reports whether the results of the type are streamed
+func isStreamType(typ reflect.Type) bool {
+	if typ.Kind() == reflect.Chan {
+		return typ.ChanDir()&reflect.RecvDir != 0
+	}
+	return typ.Implements(streamReaderType)
+}
+
+// newStreamReader returns the reader of the stream result of a method. The context of the method is cancelled by
+// cancel once the stream has ended or has been cancelled.
+func newStreamReader(result interface{}, cancel context.CancelFunc) StreamReader {
+	value := reflect.ValueOf(result)
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		if value.IsNil() {
+			value = reflect.Value{}
+		}
+	}
+	if !value.IsValid() {
+		// A nil stream has no values
+		cancel()
+		return &channelReader{cancel: cancel}
+	}
+	if reader, ok := result.(StreamReader); ok {
+		return &cancelReader{StreamReader: reader, cancel: cancel}
+	}
+	return &channelReader{channel: value, cancel: cancel}
+}
+
+// cancelReader cancels the context of the method which returned the reader once the stream has ended
+type cancelReader struct {
+	StreamReader
+	cancel context.CancelFunc
+}
+
+func (r *cancelReader) Recv(ctx context.Context) (interface{}, error) {
+	value, err := r.StreamReader.Recv(ctx)
+	if err != nil {
+		r.cancel()
+	}
+	return value, err
+}
+
+func (r *cancelReader) Cancel() {
+	r.StreamReader.Cancel()
+	r.cancel()
+}
+
+// channelReader reads the values of a channel until it is closed. A method returning a channel should stop
+// sending to it once its context is cancelled.
+type channelReader struct {
+	channel reflect.Value
+	cancel  context.CancelFunc
+}
+
+func (r *channelReader) Recv(ctx context.Context) (interface{}, error) {
+	if !r.channel.IsValid() {
+		return nil, io.EOF
+	}
+	chosen, value, ok := reflect.Select([]reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: r.channel},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+	})
+	if chosen == 1 {
+		return nil, ctx.Err()
+	}
+	if !ok {
+		r.cancel()
+		return nil, io.EOF
+	}
+	return value.Interface(), nil
+}
+
+func (r *channelReader) Cancel() {
+	r.cancel()
+}
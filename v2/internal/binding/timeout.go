@@ -0,0 +1,83 @@
+package binding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// TimeoutError is the error of a call which exceeded the timeout of the method. It is a CodedError with the
+// code "timeout" and the method and the timeout in milliseconds as data.
+type TimeoutError struct {
+	Method  string
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("call of '%s' timed out after %s", e.Method, e.Timeout)
+}
+
+func (e *TimeoutError) ErrorCode() string {
+	return "timeout"
+}
+
+func (e *TimeoutError) ErrorData() map[string]interface{} {
+	return map[string]interface{}{
+		"method":  e.Method,
+		"timeout": e.Timeout.Milliseconds(),
+	}
+}
+
+// SetCallTimeouts sets the timeouts of the bound methods by their qualified name, e.g. "main.App.Search".
+// The timeout of the "*" key applies to the methods without one. The methods returning a stream have no timeout.
+func (b *Bindings) SetCallTimeouts(timeouts map[string]time.Duration) error {
+	for name, timeout := range timeouts {
+		if timeout < 0 {
+			return fmt.Errorf("the timeout of '%s' is negative", name)
+		}
+		if name != "*" && b.db.GetMethod(name) == nil {
+			return fmt.Errorf("cannot set the timeout of '%s': method not bound", name)
+		}
+	}
+	b.defaultTimeout = timeouts["*"]
+	b.db.lock.Lock()
+	defer b.db.lock.Unlock()
+	for name, method := range b.db.methodMap {
+		timeout, ok := timeouts[name]
+		if !ok {
+			timeout = timeouts["*"]
+		}
+		method.timeout = timeout
+	}
+	return nil
+}
+
+// callWithTimeout calls the method with a context which is cancelled at the timeout. A call exceeding the
+// timeout fails with a TimeoutError right away, even if the method does not return.
+func (b *BoundMethod) callWithTimeout(ctx context.Context, args []interface{}) (interface{}, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, b.timeout)
+	defer cancel()
+
+	type callResult struct {
+		value interface{}
+		err   error
+	}
+	results := make(chan callResult, 1)
+	go func() {
+		value, err := b.call(ctx, args)
+		results <- callResult{value: value, err: err}
+	}()
+	select {
+	case result := <-results:
+		return result.value, result.err
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, &TimeoutError{Method: b.Name, Timeout: b.timeout}
+		}
+		return nil, ctx.Err()
+	}
+}
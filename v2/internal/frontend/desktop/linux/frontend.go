@@ -78,6 +78,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	iofs "io/fs"
 	"log"
 	"net"
 	"net/url"
@@ -218,6 +219,28 @@ func (f *Frontend) WindowReload() {
 	f.ExecJS("runtime.WindowReload();")
 }
 
+// SetAssetOverlay layers the overlay over the assets and reloads the window to pick it up
+func (f *Frontend) SetAssetOverlay(overlay iofs.FS) error {
+	if f.assets == nil {
+		return errors.New("the assets are not served by the app")
+	}
+	f.assets.SetOverlayFS(overlay)
+	f.WindowReload()
+	return nil
+}
+
+// AssetManifest returns the hashes of the asset files by their URL path
+func (f *Frontend) AssetManifest() (map[string]string, error) {
+	if f.assets == nil {
+		return nil, errors.New("the assets are not served by the app")
+	}
+	manifest := f.assets.Manifest()
+	if manifest == nil {
+		return nil, errors.New("the manifest of the assets is not enabled")
+	}
+	return manifest.Files, nil
+}
+
 func (f *Frontend) WindowSetSystemDefaultTheme() {
 	return
 }
@@ -452,7 +475,7 @@ func (f *Frontend) processMessage(message string) {
 	}
 
 	go func() {
-		result, err := f.dispatcher.ProcessMessage(message, f)
+		result, err := f.dispatcher.ProcessMessage(f.ctx, message, f)
 		if err != nil {
 			f.logger.Error(err.Error())
 			f.Callback(result)
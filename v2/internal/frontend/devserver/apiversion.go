@@ -0,0 +1,51 @@
+package devserver
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/wailsapp/wails/v2/internal/frontend"
+	pkgruntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// apiVersionQuery is the query parameter of the IPC websocket with the API version of the page of the client.
+// The injected IPC script always sends it, empty if the page has been served without an API version.
+const apiVersionQuery = "apiversion"
+
+// checkAPIVersion compares the API version of the page of the client with the APIVersion of the app. A client
+// whose page has been loaded from another version, e.g. a stale tab which reconnected after a restart of the
+// app, is sent the EventAPIVersionMismatch event, which the Go listeners get too, and is reloaded if
+// ReloadOnAPIVersionMismatch is set. Clients which don't send a version, e.g. custom clients, are not checked.
+func (d *DevWebServer) checkAPIVersion(conn *websocket.Conn, info *WebsocketInfo, req *http.Request) {
+	serverVersion := d.appoptions.APIVersion
+	query := req.URL.Query()
+	if serverVersion == "" || !query.Has(apiVersionQuery) {
+		return
+	}
+	clientVersion := query.Get(apiVersionQuery)
+	if clientVersion == serverVersion {
+		return
+	}
+	mismatch := pkgruntime.APIVersionMismatch{
+		ClientID:      info.id,
+		ClientVersion: clientVersion,
+		ServerVersion: serverVersion,
+		Reload:        d.appoptions.DevServer.ReloadOnAPIVersionMismatch,
+	}
+	d.logger.Warning("Websocket client '%s' has been loaded from API version '%s', the app has '%s'", info.id, clientVersion, serverVersion)
+	if events, ok := d.ctx.Value("events").(frontend.Events); ok {
+		events.Notify(d, pkgruntime.EventAPIVersionMismatch, mismatch)
+	}
+	message, err := d.goEventMessage(pkgruntime.EventAPIVersionMismatch, []interface{}{mismatch})
+	if err != nil {
+		d.logger.Error(err.Error())
+		return
+	}
+	if err := d.writeMessage(conn, info, websocket.TextMessage, []byte(message), time.Time{}); err != nil {
+		return
+	}
+	if mismatch.Reload {
+		_ = d.writeMessage(conn, info, websocket.TextMessage, []byte(d.reloadMessage), time.Time{})
+	}
+}
@@ -0,0 +1,77 @@
+package devserver
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// authCookie is the cookie the browsers send the AuthToken with, see authMiddleware
+const authCookie = "wails_token"
+
+// requestToken returns the token the client sent, either as a bearer token, with the "token" query parameter
+// or with the cookie set by authMiddleware. Browsers can't set headers on websockets, so they use the latter.
+func requestToken(req *http.Request) string {
+	if token := req.URL.Query().Get("token"); token != "" {
+		return token
+	}
+	scheme, token, ok := strings.Cut(req.Header.Get("Authorization"), " ")
+	if ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	if cookie, err := req.Cookie(authCookie); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+// isAuthorized reports whether the client may connect to the IPC websocket
+func (d *DevWebServer) isAuthorized(req *http.Request) bool {
+	expected := d.appoptions.WebSocket.AuthToken
+	if expected == "" {
+		return true
+	}
+	return subtle.ConstantTimeCompare([]byte(requestToken(req)), []byte(expected)) == 1
+}
+
+// authMiddleware requires the AuthToken from all requests, so neither the pages with the injected scripts nor
+// the IPC routes are served to clients without it. A page loaded with the "token" query parameter sets an
+// HttpOnly cookie with it, which the browser sends with its following requests and the IPC websocket.
+func (d *DevWebServer) authMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+		// Preflight requests never carry credentials
+		if req.Method == http.MethodOptions && req.Header.Get("Access-Control-Request-Method") != "" {
+			return next(c)
+		}
+		if d.isWebDAVRequest(req) {
+			return next(c)
+		}
+		if !d.isAuthorized(req) {
+			return c.String(http.StatusUnauthorized, "invalid or missing token")
+		}
+		if token := req.URL.Query().Get("token"); token != "" {
+			if cookie, err := req.Cookie(authCookie); err != nil || cookie.Value != token {
+				http.SetCookie(c.Response(), &http.Cookie{
+					Name:     authCookie,
+					Value:    token,
+					Path:     d.cookiePath(),
+					HttpOnly: true,
+					Secure:   d.usesTLS(),
+					SameSite: http.SameSiteStrictMode,
+				})
+			}
+		}
+		return next(c)
+	}
+}
+
+// cookiePath is the path of the cookies of the dev server, which covers the pages and the IPC routes
+func (d *DevWebServer) cookiePath() string {
+	if d.basePath == "" {
+		return "/"
+	}
+	return d.basePath
+}
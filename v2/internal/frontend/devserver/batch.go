@@ -0,0 +1,107 @@
+package devserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/wailsapp/wails/v2/internal/binding"
+	"github.com/wailsapp/wails/v2/internal/frontend/dispatcher"
+)
+
+// maxBatchSize is the maximum number of calls in a batch
+const maxBatchSize = 100
+
+// isBatchCall reports whether the message is a batch of calls, "C" and the JSON array of the calls. The calls
+// are processed concurrently and their results are sent at once when all have returned, as "c" and the JSON
+// array of the callbacks in the order of the calls. This saves the round trips of the calls made together, e.g.
+// by a dashboard loading its data over a remote connection.
+func isBatchCall(message string) bool {
+	return len(message) > 1 && message[0] == 'C' && message[1] == '['
+}
+
+// processBatch dispatches the calls of the batch and sends their results to the client. The results which are
+// sent on their own otherwise, like streams, are not supported. []byte results are encoded in base64.
+func (d *DevWebServer) processBatch(conn *websocket.Conn, info *WebsocketInfo, message string) error {
+	var calls []json.RawMessage
+	if err := json.Unmarshal([]byte(message[1:]), &calls); err != nil {
+		d.logger.Error("Invalid batch of websocket client %p: %s", conn, err.Error())
+		return nil
+	}
+	results := make([]*dispatcher.CallbackMessage, len(calls))
+	var wait sync.WaitGroup
+	for index, call := range calls {
+		callMessage := "C" + string(call)
+		if len(calls) > maxBatchSize {
+			results[index] = &dispatcher.CallbackMessage{
+				CallbackID: callbackIDOf([]byte(callMessage)),
+				Err:        fmt.Sprintf("a batch has at most %d calls", maxBatchSize),
+			}
+			continue
+		}
+		wait.Add(1)
+		go func(index int) {
+			defer wait.Done()
+			results[index] = d.batchCallResult(conn, info, callMessage)
+		}(index)
+	}
+	wait.Wait()
+
+	payloads := make([]json.RawMessage, len(results))
+	for index, result := range results {
+		payload, err := json.Marshal(result)
+		if err != nil {
+			d.logger.Error(err.Error())
+			payload, _ = json.Marshal(&dispatcher.CallbackMessage{Err: err.Error(), CallbackID: result.CallbackID})
+		}
+		payloads[index] = payload
+	}
+	reply, err := json.Marshal(payloads)
+	if err != nil {
+		return err
+	}
+	return d.writeMessage(conn, info, websocket.TextMessage, append([]byte("c"), reply...), time.Time{})
+}
+
+// batchCallResult dispatches the call of a batch and returns its result
+func (d *DevWebServer) batchCallResult(conn *websocket.Conn, info *WebsocketInfo, message string) *dispatcher.CallbackMessage {
+	if callbackMessage, ok := d.processSyntheticCall(message); ok {
+		return callbackMessage
+	}
+	var callbackMessage *dispatcher.CallbackMessage
+	var err error
+	if processor, ok := d.dispatcher.(callProcessor); ok {
+		callbackMessage, err = processor.ProcessCall(d.withProgress(conn, info, message), message, d)
+	} else {
+		var result string
+		result, err = d.dispatcher.ProcessMessage(d.withProgress(conn, info, message), message, d)
+		if strings.HasPrefix(result, "c") {
+			callbackMessage = &dispatcher.CallbackMessage{}
+			if unmarshalErr := json.Unmarshal([]byte(result[1:]), callbackMessage); unmarshalErr != nil {
+				callbackMessage, err = nil, unmarshalErr
+			}
+		}
+	}
+	if err != nil {
+		d.logger.Error(err.Error())
+	}
+	if callbackMessage == nil {
+		callbackMessage = &dispatcher.CallbackMessage{CallbackID: callbackIDOf([]byte(message))}
+		if err != nil {
+			callbackMessage.Err = err.Error()
+		}
+		return callbackMessage
+	}
+	if reader, ok := callbackMessage.Result.(binding.StreamReader); ok && callbackMessage.Err == nil {
+		reader.Cancel()
+		return &dispatcher.CallbackMessage{
+			CallbackID: callbackMessage.CallbackID,
+			Err:        "streamed results can't be returned in a batch",
+		}
+	}
+	return d.downloadResult(info, callbackMessage)
+}
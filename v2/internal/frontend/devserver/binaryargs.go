@@ -0,0 +1,124 @@
+package devserver
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+const (
+	// maxPendingBinaryArguments is the number of binary arguments a client may send ahead of its calls. The oldest
+	// is dropped when the client sends more, e.g. because a call referencing them has never been sent.
+	maxPendingBinaryArguments = 64
+
+	// maxPendingBinaryBytes limits the size of the pending binary arguments of a client the same way, it is raised
+	// to the MaxMessageSize if that is larger, so a single argument always fits
+	maxPendingBinaryBytes = 16 << 20
+)
+
+// binaryArguments holds the binary arguments a client sent for its next calls. A binary argument is a binary
+// message consisting of the "a" prefix, the reference of the argument, a zero byte and the bytes. The call
+// references it in place of the argument with {"$binary":"<reference>"}, which avoids the overhead of base64
+// for e.g. images passed to bound methods taking a []byte. The arguments are sent before the call on the same
+// connection, so they have always been received when the call is.
+type binaryArguments struct {
+	lock  sync.Mutex
+	data  map[string][]byte
+	order []string
+	// size is the number of bytes held, maxSize the limit, zero for none
+	size    int64
+	maxSize int64
+}
+
+// isBinaryArgument reports whether the binary message of a client is a binary argument
+func isBinaryArgument(message []byte) bool {
+	return len(message) > 0 && message[0] == 'a' && bytes.IndexByte(message, 0) > 1
+}
+
+// add stores the binary argument of the message until the call referencing it
+func (b *binaryArguments) add(message []byte) {
+	separator := bytes.IndexByte(message, 0)
+	reference := string(message[1:separator])
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.data == nil {
+		b.data = make(map[string][]byte)
+	}
+	if previous, exists := b.data[reference]; exists {
+		b.size -= int64(len(previous))
+	} else {
+		b.order = append(b.order, reference)
+	}
+	b.data[reference] = message[separator+1:]
+	b.size += int64(len(message) - separator - 1)
+	for len(b.order) > maxPendingBinaryArguments || len(b.order) > 1 && b.maxSize > 0 && b.size > b.maxSize {
+		b.size -= int64(len(b.data[b.order[0]]))
+		delete(b.data, b.order[0])
+		b.order = b.order[1:]
+	}
+}
+
+// take removes the binary argument with the reference
+func (b *binaryArguments) take(reference string) ([]byte, bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	data, ok := b.data[reference]
+	if !ok {
+		return nil, false
+	}
+	delete(b.data, reference)
+	b.size -= int64(len(data))
+	for index, pending := range b.order {
+		if pending == reference {
+			b.order = append(b.order[:index], b.order[index+1:]...)
+			break
+		}
+	}
+	return data, true
+}
+
+// binaryReference is an argument of a call which has been sent as a binary argument
+type binaryReference struct {
+	Reference *string `json:"$binary"`
+}
+
+// resolve replaces the references to binary arguments in the call message with the bytes, encoded as the
+// dispatcher expects a []byte argument. Messages which are not calls, or can't be read, e.g. because they are
+// encrypted, are returned as they are.
+func (b *binaryArguments) resolve(message []byte) ([]byte, error) {
+	if len(message) < 2 || message[0] != 'C' || !bytes.Contains(message, []byte(`"$binary"`)) {
+		return message, nil
+	}
+	var call map[string]json.RawMessage
+	if err := json.Unmarshal(message[1:], &call); err != nil {
+		return message, nil
+	}
+	var args []json.RawMessage
+	if err := json.Unmarshal(call["args"], &args); err != nil {
+		return message, nil
+	}
+	resolved := false
+	for index, arg := range args {
+		var ref binaryReference
+		if len(arg) == 0 || arg[0] != '{' || json.Unmarshal(arg, &ref) != nil || ref.Reference == nil {
+			continue
+		}
+		data, ok := b.take(*ref.Reference)
+		if !ok {
+			return nil, fmt.Errorf("binary argument '%s' has not been received", *ref.Reference)
+		}
+		args[index], _ = json.Marshal(base64.StdEncoding.EncodeToString(data))
+		resolved = true
+	}
+	if !resolved {
+		return message, nil
+	}
+	call["args"], _ = json.Marshal(args)
+	payload, err := json.Marshal(call)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte("C"), payload...), nil
+}
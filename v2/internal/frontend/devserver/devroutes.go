@@ -0,0 +1,89 @@
+//go:build dev
+// +build dev
+
+package devserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+
+	"github.com/labstack/echo/v4"
+	"github.com/wailsapp/wails/v2/pkg/assetserver"
+	assetserveroptions "github.com/wailsapp/wails/v2/pkg/options/assetserver"
+)
+
+// setupDevRoutes sets up the routes only served by dev builds: the reload endpoint, the stats, the asset
+// directory and the assets tarball. It returns the proxy to the frontend dev server for the websockets of
+// the frontend, nil if the assets are not served by a frontend dev server.
+func (d *DevWebServer) setupDevRoutes(ctx context.Context, routes *echo.Group, assetServerConfig assetserveroptions.Options, myLogger assetserver.Logger) (http.Handler, error) {
+	routes.GET("/wails/reload", d.handleReload)
+	routes.GET("/wails/stats", d.handleStats)
+	routes.GET("/wails/stats/queues", d.handleSendQueues)
+	if d.appoptions.DevServer.ServeAssetsTarball {
+		tarballHandler, err := assetserver.NewAssetsTarballHandler(assetServerConfig, myLogger)
+		if err != nil {
+			return nil, err
+		}
+		routes.GET("/wails/assets.tar.gz", echo.WrapHandler(tarballHandler))
+	}
+
+	frontendDevServerURL, _ := ctx.Value("frontenddevserverurl").(string)
+	if frontendDevServerURL == "" {
+		assetdir, _ := ctx.Value("assetdir").(string)
+		routes.GET("/wails/assetdir", func(c echo.Context) error {
+			return c.String(http.StatusOK, assetdir)
+		})
+		return nil, nil
+	}
+	externalURLs, err := assetserver.ParseFrontendDevServerURLs(frontendDevServerURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// WebSockets aren't currently supported in prod mode, so a WebSocket connection is the result of the
+	// FrontendDevServer e.g. Vite to support auto reloads.
+	// Therefore we direct WebSockets directly to the FrontendDevServer instead of returning a NotImplementedStatus.
+	return d.newFrontendDevServerProxy(externalURLs...), nil
+}
+
+// newFrontendDevServerProxy creates the reverse proxy to the frontend dev server. The X-Forwarded-Host
+// and X-Forwarded-Proto headers are set by default and the user supplied director is applied last.
+// If a ProxyObserver is set, it is called for every round trip to the frontend dev server.
+// With multiple targets, the next one is tried if the connection to a target fails.
+func (d *DevWebServer) newFrontendDevServerProxy(targets ...*url.URL) *httputil.ReverseProxy {
+	proxy := httputil.NewSingleHostReverseProxy(targets[0])
+	baseDirector := proxy.Director
+	userDirector := d.appoptions.DevServer.ProxyDirector
+	proxy.Director = func(req *http.Request) {
+		host := req.Host
+		proto := "http"
+		if req.TLS != nil {
+			proto = "https"
+		}
+
+		baseDirector(req)
+
+		setForwardedHeaders(req, host, proto)
+		if userDirector != nil {
+			userDirector(req)
+		}
+	}
+	if observer := d.appoptions.DevServer.ProxyObserver; observer != nil {
+		transport := http.DefaultTransport
+		if proxy.Transport != nil {
+			transport = proxy.Transport
+		}
+		proxy.Transport = &observedTransport{
+			transport: transport,
+			observer:  observer,
+			logger:    d.logger,
+		}
+	}
+	if len(targets) > 1 {
+		// The failover wraps the observer, so every attempt is reported with the target actually used
+		proxy.Transport = assetserver.NewFailoverTransport(d.logger, targets, proxy.Transport)
+	}
+	return proxy
+}
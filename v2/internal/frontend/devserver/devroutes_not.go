@@ -0,0 +1,19 @@
+//go:build !dev
+// +build !dev
+
+package devserver
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/wailsapp/wails/v2/pkg/assetserver"
+	assetserveroptions "github.com/wailsapp/wails/v2/pkg/options/assetserver"
+)
+
+// setupDevRoutes sets up nothing, the reload endpoint, the stats, the asset directory, the assets tarball and
+// the proxy to the frontend dev server are only served by dev builds
+func (d *DevWebServer) setupDevRoutes(ctx context.Context, routes *echo.Group, assetServerConfig assetserveroptions.Options, myLogger assetserver.Logger) (http.Handler, error) {
+	return nil, nil
+}
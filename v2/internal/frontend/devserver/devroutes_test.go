@@ -0,0 +1,196 @@
+//go:build dev
+// +build dev
+
+package devserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/matryer/is"
+	"github.com/wailsapp/wails/v2/pkg/options"
+)
+
+func TestStats(t *testing.T) {
+	i := is.New(t)
+	d, server := runTestServer(t, nil)
+
+	getStats := func() Stats {
+		resp, body := get(t, server, "/wails/stats", nil)
+		i.Equal(resp.StatusCode, http.StatusOK)
+		var stats Stats
+		i.NoErr(json.Unmarshal([]byte(body), &stats))
+		return stats
+	}
+
+	stats := getStats()
+	i.Equal(stats.Clients, 0)
+	i.Equal(stats.EventsBroadcast, uint64(0))
+	i.Equal(stats.IPCCalls, uint64(0))
+	i.True(stats.Uptime > 0)
+
+	conn := dialIPC(t, server)
+	i.True(waitForClients(d, 1))
+	d.Notify("test", 1)
+	_, err := receive(conn, time.Second)
+	i.NoErr(err)
+	i.NoErr(send(conn, `C{"name":"test","callbackID":"1"}`))
+	_, err = receive(conn, time.Second)
+	i.NoErr(err)
+
+	stats = getStats()
+	i.Equal(stats.Clients, 1)
+	i.Equal(stats.EventsBroadcast, uint64(1))
+	i.Equal(stats.IPCCalls, uint64(1))
+}
+
+func TestReloadBasePath(t *testing.T) {
+	i := is.New(t)
+	d, server := runTestServer(t, &options.App{
+		DevServer: options.DevServer{BasePath: "/myapp/"},
+	})
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/myapp/wails/ipc"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	i.NoErr(err)
+	defer conn.Close()
+	receiveClientID(t, conn)
+	i.True(waitForClients(d, 1))
+
+	// The reload endpoint is served under the base path
+	resp, _ := get(t, server, "/myapp/wails/reload", nil)
+	i.Equal(resp.StatusCode, http.StatusNoContent)
+	msg, err := receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, "reload")
+	resp, _ = get(t, server, "/wails/reload", nil)
+	i.Equal(resp.StatusCode, http.StatusNotFound)
+}
+
+func TestFrontendDevServerProxyHeaders(t *testing.T) {
+	i := is.New(t)
+
+	var received *http.Request
+	upstream := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		received = req
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+	upstreamURL, err := url.Parse(upstream.URL)
+	i.NoErr(err)
+
+	d, _ := newTestServer(t, &options.App{
+		DevServer: options.DevServer{
+			ProxyDirector: func(req *http.Request) {
+				req.Header.Set("Authorization", "Bearer secret")
+				req.URL.Path = "/rewritten" + req.URL.Path
+				req.Host = "frontend.local"
+			},
+		},
+	})
+	proxy := d.newFrontendDevServerProxy(upstreamURL)
+
+	req := httptest.NewRequest(http.MethodGet, "http://wails.local:34115/index.html", nil)
+	rec := httptest.NewRecorder()
+	proxy.ServeHTTP(rec, req)
+
+	i.Equal(rec.Code, http.StatusOK)
+	i.True(received != nil)
+	i.Equal(received.Header.Get("Authorization"), "Bearer secret")
+	i.Equal(received.Header.Get("X-Forwarded-Host"), "wails.local:34115")
+	i.Equal(received.Header.Get("X-Forwarded-Proto"), "http")
+	i.Equal(received.URL.Path, "/rewritten/index.html")
+	i.Equal(received.Host, "frontend.local")
+}
+
+func TestFrontendDevServerProxyObserver(t *testing.T) {
+	i := is.New(t)
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusTeapot)
+	}))
+	defer upstream.Close()
+	upstreamURL, err := url.Parse(upstream.URL)
+	i.NoErr(err)
+
+	type observation struct {
+		upstream string
+		status   int
+		duration time.Duration
+		err      error
+	}
+	var observations []observation
+	d, _ := newTestServer(t, &options.App{
+		DevServer: options.DevServer{
+			ProxyObserver: func(req *http.Request, resp *http.Response, duration time.Duration, err error) {
+				o := observation{upstream: req.URL.Host, duration: duration, err: err}
+				if resp != nil {
+					o.status = resp.StatusCode
+				}
+				observations = append(observations, o)
+				panic("the observer must not break the proxy")
+			},
+		},
+	})
+
+	proxy := d.newFrontendDevServerProxy(upstreamURL)
+	rec := httptest.NewRecorder()
+	proxy.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://wails.local/", nil))
+	i.Equal(rec.Code, http.StatusTeapot)
+
+	// The observer is also called for failed requests
+	upstream.Close()
+	rec = httptest.NewRecorder()
+	proxy.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://wails.local/", nil))
+	i.Equal(rec.Code, http.StatusBadGateway)
+
+	i.Equal(len(observations), 2)
+	i.Equal(observations[0].upstream, upstreamURL.Host)
+	i.Equal(observations[0].status, http.StatusTeapot)
+	i.NoErr(observations[0].err)
+	i.True(observations[0].duration > 0)
+	i.Equal(observations[1].status, 0)
+	i.True(observations[1].err != nil)
+}
+
+func TestFrontendDevServerProxyFailover(t *testing.T) {
+	i := is.New(t)
+
+	primary := httptest.NewServer(http.NotFoundHandler())
+	primaryURL, err := url.Parse(primary.URL)
+	i.NoErr(err)
+	primary.Close()
+
+	secondary := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, _ = rw.Write([]byte("secondary"))
+	}))
+	defer secondary.Close()
+	secondaryURL, err := url.Parse(secondary.URL)
+	i.NoErr(err)
+
+	var upstreams []string
+	d, _ := newTestServer(t, &options.App{
+		DevServer: options.DevServer{
+			ProxyObserver: func(req *http.Request, resp *http.Response, duration time.Duration, err error) {
+				upstreams = append(upstreams, req.URL.Host)
+			},
+		},
+	})
+	proxy := d.newFrontendDevServerProxy(primaryURL, secondaryURL)
+
+	for n := 0; n < 2; n++ {
+		req := httptest.NewRequest(http.MethodGet, "http://wails.local:34115/index.html", nil)
+		rec := httptest.NewRecorder()
+		proxy.ServeHTTP(rec, req)
+
+		i.Equal(rec.Code, http.StatusOK)
+		i.Equal(rec.Body.String(), "secondary")
+	}
+	// The primary is skipped after the failed attempt
+	i.Equal(upstreams, []string{primaryURL.Host, secondaryURL.Host, secondaryURL.Host})
+}
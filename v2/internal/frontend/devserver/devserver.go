@@ -1,38 +1,54 @@
-//go:build dev
-// +build dev
-
 // Package devserver provides a web-based frontend so that
 // it is possible to run a Wails app in a browsers.
+// It is used by dev builds and by production builds with options.App.WebServer enabled.
 package devserver
 
 import (
-	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	iofs "io/fs"
 	"log"
+	"net"
 	"net/http"
-	"net/http/httputil"
-	"net/url"
+	goruntime "runtime"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/wailsapp/wails/v2/pkg/assetserver"
 
 	"github.com/wailsapp/wails/v2/internal/frontend/runtime"
 
+	"github.com/gorilla/websocket"
 	"github.com/labstack/echo/v4"
 	"github.com/wailsapp/wails/v2/internal/binding"
 	"github.com/wailsapp/wails/v2/internal/frontend"
+	"github.com/wailsapp/wails/v2/internal/frontend/dispatcher"
 	"github.com/wailsapp/wails/v2/internal/logger"
 	"github.com/wailsapp/wails/v2/internal/menumanager"
 	"github.com/wailsapp/wails/v2/pkg/options"
-	"golang.org/x/net/websocket"
+	pkgruntime "github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
 type Screen = frontend.Screen
 
+const (
+	defaultMaxMessageSize     = 4 << 20
+	defaultMaxConcurrentCalls = 16
+	defaultSendQueueSize      = 256
+	defaultShutdownTimeout    = 5 * time.Second
+
+	// serverClosingMessage is the last message the clients receive before the server disconnects them on shutdown
+	serverClosingMessage = "server-closing"
+)
+
 type DevWebServer struct {
 	server           *echo.Echo
 	ctx              context.Context
@@ -41,9 +57,76 @@ type DevWebServer struct {
 	appBindings      *binding.Bindings
 	dispatcher       frontend.Dispatcher
 	socketMutex      sync.Mutex
-	websocketClients map[*websocket.Conn]*sync.Mutex
+	websocketClients map[*websocket.Conn]*WebsocketInfo
 	menuManager      *menumanager.Manager
-	starttime        string
+	starttime        time.Time
+
+	// clientIDs holds the IDs in use by the websocket clients, guarded by socketMutex
+	clientIDs map[string]bool
+
+	// disconnectedSessions holds the clients whose missed events are kept for a replay, guarded by socketMutex
+	disconnectedSessions map[string]*WebsocketInfo
+	// connectedSessions holds the sessions of the connected websocket clients, guarded by socketMutex
+	connectedSessions map[string]bool
+
+	// sseClients are the clients of the event stream, guarded by sseMutex
+	sseMutex   sync.Mutex
+	sseClients map[*sseClient]struct{}
+
+	// Counters reported by the stats endpoint
+	eventsBroadcast         atomic.Uint64
+	ipcCalls                atomic.Uint64
+	droppedMessages         atomic.Uint64
+	disconnectedSlowClients atomic.Uint64
+
+	// metrics are nil unless they are served
+	metrics *metrics
+
+	// tracer traces the calls over HTTP, nil unless the Telemetry is enabled
+	tracer options.Tracer
+
+	// callsInProgress counts the calls being processed, which are waited for on shutdown
+	callsInProgress atomic.Int64
+
+	// closing is closed when the shutdown starts
+	closing      chan struct{}
+	shutdownOnce sync.Once
+
+	eventClockMutex sync.Mutex
+	eventClock      int64
+
+	sessionStore options.SessionStore
+	// sessionWriter orders the writes of the session states to the sessionStore, see replay.go
+	sessionWriter *sessionWriter
+
+	assetServer *assetserver.AssetServer
+
+	eventReferences *eventReferences
+
+	// eventAcks are the reliable events waiting for the acknowledgements of the clients
+	eventAcks eventAcks
+
+	// downloads holds the streamed call results until they are fetched
+	downloads *downloads
+
+	// uploads holds the incomplete uploads of the clients, nil if uploads are disabled
+	uploads *uploads
+
+	// relayedEvents holds the browser events being relayed to the desktop frontend, to drop their echoes
+	relayedEvents *relayedEvents
+
+	// broadcastLimiter is nil if the broadcasts are not rate limited
+	broadcastLimiter *tokenBucket
+
+	syntheticMethods syntheticMethods
+
+	reloadMessage    string
+	reloadAppMessage string
+
+	// basePath is the prefix of all routes, empty when served at the root
+	basePath string
+	// webDAVPrefix is the path the WebDAV directory is served at, empty if it is disabled
+	webDAVPrefix string
 
 	// Desktop frontend
 	frontend.Frontend
@@ -54,8 +137,53 @@ type DevWebServer struct {
 func (d *DevWebServer) Run(ctx context.Context) error {
 	d.ctx = ctx
 
-	d.server.GET("/wails/reload", d.handleReload)
-	d.server.GET("/wails/ipc", d.handleIPCWebSocket)
+	if d.reloadMessage == d.reloadAppMessage {
+		return fmt.Errorf("the reload message and the reload app message must be different, both are '%s'", d.reloadMessage)
+	}
+
+	if d.appoptions.DevServer.AccessLog || d.metrics != nil {
+		d.server.Use(d.accessLogMiddleware)
+	}
+	if d.appoptions.WebSocket.EnableCORS {
+		d.server.Use(d.corsMiddleware)
+	}
+	// All routes require the token, the pages with the injected scripts as well as the IPC routes
+	if d.appoptions.WebSocket.AuthToken != "" {
+		d.server.Use(d.authMiddleware)
+	}
+	routes := d.server.Group(d.basePath)
+	routes.GET("/wails/ipc", d.handleIPCWebSocket)
+	routes.GET("/wails/event/:id", d.handleEventReference)
+	routes.GET("/wails/download/:id", d.handleDownload)
+	if d.metrics != nil {
+		routes.GET("/wails/metrics", d.handleMetrics)
+	}
+	if d.appoptions.WebSocket.EnableHTTPCalls {
+		d.tracer = dispatcher.NewTracer(d.appoptions.Telemetry)
+		routes.POST("/wails/call/:package/:struct/:method", d.handleHTTPCall)
+		routes.GET("/wails/openapi.json", d.handleOpenAPI)
+	}
+	if d.appoptions.WebSocket.EnableEventStream {
+		routes.GET("/wails/events", d.handleEventStream)
+		routes.POST("/wails/events", d.handleEventStreamEmit)
+	}
+	if config := d.appoptions.WebSocket.Uploads; config != nil {
+		uploads, err := newUploads(config)
+		if err != nil {
+			return err
+		}
+		d.uploads = uploads
+		routes.OPTIONS("/wails/upload", d.handleUploadOptions)
+		routes.POST("/wails/upload", d.handleUploadCreate)
+		routes.HEAD("/wails/upload/:id", d.handleUploadOffset)
+		routes.PATCH("/wails/upload/:id", d.handleUploadChunk)
+		routes.DELETE("/wails/upload/:id", d.handleUploadTerminate)
+	}
+	if webDAV := d.appoptions.WebServer.WebDAV; webDAV != nil {
+		if err := d.registerWebDAV(routes, webDAV); err != nil {
+			return err
+		}
+	}
 
 	assetServerConfig, err := assetserver.BuildAssetServerConfig(d.appoptions)
 	if err != nil {
@@ -67,26 +195,11 @@ func (d *DevWebServer) Run(ctx context.Context) error {
 		myLogger = _logger.(*logger.Logger)
 	}
 
-	var wsHandler http.Handler
-
-	_fronendDevServerURL, _ := ctx.Value("frontenddevserverurl").(string)
-	if _fronendDevServerURL == "" {
-		assetdir, _ := ctx.Value("assetdir").(string)
-		d.server.GET("/wails/assetdir", func(c echo.Context) error {
-			return c.String(http.StatusOK, assetdir)
-		})
-
-	} else {
-		externalURL, err := url.Parse(_fronendDevServerURL)
-		if err != nil {
-			return err
-		}
-
-		// WebSockets aren't currently supported in prod mode, so a WebSocket connection is the result of the
-		// FrontendDevServer e.g. Vite to support auto reloads.
-		// Therefore we direct WebSockets directly to the FrontendDevServer instead of returning a NotImplementedStatus.
-		wsHandler = httputil.NewSingleHostReverseProxy(externalURL)
+	wsHandler, err := d.setupDevRoutes(ctx, routes, assetServerConfig, myLogger)
+	if err != nil {
+		return err
 	}
+	_fronendDevServerURL, _ := ctx.Value("frontenddevserverurl").(string)
 
 	assetHandler, err := assetserver.NewAssetHandler(assetServerConfig, myLogger)
 	if err != nil {
@@ -94,7 +207,7 @@ func (d *DevWebServer) Run(ctx context.Context) error {
 	}
 
 	// Setup internal dev server
-	bindingsJSON, err := d.appBindings.ToJSON()
+	bindingsJSON, err := d.bindingsJSON()
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -103,56 +216,446 @@ func (d *DevWebServer) Run(ctx context.Context) error {
 	if err != nil {
 		log.Fatal(err)
 	}
+	assetServer.UseMiddlewares(assetServerConfig.Middlewares...)
+	assetServer.UseSPAFallback(assetServerConfig.SPAFallback)
+	assetServer.UseSecurityHeaders(assetServerConfig.SecurityHeaders)
+	assetServer.UseIndexTemplate(assetServerConfig.IndexTemplate)
+	assetServer.UseMimeTypes(assetServerConfig.MimeTypes, assetServerConfig.MimeSniffing)
+	assetServer.UseCompression(assetServerConfig.Compression)
+	assetServer.UseNotFoundHandler(assetServerConfig.NotFoundHandler)
+	assetServer.UseMounts(assetServerConfig.Mounts)
+	if assetServerConfig.EnableManifest && _fronendDevServerURL == "" {
+		if err := assetServer.UseManifest(assetServerConfig.Assets); err != nil {
+			return err
+		}
+	}
+	if err := assetServer.UseRuntimeModules(d.appoptions.DevServer.RuntimeModules); err != nil {
+		return err
+	}
+	assetServer.UseDesktopIPCPredicate(d.appoptions.DevServer.UseDesktopIPC)
+	assetServer.UseSpinner(!d.appoptions.DevServer.DisableSpinner)
+	assetServer.SetWebsocketIPCConfig("reload", d.reloadMessage)
+	assetServer.SetWebsocketIPCConfig("reloadapp", d.reloadAppMessage)
+	assetServer.UseBasePath(d.basePath)
+	if apiVersion := d.appoptions.APIVersion; apiVersion != "" {
+		assetServer.UseAPIVersion(apiVersion)
+	}
+	if d.appoptions.WebSocket.EnableMessagePack {
+		assetServer.SetWebsocketIPCConfig("codec", "msgpack")
+	}
+	if d.appoptions.WebSocket.EnableEventStream {
+		assetServer.SetWebsocketIPCConfig("eventstream", true)
+	}
+	d.assetServer = assetServer
 
-	d.server.Any("/*", func(c echo.Context) error {
-		if c.IsWebSocket() {
+	// The asset server and the frontend dev server get the requests without the base path
+	var assetsHandler http.Handler = assetServer
+	if d.basePath != "" {
+		assetsHandler = http.StripPrefix(d.basePath, assetServer)
+		if wsHandler != nil {
+			wsHandler = http.StripPrefix(d.basePath, wsHandler)
+		}
+		routes.Any("", func(c echo.Context) error {
+			return c.Redirect(http.StatusMovedPermanently, d.basePath+"/")
+		})
+	}
+	proxies, err := d.newProxyRules(d.appoptions.DevServer.Proxies)
+	if err != nil {
+		return err
+	}
+	routes.Any("/*", func(c echo.Context) error {
+		if proxy := proxies.match(strings.TrimPrefix(c.Request().URL.Path, d.basePath)); proxy != nil {
+			if d.basePath != "" {
+				proxy = http.StripPrefix(d.basePath, proxy)
+			}
+			proxy.ServeHTTP(c.Response(), c.Request())
+			return nil
+		}
+		if c.IsWebSocket() && wsHandler != nil {
 			wsHandler.ServeHTTP(c.Response(), c.Request())
 		} else {
-			assetServer.ServeHTTP(c.Response(), c.Request())
+			assetsHandler.ServeHTTP(c.Response(), d.withClientContext(c.Request()))
 		}
 		return nil
 	})
 
-	if devServerAddr := d.devServerAddr; devServerAddr != "" {
+	devServerAddr := d.devServerAddr
+	if listener := d.appoptions.WebSocket.Listener; listener != nil {
+		devServerAddr = listener.Addr().String()
+	}
+	if devServerAddr != "" {
 		// Start server
 		d.server.StdLogger = log.New(io.Discard, "", 0)
 
-		go func(server *echo.Echo, log *logger.Logger) {
-			var err2 error
-			if d.appoptions.WebSocket.Server != nil {
-				err2 = server.StartServer(d.appoptions.WebSocket.Server)
-			} else {
-				err2 = server.Start(devServerAddr)
-			}
-			if err2 != nil {
+		go func(log *logger.Logger) {
+			err2 := d.startServer(devServerAddr)
+			if err2 != nil && !errors.Is(err2, http.ErrServerClosed) {
 				log.Error(err2.Error())
 			}
-			d.LogDebug("Shutdown completed")
-		}(d.server, d.logger)
+		}(d.logger)
 
-		d.LogDebug("Serving DevServer at http://%s", devServerAddr)
+		scheme := "http"
+		if d.usesTLS() {
+			scheme = "https"
+		}
+		d.LogDebug("Serving DevServer at %s://%s", scheme, devServerAddr)
 	}
 
+	// The app context is not cancelled when the app quits, the server is shut down once the desktop app
+	// returns. A cancelled context shuts it down early.
+	stopped := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			d.shutdown()
+		case <-stopped:
+		}
+	}()
+
 	// Launch desktop app
 	err = d.Frontend.Run(ctx)
+	close(stopped)
+	d.shutdown()
 
 	return err
 }
 
+// startServer serves the routes at addr, over TLS if a certificate has been configured
+func (d *DevWebServer) startServer(addr string) error {
+	opts := d.appoptions.WebSocket
+	if opts.Listener != nil {
+		return d.serveListener(opts.Listener)
+	}
+	switch {
+	case opts.Server != nil:
+		return d.server.StartServer(opts.Server)
+	case opts.TLSConfig != nil:
+		d.server.TLSServer.Addr = addr
+		d.server.TLSServer.TLSConfig = opts.TLSConfig
+		return d.server.StartServer(d.server.TLSServer)
+	case opts.TLSCertFile != "":
+		return d.server.StartTLS(addr, opts.TLSCertFile, opts.TLSKeyFile)
+	default:
+		return d.server.Start(addr)
+	}
+}
+
+// serveListener serves the routes on the listener instead of listening on an address, over TLS if a
+// certificate has been configured
+func (d *DevWebServer) serveListener(listener net.Listener) error {
+	opts := d.appoptions.WebSocket
+	// The server of echo is shut down with it
+	server := d.server.Server
+	var tlsConfig *tls.Config
+	switch {
+	case opts.Server != nil:
+		server = opts.Server
+		tlsConfig = opts.Server.TLSConfig
+	case opts.TLSConfig != nil:
+		tlsConfig = opts.TLSConfig
+	case opts.TLSCertFile != "":
+		certificate, err := tls.LoadX509KeyPair(opts.TLSCertFile, opts.TLSKeyFile)
+		if err != nil {
+			return err
+		}
+		tlsConfig = &tls.Config{Certificates: []tls.Certificate{certificate}}
+	}
+	if tlsConfig != nil {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+	server.Handler = d.server
+	server.ErrorLog = d.server.StdLogger
+	return server.Serve(listener)
+}
+
+// usesTLS reports whether the routes are served over TLS
+func (d *DevWebServer) usesTLS() bool {
+	opts := d.appoptions.WebSocket
+	if opts.Server != nil {
+		return opts.Server.TLSConfig != nil
+	}
+	return opts.TLSConfig != nil || opts.TLSCertFile != ""
+}
+
+// shutdown stops the server and closes the connections of all websocket clients. The running requests and
+// calls may complete within the ShutdownTimeout, then the clients get the server closing message.
+// Only the first call shuts the server down, the others wait until it has been.
+func (d *DevWebServer) shutdown() {
+	d.shutdownOnce.Do(d.shutdownServer)
+}
+
+func (d *DevWebServer) shutdownServer() {
+	close(d.closing)
+	timeout := d.appoptions.DevServer.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := d.server.Shutdown(shutdownCtx); err != nil {
+		d.logger.Error("Unable to shutdown the DevServer: %s", err.Error())
+	}
+	d.waitForCalls(shutdownCtx)
+	if d.uploads != nil {
+		d.uploads.close()
+	}
+
+	d.socketMutex.Lock()
+	clients := d.websocketClients
+	d.websocketClients = make(map[*websocket.Conn]*WebsocketInfo)
+	d.socketMutex.Unlock()
+
+	closeMessage := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutdown")
+	for client, info := range clients {
+		info.lock.Lock()
+		_ = client.SetWriteDeadline(time.Now().Add(time.Second))
+		closing := []byte(serverClosingMessage)
+		if info.protocolVersion == envelopeProtocolVersion {
+			closing = d.encodeEnvelope(closing)
+		}
+		_ = client.WriteMessage(websocket.TextMessage, closing)
+		_ = client.WriteControl(websocket.CloseMessage, closeMessage, time.Now().Add(time.Second))
+		info.lock.Unlock()
+		_ = client.Close()
+	}
+
+	d.LogDebug("Shutdown completed")
+}
+
+// isClosing reports whether the server is being shut down
+func (d *DevWebServer) isClosing() bool {
+	select {
+	case <-d.closing:
+		return true
+	default:
+		return false
+	}
+}
+
+// waitForCalls waits until no call is in progress or the context is done
+func (d *DevWebServer) waitForCalls(ctx context.Context) {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for d.callsInProgress.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			d.logger.Warning("%d calls did not complete before the shutdown", d.callsInProgress.Load())
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
 func (d *DevWebServer) WindowReload() {
-	d.broadcast("reload")
+	d.broadcast(d.reloadMessage)
 	d.Frontend.WindowReload()
 }
 
 func (d *DevWebServer) WindowReloadApp() {
-	d.broadcast("reloadapp")
+	d.broadcast(d.reloadAppMessage)
 	d.Frontend.WindowReloadApp()
 }
 
+// RefreshBindings regenerates the bindings JSON from the app bindings and the synthetic methods and reloads all
+// connected clients, so that they pick up added or changed bound methods.
+func (d *DevWebServer) RefreshBindings() error {
+	bindingsJSON, err := d.bindingsJSON()
+	if err != nil {
+		return err
+	}
+	d.SetBindingsJSON(bindingsJSON)
+	return nil
+}
+
+// PushBindings regenerates the bindings JSON like RefreshBindings, but sends it to the connected clients, which
+// update window.go without reloading, e.g. after methods have been bound at runtime.
+func (d *DevWebServer) PushBindings() error {
+	bindingsJSON, err := d.bindingsJSON()
+	if err != nil {
+		return err
+	}
+	if d.assetServer != nil {
+		d.assetServer.SetBindingsJSON(bindingsJSON)
+	}
+	d.broadcast("b" + bindingsJSON)
+	return nil
+}
+
+// SetBindingsJSON replaces the bindings injected into the served pages and reloads all connected clients.
+func (d *DevWebServer) SetBindingsJSON(bindingsJSON string) {
+	if d.assetServer == nil {
+		return
+	}
+	d.assetServer.SetBindingsJSON(bindingsJSON)
+	d.WindowReload()
+}
+
+// SetAssetOverlay layers the overlay over the assets of the dev server and of the desktop window, then reloads
+// all connected clients.
+func (d *DevWebServer) SetAssetOverlay(overlay iofs.FS) error {
+	if d.assetServer == nil {
+		return errors.New("the dev server does not serve the assets")
+	}
+	d.assetServer.SetOverlayFS(overlay)
+	d.broadcast(d.reloadMessage)
+	if desktop, ok := d.Frontend.(interface{ SetAssetOverlay(iofs.FS) error }); ok && desktop.SetAssetOverlay(overlay) == nil {
+		// The window has been reloaded with the overlay
+		return nil
+	}
+	d.Frontend.WindowReload()
+	return nil
+}
+
+// AssetManifest returns the hashes of the asset files of the dev server, or the ones of the desktop window if
+// the dev server has no manifest
+func (d *DevWebServer) AssetManifest() (map[string]string, error) {
+	if d.assetServer != nil {
+		if manifest := d.assetServer.Manifest(); manifest != nil {
+			return manifest.Files, nil
+		}
+	}
+	if desktop, ok := d.Frontend.(interface {
+		AssetManifest() (map[string]string, error)
+	}); ok {
+		return desktop.AssetManifest()
+	}
+	return nil, errors.New("the manifest of the assets is not enabled")
+}
+
 func (d *DevWebServer) Notify(name string, data ...interface{}) {
 	d.notify(name, data...)
 }
 
+// NotifySync notifies all connected websocket clients of the event and blocks until the
+// notification has been written to every client, a write failed or the context is done.
+// The events notified before have been written then too. Unlike Notify, the desktop frontend is not notified.
+func (d *DevWebServer) NotifySync(ctx context.Context, name string, data ...interface{}) error {
+	notification := EventNotify{
+		Name: name,
+		Data: data,
+	}
+	d.tagEvent(&notification, eventSourceGo)
+	payload, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+	return d.broadcastSync(ctx, name, "n"+string(payload))
+}
+
+// ClientIDs returns the IDs of the connected websocket clients
+func (d *DevWebServer) ClientIDs() []string {
+	d.socketMutex.Lock()
+	defer d.socketMutex.Unlock()
+	result := make([]string, 0, len(d.websocketClients))
+	for _, info := range d.websocketClients {
+		result = append(result, info.id)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// ConnectedClients returns the connected websocket clients with the parameters negotiated in their
+// handshake, sorted by ID
+func (d *DevWebServer) ConnectedClients() []ClientInfo {
+	d.socketMutex.Lock()
+	defer d.socketMutex.Unlock()
+	result := make([]ClientInfo, 0, len(d.websocketClients))
+	for _, info := range d.websocketClients {
+		result = append(result, info.clientInfo())
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// BrowserClients returns the connected websocket clients with their subscriptions, sorted by ID
+func (d *DevWebServer) BrowserClients() []pkgruntime.ClientInfo {
+	d.socketMutex.Lock()
+	defer d.socketMutex.Unlock()
+	result := make([]pkgruntime.ClientInfo, 0, len(d.websocketClients))
+	for _, info := range d.websocketClients {
+		result = append(result, pkgruntime.ClientInfo{
+			ID:            info.id,
+			RemoteAddr:    info.remoteAddr,
+			UserAgent:     info.userAgent,
+			ConnectedAt:   info.connectedAt,
+			SessionID:     info.sessionID,
+			Subscriptions: info.subscriptions.Subscriptions(),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// DisconnectClient closes the connection of the websocket client, after sending it a close frame
+func (d *DevWebServer) DisconnectClient(clientID string) error {
+	conn, info := d.websocketConn(clientID)
+	if conn == nil {
+		return fmt.Errorf("client '%s' is not connected", clientID)
+	}
+	closeMessage := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "disconnected by the app")
+	info.lock.Lock()
+	_ = conn.WriteControl(websocket.CloseMessage, closeMessage, time.Now().Add(time.Second))
+	info.lock.Unlock()
+	return conn.Close()
+}
+
+// LoadSessionState returns the value stored for the key of the session in the SessionStore
+func (d *DevWebServer) LoadSessionState(sessionID string, key string) ([]byte, bool, error) {
+	return d.sessionStore.Load(sessionID, key)
+}
+
+// SaveSessionState stores the value for the key of the session in the SessionStore. The keys starting with
+// "wails:" are reserved for the state of the session kept by the dev server.
+func (d *DevWebServer) SaveSessionState(sessionID string, key string, value []byte) error {
+	if isReservedSessionKey(key) {
+		return fmt.Errorf("the session key '%s' is reserved", key)
+	}
+	return d.sessionStore.Save(sessionID, key, value)
+}
+
+// ClientSubscriptions returns the names of the events the client has subscribed to.
+// It returns nil if no client with the given ID is connected.
+func (d *DevWebServer) ClientSubscriptions(clientID string) []string {
+	info := d.websocketClient(clientID)
+	if info == nil {
+		return nil
+	}
+	return info.subscriptions.Subscriptions()
+}
+
+// ClearClientSubscriptions removes all event subscriptions of the client. Until it subscribes
+// again, the client receives all events, the same as a newly connected client.
+func (d *DevWebServer) ClearClientSubscriptions(clientID string) {
+	if info := d.websocketClient(clientID); info != nil {
+		info.subscriptions.Clear()
+	}
+}
+
+func (d *DevWebServer) websocketClient(clientID string) *WebsocketInfo {
+	_, info := d.websocketConn(clientID)
+	return info
+}
+
+func (d *DevWebServer) websocketConn(clientID string) (*websocket.Conn, *WebsocketInfo) {
+	d.socketMutex.Lock()
+	defer d.socketMutex.Unlock()
+	for conn, info := range d.websocketClients {
+		if info.id == clientID {
+			return conn, info
+		}
+	}
+	return nil, nil
+}
+
+// normalizeBasePath returns the base path with a leading and without a trailing slash, "" for the root
+func normalizeBasePath(basePath string) string {
+	basePath = strings.Trim(strings.TrimSpace(basePath), "/")
+	if basePath == "" {
+		return ""
+	}
+	return "/" + basePath
+}
+
 func (d *DevWebServer) handleReload(c echo.Context) error {
 	d.WindowReload()
 	return c.NoContent(http.StatusNoContent)
@@ -163,73 +666,672 @@ func (d *DevWebServer) handleReloadApp(c echo.Context) error {
 	return c.NoContent(http.StatusNoContent)
 }
 
+func (d *DevWebServer) handleEventReference(c echo.Context) error {
+	payload, ok := d.eventReferences.get(c.Param("id"))
+	if !ok {
+		return c.NoContent(http.StatusNotFound)
+	}
+	return c.Blob(http.StatusOK, echo.MIMEApplicationJSONCharsetUTF8, payload)
+}
+
+// Stats reports the state of the DevWebServer
+type Stats struct {
+	Clients         int    `json:"clients"`
+	EventsBroadcast uint64 `json:"eventsBroadcast"`
+	IPCCalls        uint64 `json:"ipcCalls"`
+	// DroppedMessages are the events dropped from the send queues of slow clients
+	DroppedMessages uint64 `json:"droppedMessages"`
+	// DisconnectedSlowClients are the clients disconnected because their send queue was full
+	DisconnectedSlowClients uint64    `json:"disconnectedSlowClients"`
+	StartTime               time.Time `json:"startTime"`
+	// Uptime in seconds
+	Uptime float64 `json:"uptime"`
+}
+
+// Stats returns the current number of websocket clients, the number of events broadcast and
+// IPC messages processed since the start and the uptime of the DevWebServer.
+func (d *DevWebServer) Stats() Stats {
+	d.socketMutex.Lock()
+	clients := len(d.websocketClients)
+	d.socketMutex.Unlock()
+
+	return Stats{
+		Clients:                 clients,
+		EventsBroadcast:         d.eventsBroadcast.Load(),
+		IPCCalls:                d.ipcCalls.Load(),
+		DroppedMessages:         d.droppedMessages.Load(),
+		DisconnectedSlowClients: d.disconnectedSlowClients.Load(),
+		StartTime:               d.starttime,
+		Uptime:                  time.Since(d.starttime).Seconds(),
+	}
+}
+
+func (d *DevWebServer) handleStats(c echo.Context) error {
+	return c.JSON(http.StatusOK, d.Stats())
+}
+
+// SendQueueStats reports the send queue of a websocket client
+type SendQueueStats struct {
+	ID string `json:"id"`
+	// Length is the number of queued events, Capacity the size of the queue
+	Length   int `json:"length"`
+	Capacity int `json:"capacity"`
+	// DroppedMessages are the events dropped because the queue was full
+	DroppedMessages uint64 `json:"droppedMessages"`
+}
+
+// SendQueues returns the state of the send queues of the websocket clients, sorted by ID, to find the
+// clients which do not keep up with the events
+func (d *DevWebServer) SendQueues() []SendQueueStats {
+	d.socketMutex.Lock()
+	defer d.socketMutex.Unlock()
+	result := make([]SendQueueStats, 0, len(d.websocketClients))
+	for _, info := range d.websocketClients {
+		result = append(result, SendQueueStats{
+			ID:              info.id,
+			Length:          len(info.outbound),
+			Capacity:        cap(info.outbound),
+			DroppedMessages: info.droppedMessages.Load(),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+func (d *DevWebServer) handleSendQueues(c echo.Context) error {
+	return c.JSON(http.StatusOK, d.SendQueues())
+}
+
 func (d *DevWebServer) handleIPCWebSocket(c echo.Context) error {
-	websocket.Handler(func(c *websocket.Conn) {
-		d.LogDebug(fmt.Sprintf("Websocket client %p connected", c))
+	upgrader := websocket.Upgrader{
+		EnableCompression: d.appoptions.WebSocket.EnableCompression,
+		CheckOrigin:       d.checkOrigin,
+	}
+	if d.appoptions.WebSocket.EnableMessagePack {
+		upgrader.Subprotocols = []string{msgpackSubprotocol}
+	}
+	if !d.isAuthorized(c.Request()) {
+		return c.String(http.StatusUnauthorized, "invalid or missing token")
+	}
+	clientID, err := d.reserveClientID(requestedClientID(c.Request()))
+	if err != nil {
+		return c.String(http.StatusConflict, err.Error())
+	}
+	defer d.releaseClientID(clientID)
+	c.Set(clientIDContextKey, clientID)
+
+	requestedSession, err := sessionIDOf(c.Request())
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	owner, ownerCookie, err := d.sessionOwnerOf(c.Request())
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+	var stored *sessionState
+	if requestedSession != "" {
+		stored = d.loadSession(requestedSession)
+	}
+	sessionID, err := d.reserveSession(requestedSession, owner, stored)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+	if sessionID != requestedSession {
+		stored = nil
+	}
+	// The client learns the ID of a new session from the response
+	responseHeader := http.Header{headerSessionID: {sessionID}}
+	if ownerCookie != nil {
+		responseHeader.Set("Set-Cookie", ownerCookie.String())
+	}
+	protocolVersion := requestedProtocolVersion(c.Request())
+	if protocolVersion != legacyProtocolVersion {
+		responseHeader.Set(headerProtocol, strconv.Itoa(protocolVersion))
+	}
+	conn, err := upgrader.Upgrade(c.Response(), c.Request(), responseHeader)
+	if err != nil {
+		// The upgrader has already replied with an error
+		d.LogDebug("Websocket upgrade failed: %s", err.Error())
+		d.releaseSession(sessionID)
+		return nil
+	}
+
+	d.LogDebug(fmt.Sprintf("Websocket client %p connected with id '%s'", conn, clientID))
+	info := d.newWebsocketInfo(clientID)
+	var cancelCalls context.CancelFunc
+	info.ctx, cancelCalls = context.WithCancel(context.WithValue(context.WithValue(d.ctx, "clientid", clientID), "sessionid", sessionID))
+	defer cancelCalls()
+	info.connectedAt = time.Now()
+	info.remoteAddr = c.Request().RemoteAddr
+	info.userAgent = c.Request().UserAgent()
+	info.subprotocol = conn.Subprotocol()
+	info.protocolVersion = protocolVersion
+	info.sessionID = sessionID
+	info.owner = owner
+	info.compression = negotiatedCompression(c.Request(), upgrader.EnableCompression)
+
+	// Tell the client its ID before it is registered, so it is the first message the client receives
+	if err := d.writeMessage(conn, info, websocket.TextMessage, []byte("id"+clientID), time.Time{}); err != nil {
+		d.releaseSession(sessionID)
+		_ = conn.Close()
+		return nil
+	}
+
+	// The events are sent by the writer of the client, in the order they have been queued
+	go d.writeLoop(conn, info)
+	defer close(info.closed)
+
+	d.socketMutex.Lock()
+	d.websocketClients[conn] = info
+	// The missed events are queued before any new event
+	missed := d.resumeSession(info, stored)
+	for _, message := range missed {
+		d.enqueue(conn, info, []byte(message))
+	}
+	// The session is stored with its owner, also if it is new, so other browsers can't resume it
+	connected := d.connectedState(info)
+	clients := len(d.websocketClients)
+	d.socketMutex.Unlock()
+	d.storeSession(connected)
+	d.callClientHook("OnClientConnect", d.appoptions.WebSocket.OnClientConnect, clientID)
+	d.emitClientEvent(pkgruntime.EventClientConnected, info, clients)
+	d.checkAPIVersion(conn, info, c.Request())
+
+	defer func() {
 		d.socketMutex.Lock()
-		d.websocketClients[c] = &sync.Mutex{}
-		locker := d.websocketClients[c]
+		delete(d.websocketClients, conn)
+		delete(d.connectedSessions, info.sessionID)
+		clients := len(d.websocketClients)
+		state := d.retainSession(info)
 		d.socketMutex.Unlock()
+		d.storeSession(state)
+		d.LogDebug(fmt.Sprintf("Websocket client %p disconnected", conn))
+		d.downloads.releaseClient(clientID)
+		d.callClientHook("OnClientDisconnect", d.appoptions.WebSocket.OnClientDisconnect, clientID)
+		d.emitClientEvent(pkgruntime.EventClientDisconnected, info, clients)
+	}()
 
-		defer func() {
-			d.socketMutex.Lock()
-			delete(d.websocketClients, c)
-			d.socketMutex.Unlock()
-			d.LogDebug(fmt.Sprintf("Websocket client %p disconnected", c))
-		}()
-
-		defer c.Close()
-		for {
-			var fullMsg []byte
-			var msg []byte
-			if err := websocket.Message.Receive(c, &msg); err != nil {
-				break
+	if interval := d.appoptions.WebSocket.KeepAliveInterval; interval > 0 {
+		done := make(chan struct{})
+		defer close(done)
+		go d.keepAlive(conn, info, interval, done)
+	}
+
+	defer conn.Close()
+	maxMessageSize := d.maxMessageSize()
+	conn.SetReadLimit(maxMessageSize)
+	if level := d.appoptions.WebSocket.CompressionLevel; level != 0 && info.compression {
+		if err := conn.SetCompressionLevel(level); err != nil {
+			d.logger.Error("Unable to set the compression level of websocket client %p: %s", conn, err.Error())
+		}
+	}
+	calls := make(chan struct{}, d.maxConcurrentCalls())
+	readTimeout := d.appoptions.WebSocket.ReadTimeout
+	if readTimeout > 0 {
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(readTimeout))
+		})
+	}
+	for {
+		if readTimeout > 0 {
+			_ = conn.SetReadDeadline(time.Now().Add(readTimeout))
+		}
+		// Messages split over continuation frames are reassembled, bounded by the read limit
+		messageType, fullMsg, err := readMessage(conn, maxMessageSize)
+		if err != nil {
+			var netErr net.Error
+			if errors.Is(err, websocket.ErrReadLimit) {
+				d.logger.Error("Websocket client %p exceeded the maximum message size of %d bytes", conn, maxMessageSize)
+			} else if errors.As(err, &netErr) && netErr.Timeout() {
+				d.LogDebug("Websocket client %p did not send anything within %s, disconnecting", conn, readTimeout)
+			}
+			break
+		}
+		// The IPC messages of the clients are text, binary is only used for the binary arguments of calls
+		if messageType != websocket.TextMessage {
+			if !isBinaryArgument(fullMsg) {
+				d.LogDebug("Ignoring binary message of websocket client %p", conn)
+				continue
 			}
-			buffer := bytes.Buffer{}
-			buffer.Write(msg)
-			// 修复websocket分帧导致数据不完整
-			if bytes.HasPrefix(msg, []byte(`C{"`)) {
-				for {
-					if bytes.HasSuffix(msg, []byte(`"}`)) {
-						break
-					}
-					msg = make([]byte, 0)
-					if err := websocket.Message.Receive(c, &msg); err != nil {
-						return
-					}
-					buffer.Write(msg)
+			// The binary arguments count against the rate limit of the calls
+			if !info.allow(fullMsg) {
+				d.logger.Warning("Websocket client %p exceeded the rate limit, dropping binary argument", conn)
+				if info.exceededDropThreshold() {
+					d.logger.Error("Websocket client %p exceeded the rate limit too often, disconnecting", conn)
+					break
 				}
+				continue
 			}
-			fullMsg = buffer.Bytes()
-			buffer.Reset()
-			// We do not support drag in browsers
-			if len(fullMsg) == 4 && string(fullMsg) == "drag" {
+			info.binaryArguments.add(fullMsg)
+			continue
+		}
+		if info.protocolVersion == envelopeProtocolVersion {
+			if fullMsg, err = decodeEnvelope(fullMsg); err != nil {
+				d.logger.Error("Invalid message of websocket client %p: %s", conn, err.Error())
 				continue
 			}
+		}
+		// We do not support drag in browsers
+		if len(fullMsg) == 4 && string(fullMsg) == "drag" {
+			continue
+		}
+
+		if !info.allow(fullMsg) {
+			d.logger.Warning("Websocket client %p exceeded the rate limit, dropping message", conn)
+			if reply := rateLimitedReply(fullMsg); reply != "" {
+				err = d.writeMessage(conn, info, websocket.TextMessage, []byte(reply), time.Time{})
+				if err != nil {
+					break
+				}
+			}
+			if info.exceededDropThreshold() {
+				d.logger.Error("Websocket client %p exceeded the rate limit too often, disconnecting", conn)
+				break
+			}
+			continue
+		}
 
-			// Notify the other browsers of "EventEmit"
-			if len(fullMsg) > 2 && strings.HasPrefix(string(fullMsg), "EE") {
-				d.notifyExcludingSender([]byte(fullMsg), c)
+		// Diagnostics queries are answered by the dev server, they are never dispatched
+		if isDiagnosticsQuery(fullMsg) {
+			if err := d.handleDiagnosticsQuery(conn, info, fullMsg); err != nil {
+				break
 			}
+			continue
+		}
 
-			// Send the message to dispatch to the frontend
-			result, err := d.dispatcher.ProcessMessage(string(fullMsg), d)
-			if err != nil {
-				d.logger.Error(err.Error())
+		// The acknowledgements of the reliable events are not dispatched either
+		if isEventAck(fullMsg) {
+			d.eventAcks.acknowledge(string(fullMsg[2:]), info.id)
+			continue
+		}
+
+		// Track the event subscriptions of the client, these are not dispatched
+		if len(fullMsg) > 2 && strings.HasPrefix(string(fullMsg), "EB") {
+			info.subscriptions.Subscribe(string(fullMsg[2:]), 0)
+			continue
+		}
+		if len(fullMsg) > 2 && strings.HasPrefix(string(fullMsg), "ES") {
+			if subscription, err := parseSubscribeMessage(fullMsg); err != nil {
+				d.logger.Error("Invalid subscription of websocket client %p: %s", conn, err.Error())
+			} else {
+				info.subscriptions.Subscribe(subscription.Name, subscription.Count)
 			}
-			if result != "" {
-				locker.Lock()
-				if err = websocket.Message.Send(c, result); err != nil {
-					locker.Unlock()
+			continue
+		}
+		if len(fullMsg) > 2 && strings.HasPrefix(string(fullMsg), "EX") {
+			info.subscriptions.Unsubscribe(string(fullMsg[2:]))
+		}
+		if isStreamCancel(fullMsg) {
+			info.cancelStream(string(fullMsg[2:]))
+			continue
+		}
+		if isCallCancel(fullMsg) {
+			// The call may have returned a stream already, the dispatcher cancels the call if it is in progress
+			info.cancelStream(string(fullMsg[1:]))
+		}
+
+		// Notify the other browsers of "EventEmit"
+		if len(fullMsg) > 2 && strings.HasPrefix(string(fullMsg), "EE") {
+			d.notifyExcludingSender([]byte(fullMsg), info.id)
+		}
+
+		resolved, err := info.binaryArguments.resolve(fullMsg)
+		if err != nil {
+			d.logger.Error("Invalid call of websocket client %p: %s", conn, err.Error())
+			if reply := errorReply(fullMsg, err.Error()); reply != "" {
+				if err := d.writeMessage(conn, info, websocket.TextMessage, []byte(reply), time.Time{}); err != nil {
 					break
 				}
-				locker.Unlock()
 			}
+			continue
+		}
+		fullMsg = resolved
+
+		// Send the message to dispatch to the frontend
+		d.ipcCalls.Add(1)
+		message := string(fullMsg)
+		if isCallMessage(fullMsg) {
+			// Calls are processed concurrently, so a slow bound method does not block the connection.
+			// The replies carry the callback ID, so they may be sent in any order.
+			calls <- struct{}{}
+			d.callsInProgress.Add(1)
+			go func() {
+				defer func() { <-calls }()
+				defer d.callsInProgress.Add(-1)
+				_ = d.processMessage(conn, info, message)
+			}()
+			continue
+		}
+		if err := d.processMessage(conn, info, message); err != nil {
+			break
 		}
-	}).ServeHTTP(c.Response(), c.Request())
+	}
 	return nil
 }
 
+// processMessage dispatches the message and sends the result to the client
+func (d *DevWebServer) processMessage(conn *websocket.Conn, info *WebsocketInfo, message string) error {
+	defer d.metrics.observeDispatch(time.Now())
+	if isBatchCall(message) {
+		return d.processBatch(conn, info, message)
+	}
+	if callbackMessage, ok := d.processSyntheticCall(message); ok {
+		return d.sendCallback(conn, info, callbackMessage)
+	}
+	ctx := info.ctx
+	if strings.HasPrefix(message, "C") {
+		ctx = d.withProgress(conn, info, message)
+	}
+	if processor, ok := d.dispatcher.(callProcessor); ok && strings.HasPrefix(message, "C") {
+		callbackMessage, err := processor.ProcessCall(ctx, message, d)
+		if err != nil {
+			d.logger.Error(err.Error())
+		}
+		if callbackMessage == nil {
+			return d.acknowledgeCall(conn, info, message, err)
+		}
+		return d.sendCallback(conn, info, callbackMessage)
+	}
+
+	result, err := d.dispatcher.ProcessMessage(ctx, message, d)
+	if err != nil {
+		d.logger.Error(err.Error())
+	}
+	if result == "" {
+		if isCallMessage([]byte(message)) {
+			return d.acknowledgeCall(conn, info, message, err)
+		}
+		return nil
+	}
+	return d.writeMessage(conn, info, websocket.TextMessage, []byte(result), time.Time{})
+}
+
+// callProcessor is implemented by dispatchers which return the result of a call before it is marshalled
+type callProcessor interface {
+	ProcessCall(ctx context.Context, message string, sender frontend.Frontend) (*dispatcher.CallbackMessage, error)
+}
+
+// sendCallback sends the result of a call to the client. A []byte result is sent as a binary message, to avoid
+// the overhead of base64, consisting of the "c" prefix, the callback ID, a zero byte and the bytes.
+// An io.Reader result, e.g. a runtime.Download, is not sent at all, the result is the URL the client streams
+// it from instead. The values of a stream result are sent one by one, see sendStream.
+// Clients which negotiated MessagePack get all results as binary messages with the "M" prefix.
+func (d *DevWebServer) sendCallback(conn *websocket.Conn, info *WebsocketInfo, callbackMessage *dispatcher.CallbackMessage) error {
+	if reader, ok := callbackMessage.Result.(binding.StreamReader); ok && callbackMessage.Err == nil {
+		go d.sendStream(conn, info, callbackMessage.CallbackID, reader)
+		return nil
+	}
+	callbackMessage = d.downloadResult(info, callbackMessage)
+	if info.subprotocol == msgpackSubprotocol {
+		payload, err := marshalMsgpack(callbackMessage)
+		if err != nil {
+			d.logger.Error(err.Error())
+			payload, _ = marshalMsgpack(&dispatcher.CallbackMessage{
+				Err:        err.Error(),
+				CallbackID: callbackMessage.CallbackID,
+			})
+		}
+		return d.writeMessage(conn, info, websocket.BinaryMessage, append([]byte("M"), payload...), time.Time{})
+	}
+	if data, ok := callbackMessage.Result.([]byte); ok && callbackMessage.Err == nil {
+		message := make([]byte, 0, len(callbackMessage.CallbackID)+len(data)+2)
+		message = append(message, 'c')
+		message = append(message, callbackMessage.CallbackID...)
+		message = append(message, 0)
+		message = append(message, data...)
+		return d.writeMessage(conn, info, websocket.BinaryMessage, message, time.Time{})
+	}
+
+	payload, err := json.Marshal(callbackMessage)
+	if err != nil {
+		d.logger.Error(err.Error())
+		payload, _ = json.Marshal(&dispatcher.CallbackMessage{
+			Err:        err.Error(),
+			CallbackID: callbackMessage.CallbackID,
+		})
+	}
+	return d.writeMessage(conn, info, websocket.TextMessage, append([]byte("c"), payload...), time.Time{})
+}
+
+// downloadResult replaces an io.Reader result with the URL the client streams it from
+func (d *DevWebServer) downloadResult(info *WebsocketInfo, callbackMessage *dispatcher.CallbackMessage) *dispatcher.CallbackMessage {
+	reader, ok := callbackMessage.Result.(io.Reader)
+	if !ok || callbackMessage.Err != nil {
+		return callbackMessage
+	}
+	callbackMessage = &dispatcher.CallbackMessage{CallbackID: callbackMessage.CallbackID}
+	if id, err := d.downloads.add(reader, info.id); err != nil {
+		(&download{reader: reader}).close()
+		callbackMessage.Err = err.Error()
+	} else {
+		callbackMessage.Result = d.basePath + "/wails/download/" + id
+	}
+	return callbackMessage
+}
+
+// writeMessage writes the message to the client, serialised with the other writes to the connection. The write
+// fails once the deadline has passed, or after the WriteTimeout if it is earlier. A zero deadline means none.
+// The connection is closed after a timeout, as it can't be written to anymore, which also ends its read loop.
+func (d *DevWebServer) writeMessage(conn *websocket.Conn, info *WebsocketInfo, messageType int, data []byte, deadline time.Time) error {
+	if timeout := d.appoptions.WebSocket.WriteTimeout; timeout > 0 {
+		if timeoutDeadline := time.Now().Add(timeout); deadline.IsZero() || timeoutDeadline.Before(deadline) {
+			deadline = timeoutDeadline
+		}
+	}
+	if messageType == websocket.TextMessage && info.protocolVersion == envelopeProtocolVersion {
+		data = d.encodeEnvelope(data)
+	}
+	info.lock.Lock()
+	defer info.lock.Unlock()
+	_ = conn.SetWriteDeadline(deadline)
+	err := conn.WriteMessage(messageType, data)
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		d.logger.Error("Write to websocket client %p timed out, disconnecting", conn)
+		_ = conn.Close()
+	}
+	return err
+}
+
+// acknowledgeCall replies to a call which yielded no reply, so the promise in the frontend is settled. The reply
+// has an empty result, or the error if processing the call failed.
+func (d *DevWebServer) acknowledgeCall(conn *websocket.Conn, info *WebsocketInfo, message string, err error) error {
+	if d.appoptions.WebSocket.EmptyCallResultPolicy == options.EmptyCallResultIgnore {
+		return nil
+	}
+	callbackID := callbackIDOf([]byte(message))
+	if callbackID == "" {
+		return nil
+	}
+	callbackMessage := &dispatcher.CallbackMessage{CallbackID: callbackID}
+	if err != nil {
+		callbackMessage.Err = err.Error()
+	}
+	return d.sendCallback(conn, info, callbackMessage)
+}
+
+func isCallMessage(message []byte) bool {
+	return len(message) > 0 && (message[0] == 'C' || message[0] == 'c')
+}
+
+func (d *DevWebServer) sendQueueSize() int {
+	if size := d.appoptions.WebSocket.SendQueueSize; size > 0 {
+		return size
+	}
+	return defaultSendQueueSize
+}
+
+func (d *DevWebServer) maxConcurrentCalls() int {
+	if calls := d.appoptions.WebSocket.MaxConcurrentCalls; calls > 0 {
+		return calls
+	}
+	return defaultMaxConcurrentCalls
+}
+
+// callClientHook calls the connect or disconnect callback of the application. It must not be called while
+// holding socketMutex, as the callback may broadcast events. A panic in the callback is logged.
+func (d *DevWebServer) callClientHook(name string, hook func(clientID string), clientID string) {
+	if hook == nil {
+		return
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			d.logger.Error("%s panicked for websocket client '%s': %v", name, clientID, r)
+		}
+	}()
+	hook(clientID)
+}
+
+// emitClientEvent notifies the Go listeners and the desktop frontend that a client connected or disconnected.
+// clients is the number of clients connected after the change.
+func (d *DevWebServer) emitClientEvent(name string, info *WebsocketInfo, clients int) {
+	events, ok := d.ctx.Value("events").(frontend.Events)
+	if !ok {
+		return
+	}
+	events.Notify(d, name, pkgruntime.ClientEvent{
+		ID:         info.id,
+		RemoteAddr: info.remoteAddr,
+		UserAgent:  info.userAgent,
+		SessionID:  info.sessionID,
+		Clients:    clients,
+	})
+}
+
+var (
+	errMessageDropped     = errors.New("the message was dropped from the send queue of a slow websocket client")
+	errClientDisconnected = errors.New("the websocket client disconnected before the message was written")
+)
+
+// enqueue queues the text message for the writer of the client. A client whose queue is full is too slow
+// to keep up with the events, it is handled according to the SlowClientPolicy rather than buffering an
+// unbounded number of messages.
+func (d *DevWebServer) enqueue(conn *websocket.Conn, info *WebsocketInfo, message []byte) bool {
+	return d.enqueueMessage(conn, info, outboundMessage{data: message})
+}
+
+// enqueueMessage queues the message like enqueue, it reports whether the message has been queued
+func (d *DevWebServer) enqueueMessage(conn *websocket.Conn, info *WebsocketInfo, message outboundMessage) bool {
+	for {
+		select {
+		case <-info.closed:
+			return false
+		case info.outbound <- message:
+			return true
+		default:
+		}
+		if d.appoptions.WebSocket.SlowClientPolicy != options.SlowClientDropOldest {
+			// The queue stays full until the client has been unregistered, it is only disconnected once
+			if info.evicted.CompareAndSwap(false, true) {
+				d.logger.Error("Websocket client %p does not keep up with the events, disconnecting", conn)
+				d.disconnectedSlowClients.Add(1)
+				_ = conn.Close()
+			}
+			return false
+		}
+		select {
+		case dropped := <-info.outbound:
+			d.LogDebug("Websocket client %p does not keep up with the events, dropping the oldest one", conn)
+			info.droppedMessages.Add(1)
+			d.droppedMessages.Add(1)
+			if dropped.written != nil {
+				dropped.written <- errMessageDropped
+			}
+		default:
+		}
+	}
+}
+
+// waitWritten waits until the writer of the client wrote the message queued with the written channel
+func waitWritten(ctx context.Context, info *WebsocketInfo, written chan error) error {
+	select {
+	case err := <-written:
+		return err
+	case <-info.closed:
+		// The message may have been written right before the client disconnected
+		select {
+		case err := <-written:
+			return err
+		default:
+			return errClientDisconnected
+		}
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// writeLoop sends the queued messages to the client until it disconnects
+func (d *DevWebServer) writeLoop(conn *websocket.Conn, info *WebsocketInfo) {
+	for {
+		select {
+		case <-info.closed:
+			return
+		case message := <-info.outbound:
+			err := d.writeMessage(conn, info, websocket.TextMessage, message.data, message.deadline)
+			if message.written != nil {
+				message.written <- err
+			}
+			if err != nil {
+				d.LogDebug("Unable to send to websocket client %p: %s", conn, err.Error())
+				_ = conn.Close()
+				return
+			}
+		}
+	}
+}
+
+// keepAliveMessage is a no-op message which keeps proxies from closing idle connections
+const keepAliveMessage = "k"
+
+// keepAlive sends the keep-alive message to the client at the given interval until done is closed
+func (d *DevWebServer) keepAlive(conn *websocket.Conn, info *WebsocketInfo, interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			err := d.writeMessage(conn, info, websocket.TextMessage, []byte(keepAliveMessage), time.Time{})
+			if err == nil && d.appoptions.WebSocket.ReadTimeout > 0 {
+				// Browsers answer pings automatically, the pongs keep the read deadline from expiring
+				err = d.writeMessage(conn, info, websocket.PingMessage, nil, time.Time{})
+			}
+			if err != nil {
+				d.LogDebug("Unable to send keep-alive to websocket client %p: %s", conn, err.Error())
+				return
+			}
+		}
+	}
+}
+
+// readMessage reads the next message of the client, reassembling the continuation frames after decompressing
+// them. The read limit of the connection only applies to the bytes on the wire, so the size of a compressed
+// message is limited again after decompression.
+func readMessage(conn *websocket.Conn, maxMessageSize int64) (int, []byte, error) {
+	messageType, reader, err := conn.NextReader()
+	if err != nil {
+		return messageType, nil, err
+	}
+	message, err := io.ReadAll(io.LimitReader(reader, maxMessageSize+1))
+	if err != nil {
+		return messageType, nil, err
+	}
+	if int64(len(message)) > maxMessageSize {
+		closeMessage := websocket.FormatCloseMessage(websocket.CloseMessageTooBig, "")
+		_ = conn.WriteControl(websocket.CloseMessage, closeMessage, time.Now().Add(time.Second))
+		return messageType, nil, websocket.ErrReadLimit
+	}
+	return messageType, message, nil
+}
+
+func (d *DevWebServer) maxMessageSize() int64 {
+	if size := d.appoptions.WebSocket.MaxMessageSize; size > 0 {
+		return size
+	}
+	return defaultMaxMessageSize
+}
+
 func (d *DevWebServer) LogDebug(message string, args ...interface{}) {
 	d.logger.Debug("[DevWebServer] "+message, args...)
 }
@@ -237,89 +1339,333 @@ func (d *DevWebServer) LogDebug(message string, args ...interface{}) {
 type EventNotify struct {
 	Name string        `json:"name"`
 	Data []interface{} `json:"data"`
+
+	// Sender is the ID of the websocket client that emitted the event, empty for events emitted by Go
+	Sender string `json:"sender,omitempty"`
+
+	// AckID is set on the reliable events, whose receipt the clients acknowledge, see NotifyReliable
+	AckID string `json:"ackid,omitempty"`
+
+	// Source and Timestamp are only set when an EventPolicy other than EventPolicyNone is used
+	Source         string `json:"source,omitempty"`
+	Timestamp      int64  `json:"timestamp,omitempty"`
+	LastWriterWins bool   `json:"lww,omitempty"`
+}
+
+const (
+	eventSourceGo      = "go"
+	eventSourceBrowser = "browser"
+)
+
+// tagEvent applies the configured EventPolicy to the notification
+func (d *DevWebServer) tagEvent(notification *EventNotify, source string) {
+	if d.appoptions.WebSocket.EventPolicy == options.EventPolicyNone {
+		return
+	}
+	notification.Source = source
+	notification.Timestamp = d.nextEventTimestamp()
+	notification.LastWriterWins = d.appoptions.WebSocket.EventPolicy == options.EventPolicyLastWriterWins
+}
+
+// nextEventTimestamp returns a strictly increasing timestamp so that events stamped in
+// quick succession can still be ordered by the clients.
+func (d *DevWebServer) nextEventTimestamp() int64 {
+	d.eventClockMutex.Lock()
+	defer d.eventClockMutex.Unlock()
+	now := time.Now().UnixMicro()
+	if now <= d.eventClock {
+		now = d.eventClock + 1
+	}
+	d.eventClock = now
+	return now
 }
 
 func (d *DevWebServer) broadcast(message string) {
 	d.socketMutex.Lock()
 	defer d.socketMutex.Unlock()
-	for client, locker := range d.websocketClients {
-		go func(client *websocket.Conn, locker *sync.Mutex) {
-			if client == nil {
-				d.logger.Error("Lost connection to websocket server")
-				return
+	for client, info := range d.websocketClients {
+		d.enqueue(client, info, []byte(message))
+	}
+	d.notifyEventStreamClients("", message, "")
+}
+
+// broadcastSync sends the message to all clients and waits for the writes to complete. The message is queued
+// after the events already queued for each client, so these have been written too once it returns. If the
+// context has a deadline, it is used as the write deadline of each client.
+func (d *DevWebServer) broadcastSync(ctx context.Context, eventName string, message string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	message, err := d.limitEventSize(eventName, message)
+	if err != nil {
+		return err
+	}
+	if d.broadcastLimiter != nil {
+		if err := d.broadcastLimiter.wait(ctx); err != nil {
+			return err
+		}
+	}
+	d.eventsBroadcast.Add(1)
+	d.notifyEventStreamClients(eventName, message, "")
+
+	d.socketMutex.Lock()
+	clients := make(map[*websocket.Conn]*WebsocketInfo, len(d.websocketClients))
+	for client, info := range d.websocketClients {
+		if info.subscriptions.Matches(eventName) {
+			clients[client] = info
+		}
+	}
+	d.socketMutex.Unlock()
+
+	deadline, _ := ctx.Deadline()
+	yieldEvery := d.appoptions.WebSocket.BroadcastYieldEvery
+	sent := 0
+	written := make(map[*WebsocketInfo]chan error, len(clients))
+	var result error
+	for client, info := range clients {
+		sent++
+		if yieldEvery > 0 && sent%yieldEvery == 0 {
+			goruntime.Gosched()
+		}
+		done := make(chan error, 1)
+		if !d.enqueueMessage(client, info, outboundMessage{data: []byte(message), deadline: deadline, written: done}) {
+			if result == nil {
+				result = errClientDisconnected
 			}
-			locker.Lock()
-			err := websocket.Message.Send(client, message)
-			if err != nil {
-				locker.Unlock()
-				d.logger.Error(err.Error())
-				return
+			continue
+		}
+		info.subscriptions.Consumed(eventName)
+		written[info] = done
+	}
+
+	for info, done := range written {
+		if err := waitWritten(ctx, info, done); err != nil {
+			if ctx.Err() != nil {
+				return err
 			}
-			locker.Unlock()
-		}(client, locker)
+			if result == nil {
+				result = err
+			}
+		}
+	}
+	return result
+}
+
+var errEventTooLarge = errors.New("event exceeds the maximum event size")
+
+// limitEventSize applies the OversizedEventPolicy to event messages larger than the MaxEventSize
+func (d *DevWebServer) limitEventSize(eventName string, message string) (string, error) {
+	maxEventSize := d.appoptions.WebSocket.MaxEventSize
+	if maxEventSize <= 0 || len(message) <= maxEventSize {
+		return message, nil
+	}
+	switch d.appoptions.WebSocket.OversizedEventPolicy {
+	case options.OversizedEventReference:
+		id, err := d.eventReferences.add([]byte(message[1:]))
+		if err != nil {
+			return "", err
+		}
+		reference, err := json.Marshal(struct {
+			Name string `json:"name"`
+			URL  string `json:"url"`
+		}{
+			Name: eventName,
+			URL:  d.basePath + "/wails/event/" + id,
+		})
+		if err != nil {
+			return "", err
+		}
+		return "r" + string(reference), nil
+	default:
+		return "", fmt.Errorf("%w: event '%s' is %d bytes, the limit is %d bytes", errEventTooLarge, eventName, len(message), maxEventSize)
 	}
 }
 
 func (d *DevWebServer) notify(name string, data ...interface{}) {
+	// Events relayed from a browser have already been sent to the other clients, the sender must not get them back
+	if d.relayedEvents.isEcho(name, data) {
+		d.LogDebug("Dropping the echo of event '%s' emitted by a browser", name)
+		return
+	}
+
+	if d.isReliableEvent(name) {
+		if err := d.NotifyReliable(context.Background(), name, data...); err != nil {
+			d.logger.Warning(err.Error())
+		}
+		return
+	}
+
 	// Notify
+	message, err := d.goEventMessage(name, data)
+	if err != nil {
+		d.logger.Error(err.Error())
+		return
+	}
+	d.broadcastEvent(name, message, "")
+}
+
+// NotifyClient sends the event to a single websocket client, neither the other clients nor the desktop
+// frontend are notified. The event is not sent if the client subscribed to other events only.
+func (d *DevWebServer) NotifyClient(clientID string, name string, data ...interface{}) error {
+	conn, info := d.websocketConn(clientID)
+	if info == nil {
+		return fmt.Errorf("no websocket client with id '%s' is connected", clientID)
+	}
+	if !info.subscriptions.Matches(name) {
+		return nil
+	}
+	message, err := d.goEventMessage(name, data)
+	if err != nil {
+		return err
+	}
+	message, err = d.limitEventSize(name, message)
+	if err != nil {
+		return err
+	}
+	d.eventsBroadcast.Add(1)
+	// The event is queued, so it is not sent before the events queued for the client earlier
+	written := make(chan error, 1)
+	if !d.enqueueMessage(conn, info, outboundMessage{data: []byte(message), written: written}) {
+		return errClientDisconnected
+	}
+	info.subscriptions.Consumed(name)
+	return waitWritten(context.Background(), info, written)
+}
+
+// goEventMessage returns the notification message of an event emitted in Go
+func (d *DevWebServer) goEventMessage(name string, data []interface{}) (string, error) {
 	notification := EventNotify{
 		Name: name,
 		Data: data,
 	}
+	d.tagEvent(&notification, eventSourceGo)
 	payload, err := json.Marshal(notification)
+	if err != nil {
+		return "", err
+	}
+	return "n" + string(payload), nil
+}
+
+// broadcastEvent sends the event message to all clients that subscribed to it, except the sender
+func (d *DevWebServer) broadcastEvent(eventName string, message string, senderID string) {
+	message, err := d.limitEventSize(eventName, message)
 	if err != nil {
 		d.logger.Error(err.Error())
 		return
 	}
-	d.broadcast("n" + string(payload))
-}
+	if d.broadcastLimiter != nil {
+		_ = d.broadcastLimiter.wait(context.Background())
+	}
+	d.eventsBroadcast.Add(1)
+	d.notifyEventStreamClients(eventName, message, senderID)
 
-func (d *DevWebServer) broadcastExcludingSender(message string, sender *websocket.Conn) {
+	// The clients are fanned out to without holding the lock, so the yields let connects and disconnects proceed
 	d.socketMutex.Lock()
-	defer d.socketMutex.Unlock()
-	for client, locker := range d.websocketClients {
-		go func(client *websocket.Conn, locker *sync.Mutex) {
-			if client == sender {
-				return
-			}
-			locker.Lock()
-			err := websocket.Message.Send(client, message)
-			if err != nil {
-				locker.Unlock()
-				d.logger.Error(err.Error())
-				return
-			}
-			locker.Unlock()
-		}(client, locker)
+	clients := make(map[*websocket.Conn]*WebsocketInfo, len(d.websocketClients))
+	for client, info := range d.websocketClients {
+		if (senderID != "" && info.id == senderID) || !info.subscriptions.Matches(eventName) {
+			continue
+		}
+		clients[client] = info
+	}
+	var missed []sessionState
+	for _, info := range d.disconnectedSessions {
+		if info.subscriptions.Matches(eventName) {
+			info.missed.push(message)
+			info.subscriptions.Consumed(eventName)
+			missed = append(missed, d.disconnectedState(info))
+		}
+	}
+	d.socketMutex.Unlock()
+	for _, state := range missed {
+		d.storeSession(state)
 	}
-}
 
-func (d *DevWebServer) notifyExcludingSender(eventMessage []byte, sender *websocket.Conn) {
-	message := "n" + string(eventMessage[2:])
-	d.broadcastExcludingSender(message, sender)
+	yieldEvery := d.appoptions.WebSocket.BroadcastYieldEvery
+	sent := 0
+	for client, info := range clients {
+		sent++
+		if yieldEvery > 0 && sent%yieldEvery == 0 {
+			goruntime.Gosched()
+		}
+		if d.enqueue(client, info, []byte(message)) {
+			info.subscriptions.Consumed(eventName)
+		}
+	}
+}
 
+func (d *DevWebServer) notifyExcludingSender(eventMessage []byte, senderID string) {
 	var notifyMessage EventNotify
 	err := json.Unmarshal(eventMessage[2:], &notifyMessage)
+
+	message := "n" + string(eventMessage[2:])
+	if err == nil {
+		notifyMessage.Sender = senderID
+		d.tagEvent(&notifyMessage, eventSourceBrowser)
+		payload, err := json.Marshal(notifyMessage)
+		if err != nil {
+			d.logger.Error(err.Error())
+			return
+		}
+		message = "n" + string(payload)
+	}
+	d.broadcastEvent(notifyMessage.Name, message, senderID)
+
 	if err != nil {
 		d.logger.Error(err.Error())
 		return
 	}
+	done := d.relayedEvents.relay(notifyMessage.Name, notifyMessage.Data)
+	defer done()
 	d.Frontend.Notify(notifyMessage.Name, notifyMessage.Data...)
 }
 
 func NewFrontend(ctx context.Context, appoptions *options.App, myLogger *logger.Logger, appBindings *binding.Bindings, dispatcher frontend.Dispatcher, menuManager *menumanager.Manager, desktopFrontend frontend.Frontend) *DevWebServer {
 	result := &DevWebServer{
-		ctx:              ctx,
-		Frontend:         desktopFrontend,
-		appoptions:       appoptions,
-		logger:           myLogger,
-		appBindings:      appBindings,
-		dispatcher:       dispatcher,
-		server:           echo.New(),
-		menuManager:      menuManager,
-		websocketClients: make(map[*websocket.Conn]*sync.Mutex),
+		ctx:                  ctx,
+		Frontend:             desktopFrontend,
+		appoptions:           appoptions,
+		logger:               myLogger,
+		appBindings:          appBindings,
+		dispatcher:           dispatcher,
+		server:               echo.New(),
+		menuManager:          menuManager,
+		websocketClients:     make(map[*websocket.Conn]*WebsocketInfo),
+		clientIDs:            make(map[string]bool),
+		sseClients:           make(map[*sseClient]struct{}),
+		disconnectedSessions: make(map[string]*WebsocketInfo),
+		connectedSessions:    make(map[string]bool),
+		sessionWriter:        newSessionWriter(),
+		eventReferences:      newEventReferences(),
+		downloads:            newDownloads(),
+		relayedEvents:        newRelayedEvents(),
+		closing:              make(chan struct{}),
+		starttime:            time.Now(),
+	}
+
+	result.sessionStore = appoptions.WebSocket.SessionStore
+	if result.sessionStore == nil {
+		result.sessionStore = newMemorySessionStore()
+	}
+
+	if appoptions.DevServer.EnableMetrics {
+		result.metrics = newMetrics()
+	}
+
+	if rate := appoptions.WebSocket.BroadcastRateLimit; rate > 0 {
+		result.broadcastLimiter = newTokenBucket(rate, 0)
+	}
+
+	result.reloadMessage = appoptions.DevServer.ReloadMessage
+	if result.reloadMessage == "" {
+		result.reloadMessage = "reload"
+	}
+	result.reloadAppMessage = appoptions.DevServer.ReloadAppMessage
+	if result.reloadAppMessage == "" {
+		result.reloadAppMessage = "reloadapp"
 	}
 
+	result.basePath = normalizeBasePath(appoptions.DevServer.BasePath)
 	result.devServerAddr, _ = ctx.Value("devserver").(string)
 	result.server.HideBanner = true
 	result.server.HidePort = true
@@ -0,0 +1,2706 @@
+package devserver
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+	"github.com/matryer/is"
+	"github.com/wailsapp/wails/v2/internal/binding"
+	"github.com/wailsapp/wails/v2/internal/frontend"
+	"github.com/wailsapp/wails/v2/internal/frontend/dispatcher"
+	"github.com/wailsapp/wails/v2/internal/logger"
+	"github.com/wailsapp/wails/v2/pkg/assetserver"
+	pkglogger "github.com/wailsapp/wails/v2/pkg/logger"
+	"github.com/wailsapp/wails/v2/pkg/options"
+	assetserveroptions "github.com/wailsapp/wails/v2/pkg/options/assetserver"
+	pkgruntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+type mockFrontend struct {
+	frontend.Frontend
+
+	lock     sync.Mutex
+	notified []string
+	scripts  []string
+
+	// onNotify is called for every notification if set
+	onNotify func(name string, data ...interface{})
+
+	// Run closes started and returns when quit is closed, like the desktop app when it is quit, or once
+	// the context is done
+	started chan struct{}
+	quit    chan struct{}
+}
+
+func (m *mockFrontend) Notify(name string, data ...interface{}) {
+	m.lock.Lock()
+	m.notified = append(m.notified, name)
+	m.lock.Unlock()
+	if m.onNotify != nil {
+		m.onNotify(name, data...)
+	}
+}
+
+func (m *mockFrontend) ExecJS(js string) {
+	m.lock.Lock()
+	m.scripts = append(m.scripts, js)
+	m.lock.Unlock()
+}
+
+func (m *mockFrontend) Run(ctx context.Context) error {
+	if m.started != nil {
+		close(m.started)
+	}
+	select {
+	case <-ctx.Done():
+	case <-m.quit:
+	}
+	return nil
+}
+
+func (m *mockFrontend) WindowReload()    {}
+func (m *mockFrontend) WindowReloadApp() {}
+
+type mockDispatcher struct {
+	lock     sync.Mutex
+	messages []string
+}
+
+func (m *mockDispatcher) ProcessMessage(ctx context.Context, message string, sender frontend.Frontend) (string, error) {
+	m.lock.Lock()
+	m.messages = append(m.messages, message)
+	m.lock.Unlock()
+	if strings.HasPrefix(message, "C") {
+		switch {
+		case strings.Contains(message, `"slow"`):
+			time.Sleep(300 * time.Millisecond)
+		case strings.Contains(message, `"void"`):
+			return "", nil
+		case strings.Contains(message, `"unknown"`):
+			return "", errors.New("method 'unknown' not registered")
+		}
+		return "c" + message[1:], nil
+	}
+	return "", nil
+}
+
+func newTestFrontend(t *testing.T, appoptions *options.App) *DevWebServer {
+	t.Helper()
+	if appoptions == nil {
+		appoptions = &options.App{}
+	}
+	myLogger := logger.New(pkglogger.NewDefaultLogger())
+	myLogger.SetLogLevel(pkglogger.ERROR)
+	appBindings := binding.NewBindings(myLogger, nil, nil, false, nil)
+	return NewFrontend(context.Background(), appoptions, myLogger, appBindings, &mockDispatcher{}, nil, &mockFrontend{})
+}
+
+func newTestServer(t *testing.T, appoptions *options.App) (*DevWebServer, *httptest.Server) {
+	t.Helper()
+	d := newTestFrontend(t, appoptions)
+	d.server.GET("/wails/ipc", d.handleIPCWebSocket)
+	server := httptest.NewServer(d.server)
+	t.Cleanup(server.Close)
+	return d, server
+}
+
+// runTestServer sets up all the routes of the dev server by running it, serving an index.html by default
+func runTestServer(t *testing.T, appoptions *options.App) (*DevWebServer, *httptest.Server) {
+	t.Helper()
+	d := newTestFrontend(t, withTestAssets(appoptions))
+	startTestFrontend(t, context.Background(), d)
+	server := httptest.NewServer(d.server)
+	t.Cleanup(server.Close)
+	return d, server
+}
+
+// startTestFrontend runs the dev server until the test ends and waits until it has set up its routes.
+// The mocked desktop app runs until the context is done or its quit channel is closed. The result of Run
+// is sent to the returned channel.
+func startTestFrontend(t *testing.T, ctx context.Context, d *DevWebServer) <-chan error {
+	t.Helper()
+	desktop := d.Frontend.(*mockFrontend)
+	desktop.started = make(chan struct{})
+	ctx, cancel := context.WithCancel(ctx)
+	result := make(chan error, 1)
+	go func() {
+		err := d.Run(ctx)
+		result <- err
+		close(result)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-result
+	})
+	select {
+	case <-desktop.started:
+	case err := <-result:
+		t.Fatalf("the dev server did not start: %v", err)
+	}
+	return result
+}
+
+func withTestAssets(appoptions *options.App) *options.App {
+	if appoptions == nil {
+		appoptions = &options.App{}
+	}
+	if appoptions.AssetServer == nil {
+		appoptions.AssetServer = &assetserveroptions.Options{
+			Assets: fstest.MapFS{
+				"index.html": &fstest.MapFile{Data: []byte("<html><head></head><body></body></html>")},
+			},
+		}
+	}
+	return appoptions
+}
+
+func get(t *testing.T, server *httptest.Server, path string, header http.Header) (*http.Response, string) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, server.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, string(body)
+}
+
+func dialIPC(t *testing.T, server *httptest.Server) *websocket.Conn {
+	t.Helper()
+	return dialIPCWith(t, server, websocket.DefaultDialer)
+}
+
+func dialIPCWith(t *testing.T, server *httptest.Server, dialer *websocket.Dialer) *websocket.Conn {
+	t.Helper()
+	conn, _ := dialIPCResponse(t, server, dialer)
+	return conn
+}
+
+func dialIPCResponse(t *testing.T, server *httptest.Server, dialer *websocket.Dialer) (*websocket.Conn, *http.Response) {
+	t.Helper()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/wails/ipc"
+	conn, resp, err := dialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	receiveClientID(t, conn)
+	return conn, resp
+}
+
+// receiveClientID reads the ID the server sends to every client after connecting
+func receiveClientID(t *testing.T, conn *websocket.Conn) string {
+	t.Helper()
+	msg, err := receive(conn, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(msg, "id") || len(msg) == 2 {
+		t.Fatalf("expected the client id, got '%s'", msg)
+	}
+	return msg[2:]
+}
+
+func send(conn *websocket.Conn, message string) error {
+	return conn.WriteMessage(websocket.TextMessage, []byte(message))
+}
+
+func receive(conn *websocket.Conn, timeout time.Duration) (string, error) {
+	_ = conn.SetReadDeadline(time.Now().Add(timeout))
+	_, msg, err := conn.ReadMessage()
+	return string(msg), err
+}
+
+func waitForClients(d *DevWebServer, count int) bool {
+	for i := 0; i < 100; i++ {
+		d.socketMutex.Lock()
+		n := len(d.websocketClients)
+		d.socketMutex.Unlock()
+		if n == count {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
+
+func TestMaxMessageSize(t *testing.T) {
+	i := is.New(t)
+	_, server := newTestServer(t, &options.App{
+		WebSocket: options.WebSocket{MaxMessageSize: 1024},
+	})
+
+	good := dialIPC(t, server)
+	oversized := dialIPC(t, server)
+	fragmented := dialIPCWith(t, server, &websocket.Dialer{WriteBufferSize: 256})
+
+	// A single frame larger than the limit
+	i.NoErr(send(oversized, "C"+strings.Repeat("x", 2048)))
+	_, err := receive(oversized, time.Second)
+	i.True(err != nil)
+
+	// A message split over frames which exceeds the limit in total
+	_ = send(fragmented, "C"+strings.Repeat("y", 2048))
+	_, err = receive(fragmented, time.Second)
+	i.True(err != nil)
+
+	// Other clients are unaffected
+	i.NoErr(send(good, `C{"name":"test"}`))
+	reply, err := receive(good, time.Second)
+	i.NoErr(err)
+	i.Equal(reply, `c{"name":"test"}`)
+}
+
+func TestEventPolicy(t *testing.T) {
+	i := is.New(t)
+
+	// Default behaviour leaves the notification untouched
+	d, server := newTestServer(t, nil)
+	conn := dialIPC(t, server)
+	i.True(waitForClients(d, 1))
+	d.Notify("test", 1)
+	msg, err := receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `n{"name":"test","data":[1]}`)
+
+	d, server = newTestServer(t, &options.App{
+		WebSocket: options.WebSocket{EventPolicy: options.EventPolicyTagSource},
+	})
+	sender := dialIPC(t, server)
+	receiver := dialIPC(t, server)
+	i.True(waitForClients(d, 2))
+
+	d.Notify("test", 1)
+	msg, err = receive(receiver, time.Second)
+	i.NoErr(err)
+	var fromGo EventNotify
+	i.NoErr(json.Unmarshal([]byte(msg[1:]), &fromGo))
+	i.Equal(fromGo.Source, "go")
+	i.True(fromGo.Timestamp > 0)
+	i.True(!fromGo.LastWriterWins)
+
+	i.NoErr(send(sender, `EE{"name":"test","data":[2]}`))
+	msg, err = receive(receiver, time.Second)
+	i.NoErr(err)
+	var fromBrowser EventNotify
+	i.NoErr(json.Unmarshal([]byte(msg[1:]), &fromBrowser))
+	i.Equal(fromBrowser.Source, "browser")
+	i.True(fromBrowser.Timestamp > fromGo.Timestamp)
+}
+
+func TestCompression(t *testing.T) {
+	i := is.New(t)
+	d, server := newTestServer(t, &options.App{
+		WebSocket: options.WebSocket{EnableCompression: true},
+	})
+
+	compressed, resp := dialIPCResponse(t, server, &websocket.Dialer{EnableCompression: true})
+	i.True(strings.Contains(resp.Header.Get("Sec-WebSocket-Extensions"), "permessage-deflate"))
+	uncompressed, resp := dialIPCResponse(t, server, &websocket.Dialer{})
+	i.Equal(resp.Header.Get("Sec-WebSocket-Extensions"), "")
+	i.True(waitForClients(d, 2))
+
+	// The negotiated parameters are reported for every client
+	clients := d.ConnectedClients()
+	i.Equal(len(clients), 2)
+	i.True(clients[0].Compression != clients[1].Compression)
+	for _, client := range clients {
+		i.Equal(client.Subprotocol, "")
+		i.True(!client.ConnectedAt.IsZero())
+	}
+
+	payload := strings.Repeat("wails", 1000)
+	d.Notify("test", payload)
+	for _, conn := range []*websocket.Conn{compressed, uncompressed} {
+		msg, err := receive(conn, time.Second)
+		i.NoErr(err)
+		i.Equal(msg, `n{"name":"test","data":["`+payload+`"]}`)
+
+		i.NoErr(send(conn, `C{"name":"`+payload+`"}`))
+		msg, err = receive(conn, time.Second)
+		i.NoErr(err)
+		i.Equal(msg, `c{"name":"`+payload+`"}`)
+	}
+}
+
+func TestNotifySync(t *testing.T) {
+	i := is.New(t)
+	d, server := newTestServer(t, nil)
+	first := dialIPC(t, server)
+	second := dialIPC(t, server)
+	i.True(waitForClients(d, 2))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	i.NoErr(d.NotifySync(ctx, "test", "sync"))
+
+	// The notification has already been flushed to both sockets
+	for _, conn := range []*websocket.Conn{first, second} {
+		msg, err := receive(conn, 100*time.Millisecond)
+		i.NoErr(err)
+		i.Equal(msg, `n{"name":"test","data":["sync"]}`)
+	}
+
+	// The events queued before are written first
+	for n := 0; n < 50; n++ {
+		d.Notify("queued", n)
+	}
+	i.NoErr(d.NotifySync(ctx, "test", "last"))
+	for _, conn := range []*websocket.Conn{first, second} {
+		for n := 0; n < 50; n++ {
+			msg, err := receive(conn, time.Second)
+			i.NoErr(err)
+			i.Equal(msg, `n{"name":"queued","data":[`+strconv.Itoa(n)+`]}`)
+		}
+		msg, err := receive(conn, time.Second)
+		i.NoErr(err)
+		i.Equal(msg, `n{"name":"test","data":["last"]}`)
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := d.NotifySync(cancelled, "test")
+	i.True(err != nil)
+}
+
+func TestNotifyReliable(t *testing.T) {
+	i := is.New(t)
+	d, server := newTestServer(t, &options.App{
+		WebSocket: options.WebSocket{
+			ReliableEvents:  []string{"order:*"},
+			EventAckTimeout: 200 * time.Millisecond,
+		},
+	})
+	dial := func(clientID string) *websocket.Conn {
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/wails/ipc?clientid="+clientID, nil)
+		i.NoErr(err)
+		t.Cleanup(func() { _ = conn.Close() })
+		receiveClientID(t, conn)
+		return conn
+	}
+	first, second := dial("first"), dial("second")
+	i.True(waitForClients(d, 2))
+
+	notify := func(name string) chan error {
+		result := make(chan error, 1)
+		go func() {
+			result <- d.NotifyReliable(context.Background(), name, "data")
+		}()
+		return result
+	}
+	acknowledge := func(conn *websocket.Conn, expected string) {
+		msg, err := receive(conn, time.Second)
+		i.NoErr(err)
+		i.Equal(msg, expected)
+		var event EventNotify
+		i.NoErr(json.Unmarshal([]byte(msg[1:]), &event))
+		i.NoErr(send(conn, "EA"+event.AckID))
+	}
+
+	// The event is delivered once both clients acknowledged it
+	result := notify("test")
+	acknowledge(first, `n{"name":"test","data":["data"],"ackid":"1"}`)
+	select {
+	case <-result:
+		t.Fatal("the event has been delivered before all clients acknowledged it")
+	case <-time.After(50 * time.Millisecond):
+	}
+	acknowledge(second, `n{"name":"test","data":["data"],"ackid":"1"}`)
+	i.NoErr(<-result)
+
+	// The clients which did not acknowledge the event in time are reported
+	result = notify("test")
+	acknowledge(first, `n{"name":"test","data":["data"],"ackid":"2"}`)
+	err := <-result
+	var ackErr *EventAckError
+	i.True(errors.As(err, &ackErr))
+	i.Equal(ackErr.Clients, []string{"second"})
+	i.Equal(err.Error(), "event 'test' has not been acknowledged by the clients 'second'")
+	_, _ = receive(second, time.Second)
+
+	// The ReliableEvents are delivered reliably by Notify
+	done := make(chan struct{})
+	go func() {
+		d.Notify("order:placed", "data")
+		close(done)
+	}()
+	acknowledge(first, `n{"name":"order:placed","data":["data"],"ackid":"3"}`)
+	acknowledge(second, `n{"name":"order:placed","data":["data"],"ackid":"3"}`)
+	select {
+	case <-done:
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("Notify did not return once the event has been acknowledged")
+	}
+
+	// The other events are not
+	d.Notify("other", "data")
+	msg, err := receive(first, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `n{"name":"other","data":["data"]}`)
+}
+
+type mockRuntimeAssets struct{}
+
+func (mockRuntimeAssets) DesktopIPC() []byte       { return []byte("desktopipc") }
+func (mockRuntimeAssets) WebsocketIPC() []byte     { return []byte("websocketipc") }
+func (mockRuntimeAssets) RuntimeDesktopJS() []byte { return []byte("runtime") }
+
+func TestSetBindingsJSON(t *testing.T) {
+	i := is.New(t)
+	d, server := newTestServer(t, nil)
+
+	handler := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusNotFound)
+	})
+	assetServer, err := assetserver.NewDevAssetServer(handler, `{"go":{"old":{}}}`, false, nil, mockRuntimeAssets{})
+	i.NoErr(err)
+	d.assetServer = assetServer
+	d.server.Any("/*", echo.WrapHandler(assetServer))
+
+	getRuntime := func() string {
+		resp, err := http.Get(server.URL + "/wails/runtime.js")
+		i.NoErr(err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		i.NoErr(err)
+		return string(body)
+	}
+	i.True(strings.Contains(getRuntime(), "old"))
+
+	conn := dialIPC(t, server)
+	i.True(waitForClients(d, 1))
+	d.SetBindingsJSON(`{"go":{"new":{}}}`)
+
+	msg, err := receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, "reload")
+
+	runtimeJS := getRuntime()
+	i.True(strings.Contains(runtimeJS, "new"))
+	i.True(!strings.Contains(runtimeJS, "old"))
+}
+
+func TestRateLimit(t *testing.T) {
+	i := is.New(t)
+	d, server := newTestServer(t, &options.App{
+		WebSocket: options.WebSocket{
+			RateLimit:                    0.1,
+			RateLimitBurst:               2,
+			RateLimitDisconnectThreshold: 5,
+		},
+	})
+	flooder := dialIPC(t, server)
+	good := dialIPC(t, server)
+	i.True(waitForClients(d, 2))
+
+	for n := 0; n < 4; n++ {
+		i.NoErr(send(flooder, `C{"name":"flood","callbackID":"flood-`+strconv.Itoa(n)+`"}`))
+	}
+	limited := 0
+	for n := 0; n < 4; n++ {
+		msg, err := receive(flooder, time.Second)
+		i.NoErr(err)
+		if strings.Contains(msg, "rate limit exceeded") {
+			limited++
+		}
+	}
+	i.Equal(limited, 2)
+
+	// The other client is unaffected
+	i.NoErr(send(good, `C{"name":"good","callbackID":"good-1"}`))
+	msg, err := receive(good, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `c{"name":"good","callbackID":"good-1"}`)
+
+	// Keep flooding until the client is disconnected
+	for n := 0; n < 10; n++ {
+		if send(flooder, `EE{"name":"flood","data":[]}`) != nil {
+			break
+		}
+	}
+	i.True(waitForClients(d, 1))
+}
+
+func TestReloadMessages(t *testing.T) {
+	i := is.New(t)
+	d, server := runTestServer(t, &options.App{
+		DevServer: options.DevServer{
+			ReloadMessage:    "custom-reload",
+			ReloadAppMessage: "custom-reloadapp",
+		},
+	})
+
+	// The injected IPC script is configured with the same values
+	_, ipc := get(t, server, "/wails/ipc.js", nil)
+	i.True(strings.HasPrefix(ipc, `window.wailsipcconfig={"reload":"custom-reload","reloadapp":"custom-reloadapp"};`))
+
+	conn := dialIPC(t, server)
+	i.True(waitForClients(d, 1))
+	d.WindowReload()
+	msg, err := receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, "custom-reload")
+	d.WindowReloadApp()
+	msg, err = receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, "custom-reloadapp")
+
+	invalid := newTestFrontend(t, &options.App{
+		DevServer: options.DevServer{ReloadMessage: "reloadapp"},
+	})
+	i.True(invalid.Run(context.Background()) != nil)
+}
+
+func TestClientSubscriptions(t *testing.T) {
+	i := is.New(t)
+	d, server := newTestServer(t, nil)
+	conn := dialIPC(t, server)
+	i.True(waitForClients(d, 1))
+	clientIDs := d.ClientIDs()
+	i.Equal(len(clientIDs), 1)
+	clientID := clientIDs[0]
+	i.Equal(d.ClientSubscriptions(clientID), []string{})
+	i.Equal(d.ClientSubscriptions("unknown"), nil)
+
+	i.NoErr(send(conn, "EBb"))
+	i.NoErr(send(conn, "EBa"))
+	i.NoErr(send(conn, "EBc"))
+	i.NoErr(send(conn, "EXc"))
+	deadline := time.Now().Add(time.Second)
+	for len(d.ClientSubscriptions(clientID)) != 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	i.Equal(d.ClientSubscriptions(clientID), []string{"a", "b"})
+
+	// Only subscribed events are sent
+	d.Notify("c", 1)
+	d.Notify("a", 2)
+	msg, err := receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `n{"name":"a","data":[2]}`)
+
+	// Once cleared, the client receives all events again
+	d.ClearClientSubscriptions(clientID)
+	i.Equal(d.ClientSubscriptions(clientID), []string{})
+	d.Notify("c", 3)
+	msg, err = receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `n{"name":"c","data":[3]}`)
+}
+
+func TestWildcardSubscriptions(t *testing.T) {
+	i := is.New(t)
+	d, server := newTestServer(t, nil)
+	exact := dialIPC(t, server)
+	prefix := dialIPC(t, server)
+	wildcard := dialIPC(t, server)
+	i.True(waitForClients(d, 3))
+
+	// The runtime subscribes with ES for every listener of EventsOn, EventsOnce and EventsOnMultiple
+	i.NoErr(send(exact, "EBapp:started"))
+	i.NoErr(send(prefix, `ES{"name":"app:*","count":0}`))
+	i.NoErr(send(wildcard, `ES{"name":"*","count":0}`))
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		subscribed := 0
+		for _, clientID := range d.ClientIDs() {
+			subscribed += len(d.ClientSubscriptions(clientID))
+		}
+		if subscribed == 3 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Each client reads the events it receives before the next one is sent, so the first
+	// event a client receives shows it did not receive the earlier, non-matching ones
+	tests := []struct {
+		event     string
+		receivers []*websocket.Conn
+	}{
+		{"other", []*websocket.Conn{wildcard}},
+		{"app:stopped", []*websocket.Conn{wildcard, prefix}},
+		{"app:started", []*websocket.Conn{wildcard, prefix, exact}},
+	}
+	for _, tt := range tests {
+		d.Notify(tt.event, 1)
+		for _, conn := range tt.receivers {
+			msg, err := receive(conn, time.Second)
+			i.NoErr(err)
+			i.Equal(msg, `n{"name":"`+tt.event+`","data":[1]}`)
+		}
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	i := is.New(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	i.NoErr(err)
+	addr := listener.Addr().String()
+	i.NoErr(listener.Close())
+
+	d := newTestFrontend(t, withTestAssets(nil))
+	d.devServerAddr = addr
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	startTestFrontend(t, ctx, d)
+
+	var conn *websocket.Conn
+	deadline := time.Now().Add(time.Second)
+	for {
+		conn, _, err = websocket.DefaultDialer.Dial("ws://"+addr+"/wails/ipc", nil)
+		if err == nil || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	i.NoErr(err)
+	defer conn.Close()
+	receiveClientID(t, conn)
+	i.True(waitForClients(d, 1))
+
+	// The call in progress completes before the client is disconnected
+	i.NoErr(send(conn, `C{"name":"slow","args":[],"callbackID":"slow-1"}`))
+	deadline = time.Now().Add(time.Second)
+	for d.callsInProgress.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	msg, err := receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `c{"name":"slow","args":[],"callbackID":"slow-1"}`)
+
+	// The client receives the closing message and a close frame
+	msg, err = receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, "server-closing")
+	_, err = receive(conn, time.Second)
+	var closeErr *websocket.CloseError
+	i.True(errors.As(err, &closeErr))
+	i.Equal(closeErr.Code, websocket.CloseGoingAway)
+	i.True(waitForClients(d, 0))
+
+	// The listener has been released
+	deadline = time.Now().Add(time.Second)
+	for {
+		listener, err = net.Listen("tcp", addr)
+		if err == nil || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	i.NoErr(err)
+	i.NoErr(listener.Close())
+}
+
+func TestShutdownOnQuit(t *testing.T) {
+	i := is.New(t)
+	d := newTestFrontend(t, withTestAssets(&options.App{
+		WebSocket: options.WebSocket{EnableEventStream: true},
+	}))
+	desktop := d.Frontend.(*mockFrontend)
+	desktop.quit = make(chan struct{})
+	// Like the context of the app, it is never cancelled
+	result := startTestFrontend(t, context.Background(), d)
+	server := httptest.NewServer(d.server)
+	defer server.Close()
+	conn := dialIPC(t, server)
+	i.True(waitForClients(d, 1))
+	resp, err := http.Get(server.URL + "/wails/events")
+	i.NoErr(err)
+	defer resp.Body.Close()
+	stream := bufio.NewReader(resp.Body)
+	line, err := stream.ReadString('\n')
+	i.NoErr(err)
+	i.True(strings.HasPrefix(line, "data: id"))
+
+	// Quitting the desktop app shuts the server down before Run returns
+	close(desktop.quit)
+	select {
+	case err := <-result:
+		i.NoErr(err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return")
+	}
+	i.True(d.isClosing())
+	msg, err := receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, "server-closing")
+
+	// The event stream clients get the closing message too
+	body, err := io.ReadAll(stream)
+	i.NoErr(err)
+	i.True(strings.Contains(string(body), "data: server-closing\n"))
+	_, err = receive(conn, time.Second)
+	var closeErr *websocket.CloseError
+	i.True(errors.As(err, &closeErr))
+	i.Equal(closeErr.Code, websocket.CloseGoingAway)
+	i.True(waitForClients(d, 0))
+}
+
+func TestMaxEventSize(t *testing.T) {
+	i := is.New(t)
+	large := strings.Repeat("x", 100)
+
+	// Oversized events are dropped by default
+	d, server := newTestServer(t, &options.App{
+		WebSocket: options.WebSocket{MaxEventSize: 50},
+	})
+	conn := dialIPC(t, server)
+	i.True(waitForClients(d, 1))
+	d.Notify("large", large)
+	d.Notify("small", 1)
+	msg, err := receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `n{"name":"small","data":[1]}`)
+	i.True(errors.Is(d.NotifySync(context.Background(), "large", large), errEventTooLarge))
+
+	// Or replaced by a reference
+	d, server = runTestServer(t, &options.App{
+		WebSocket: options.WebSocket{
+			MaxEventSize:         50,
+			OversizedEventPolicy: options.OversizedEventReference,
+		},
+	})
+	conn = dialIPC(t, server)
+	i.True(waitForClients(d, 1))
+	d.Notify("large", large)
+	msg, err = receive(conn, time.Second)
+	i.NoErr(err)
+	i.True(strings.HasPrefix(msg, "r"))
+	var reference struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	}
+	i.NoErr(json.Unmarshal([]byte(msg[1:]), &reference))
+	i.Equal(reference.Name, "large")
+
+	resp, body := get(t, server, reference.URL, nil)
+	i.Equal(resp.StatusCode, http.StatusOK)
+	i.Equal(body, `{"name":"large","data":["`+large+`"]}`)
+
+	resp, _ = get(t, server, "/wails/event/unknown", nil)
+	i.Equal(resp.StatusCode, http.StatusNotFound)
+}
+
+func TestRequestedClientIDs(t *testing.T) {
+	i := is.New(t)
+	d, server := newTestServer(t, nil)
+	dialIPCAt := func(path string, header http.Header) (*websocket.Conn, *http.Response, error) {
+		conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+path, header)
+		if err == nil {
+			receiveClientID(t, conn)
+		}
+		return conn, resp, err
+	}
+
+	first, _, err := dialIPCAt("/wails/ipc?clientid=ts-generator", nil)
+	i.NoErr(err)
+	defer first.Close()
+	second, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/wails/ipc", http.Header{"X-Wails-Client-ID": []string{"ts-generator"}})
+	i.NoErr(err)
+	defer second.Close()
+	i.Equal(receiveClientID(t, second), "ts-generator-2")
+	third, _, err := dialIPCAt("/wails/ipc", nil)
+	i.NoErr(err)
+	defer third.Close()
+	i.True(waitForClients(d, 3))
+
+	clientIDs := d.ClientIDs()
+	i.Equal(clientIDs[1:], []string{"ts-generator", "ts-generator-2"})
+	_, err = strconv.Atoi(clientIDs[0])
+	i.NoErr(err) // server assigned ID
+
+	// The ID is released once the client disconnects
+	i.NoErr(first.Close())
+	i.True(waitForClients(d, 2))
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		d.socketMutex.Lock()
+		released := !d.clientIDs["ts-generator"]
+		d.socketMutex.Unlock()
+		if released {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	first, _, err = dialIPCAt("/wails/ipc?clientid=ts-generator", nil)
+	i.NoErr(err)
+	defer first.Close()
+	i.True(waitForClients(d, 3))
+	i.Equal(d.ClientIDs()[1:], []string{"ts-generator", "ts-generator-2"})
+
+	// Duplicates can be rejected
+	d, server = newTestServer(t, &options.App{
+		WebSocket: options.WebSocket{RejectDuplicateClientIDs: true},
+	})
+	conn, _, err := dialIPCAt("/wails/ipc?clientid=ts-generator", nil)
+	i.NoErr(err)
+	defer conn.Close()
+	i.True(waitForClients(d, 1))
+	_, resp, err := dialIPCAt("/wails/ipc?clientid=ts-generator", nil)
+	i.True(err != nil)
+	i.Equal(resp.StatusCode, http.StatusConflict)
+}
+
+func TestFragmentedMessages(t *testing.T) {
+	i := is.New(t)
+	d, server := newTestServer(t, nil)
+
+	// Every frame carries a single byte of the message
+	fragmenting := &websocket.Dialer{WriteBufferSize: 1}
+	sender := dialIPCWith(t, server, fragmenting)
+	receiver := dialIPC(t, server)
+	i.True(waitForClients(d, 2))
+
+	i.NoErr(send(sender, `EE{"name":"fragmented","data":[1]}`))
+	msg, err := receive(receiver, time.Second)
+	i.NoErr(err)
+	i.True(strings.HasPrefix(msg, `n{"name":"fragmented","data":[1],"sender":`))
+
+	i.NoErr(send(sender, `C{"name":"fragmented","callbackID":"1"}`))
+	msg, err = receive(sender, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `c{"name":"fragmented","callbackID":"1"}`)
+
+	// Messages without a known prefix are reassembled as well
+	i.NoErr(send(sender, "EBreload"))
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		subscribed := false
+		for _, clientID := range d.ClientIDs() {
+			subscriptions := d.ClientSubscriptions(clientID)
+			subscribed = subscribed || (len(subscriptions) == 1 && subscriptions[0] == "reload")
+		}
+		if subscribed {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	d.Notify("reload", 2)
+	msg, err = receive(sender, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `n{"name":"reload","data":[2]}`)
+}
+
+func TestClientIDMessage(t *testing.T) {
+	i := is.New(t)
+	d, server := newTestServer(t, nil)
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/wails/ipc"
+
+	first, _, err := websocket.DefaultDialer.Dial(url, nil)
+	i.NoErr(err)
+	defer first.Close()
+	second, _, err := websocket.DefaultDialer.Dial(url, nil)
+	i.NoErr(err)
+	defer second.Close()
+	firstID := receiveClientID(t, first)
+	secondID := receiveClientID(t, second)
+	i.True(firstID != secondID)
+	i.True(waitForClients(d, 2))
+
+	// The ID stays the same for the life of the connection and is used in the notifications
+	for n := 0; n < 2; n++ {
+		i.NoErr(send(first, `EE{"name":"test","data":[]}`))
+		msg, err := receive(second, time.Second)
+		i.NoErr(err)
+		var notification EventNotify
+		i.NoErr(json.Unmarshal([]byte(msg[1:]), &notification))
+		i.Equal(notification.Sender, firstID)
+	}
+	clientIDs := d.ClientIDs()
+	i.True(clientIDs[0] == firstID || clientIDs[1] == firstID)
+}
+
+func TestKeepAlive(t *testing.T) {
+	i := is.New(t)
+
+	d, server := newTestServer(t, &options.App{
+		WebSocket: options.WebSocket{KeepAliveInterval: 20 * time.Millisecond},
+	})
+	conn := dialIPC(t, server)
+	i.True(waitForClients(d, 1))
+	for n := 0; n < 3; n++ {
+		msg, err := receive(conn, time.Second)
+		i.NoErr(err)
+		i.Equal(msg, keepAliveMessage)
+	}
+
+	// Disabled by default
+	d, server = newTestServer(t, nil)
+	conn = dialIPC(t, server)
+	i.True(waitForClients(d, 1))
+	_, err := receive(conn, 100*time.Millisecond)
+	i.True(err != nil)
+}
+
+func TestConcurrentCalls(t *testing.T) {
+	i := is.New(t)
+	_, server := newTestServer(t, nil)
+	conn := dialIPC(t, server)
+
+	i.NoErr(send(conn, `C{"name":"slow","callbackID":"1"}`))
+	i.NoErr(send(conn, `C{"name":"fast","callbackID":"2"}`))
+
+	msg, err := receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `c{"name":"fast","callbackID":"2"}`)
+	msg, err = receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `c{"name":"slow","callbackID":"1"}`)
+
+	// Calls are processed one after the other if only a single call may run at a time
+	_, server = newTestServer(t, &options.App{
+		WebSocket: options.WebSocket{MaxConcurrentCalls: 1},
+	})
+	conn = dialIPC(t, server)
+	i.NoErr(send(conn, `C{"name":"slow","callbackID":"1"}`))
+	i.NoErr(send(conn, `C{"name":"fast","callbackID":"2"}`))
+	msg, err = receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `c{"name":"slow","callbackID":"1"}`)
+}
+
+type BinaryApp struct{}
+
+func (b *BinaryApp) Data(size int) []byte {
+	return bytes.Repeat([]byte{0xff}, size)
+}
+
+func (b *BinaryApp) Text() string {
+	return "text"
+}
+
+func (b *BinaryApp) Sum(name string, data []byte) string {
+	sum := 0
+	for _, value := range data {
+		sum += int(value)
+	}
+	return fmt.Sprintf("%s:%d:%d", name, len(data), sum)
+}
+
+func TestBinaryCallResults(t *testing.T) {
+	i := is.New(t)
+	myLogger := logger.New(pkglogger.NewDefaultLogger())
+	myLogger.SetLogLevel(pkglogger.ERROR)
+	appBindings := binding.NewBindings(myLogger, []interface{}{&BinaryApp{}}, nil, false, nil)
+	messageDispatcher := dispatcher.NewDispatcher(context.Background(), myLogger, appBindings, nil, nil)
+	d := NewFrontend(context.Background(), &options.App{}, myLogger, appBindings, messageDispatcher, nil, &mockFrontend{})
+	d.server.GET("/wails/ipc", d.handleIPCWebSocket)
+	server := httptest.NewServer(d.server)
+	defer server.Close()
+	conn := dialIPC(t, server)
+
+	// []byte results are sent as binary, without base64 encoding
+	i.NoErr(send(conn, `C{"name":"devserver.BinaryApp.Data","args":[1024],"callbackID":"data-1"}`))
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	messageType, msg, err := conn.ReadMessage()
+	i.NoErr(err)
+	i.Equal(messageType, websocket.BinaryMessage)
+	i.Equal(len(msg), len("cdata-1")+1+1024)
+	i.Equal(string(msg[:len("cdata-1")+1]), "cdata-1\x00")
+	i.Equal(msg[len("cdata-1")+1:], bytes.Repeat([]byte{0xff}, 1024))
+
+	// Other results are unchanged
+	i.NoErr(send(conn, `C{"name":"devserver.BinaryApp.Text","args":[],"callbackID":"text-1"}`))
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	messageType, msg, err = conn.ReadMessage()
+	i.NoErr(err)
+	i.Equal(messageType, websocket.TextMessage)
+	i.Equal(string(msg), `c{"result":"text","error":null,"callbackid":"text-1"}`)
+}
+
+func TestBinaryCallArguments(t *testing.T) {
+	i := is.New(t)
+	myLogger := logger.New(pkglogger.NewDefaultLogger())
+	myLogger.SetLogLevel(pkglogger.ERROR)
+	appBindings := binding.NewBindings(myLogger, []interface{}{&BinaryApp{}}, nil, false, nil)
+	messageDispatcher := dispatcher.NewDispatcher(context.Background(), myLogger, appBindings, nil, nil)
+	d := NewFrontend(context.Background(), &options.App{}, myLogger, appBindings, messageDispatcher, nil, &mockFrontend{})
+	d.server.GET("/wails/ipc", d.handleIPCWebSocket)
+	server := httptest.NewServer(d.server)
+	defer server.Close()
+	conn := dialIPC(t, server)
+
+	// The binary argument is sent before the call referencing it
+	argument := append([]byte("a1\x00"), bytes.Repeat([]byte{2}, 1000)...)
+	i.NoErr(conn.WriteMessage(websocket.BinaryMessage, argument))
+	i.NoErr(send(conn, `C{"name":"devserver.BinaryApp.Sum","args":["image",{"$binary":"1"}],"callbackID":"sum-1"}`))
+	msg, err := receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `c{"result":"image:1000:2000","error":null,"callbackid":"sum-1"}`)
+
+	// A binary argument is only used once
+	i.NoErr(send(conn, `C{"name":"devserver.BinaryApp.Sum","args":["image",{"$binary":"1"}],"callbackID":"sum-2"}`))
+	msg, err = receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `c{"error":"binary argument '1' has not been received","callbackid":"sum-2"}`)
+
+	// The arguments may still be sent in base64
+	i.NoErr(send(conn, `C{"name":"devserver.BinaryApp.Sum","args":["text","AQE="],"callbackID":"sum-3"}`))
+	msg, err = receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `c{"result":"text:2:2","error":null,"callbackid":"sum-3"}`)
+}
+
+func TestBinaryArgumentLimits(t *testing.T) {
+	i := is.New(t)
+
+	// The oldest pending arguments are dropped once they exceed the size limit
+	var pending binaryArguments
+	pending.maxSize = 2000
+	for _, reference := range []string{"1", "2", "3"} {
+		pending.add(append([]byte("a"+reference+"\x00"), bytes.Repeat([]byte{1}, 1000)...))
+	}
+	_, ok := pending.take("1")
+	i.True(!ok)
+	_, ok = pending.take("2")
+	i.True(ok)
+	_, ok = pending.take("3")
+	i.True(ok)
+	i.Equal(pending.size, int64(0))
+
+	// The binary arguments count against the rate limit of the calls
+	myLogger := logger.New(pkglogger.NewDefaultLogger())
+	myLogger.SetLogLevel(pkglogger.ERROR)
+	appBindings := binding.NewBindings(myLogger, []interface{}{&BinaryApp{}}, nil, false, nil)
+	messageDispatcher := dispatcher.NewDispatcher(context.Background(), myLogger, appBindings, nil, nil)
+	d := NewFrontend(context.Background(), &options.App{
+		WebSocket: options.WebSocket{RateLimit: 0.1, RateLimitBurst: 1},
+	}, myLogger, appBindings, messageDispatcher, nil, &mockFrontend{})
+	d.server.GET("/wails/ipc", d.handleIPCWebSocket)
+	server := httptest.NewServer(d.server)
+	defer server.Close()
+	conn := dialIPC(t, server)
+
+	i.NoErr(conn.WriteMessage(websocket.BinaryMessage, []byte("a1\x00\x01")))
+	i.NoErr(conn.WriteMessage(websocket.BinaryMessage, []byte("a2\x00\x01")))
+	i.True(waitForClients(d, 1))
+	deadline := time.Now().Add(time.Second)
+	for {
+		info := d.websocketClient(d.ClientIDs()[0])
+		info.droppedMutex.Lock()
+		dropped := info.droppedByRate
+		info.droppedMutex.Unlock()
+		if dropped == 1 {
+			_, ok = info.binaryArguments.take("2")
+			i.True(!ok)
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the binary argument was not rate limited")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestBatchCalls(t *testing.T) {
+	i := is.New(t)
+	myLogger := logger.New(pkglogger.NewDefaultLogger())
+	myLogger.SetLogLevel(pkglogger.ERROR)
+	appBindings := binding.NewBindings(myLogger, []interface{}{&BinaryApp{}}, nil, false, nil)
+	messageDispatcher := dispatcher.NewDispatcher(context.Background(), myLogger, appBindings, nil, nil)
+	d := NewFrontend(context.Background(), &options.App{}, myLogger, appBindings, messageDispatcher, nil, &mockFrontend{})
+	d.server.GET("/wails/ipc", d.handleIPCWebSocket)
+	server := httptest.NewServer(d.server)
+	defer server.Close()
+	conn := dialIPC(t, server)
+
+	// The results are sent at once, in the order of the calls
+	i.NoErr(send(conn, `C[`+
+		`{"name":"devserver.BinaryApp.Text","args":[],"callbackID":"1"},`+
+		`{"name":"devserver.BinaryApp.Sum","args":["a","AQE="],"callbackID":"2"},`+
+		`{"name":"devserver.BinaryApp.Missing","args":[],"callbackID":"3"},`+
+		`{"name":"devserver.BinaryApp.Data","args":[2],"callbackID":"4"}]`))
+	msg, err := receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `c[`+
+		`{"result":"text","error":null,"callbackid":"1"},`+
+		`{"result":"a:2:2","error":null,"callbackid":"2"},`+
+		`{"result":null,"error":"method 'devserver.BinaryApp.Missing' not registered","callbackid":"3"},`+
+		`{"result":"//8=","error":null,"callbackid":"4"}]`)
+
+	// A batch has a maximum size
+	calls := make([]string, maxBatchSize+1)
+	for index := range calls {
+		calls[index] = fmt.Sprintf(`{"name":"devserver.BinaryApp.Text","args":[],"callbackID":"%d"}`, index)
+	}
+	i.NoErr(send(conn, "C["+strings.Join(calls, ",")+"]"))
+	msg, err = receive(conn, time.Second)
+	i.NoErr(err)
+	i.True(strings.HasPrefix(msg, `c[{"result":null,"error":"a batch has at most 100 calls","callbackid":"0"},`))
+}
+
+type ImportApp struct{}
+
+func (a *ImportApp) Import(ctx context.Context, progress *pkgruntime.Progress, files int) int {
+	for file := 0; file < files; file++ {
+		progress.Report(int64(file), int64(files), "importing")
+	}
+	progress.Report(int64(files), int64(files), "done")
+	return files
+}
+
+func TestCallProgress(t *testing.T) {
+	i := is.New(t)
+	myLogger := logger.New(pkglogger.NewDefaultLogger())
+	myLogger.SetLogLevel(pkglogger.ERROR)
+	appBindings := binding.NewBindings(myLogger, []interface{}{&ImportApp{}}, nil, false, nil)
+	messageDispatcher := dispatcher.NewDispatcher(context.Background(), myLogger, appBindings, nil, nil)
+	d := NewFrontend(context.Background(), &options.App{}, myLogger, appBindings, messageDispatcher, nil, &mockFrontend{})
+	d.server.GET("/wails/ipc", d.handleIPCWebSocket)
+	server := httptest.NewServer(d.server)
+	defer server.Close()
+	conn := dialIPC(t, server)
+
+	// The progress is not an argument of the frontend
+	method := appBindings.DB().GetMethod("devserver.ImportApp.Import")
+	i.Equal(method.InputCount(), 1)
+
+	// The updates are throttled, the first and the completing one are sent before the result
+	i.NoErr(send(conn, `C{"name":"devserver.ImportApp.Import","args":[100],"callbackID":"import-1"}`))
+	msg, err := receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `p{"callbackid":"import-1","current":0,"total":100,"message":"importing"}`)
+	msg, err = receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `p{"callbackid":"import-1","current":100,"total":100,"message":"done"}`)
+	msg, err = receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `c{"result":100,"error":null,"callbackid":"import-1"}`)
+
+	// The reports are dropped if the call has no client
+	result, err := method.Call(context.Background(), []interface{}{2})
+	i.NoErr(err)
+	i.Equal(result, 2)
+}
+
+func TestBroadcastThrottling(t *testing.T) {
+	i := is.New(t)
+	d, server := newTestServer(t, &options.App{
+		WebSocket: options.WebSocket{
+			BroadcastRateLimit:  20,
+			BroadcastYieldEvery: 1,
+		},
+	})
+	conn := dialIPC(t, server)
+	i.True(waitForClients(d, 1))
+
+	// A burst of 20 events is sent right away, the 10 following ones take half a second
+	start := time.Now()
+	for n := 0; n < 30; n++ {
+		i.NoErr(d.NotifySync(context.Background(), "test", n))
+	}
+	i.True(time.Since(start) >= 400*time.Millisecond)
+	for n := 0; n < 30; n++ {
+		msg, err := receive(conn, time.Second)
+		i.NoErr(err)
+		i.Equal(msg, `n{"name":"test","data":[`+strconv.Itoa(n)+`]}`)
+	}
+
+	// A cancelled context stops waiting
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	for n := 0; n < 30; n++ {
+		if err := d.NotifySync(ctx, "test", n); err != nil {
+			i.True(errors.Is(err, context.DeadlineExceeded))
+			return
+		}
+	}
+	t.Fatal("expected the rate limit to exceed the deadline")
+}
+
+func TestClientLifecycleCallbacks(t *testing.T) {
+	i := is.New(t)
+	connected := make(chan string, 1)
+	disconnected := make(chan string, 1)
+	var d *DevWebServer
+	d, server := newTestServer(t, &options.App{
+		WebSocket: options.WebSocket{
+			OnClientConnect: func(clientID string) {
+				// Broadcasting from the callback must not deadlock
+				d.Notify("welcome", clientID)
+				connected <- clientID
+			},
+			OnClientDisconnect: func(clientID string) {
+				disconnected <- clientID
+			},
+		},
+	})
+
+	conn := dialIPC(t, server)
+	var clientID string
+	select {
+	case clientID = <-connected:
+	case <-time.After(time.Second):
+		t.Fatal("OnClientConnect not called")
+	}
+	i.Equal(d.ClientIDs(), []string{clientID})
+	msg, err := receive(conn, time.Second)
+	i.NoErr(err)
+	i.True(strings.HasPrefix(msg, `n{"name":"welcome"`))
+
+	i.NoErr(conn.Close())
+	select {
+	case id := <-disconnected:
+		i.Equal(id, clientID)
+	case <-time.After(time.Second):
+		t.Fatal("OnClientDisconnect not called")
+	}
+
+	// A panicking callback does not break the connection or the server
+	_, server = newTestServer(t, &options.App{
+		WebSocket: options.WebSocket{
+			OnClientConnect:    func(string) { panic("connect") },
+			OnClientDisconnect: func(string) { panic("disconnect") },
+		},
+	})
+	for n := 0; n < 2; n++ {
+		conn = dialIPC(t, server)
+		i.NoErr(send(conn, `C{"name":"test","callbackID":"1"}`))
+		msg, err = receive(conn, time.Second)
+		i.NoErr(err)
+		i.Equal(msg, `c{"name":"test","callbackID":"1"}`)
+		i.NoErr(conn.Close())
+	}
+}
+
+func TestEmptyCallResults(t *testing.T) {
+	i := is.New(t)
+	_, server := newTestServer(t, nil)
+	conn := dialIPC(t, server)
+
+	i.NoErr(send(conn, `C{"name":"void","callbackID":"1"}`))
+	msg, err := receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `c{"result":null,"error":null,"callbackid":"1"}`)
+
+	i.NoErr(send(conn, `C{"name":"unknown","callbackID":"2"}`))
+	msg, err = receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `c{"result":null,"error":"method 'unknown' not registered","callbackid":"2"}`)
+
+	// Other messages are not acknowledged
+	i.NoErr(send(conn, `EE{"name":"test","data":[]}`))
+	i.NoErr(send(conn, `C{"name":"test","callbackID":"3"}`))
+	msg, err = receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `c{"name":"test","callbackID":"3"}`)
+
+	_, server = newTestServer(t, &options.App{
+		WebSocket: options.WebSocket{EmptyCallResultPolicy: options.EmptyCallResultIgnore},
+	})
+	conn = dialIPC(t, server)
+	i.NoErr(send(conn, `C{"name":"void","callbackID":"1"}`))
+	_, err = receive(conn, 200*time.Millisecond)
+	i.True(err != nil)
+}
+
+func TestRelayedEventEcho(t *testing.T) {
+	i := is.New(t)
+	d, server := newTestServer(t, nil)
+	// The desktop frontend notifies the dev server of every event again, like a hybrid setup does
+	d.Frontend.(*mockFrontend).onNotify = d.Notify
+	sender := dialIPC(t, server)
+	receiver := dialIPC(t, server)
+	i.True(waitForClients(d, 2))
+
+	i.NoErr(send(sender, `EE{"name":"test","data":[1]}`))
+	msg, err := receive(receiver, time.Second)
+	i.NoErr(err)
+	i.True(strings.HasPrefix(msg, `n{"name":"test","data":[1],"sender":"`))
+
+	// Events of Go with the same name are still delivered, once the relayed event has been handled
+	d.Notify("test", 2)
+	for _, conn := range []*websocket.Conn{sender, receiver} {
+		msg, err = receive(conn, time.Second)
+		i.NoErr(err)
+		i.Equal(msg, `n{"name":"test","data":[2]}`)
+	}
+
+	// Neither client gets the echo of the relayed event
+	for _, conn := range []*websocket.Conn{sender, receiver} {
+		_, err = receive(conn, 200*time.Millisecond)
+		i.True(err != nil)
+	}
+}
+
+func TestWriteTimeout(t *testing.T) {
+	i := is.New(t)
+	d, server := newTestServer(t, &options.App{
+		WebSocket: options.WebSocket{WriteTimeout: 100 * time.Millisecond},
+	})
+	// The client stops reading after the ID, so the buffers fill up and the writes block
+	dialIPC(t, server)
+	i.True(waitForClients(d, 1))
+
+	payload := strings.Repeat("x", 1<<20)
+	var err error
+	for n := 0; n < 256 && err == nil; n++ {
+		start := time.Now()
+		err = d.NotifySync(context.Background(), "test", payload)
+		i.True(time.Since(start) < 2*time.Second)
+	}
+	i.True(err != nil)
+	// The stalled client is disconnected
+	i.True(waitForClients(d, 0))
+}
+
+func TestSlowClientEviction(t *testing.T) {
+	i := is.New(t)
+	d, server := newTestServer(t, &options.App{
+		WebSocket: options.WebSocket{SendQueueSize: 4},
+	})
+	// The client stops reading after the ID, so its queue fills up once the buffers are full
+	dialIPC(t, server)
+	fast := dialIPC(t, server)
+	i.True(waitForClients(d, 2))
+
+	payload := strings.Repeat("x", 1<<20)
+	for n := 0; n < 256 && d.Stats().Clients == 2; n++ {
+		d.Notify("test", payload)
+		if _, err := receive(fast, 5*time.Second); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The slow client is disconnected, the other one keeps receiving the events
+	i.True(waitForClients(d, 1))
+	i.Equal(d.Stats().DisconnectedSlowClients, uint64(1))
+	d.Notify("test", "ok")
+	msg, err := receive(fast, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `n{"name":"test","data":["ok"]}`)
+}
+
+func TestSlowClientDropOldest(t *testing.T) {
+	i := is.New(t)
+	d, server := newTestServer(t, &options.App{
+		WebSocket: options.WebSocket{
+			SendQueueSize:    4,
+			SlowClientPolicy: options.SlowClientDropOldest,
+		},
+	})
+	d.server.GET("/wails/stats/queues", d.handleSendQueues)
+	// The client stops reading after the ID, so its queue fills up once the buffers are full
+	dialIPC(t, server)
+	i.True(waitForClients(d, 1))
+
+	payload := strings.Repeat("x", 1<<20)
+	for n := 0; n < 256 && d.Stats().DroppedMessages == 0; n++ {
+		d.Notify("test", payload)
+	}
+	// The slow client misses events but stays connected
+	i.True(d.Stats().DroppedMessages > 0)
+	i.Equal(d.Stats().Clients, 1)
+	i.Equal(d.Stats().DisconnectedSlowClients, uint64(0))
+
+	resp, body := get(t, server, "/wails/stats/queues", nil)
+	i.Equal(resp.StatusCode, http.StatusOK)
+	var queues []SendQueueStats
+	i.NoErr(json.Unmarshal([]byte(body), &queues))
+	i.Equal(len(queues), 1)
+	i.Equal(queues[0].Capacity, 4)
+	i.True(queues[0].Length <= 4)
+	i.Equal(queues[0].DroppedMessages, d.Stats().DroppedMessages)
+}
+
+func TestReadTimeout(t *testing.T) {
+	i := is.New(t)
+	d, server := newTestServer(t, &options.App{
+		WebSocket: options.WebSocket{ReadTimeout: 100 * time.Millisecond},
+	})
+	dialIPC(t, server)
+	i.True(waitForClients(d, 1))
+	i.True(waitForClients(d, 0))
+
+	// The pongs to the pings sent with the keep-alive messages keep the client connected
+	d, server = newTestServer(t, &options.App{
+		WebSocket: options.WebSocket{
+			ReadTimeout:       100 * time.Millisecond,
+			KeepAliveInterval: 30 * time.Millisecond,
+		},
+	})
+	conn := dialIPC(t, server)
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+	time.Sleep(300 * time.Millisecond)
+	i.Equal(len(d.ClientIDs()), 1)
+}
+
+func TestCompressedFragmentedMessages(t *testing.T) {
+	i := is.New(t)
+	d, server := newTestServer(t, &options.App{
+		WebSocket: options.WebSocket{
+			EnableCompression: true,
+			CompressionLevel:  9,
+			MaxMessageSize:    4096,
+		},
+	})
+
+	// Random data does not compress well, so the compressed message is split over many frames
+	random := make([]byte, 1024)
+	_, err := rand.Read(random)
+	i.NoErr(err)
+	name := hex.EncodeToString(random)
+	fragmenting := &websocket.Dialer{EnableCompression: true, WriteBufferSize: 64}
+	conn, resp := dialIPCResponse(t, server, fragmenting)
+	i.True(strings.Contains(resp.Header.Get("Sec-WebSocket-Extensions"), "permessage-deflate"))
+	i.True(waitForClients(d, 1))
+
+	i.NoErr(send(conn, `C{"name":"`+name+`","callbackID":"1"}`))
+	msg, err := receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `c{"name":"`+name+`","callbackID":"1"}`)
+
+	d.Notify("test", name)
+	msg, err = receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `n{"name":"test","data":["`+name+`"]}`)
+
+	// The limit applies to the decompressed message, not the compressed bytes on the wire
+	i.NoErr(send(conn, "C"+strings.Repeat("z", 100000)))
+	_, err = receive(conn, time.Second)
+	i.True(websocket.IsCloseError(err, websocket.CloseMessageTooBig))
+	i.True(waitForClients(d, 0))
+}
+
+func TestBasePath(t *testing.T) {
+	i := is.New(t)
+	d, server := runTestServer(t, &options.App{
+		DevServer: options.DevServer{BasePath: "/myapp/"},
+	})
+
+	// The assets are served under the base path and the injected scripts use it
+	resp, body := get(t, server, "/myapp/", nil)
+	i.Equal(resp.StatusCode, http.StatusOK)
+	i.True(strings.Contains(body, `<script src="/myapp/wails/ipc.js"></script>`))
+	i.True(strings.Contains(body, `<script src="/myapp/wails/runtime.js"></script>`))
+	_, ipc := get(t, server, "/myapp/wails/ipc.js", nil)
+	i.True(strings.Contains(ipc, `"basepath":"/myapp"`))
+	resp, _ = get(t, server, "/myapp", nil)
+	i.Equal(resp.Request.URL.Path, "/myapp/")
+	resp, _ = get(t, server, "/index.html", nil)
+	i.Equal(resp.StatusCode, http.StatusNotFound)
+
+	// The IPC websocket is served under the base path
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/myapp/wails/ipc"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	i.NoErr(err)
+	defer conn.Close()
+	receiveClientID(t, conn)
+	i.True(waitForClients(d, 1))
+}
+
+func TestDiagnosticsQueries(t *testing.T) {
+	i := is.New(t)
+	d, server := newTestServer(t, nil)
+	conn := dialIPC(t, server)
+
+	i.NoErr(send(conn, `D{"query":"version","id":"1"}`))
+	msg, err := receive(conn, time.Second)
+	i.NoErr(err)
+	i.True(strings.HasPrefix(msg, "d"))
+	var reply struct {
+		ID     string     `json:"id"`
+		Result ServerInfo `json:"result"`
+	}
+	i.NoErr(json.Unmarshal([]byte(msg[1:]), &reply))
+	i.Equal(reply.ID, "1")
+	i.Equal(reply.Result.ProtocolVersion, protocolVersion)
+	i.Equal(reply.Result.GoVersion, goruntime.Version())
+	i.True(reply.Result.StartTime.Equal(d.starttime))
+	i.Equal(reply.Result.Build, d.ServerInfo().Build)
+
+	i.NoErr(send(conn, `D{"query":"unknown","id":"2"}`))
+	msg, err = receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `d{"id":"2","error":"unknown diagnostics query 'unknown'"}`)
+
+	// The queries are never dispatched
+	dispatcher := d.dispatcher.(*mockDispatcher)
+	dispatcher.lock.Lock()
+	defer dispatcher.lock.Unlock()
+	i.Equal(len(dispatcher.messages), 0)
+}
+
+func TestAPIVersionMismatch(t *testing.T) {
+	i := is.New(t)
+	_, server := newTestServer(t, &options.App{
+		APIVersion: "2",
+		DevServer:  options.DevServer{ReloadOnAPIVersionMismatch: true},
+	})
+	dial := func(query string) *websocket.Conn {
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/wails/ipc?"+query, nil)
+		i.NoErr(err)
+		t.Cleanup(func() { _ = conn.Close() })
+		receiveClientID(t, conn)
+		return conn
+	}
+
+	// A stale page is told about the mismatch and reloaded
+	conn := dial("clientid=stale&apiversion=1")
+	msg, err := receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `n{"name":"wails:api-version-mismatch","data":[{"clientId":"stale","clientVersion":"1","serverVersion":"2","reload":true}]}`)
+	msg, err = receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, "reload")
+
+	// A page served without an API version is stale too
+	conn = dial("clientid=unversioned&apiversion=")
+	msg, err = receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `n{"name":"wails:api-version-mismatch","data":[{"clientId":"unversioned","clientVersion":"","serverVersion":"2","reload":true}]}`)
+
+	// The current pages and the clients which don't send a version are not checked
+	for _, query := range []string{"apiversion=2", ""} {
+		conn = dial(query)
+		_, err = receive(conn, 100*time.Millisecond)
+		i.True(err != nil)
+	}
+}
+
+func TestAuthToken(t *testing.T) {
+	i := is.New(t)
+	d, server := runTestServer(t, &options.App{
+		WebSocket: options.WebSocket{AuthToken: "s3cret"},
+	})
+
+	// Neither the page nor the injected scripts are served without the token
+	for _, path := range []string{"/", "/wails/ipc.js", "/wails/runtime.js", "/wails/openapi.json"} {
+		resp, _ := get(t, server, path, nil)
+		i.Equal(resp.StatusCode, http.StatusUnauthorized)
+	}
+
+	// The page loaded with the token sets the cookie the browser sends with its following requests,
+	// the token itself is not injected into the IPC script
+	resp, _ := get(t, server, "/?token=s3cret", nil)
+	i.Equal(resp.StatusCode, http.StatusOK)
+	cookies := resp.Cookies()
+	i.Equal(len(cookies), 1)
+	i.Equal(cookies[0].Name, authCookie)
+	i.Equal(cookies[0].Value, "s3cret")
+	i.True(cookies[0].HttpOnly)
+	i.Equal(cookies[0].SameSite, http.SameSiteStrictMode)
+	cookieHeader := http.Header{"Cookie": {cookies[0].String()}}
+	resp, ipc := get(t, server, "/wails/ipc.js", cookieHeader)
+	i.Equal(resp.StatusCode, http.StatusOK)
+	i.True(!strings.Contains(ipc, "s3cret"))
+	resp, _ = get(t, server, "/wails/ipc.js", http.Header{"Cookie": {authCookie + "=wrong"}})
+	i.Equal(resp.StatusCode, http.StatusUnauthorized)
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/wails/ipc"
+	for _, header := range []http.Header{nil, {"Authorization": {"Bearer wrong"}}} {
+		_, resp, err := websocket.DefaultDialer.Dial(url, header)
+		i.True(err != nil)
+		i.Equal(resp.StatusCode, http.StatusUnauthorized)
+	}
+	_, resp, err := websocket.DefaultDialer.Dial(url+"?token=wrong", nil)
+	i.True(err != nil)
+	i.Equal(resp.StatusCode, http.StatusUnauthorized)
+	i.Equal(len(d.ClientIDs()), 0)
+
+	conn, _, err := websocket.DefaultDialer.Dial(url+"?token=s3cret", nil)
+	i.NoErr(err)
+	defer conn.Close()
+	receiveClientID(t, conn)
+	conn, _, err = websocket.DefaultDialer.Dial(url, http.Header{"Authorization": {"Bearer s3cret"}})
+	i.NoErr(err)
+	defer conn.Close()
+	receiveClientID(t, conn)
+	conn, _, err = websocket.DefaultDialer.Dial(url, cookieHeader)
+	i.NoErr(err)
+	defer conn.Close()
+	receiveClientID(t, conn)
+	i.True(waitForClients(d, 3))
+}
+
+func TestTLS(t *testing.T) {
+	i := is.New(t)
+	// The test server is only used for its certificate and a client trusting it
+	certServer := httptest.NewTLSServer(http.NotFoundHandler())
+	defer certServer.Close()
+	clientTLSConfig := certServer.Client().Transport.(*http.Transport).TLSClientConfig
+
+	appoptions := withTestAssets(&options.App{
+		WebSocket: options.WebSocket{TLSConfig: &tls.Config{Certificates: certServer.TLS.Certificates}},
+	})
+	myLogger := logger.New(pkglogger.NewDefaultLogger())
+	myLogger.SetLogLevel(pkglogger.ERROR)
+	ctx := context.WithValue(context.Background(), "devserver", "127.0.0.1:0")
+	d := NewFrontend(ctx, appoptions, myLogger, binding.NewBindings(myLogger, nil, nil, false, nil), &mockDispatcher{}, nil, &mockFrontend{})
+	startTestFrontend(t, ctx, d)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for d.server.TLSListenerAddr() == nil && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	i.True(d.server.TLSListenerAddr() != nil)
+	addr := d.server.TLSListenerAddr().String()
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: clientTLSConfig}}
+	resp, err := client.Get("https://" + addr + "/")
+	i.NoErr(err)
+	resp.Body.Close()
+	i.Equal(resp.StatusCode, http.StatusOK)
+
+	dialer := &websocket.Dialer{TLSClientConfig: clientTLSConfig}
+	conn, _, err := dialer.Dial("wss://"+addr+"/wails/ipc", nil)
+	i.NoErr(err)
+	defer conn.Close()
+	receiveClientID(t, conn)
+	i.True(waitForClients(d, 1))
+}
+
+func TestUnixSocketListener(t *testing.T) {
+	i := is.New(t)
+	socket := filepath.Join(t.TempDir(), "wails.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("unix sockets are not supported: %s", err)
+	}
+
+	d := newTestFrontend(t, withTestAssets(&options.App{
+		WebSocket: options.WebSocket{Listener: listener},
+	}))
+	startTestFrontend(t, context.Background(), d)
+
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+	}
+	client := &http.Client{Transport: &http.Transport{DialContext: dial}}
+	resp, err := client.Get("http://wails.localhost/")
+	i.NoErr(err)
+	resp.Body.Close()
+	i.Equal(resp.StatusCode, http.StatusOK)
+
+	dialer := &websocket.Dialer{NetDialContext: dial}
+	conn, _, err := dialer.Dial("ws://wails.localhost/wails/ipc", nil)
+	i.NoErr(err)
+	defer conn.Close()
+	receiveClientID(t, conn)
+	i.True(waitForClients(d, 1))
+}
+
+func TestAllowedOrigins(t *testing.T) {
+	i := is.New(t)
+	d, server := runTestServer(t, &options.App{
+		WebSocket: options.WebSocket{
+			AllowedOrigins: []string{"https://app.example.com"},
+			EnableCORS:     true,
+		},
+	})
+
+	// The IPC websocket only accepts the allowed origins and the dev server itself
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/wails/ipc"
+	_, resp, err := websocket.DefaultDialer.Dial(url, http.Header{"Origin": {"https://evil.example.com"}})
+	i.True(err != nil)
+	i.Equal(resp.StatusCode, http.StatusForbidden)
+	for _, origin := range []string{"https://app.example.com", server.URL} {
+		conn, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Origin": {origin}})
+		i.NoErr(err)
+		defer conn.Close()
+		receiveClientID(t, conn)
+	}
+	i.True(waitForClients(d, 2))
+
+	// The assets get CORS headers for the allowed origins
+	resp, _ = get(t, server, "/", http.Header{"Origin": {"https://app.example.com"}})
+	i.Equal(resp.Header.Get("Access-Control-Allow-Origin"), "https://app.example.com")
+	resp, _ = get(t, server, "/", http.Header{"Origin": {"https://evil.example.com"}})
+	i.Equal(resp.Header.Get("Access-Control-Allow-Origin"), "")
+
+	req, err := http.NewRequest(http.MethodOptions, server.URL+"/wails/runtime.js", nil)
+	i.NoErr(err)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	req.Header.Set("Access-Control-Request-Headers", "X-Custom")
+	resp, err = http.DefaultClient.Do(req)
+	i.NoErr(err)
+	resp.Body.Close()
+	i.Equal(resp.StatusCode, http.StatusNoContent)
+	i.Equal(resp.Header.Get("Access-Control-Allow-Origin"), "https://app.example.com")
+	i.Equal(resp.Header.Get("Access-Control-Allow-Headers"), "X-Custom")
+}
+
+func TestMessagePackCallResults(t *testing.T) {
+	i := is.New(t)
+	myLogger := logger.New(pkglogger.NewDefaultLogger())
+	myLogger.SetLogLevel(pkglogger.ERROR)
+	appBindings := binding.NewBindings(myLogger, []interface{}{&BinaryApp{}}, nil, false, nil)
+	messageDispatcher := dispatcher.NewDispatcher(context.Background(), myLogger, appBindings, nil, nil)
+	appoptions := &options.App{WebSocket: options.WebSocket{EnableMessagePack: true}}
+	d := NewFrontend(context.Background(), appoptions, myLogger, appBindings, messageDispatcher, nil, &mockFrontend{})
+	d.server.GET("/wails/ipc", d.handleIPCWebSocket)
+	server := httptest.NewServer(d.server)
+	defer server.Close()
+
+	// Clients negotiating the subprotocol get the results encoded with MessagePack
+	conn, resp := dialIPCResponse(t, server, &websocket.Dialer{Subprotocols: []string{"wails.msgpack"}})
+	i.Equal(resp.Header.Get("Sec-Websocket-Protocol"), "wails.msgpack")
+	i.NoErr(send(conn, `C{"name":"devserver.BinaryApp.Data","args":[2],"callbackID":"data-1"}`))
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	messageType, msg, err := conn.ReadMessage()
+	i.NoErr(err)
+	i.Equal(messageType, websocket.BinaryMessage)
+	expected, err := marshalMsgpack(&dispatcher.CallbackMessage{Result: []byte{0xff, 0xff}, CallbackID: "data-1"})
+	i.NoErr(err)
+	i.Equal(msg, append([]byte("M"), expected...))
+
+	// Other clients keep getting JSON
+	conn = dialIPC(t, server)
+	i.NoErr(send(conn, `C{"name":"devserver.BinaryApp.Text","args":[],"callbackID":"text-1"}`))
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	messageType, msg, err = conn.ReadMessage()
+	i.NoErr(err)
+	i.Equal(messageType, websocket.TextMessage)
+	i.Equal(string(msg), `c{"result":"text","error":null,"callbackid":"text-1"}`)
+}
+
+func TestHTTPCalls(t *testing.T) {
+	i := is.New(t)
+	myLogger := logger.New(pkglogger.NewDefaultLogger())
+	myLogger.SetLogLevel(pkglogger.ERROR)
+	appBindings := binding.NewBindings(myLogger, []interface{}{&Greeter{}}, nil, false, nil)
+	appoptions := &options.App{WebSocket: options.WebSocket{AuthToken: "secret"}}
+	d := NewFrontend(context.Background(), appoptions, myLogger, appBindings, &mockDispatcher{}, nil, &mockFrontend{})
+	d.server.POST("/wails/call/:package/:struct/:method", d.handleHTTPCall)
+	server := httptest.NewServer(d.server)
+	defer server.Close()
+
+	call := func(path string, body string, token string) (int, string) {
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/wails/call/"+path, strings.NewReader(body))
+		i.NoErr(err)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		i.NoErr(err)
+		defer resp.Body.Close()
+		reply, err := io.ReadAll(resp.Body)
+		i.NoErr(err)
+		return resp.StatusCode, strings.TrimSpace(string(reply))
+	}
+
+	status, reply := call("devserver/Greeter/Greet", `["curl"]`, "secret")
+	i.Equal(status, http.StatusOK)
+	i.Equal(reply, `{"result":"Hello curl!"}`)
+
+	status, reply = call("devserver/Greeter/Fail", "", "secret")
+	i.Equal(status, http.StatusInternalServerError)
+	i.Equal(reply, `{"result":null,"error":"failed"}`)
+
+	status, _ = call("devserver/Greeter/Greet", `[1, 2]`, "secret")
+	i.Equal(status, http.StatusBadRequest)
+	status, _ = call("devserver/Greeter/Greet", `{`, "secret")
+	i.Equal(status, http.StatusBadRequest)
+	status, _ = call("devserver/Greeter/Unknown", `[]`, "secret")
+	i.Equal(status, http.StatusNotFound)
+	status, _ = call("devserver/Greeter/Greet", `["curl"]`, "")
+	i.Equal(status, http.StatusUnauthorized)
+}
+
+func TestEventStream(t *testing.T) {
+	i := is.New(t)
+	d, server := runTestServer(t, &options.App{
+		WebSocket: options.WebSocket{EnableEventStream: true, AuthToken: "secret"},
+	})
+
+	// The injected IPC script is told to fall back to the event stream
+	_, ipc := get(t, server, "/wails/ipc.js", http.Header{"Authorization": {"Bearer secret"}})
+	i.True(strings.Contains(ipc, `"eventstream":true`))
+
+	resp, _ := get(t, server, "/wails/events", nil)
+	i.Equal(resp.StatusCode, http.StatusUnauthorized)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/wails/events?token=secret", nil)
+	i.NoErr(err)
+	resp, err = http.DefaultClient.Do(req)
+	i.NoErr(err)
+	defer resp.Body.Close()
+	i.Equal(resp.Header.Get("Content-Type"), "text/event-stream")
+	stream := bufio.NewReader(resp.Body)
+	next := func() string {
+		line, err := stream.ReadString('\n')
+		i.NoErr(err)
+		blank, err := stream.ReadString('\n')
+		i.NoErr(err)
+		i.Equal(blank, "\n")
+		i.True(strings.HasPrefix(line, "data: "))
+		return strings.TrimSuffix(strings.TrimPrefix(line, "data: "), "\n")
+	}
+	clientID := next()
+	i.True(strings.HasPrefix(clientID, "id"))
+	clientID = clientID[2:]
+
+	// The stream gets the same messages as the websocket clients
+	dialer := &websocket.Dialer{}
+	conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/wails/ipc?token=secret", nil)
+	i.NoErr(err)
+	defer conn.Close()
+	receiveClientID(t, conn)
+	i.True(waitForClients(d, 1))
+	d.Notify("test", 1)
+	i.Equal(next(), `n{"name":"test","data":[1]}`)
+	msg, err := receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `n{"name":"test","data":[1]}`)
+
+	// Events posted by the stream client reach the websocket clients, but not the stream client itself
+	req, err = http.NewRequest(http.MethodPost, server.URL+"/wails/events?token=secret&clientid="+clientID, strings.NewReader(`{"name":"posted","data":[2]}`))
+	i.NoErr(err)
+	postResp, err := http.DefaultClient.Do(req)
+	i.NoErr(err)
+	postResp.Body.Close()
+	i.Equal(postResp.StatusCode, http.StatusNoContent)
+	msg, err = receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `n{"name":"posted","data":[2],"sender":"`+clientID+`"}`)
+	d.WindowReload()
+	i.Equal(next(), "reload")
+}
+
+func TestProtocolEnvelopes(t *testing.T) {
+	i := is.New(t)
+	d, server := newTestServer(t, nil)
+
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/wails/ipc?protocol=2", nil)
+	i.NoErr(err)
+	defer conn.Close()
+	i.Equal(resp.Header.Get("X-Wails-Protocol"), "2")
+	msg, err := receive(conn, time.Second)
+	i.NoErr(err)
+	i.True(strings.HasPrefix(msg, `{"v":2,"type":"id","payload":"`))
+	i.True(waitForClients(d, 1))
+	i.Equal(d.ConnectedClients()[0].ProtocolVersion, 2)
+
+	// The payloads are the ones of the legacy messages
+	i.NoErr(send(conn, `{"v":2,"type":"call","payload":{"name":"echo","args":[],"callbackID":"echo-1"}}`))
+	msg, err = receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `{"v":2,"type":"callback","payload":{"name":"echo","args":[],"callbackID":"echo-1"}}`)
+
+	// Invalid messages are ignored
+	i.NoErr(send(conn, `{"v":3,"type":"call","payload":{}}`))
+	i.NoErr(send(conn, `{"v":2,"type":"unknown"}`))
+	i.NoErr(send(conn, `{"v":2,"type":"subscribe","payload":{"name":"wanted","count":0}}`))
+	i.NoErr(send(conn, `{"v":2,"type":"unsubscribe","payload":"unwanted"}`))
+	deadline := time.Now().Add(time.Second)
+	for len(d.ClientSubscriptions(d.ClientIDs()[0])) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	d.Notify("unwanted", 1)
+	d.Notify("wanted", 2)
+	msg, err = receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `{"v":2,"type":"notify","payload":{"name":"wanted","data":[2]}}`)
+	d.WindowReload()
+	msg, err = receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `{"v":2,"type":"reload"}`)
+
+	// Legacy clients are unchanged
+	legacy := dialIPC(t, server)
+	i.True(waitForClients(d, 2))
+	d.Notify("wanted", 3)
+	msg, err = receive(legacy, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `n{"name":"wanted","data":[3]}`)
+}
+
+func TestSetAssetOverlay(t *testing.T) {
+	i := is.New(t)
+	d, server := runTestServer(t, nil)
+	conn := dialIPC(t, server)
+	i.True(waitForClients(d, 1))
+
+	i.NoErr(d.SetAssetOverlay(fstest.MapFS{"theme.css": &fstest.MapFile{Data: []byte("dark")}}))
+	msg, err := receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, "reload")
+	resp, body := get(t, server, "/theme.css", nil)
+	i.Equal(resp.StatusCode, http.StatusOK)
+	i.Equal(body, "dark")
+
+	i.NoErr(d.SetAssetOverlay(nil))
+	resp, _ = get(t, server, "/theme.css", nil)
+	i.Equal(resp.StatusCode, http.StatusNotFound)
+}
+
+// infoRecorder records the messages logged at the Info level
+type infoRecorder struct {
+	pkglogger.Logger
+	lock     sync.Mutex
+	messages []string
+}
+
+func (r *infoRecorder) Info(message string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.messages = append(r.messages, message)
+}
+
+func (r *infoRecorder) logged(substring string) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, message := range r.messages {
+		if strings.Contains(message, substring) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestAccessLogAndMetrics(t *testing.T) {
+	i := is.New(t)
+	d, server := runTestServer(t, &options.App{DevServer: options.DevServer{AccessLog: true, EnableMetrics: true}})
+	recorder := &infoRecorder{Logger: pkglogger.NewDefaultLogger()}
+	d.logger = logger.New(recorder)
+	d.logger.SetLogLevel(pkglogger.INFO)
+
+	resp, _ := get(t, server, "/", nil)
+	i.Equal(resp.StatusCode, http.StatusOK)
+	resp, _ = get(t, server, "/missing.css?clientid=tool&token=secret", nil)
+	i.Equal(resp.StatusCode, http.StatusNotFound)
+	conn := dialIPC(t, server)
+	i.True(waitForClients(d, 1))
+	clientID := d.ClientIDs()[0]
+	i.NoErr(send(conn, `C{"name":"test","callbackID":"1"}`))
+	_, err := receive(conn, time.Second)
+	i.NoErr(err)
+	i.NoErr(conn.Close())
+
+	i.True(recorder.logged("GET / 200 "))
+	// The query is not logged, it may carry the token
+	i.True(recorder.logged("GET /missing.css 404 "))
+	i.True(!recorder.logged("secret"))
+	// The websocket is logged when it is closed
+	deadline := time.Now().Add(time.Second)
+	for !recorder.logged("client='"+clientID+"'") && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	i.True(recorder.logged("GET /wails/ipc 101 "))
+
+	resp, body := get(t, server, "/wails/metrics", nil)
+	i.Equal(resp.StatusCode, http.StatusOK)
+	i.True(strings.HasPrefix(resp.Header.Get("Content-Type"), "text/plain; version=0.0.4"))
+	for _, line := range []string{
+		`wails_http_requests_total{method="GET",status="101"} 1`,
+		`wails_http_requests_total{method="GET",status="200"} 1`,
+		`wails_http_requests_total{method="GET",status="404"} 1`,
+		`wails_ipc_dispatch_duration_seconds_bucket{le="+Inf"} 1`,
+		`wails_ipc_dispatch_duration_seconds_count 1`,
+		`wails_websocket_clients 0`,
+		`wails_ipc_calls_total 1`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("%s not in the metrics:\n%s", line, body)
+		}
+	}
+
+	// The metrics are only served if they are enabled
+	_, server = runTestServer(t, nil)
+	_, body = get(t, server, "/wails/metrics", nil)
+	i.True(!strings.Contains(body, "wails_http_requests_total"))
+}
+
+func TestWebDAV(t *testing.T) {
+	i := is.New(t)
+	root := t.TempDir()
+	i.NoErr(os.WriteFile(filepath.Join(root, "notes.txt"), []byte("notes"), 0o644))
+	webDAV := &options.WebDAV{Root: root, Username: "user", Password: "secret"}
+	_, server := runTestServer(t, &options.App{WebServer: options.WebServer{WebDAV: webDAV}})
+
+	request := func(method string, path string, body string, password string) (*http.Response, string) {
+		req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		i.NoErr(err)
+		if password != "" {
+			req.SetBasicAuth("user", password)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		i.NoErr(err)
+		defer resp.Body.Close()
+		content, err := io.ReadAll(resp.Body)
+		i.NoErr(err)
+		return resp, string(content)
+	}
+
+	resp, _ := request(http.MethodGet, "/wails/webdav/notes.txt", "", "")
+	i.Equal(resp.StatusCode, http.StatusUnauthorized)
+	i.True(resp.Header.Get("WWW-Authenticate") != "")
+	resp, _ = request(http.MethodGet, "/wails/webdav/notes.txt", "", "wrong")
+	i.Equal(resp.StatusCode, http.StatusUnauthorized)
+
+	resp, body := request(http.MethodGet, "/wails/webdav/notes.txt", "", "secret")
+	i.Equal(resp.StatusCode, http.StatusOK)
+	i.Equal(body, "notes")
+	resp, _ = request("MKCOL", "/wails/webdav/docs", "", "secret")
+	i.Equal(resp.StatusCode, http.StatusCreated)
+	resp, _ = request(http.MethodPut, "/wails/webdav/docs/new.txt", "new", "secret")
+	i.Equal(resp.StatusCode, http.StatusCreated)
+	content, err := os.ReadFile(filepath.Join(root, "docs", "new.txt"))
+	i.NoErr(err)
+	i.Equal(string(content), "new")
+	resp, body = request("PROPFIND", "/wails/webdav/docs", "", "secret")
+	i.Equal(resp.StatusCode, http.StatusMultiStatus)
+	i.True(strings.Contains(body, "/wails/webdav/docs/new.txt"))
+
+	// The assets are still served besides the directory
+	resp, _ = get(t, server, "/", nil)
+	i.Equal(resp.StatusCode, http.StatusOK)
+
+	// Read-only directories can't be changed
+	webDAV.ReadOnly = true
+	_, server = runTestServer(t, &options.App{WebServer: options.WebServer{WebDAV: webDAV}})
+	resp, _ = request(http.MethodPut, "/wails/webdav/notes.txt", "changed", "secret")
+	i.Equal(resp.StatusCode, http.StatusForbidden)
+	resp, _ = request(http.MethodGet, "/wails/webdav/notes.txt", "", "secret")
+	i.Equal(resp.StatusCode, http.StatusOK)
+
+	// The file managers can't send the AuthToken, the credentials are required instead
+	webDAV.ReadOnly = false
+	_, server = runTestServer(t, &options.App{
+		WebSocket: options.WebSocket{AuthToken: "token"},
+		WebServer: options.WebServer{WebDAV: webDAV},
+	})
+	resp, _ = request(http.MethodGet, "/wails/webdav/notes.txt", "", "secret")
+	i.Equal(resp.StatusCode, http.StatusOK)
+	resp, _ = request(http.MethodGet, "/wails/webdav/notes.txt", "", "")
+	i.Equal(resp.StatusCode, http.StatusUnauthorized)
+	i.True(resp.Header.Get("WWW-Authenticate") != "")
+	resp, _ = request(http.MethodGet, "/wails/webdav/../", "", "secret")
+	i.Equal(resp.StatusCode, http.StatusUnauthorized)
+	resp, _ = get(t, server, "/", nil)
+	i.Equal(resp.StatusCode, http.StatusUnauthorized)
+
+	// Credentials are required
+	d := newTestFrontend(t, withTestAssets(&options.App{WebServer: options.WebServer{WebDAV: &options.WebDAV{Root: root}}}))
+	i.True(d.Run(context.Background()) != nil)
+}
+
+func TestUploads(t *testing.T) {
+	i := is.New(t)
+	dir := t.TempDir()
+	completed := make(chan options.Upload, 1)
+	d, server := runTestServer(t, &options.App{WebSocket: options.WebSocket{Uploads: &options.Uploads{
+		Dir:     dir,
+		MaxSize: 100,
+		OnComplete: func(upload *options.Upload) error {
+			content, err := io.ReadAll(upload.File)
+			if err != nil {
+				return err
+			}
+			if string(content) == "fail" {
+				return errors.New("invalid content")
+			}
+			upload.Metadata["content"] = string(content)
+			completed <- *upload
+			return nil
+		},
+	}}})
+
+	request := func(method string, path string, body string, header map[string]string) *http.Response {
+		req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		i.NoErr(err)
+		req.Header.Set("Tus-Resumable", "1.0.0")
+		for key, value := range header {
+			req.Header.Set(key, value)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		i.NoErr(err)
+		_ = resp.Body.Close()
+		return resp
+	}
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/wails/ipc?clientid=uploader", nil)
+	i.NoErr(err)
+	defer conn.Close()
+	receiveClientID(t, conn)
+	i.True(waitForClients(d, 1))
+	session := resp.Header.Get(headerSessionID)
+	create := func(length string, query string) string {
+		resp := request(http.MethodPost, "/wails/upload?"+query, "", map[string]string{
+			"Upload-Length":   length,
+			"Upload-Metadata": "filename cmVwb3J0LnR4dA==,empty",
+		})
+		i.Equal(resp.StatusCode, http.StatusCreated)
+		return resp.Header.Get("Location")
+	}
+	chunk := func(location string, offset string, body string) *http.Response {
+		return request(http.MethodPatch, location, body, map[string]string{
+			"Upload-Offset": offset,
+			"Content-Type":  "application/offset+octet-stream",
+		})
+	}
+
+	location := create("11", "clientid=uploader&session="+session)
+	i.True(strings.HasPrefix(location, "/wails/upload/"))
+	resp = chunk(location, "0", "hello ")
+	i.Equal(resp.StatusCode, http.StatusNoContent)
+	i.Equal(resp.Header.Get("Upload-Offset"), "6")
+
+	// A resuming client gets the offset, chunks at other offsets are rejected
+	resp = request(http.MethodHead, location, "", nil)
+	i.Equal(resp.StatusCode, http.StatusOK)
+	i.Equal(resp.Header.Get("Upload-Offset"), "6")
+	i.Equal(resp.Header.Get("Upload-Length"), "11")
+	i.Equal(chunk(location, "0", "hello ").StatusCode, http.StatusConflict)
+	i.Equal(chunk(location, "6", "world and more").StatusCode, http.StatusRequestEntityTooLarge)
+
+	i.Equal(chunk(location, "6", "world").StatusCode, http.StatusNoContent)
+	select {
+	case upload := <-completed:
+		i.Equal(upload.ClientID, "uploader")
+		i.Equal(upload.Size, int64(11))
+		i.Equal(upload.Metadata["filename"], "report.txt")
+		i.Equal(upload.Metadata["content"], "hello world")
+	case <-time.After(time.Second):
+		t.Fatal("the upload has not been completed")
+	}
+	i.Equal(request(http.MethodHead, location, "", nil).StatusCode, http.StatusNotFound)
+
+	// The client is only known if it proved its session
+	location = create("2", "clientid=uploader")
+	i.Equal(chunk(location, "0", "ok").StatusCode, http.StatusNoContent)
+	select {
+	case upload := <-completed:
+		i.Equal(upload.ClientID, "")
+	case <-time.After(time.Second):
+		t.Fatal("the upload has not been completed")
+	}
+
+	// Errors of the callback fail the upload
+	location = create("4", "")
+	i.Equal(chunk(location, "0", "fail").StatusCode, http.StatusInternalServerError)
+
+	// Uploads can be terminated, too large ones are rejected
+	location = create("4", "")
+	i.Equal(request(http.MethodDelete, location, "", nil).StatusCode, http.StatusNoContent)
+	i.Equal(request(http.MethodHead, location, "", nil).StatusCode, http.StatusNotFound)
+	resp = request(http.MethodPost, "/wails/upload", "", map[string]string{"Upload-Length": "101"})
+	i.Equal(resp.StatusCode, http.StatusRequestEntityTooLarge)
+
+	// The files of the uploads have been removed
+	entries, err := os.ReadDir(dir)
+	i.NoErr(err)
+	i.Equal(len(entries), 0)
+}
+
+type WaitApp struct {
+	started chan struct{}
+	ended   chan error
+}
+
+func (w *WaitApp) Wait(ctx context.Context) error {
+	w.started <- struct{}{}
+	<-ctx.Done()
+	w.ended <- ctx.Err()
+	return ctx.Err()
+}
+
+func TestCallCancellation(t *testing.T) {
+	i := is.New(t)
+	myLogger := logger.New(pkglogger.NewDefaultLogger())
+	myLogger.SetLogLevel(pkglogger.ERROR)
+	app := &WaitApp{started: make(chan struct{}, 1), ended: make(chan error, 1)}
+	appBindings := binding.NewBindings(myLogger, []interface{}{app}, nil, false, nil)
+	messageDispatcher := dispatcher.NewDispatcher(context.Background(), myLogger, appBindings, nil, nil)
+	d := NewFrontend(context.Background(), &options.App{}, myLogger, appBindings, messageDispatcher, nil, &mockFrontend{})
+	d.server.GET("/wails/ipc", d.handleIPCWebSocket)
+	server := httptest.NewServer(d.server)
+	defer server.Close()
+	wait := func() {
+		t.Helper()
+		select {
+		case <-app.started:
+		case <-time.After(time.Second):
+			t.Fatal("the method has not been called")
+		}
+	}
+	ended := func() error {
+		t.Helper()
+		select {
+		case err := <-app.ended:
+			return err
+		case <-time.After(time.Second):
+			t.Fatal("the context of the method has not been cancelled")
+			return nil
+		}
+	}
+
+	// The frontend cancels the call with its callback ID, the calls of other clients are not affected
+	conn := dialIPC(t, server)
+	other := dialIPC(t, server)
+	i.NoErr(send(conn, `C{"name":"devserver.WaitApp.Wait","args":[],"callbackID":"wait-1"}`))
+	wait()
+	i.NoErr(send(other, `Xwait-1`))
+	i.NoErr(send(conn, `Xunknown`))
+	select {
+	case <-app.ended:
+		t.Fatal("the call has been cancelled by another client")
+	case <-time.After(50 * time.Millisecond):
+	}
+	i.NoErr(send(conn, `Xwait-1`))
+	i.True(errors.Is(ended(), context.Canceled))
+	msg, err := receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `c{"result":null,"error":"context canceled","callbackid":"wait-1"}`)
+
+	// The calls in progress are cancelled when the client disconnects
+	i.NoErr(send(conn, `C{"name":"devserver.WaitApp.Wait","args":[],"callbackID":"wait-2"}`))
+	wait()
+	i.NoErr(conn.Close())
+	i.True(errors.Is(ended(), context.Canceled))
+}
+
+type SlowApp struct{}
+
+func (s *SlowApp) Sleep(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (s *SlowApp) Fast() string {
+	return "fast"
+}
+
+func TestCallTimeouts(t *testing.T) {
+	i := is.New(t)
+	myLogger := logger.New(pkglogger.NewDefaultLogger())
+	myLogger.SetLogLevel(pkglogger.ERROR)
+	appBindings := binding.NewBindings(myLogger, []interface{}{&SlowApp{}}, nil, false, nil)
+	i.True(appBindings.SetCallTimeouts(map[string]time.Duration{"devserver.SlowApp.Unknown": time.Second}) != nil)
+	i.NoErr(appBindings.SetCallTimeouts(map[string]time.Duration{"devserver.SlowApp.Sleep": 50 * time.Millisecond, "*": time.Second}))
+	messageDispatcher := dispatcher.NewDispatcher(context.Background(), myLogger, appBindings, nil, nil)
+	d := NewFrontend(context.Background(), &options.App{}, myLogger, appBindings, messageDispatcher, nil, &mockFrontend{})
+	d.server.GET("/wails/ipc", d.handleIPCWebSocket)
+	server := httptest.NewServer(d.server)
+	defer server.Close()
+	conn := dialIPC(t, server)
+
+	// A call exceeding the timeout fails with a timeout error object
+	start := time.Now()
+	i.NoErr(send(conn, `C{"name":"devserver.SlowApp.Sleep","args":[],"callbackID":"sleep-1"}`))
+	msg, err := receive(conn, time.Second)
+	i.NoErr(err)
+	i.True(time.Since(start) >= 50*time.Millisecond)
+	i.Equal(msg, `c{"result":null,"error":{"code":"timeout","message":"call of 'devserver.SlowApp.Sleep' timed out after 50ms","data":{"method":"devserver.SlowApp.Sleep","timeout":50}},"callbackid":"sleep-1"}`)
+
+	// The calls returning in time are not affected
+	i.NoErr(send(conn, `C{"name":"devserver.SlowApp.Fast","args":[],"callbackID":"fast-1"}`))
+	msg, err = receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `c{"result":"fast","error":null,"callbackid":"fast-1"}`)
+}
+
+func TestCallLimits(t *testing.T) {
+	i := is.New(t)
+	myLogger := logger.New(pkglogger.NewDefaultLogger())
+	myLogger.SetLogLevel(pkglogger.ERROR)
+	appBindings := binding.NewBindings(myLogger, []interface{}{&SlowApp{}}, nil, false, nil)
+	messageDispatcher := dispatcher.NewDispatcher(context.Background(), myLogger, appBindings, nil, nil)
+	messageDispatcher.UseCallLimits(options.CallLimits{MaxConcurrent: 2, MaxConcurrentPerClient: 1, QueueTimeout: 50 * time.Millisecond})
+	d := NewFrontend(context.Background(), &options.App{}, myLogger, appBindings, messageDispatcher, nil, &mockFrontend{})
+	d.server.GET("/wails/ipc", d.handleIPCWebSocket)
+	server := httptest.NewServer(d.server)
+	defer server.Close()
+	dial := func(clientID string) *websocket.Conn {
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/wails/ipc?clientid="+clientID, nil)
+		i.NoErr(err)
+		t.Cleanup(func() { _ = conn.Close() })
+		receiveClientID(t, conn)
+		return conn
+	}
+
+	// The second call of a client waits for the first one, until the queue timeout
+	first := dial("first")
+	i.NoErr(send(first, `C{"name":"devserver.SlowApp.Sleep","args":[],"callbackID":"sleep-1"}`))
+	time.Sleep(20 * time.Millisecond)
+	i.NoErr(send(first, `C{"name":"devserver.SlowApp.Fast","args":[],"callbackID":"fast-1"}`))
+	msg, err := receive(first, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `c{"result":null,"error":{"code":"busy","message":"call of 'devserver.SlowApp.Fast' rejected: the client is busy","data":{"limit":"client","method":"devserver.SlowApp.Fast"}},"callbackid":"fast-1"}`)
+
+	// The calls of all clients share the limit of the server
+	second := dial("second")
+	i.NoErr(send(second, `C{"name":"devserver.SlowApp.Sleep","args":[],"callbackID":"sleep-2"}`))
+	time.Sleep(20 * time.Millisecond)
+	third := dial("third")
+	i.NoErr(send(third, `C{"name":"devserver.SlowApp.Fast","args":[],"callbackID":"fast-3"}`))
+	msg, err = receive(third, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `c{"result":null,"error":{"code":"busy","message":"call of 'devserver.SlowApp.Fast' rejected: the server is busy","data":{"limit":"server","method":"devserver.SlowApp.Fast"}},"callbackid":"fast-3"}`)
+
+	// A waiting call gets the slot of a call which returned
+	i.NoErr(send(third, `C{"name":"devserver.SlowApp.Fast","args":[],"callbackID":"fast-4"}`))
+	i.NoErr(send(first, "Xsleep-1"))
+	msg, err = receive(third, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `c{"result":"fast","error":null,"callbackid":"fast-4"}`)
+}
+
+type aclInterceptor struct {
+	lock  sync.Mutex
+	calls []string
+}
+
+func (a *aclInterceptor) Before(_ context.Context, call *options.CallInfo) error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.calls = append(a.calls, "before "+call.Method+" "+call.ClientID)
+	if call.ClientID == "guest" {
+		return errors.New("forbidden")
+	}
+	return nil
+}
+
+func (a *aclInterceptor) After(_ context.Context, call *options.CallInfo, result interface{}, err error) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.calls = append(a.calls, fmt.Sprintf("after %s %v %v", call.Method, result, err))
+}
+
+func TestCallInterceptors(t *testing.T) {
+	i := is.New(t)
+	myLogger := logger.New(pkglogger.NewDefaultLogger())
+	myLogger.SetLogLevel(pkglogger.ERROR)
+	appBindings := binding.NewBindings(myLogger, []interface{}{&SlowApp{}}, nil, false, nil)
+	messageDispatcher := dispatcher.NewDispatcher(context.Background(), myLogger, appBindings, nil, nil)
+	interceptor := &aclInterceptor{}
+	messageDispatcher.UseInterceptors([]options.Interceptor{interceptor})
+	d := NewFrontend(context.Background(), &options.App{}, myLogger, appBindings, messageDispatcher, nil, &mockFrontend{})
+	d.server.GET("/wails/ipc", d.handleIPCWebSocket)
+	server := httptest.NewServer(d.server)
+	defer server.Close()
+	dial := func(clientID string) *websocket.Conn {
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/wails/ipc?clientid="+clientID, nil)
+		i.NoErr(err)
+		t.Cleanup(func() { _ = conn.Close() })
+		receiveClientID(t, conn)
+		return conn
+	}
+
+	conn := dial("admin")
+	i.NoErr(send(conn, `C{"name":"devserver.SlowApp.Fast","args":[],"callbackID":"fast-1"}`))
+	msg, err := receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `c{"result":"fast","error":null,"callbackid":"fast-1"}`)
+
+	// A failing Before hook fails the call without calling the method
+	conn = dial("guest")
+	i.NoErr(send(conn, `C{"name":"devserver.SlowApp.Fast","args":[],"callbackID":"fast-2"}`))
+	msg, err = receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `c{"result":null,"error":"forbidden","callbackid":"fast-2"}`)
+
+	interceptor.lock.Lock()
+	defer interceptor.lock.Unlock()
+	i.Equal(interceptor.calls, []string{
+		"before devserver.SlowApp.Fast admin",
+		"after devserver.SlowApp.Fast fast <nil>",
+		"before devserver.SlowApp.Fast guest",
+	})
+}
+
+type notFoundError struct {
+	id int
+}
+
+func (e *notFoundError) Error() string {
+	return fmt.Sprintf("item %d not found", e.id)
+}
+
+func (e *notFoundError) ErrorCode() string {
+	return "not_found"
+}
+
+func (e *notFoundError) ErrorData() map[string]interface{} {
+	return map[string]interface{}{"id": e.id}
+}
+
+type ItemsApp struct{}
+
+func (a *ItemsApp) Get(id int) (string, error) {
+	return "", fmt.Errorf("get: %w", &notFoundError{id: id})
+}
+
+func (a *ItemsApp) Delete(id int) error {
+	return errors.New("read only")
+}
+
+func TestCodedErrors(t *testing.T) {
+	i := is.New(t)
+	myLogger := logger.New(pkglogger.NewDefaultLogger())
+	myLogger.SetLogLevel(pkglogger.ERROR)
+	appBindings := binding.NewBindings(myLogger, []interface{}{&ItemsApp{}}, nil, false, nil)
+	messageDispatcher := dispatcher.NewDispatcher(context.Background(), myLogger, appBindings, nil, nil)
+	d := NewFrontend(context.Background(), &options.App{}, myLogger, appBindings, messageDispatcher, nil, &mockFrontend{})
+	d.server.GET("/wails/ipc", d.handleIPCWebSocket)
+	server := httptest.NewServer(d.server)
+	defer server.Close()
+	conn := dialIPC(t, server)
+
+	// A wrapped coded error is sent as an envelope
+	i.NoErr(send(conn, `C{"name":"devserver.ItemsApp.Get","args":[7],"callbackID":"get-1"}`))
+	msg, err := receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `c{"result":null,"error":{"code":"not_found","message":"get: item 7 not found","data":{"id":7}},"callbackid":"get-1"}`)
+
+	// The other errors are still sent as their message
+	i.NoErr(send(conn, `C{"name":"devserver.ItemsApp.Delete","args":[7],"callbackID":"delete-1"}`))
+	msg, err = receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `c{"result":null,"error":"read only","callbackid":"delete-1"}`)
+}
+
+// recordingTracer records the spans of the calls
+type recordingTracer struct {
+	lock  sync.Mutex
+	spans []*recordingSpan
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string, _ options.SpanKind, attributes []options.SpanAttribute) (context.Context, options.Span) {
+	span := &recordingSpan{name: name, attributes: attributes}
+	t.lock.Lock()
+	t.spans = append(t.spans, span)
+	t.lock.Unlock()
+	return ctx, span
+}
+
+func (t *recordingTracer) recorded() []string {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	var result []string
+	for _, span := range t.spans {
+		result = append(result, span.String())
+	}
+	return result
+}
+
+type recordingSpan struct {
+	lock       sync.Mutex
+	name       string
+	attributes []options.SpanAttribute
+	err        error
+	ended      bool
+}
+
+func (s *recordingSpan) End(err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.err = err
+	s.ended = true
+}
+
+func (s *recordingSpan) String() string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	result := s.name
+	for _, attribute := range s.attributes {
+		result += fmt.Sprintf(" %s=%s", attribute.Key, attribute.Value)
+	}
+	return fmt.Sprintf("%s err=%v ended=%v", result, s.err, s.ended)
+}
+
+func TestCallTracing(t *testing.T) {
+	i := is.New(t)
+	myLogger := logger.New(pkglogger.NewDefaultLogger())
+	myLogger.SetLogLevel(pkglogger.ERROR)
+	appBindings := binding.NewBindings(myLogger, []interface{}{&ItemsApp{}}, nil, false, nil)
+	tracer := &recordingTracer{}
+	appOptions := &options.App{Telemetry: &options.Telemetry{Tracer: tracer}}
+	appOptions.WebSocket.EnableHTTPCalls = true
+	messageDispatcher := dispatcher.NewDispatcher(context.Background(), myLogger, appBindings, nil, nil)
+	messageDispatcher.UseTelemetry(appOptions.Telemetry)
+	d := NewFrontend(context.Background(), withTestAssets(appOptions), myLogger, appBindings, messageDispatcher, nil, &mockFrontend{})
+	startTestFrontend(t, context.Background(), d)
+	server := httptest.NewServer(d.server)
+	defer server.Close()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/wails/ipc?clientid=tracer&session=tab", nil)
+	i.NoErr(err)
+	defer conn.Close()
+	receiveClientID(t, conn)
+
+	// The calls are spans with the method, the client and the error
+	i.NoErr(send(conn, `C{"name":"devserver.ItemsApp.Get","args":[7],"callbackID":"get-1"}`))
+	_, err = receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(tracer.recorded(), []string{
+		"devserver.ItemsApp.Get rpc.system=wails rpc.method=devserver.ItemsApp.Get wails.client_id=tracer wails.session_id=tab err=get: item 7 not found ended=true",
+	})
+
+	// The calls over HTTP are traced too
+	resp, err := http.Post(server.URL+"/wails/call/devserver/ItemsApp/Delete", "application/json", strings.NewReader("[1]"))
+	i.NoErr(err)
+	_ = resp.Body.Close()
+	i.Equal(tracer.recorded()[1], "devserver.ItemsApp.Delete rpc.system=wails rpc.method=devserver.ItemsApp.Delete err=read only ended=true")
+
+	// The other messages are spans of the dispatcher
+	_, err = messageDispatcher.ProcessMessage(context.Background(), "Lunknown", nil)
+	i.True(err != nil)
+	i.True(strings.HasPrefix(tracer.recorded()[2], "wails.message log wails.message.type=log err="))
+}
+
+type SpellPlugin struct{}
+
+func (s *SpellPlugin) Check(word string) bool {
+	return word == "wails"
+}
+
+func TestRuntimeBindings(t *testing.T) {
+	i := is.New(t)
+	myLogger := logger.New(pkglogger.NewDefaultLogger())
+	myLogger.SetLogLevel(pkglogger.ERROR)
+	appBindings := binding.NewBindings(myLogger, []interface{}{&SlowApp{}}, nil, false, nil)
+	messageDispatcher := dispatcher.NewDispatcher(context.Background(), myLogger, appBindings, nil, nil)
+	window := &mockFrontend{}
+	d := NewFrontend(context.Background(), &options.App{}, myLogger, appBindings, messageDispatcher, nil, window)
+	d.server.GET("/wails/ipc", d.handleIPCWebSocket)
+	server := httptest.NewServer(d.server)
+	defer server.Close()
+	conn := dialIPC(t, server)
+	ctx := context.WithValue(context.Background(), "frontend", d)
+	ctx = context.WithValue(ctx, "bindings", appBindings)
+
+	// A function is bound with a qualified name and the clients get the new bindings
+	i.True(pkgruntime.BindFunc(ctx, "plugins.Math", func(a, b int) int { return a + b }) != nil)
+	i.NoErr(pkgruntime.BindFunc(ctx, "plugins.Math.Add", func(a, b int) int { return a + b }))
+	msg, err := receive(conn, time.Second)
+	i.NoErr(err)
+	i.True(strings.HasPrefix(msg, "b"))
+	i.True(strings.Contains(msg, `"plugins":{"Math":{"Add":`))
+	i.True(strings.Contains(msg, `"devserver":{"SlowApp":`))
+	i.Equal(len(window.scripts), 1)
+	i.True(strings.Contains(window.scripts[0], `"plugins":{"Math":{"Add":`))
+	i.NoErr(send(conn, `C{"name":"plugins.Math.Add","args":[1,2],"callbackID":"add-1"}`))
+	msg, err = receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `c{"result":3,"error":null,"callbackid":"add-1"}`)
+
+	// The methods of a struct are bound under its name, a bound name can't be bound again
+	i.NoErr(pkgruntime.BindFunc(ctx, "plugins.Spell", &SpellPlugin{}))
+	i.True(pkgruntime.BindFunc(ctx, "plugins.Spell", &SpellPlugin{}) != nil)
+	msg, err = receive(conn, time.Second)
+	i.NoErr(err)
+	i.True(strings.Contains(msg, `"Spell":{"Check":`))
+	i.NoErr(send(conn, `C{"name":"plugins.Spell.Check","args":["wails"],"callbackID":"check-1"}`))
+	msg, err = receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `c{"result":true,"error":null,"callbackid":"check-1"}`)
+
+	// Unbound methods are removed from the bindings and can't be called
+	i.NoErr(pkgruntime.Unbind(ctx, "plugins.Spell"))
+	i.True(pkgruntime.Unbind(ctx, "plugins.Spell") != nil)
+	msg, err = receive(conn, time.Second)
+	i.NoErr(err)
+	i.True(!strings.Contains(msg, `"Spell"`))
+	i.True(strings.Contains(msg, `"Math":{"Add":`))
+	i.NoErr(send(conn, `C{"name":"plugins.Spell.Check","args":["wails"],"callbackID":"check-2"}`))
+	msg, err = receive(conn, time.Second)
+	i.NoErr(err)
+	i.True(strings.Contains(msg, `"callbackid":"check-2"`))
+	i.True(!strings.Contains(msg, `"error":null`))
+}
+
+type Contact struct {
+	Name    string     `json:"name"`
+	Email   string     `json:"email,omitempty"`
+	Friends []*Contact `json:"friends"`
+	secret  string
+}
+
+type AddressBook struct{}
+
+func (a *AddressBook) Add(contact Contact) (*Contact, error) {
+	return &contact, nil
+}
+
+func (a *AddressBook) Watch() <-chan Contact {
+	return nil
+}
+
+func TestOpenAPI(t *testing.T) {
+	i := is.New(t)
+	d, server := runTestServer(t, &options.App{
+		DevServer: options.DevServer{BasePath: "/myapp/"},
+		WebSocket: options.WebSocket{EnableHTTPCalls: true, AuthToken: "secret"},
+	})
+	i.NoErr(d.appBindings.Add(&AddressBook{}))
+
+	resp, _ := get(t, server, "/myapp/wails/openapi.json", nil)
+	i.Equal(resp.StatusCode, http.StatusUnauthorized)
+	resp, body := get(t, server, "/myapp/wails/openapi.json", http.Header{"Authorization": {"Bearer secret"}})
+	i.Equal(resp.StatusCode, http.StatusOK)
+
+	var document struct {
+		OpenAPI string              `json:"openapi"`
+		Servers []map[string]string `json:"servers"`
+		Paths   map[string]struct {
+			Post struct {
+				OperationID string `json:"operationId"`
+				RequestBody struct {
+					Content map[string]struct {
+						Schema json.RawMessage `json:"schema"`
+					} `json:"content"`
+				} `json:"requestBody"`
+				Responses map[string]struct {
+					Content map[string]struct {
+						Schema json.RawMessage `json:"schema"`
+					} `json:"content"`
+				} `json:"responses"`
+			} `json:"post"`
+		} `json:"paths"`
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	i.NoErr(json.Unmarshal([]byte(body), &document))
+	i.Equal(document.OpenAPI, "3.1.0")
+	i.Equal(document.Servers[0]["url"], "/myapp")
+
+	// The methods are described with the JSON Schemas of their arguments and result, streams are left out
+	i.Equal(len(document.Paths), 1)
+	add := document.Paths["/wails/call/devserver/AddressBook/Add"].Post
+	i.Equal(add.OperationID, "devserver.AddressBook.Add")
+	i.Equal(string(add.RequestBody.Content["application/json"].Schema), `{"maxItems":1,"minItems":1,"prefixItems":[{"$ref":"#/components/schemas/devserver.Contact"}],"type":"array"}`)
+	i.Equal(string(add.Responses["200"].Content["application/json"].Schema), `{"properties":{"result":{"$ref":"#/components/schemas/devserver.Contact"}},"type":"object"}`)
+	i.Equal(string(document.Components.Schemas["devserver.Contact"]), `{"properties":{"email":{"type":"string"},"friends":{"items":{"$ref":"#/components/schemas/devserver.Contact"},"type":"array"},"name":{"type":"string"}},"required":["friends","name"],"type":"object"}`)
+}
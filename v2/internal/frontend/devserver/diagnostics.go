@@ -0,0 +1,175 @@
+package devserver
+
+import (
+	"encoding/json"
+	"fmt"
+	goruntime "runtime"
+	"runtime/debug"
+	"sort"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// protocolVersion is the latest version of the IPC websocket protocol, it is incremented on incompatible changes
+const protocolVersion = envelopeProtocolVersion
+
+const wailsModulePath = "github.com/wailsapp/wails/v2"
+
+// ServerInfo describes the running dev server, for bug reports
+type ServerInfo struct {
+	// WailsVersion is the version of the Wails module the application has been built with
+	WailsVersion    string    `json:"wailsVersion"`
+	ProtocolVersion int       `json:"protocolVersion"`
+	GoVersion       string    `json:"goVersion"`
+	StartTime       time.Time `json:"startTime"`
+	Build           BuildInfo `json:"build"`
+}
+
+// BuildInfo describes the build of the application
+type BuildInfo struct {
+	Module   string `json:"module,omitempty"`
+	Version  string `json:"version,omitempty"`
+	Revision string `json:"revision,omitempty"`
+	Time     string `json:"time,omitempty"`
+	Modified bool   `json:"modified,omitempty"`
+}
+
+// ServerInfo returns the versions and build information of the dev server
+func (d *DevWebServer) ServerInfo() ServerInfo {
+	info := ServerInfo{
+		WailsVersion:    "(unknown)",
+		ProtocolVersion: protocolVersion,
+		GoVersion:       goruntime.Version(),
+		StartTime:       d.starttime,
+	}
+	buildInfo, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	info.Build.Module = buildInfo.Main.Path
+	info.Build.Version = buildInfo.Main.Version
+	if buildInfo.Main.Path == wailsModulePath {
+		info.WailsVersion = buildInfo.Main.Version
+	}
+	for _, dep := range buildInfo.Deps {
+		if dep.Path == wailsModulePath {
+			info.WailsVersion = dep.Version
+			if dep.Replace != nil {
+				info.WailsVersion += " => " + dep.Replace.Path + " " + dep.Replace.Version
+			}
+		}
+	}
+	for _, setting := range buildInfo.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			info.Build.Revision = setting.Value
+		case "vcs.time":
+			info.Build.Time = setting.Value
+		case "vcs.modified":
+			info.Build.Modified = setting.Value == "true"
+		}
+	}
+	return info
+}
+
+// diagnosticsQuery is sent by the clients with the reserved "D" prefix, which is answered by the dev server
+// itself and never dispatched. The reply is sent with the "d" prefix and the ID of the query.
+type diagnosticsQuery struct {
+	Query string `json:"query"`
+	ID    string `json:"id"`
+}
+
+type diagnosticsReply struct {
+	ID     string      `json:"id"`
+	Result interface{} `json:"result,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+func isDiagnosticsQuery(message []byte) bool {
+	return len(message) > 1 && message[0] == 'D'
+}
+
+// BoundMethodInfo describes a bound method in the catalogue returned by runtime.Bindings() in the frontend
+type BoundMethodInfo struct {
+	// Name is the name of the method as it is called, "<package>.<struct>.<method>"
+	Name     string          `json:"name"`
+	Inputs   []ParameterInfo `json:"inputs"`
+	Outputs  []ParameterInfo `json:"outputs"`
+	Comments string          `json:"comments,omitempty"`
+}
+
+// ParameterInfo is a parameter or a result of a bound method, with its Go type
+type ParameterInfo struct {
+	Name string `json:"name,omitempty"`
+	Type string `json:"type"`
+}
+
+// bindingsCatalogue returns the bound methods described by the bindings JSON, sorted by name. It is built from
+// the current bindings, so it includes the methods bound at runtime and the synthetic methods.
+func (d *DevWebServer) bindingsCatalogue() ([]BoundMethodInfo, error) {
+	bindingsJSON, err := d.bindingsJSON()
+	if err != nil {
+		return nil, err
+	}
+	var bindings map[string]map[string]map[string]struct {
+		Inputs   []ParameterInfo `json:"inputs"`
+		Outputs  []ParameterInfo `json:"outputs"`
+		Comments string          `json:"comments"`
+	}
+	if err := json.Unmarshal([]byte(bindingsJSON), &bindings); err != nil {
+		return nil, err
+	}
+	catalogue := []BoundMethodInfo{}
+	for packageName, structs := range bindings {
+		for structName, methods := range structs {
+			for methodName, method := range methods {
+				info := BoundMethodInfo{
+					Name:     packageName + "." + structName + "." + methodName,
+					Inputs:   method.Inputs,
+					Outputs:  method.Outputs,
+					Comments: method.Comments,
+				}
+				if info.Inputs == nil {
+					info.Inputs = []ParameterInfo{}
+				}
+				if info.Outputs == nil {
+					info.Outputs = []ParameterInfo{}
+				}
+				catalogue = append(catalogue, info)
+			}
+		}
+	}
+	sort.Slice(catalogue, func(i, j int) bool {
+		return catalogue[i].Name < catalogue[j].Name
+	})
+	return catalogue, nil
+}
+
+// handleDiagnosticsQuery answers a diagnostics query of the client, "version" or "bindings"
+func (d *DevWebServer) handleDiagnosticsQuery(conn *websocket.Conn, info *WebsocketInfo, message []byte) error {
+	var query diagnosticsQuery
+	if err := json.Unmarshal(message[1:], &query); err != nil {
+		d.logger.Error("Invalid diagnostics query: %s", err.Error())
+		return nil
+	}
+	reply := diagnosticsReply{ID: query.ID}
+	switch query.Query {
+	case "version":
+		reply.Result = d.ServerInfo()
+	case "bindings":
+		catalogue, err := d.bindingsCatalogue()
+		if err != nil {
+			reply.Error = err.Error()
+			break
+		}
+		reply.Result = catalogue
+	default:
+		reply.Error = fmt.Sprintf("unknown diagnostics query '%s'", query.Query)
+	}
+	payload, err := json.Marshal(reply)
+	if err != nil {
+		return err
+	}
+	return d.writeMessage(conn, info, websocket.TextMessage, append([]byte("d"), payload...), time.Time{})
+}
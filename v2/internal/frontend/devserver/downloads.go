@@ -0,0 +1,187 @@
+package devserver
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	pkgruntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// downloadTTL is the time clients have to start a download
+const downloadTTL = time.Minute
+
+const downloadBufferSize = 32 << 10
+
+// downloads holds the io.Reader results of calls until the clients fetch them from the download endpoint,
+// so large results are streamed instead of being buffered in memory
+type downloads struct {
+	lock      sync.Mutex
+	downloads map[string]*download
+}
+
+type download struct {
+	reader    io.Reader
+	clientID  string
+	expires   time.Time
+	closeOnce sync.Once
+}
+
+// close closes the reader if it is an io.Closer, e.g. an *os.File
+func (d *download) close() {
+	d.closeOnce.Do(func() {
+		if closer, ok := d.reader.(io.Closer); ok {
+			_ = closer.Close()
+		}
+	})
+}
+
+func newDownloads() *downloads {
+	return &downloads{
+		downloads: make(map[string]*download),
+	}
+}
+
+// add stores the reader and returns the ID to fetch it with. Expired downloads are closed.
+func (d *downloads) add(reader io.Reader, clientID string) (string, error) {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", err
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	now := time.Now()
+	for key, pending := range d.downloads {
+		if now.After(pending.expires) {
+			pending.close()
+			delete(d.downloads, key)
+		}
+	}
+	key := hex.EncodeToString(id[:])
+	d.downloads[key] = &download{
+		reader:   reader,
+		clientID: clientID,
+		expires:  now.Add(downloadTTL),
+	}
+	return key, nil
+}
+
+// take removes the download, a download can only be fetched once
+func (d *downloads) take(id string) (*download, bool) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	pending, ok := d.downloads[id]
+	if !ok {
+		return nil, false
+	}
+	delete(d.downloads, id)
+	if time.Now().After(pending.expires) {
+		pending.close()
+		return nil, false
+	}
+	return pending, true
+}
+
+// releaseClient closes the downloads the client has not fetched before disconnecting
+func (d *downloads) releaseClient(clientID string) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	for key, pending := range d.downloads {
+		if pending.clientID == clientID {
+			pending.close()
+			delete(d.downloads, key)
+		}
+	}
+}
+
+// downloadMetadata returns the metadata of a runtime.Download, completed with the ones of the file if the
+// reader is one
+func downloadMetadata(reader io.Reader) pkgruntime.Download {
+	var metadata pkgruntime.Download
+	switch download := reader.(type) {
+	case pkgruntime.Download:
+		metadata = download
+	case *pkgruntime.Download:
+		metadata = *download
+	default:
+		metadata.Reader = reader
+	}
+	if file, ok := metadata.Reader.(interface{ Stat() (os.FileInfo, error) }); ok {
+		if stat, err := file.Stat(); err == nil && stat.Mode().IsRegular() {
+			if metadata.Size == 0 {
+				metadata.Size = stat.Size()
+			}
+			if metadata.Filename == "" {
+				metadata.Filename = stat.Name()
+			}
+		}
+	}
+	if metadata.ContentType == "" {
+		metadata.ContentType = echo.MIMEOctetStream
+	}
+	return metadata
+}
+
+func (d *DevWebServer) handleDownload(c echo.Context) error {
+	pending, ok := d.downloads.take(c.Param("id"))
+	if !ok {
+		return c.NoContent(http.StatusNotFound)
+	}
+	defer pending.close()
+
+	// Close the reader if the client goes away, to unblock a read waiting for more data. The context is
+	// captured here, echo recycles c once the handler has returned.
+	ctx := c.Request().Context()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			pending.close()
+		case <-done:
+		}
+	}()
+
+	header := c.Response().Header()
+	metadata := downloadMetadata(pending.reader)
+	header.Set(echo.HeaderContentType, metadata.ContentType)
+	if metadata.Size > 0 {
+		header.Set(echo.HeaderContentLength, strconv.FormatInt(metadata.Size, 10))
+	}
+	if metadata.Filename != "" {
+		header.Set(echo.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": metadata.Filename}))
+	}
+	c.Response().WriteHeader(http.StatusOK)
+	c.Response().Flush()
+
+	buffer := make([]byte, downloadBufferSize)
+	for {
+		n, err := pending.reader.Read(buffer)
+		if n > 0 {
+			if _, writeErr := c.Response().Write(buffer[:n]); writeErr != nil {
+				d.LogDebug("Download aborted by the client: %s", writeErr.Error())
+				return nil
+			}
+			c.Response().Flush()
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				d.LogDebug("Download aborted by the client")
+			} else {
+				d.logger.Error("Unable to read the download: %s", err.Error())
+			}
+			return nil
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package devserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/wailsapp/wails/v2/internal/frontend/runtime"
+)
+
+// defaultEventAckTimeout is how long a reliable event waits for the acknowledgements by default
+const defaultEventAckTimeout = 5 * time.Second
+
+// EventAckError is returned when clients did not acknowledge a reliable event in time
+type EventAckError struct {
+	Event string
+	// Clients are the IDs of the clients which did not acknowledge the event, sorted
+	Clients []string
+}
+
+func (e *EventAckError) Error() string {
+	return fmt.Sprintf("event '%s' has not been acknowledged by the clients '%s'", e.Event, strings.Join(e.Clients, "', '"))
+}
+
+// isEventAck reports whether the message of a client is the acknowledgement "EA<ack ID>" of a reliable event
+func isEventAck(message []byte) bool {
+	return len(message) > 2 && message[0] == 'E' && message[1] == 'A'
+}
+
+// pendingEventAck is a reliable event waiting for the acknowledgements of the clients it has been sent to
+type pendingEventAck struct {
+	lock      sync.Mutex
+	remaining map[string]struct{}
+	done      chan struct{}
+}
+
+func (p *pendingEventAck) acknowledge(clientID string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if _, ok := p.remaining[clientID]; !ok {
+		return
+	}
+	delete(p.remaining, clientID)
+	if len(p.remaining) == 0 {
+		close(p.done)
+	}
+}
+
+// missing returns the sorted IDs of the clients which did not acknowledge the event
+func (p *pendingEventAck) missing() []string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	result := make([]string, 0, len(p.remaining))
+	for clientID := range p.remaining {
+		result = append(result, clientID)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// eventAcks tracks the reliable events by their ack ID, which is sent with the event as "ackid". The clients
+// acknowledge the receipt of the event with "EA<ack ID>".
+type eventAcks struct {
+	lock    sync.Mutex
+	nextID  uint64
+	pending map[string]*pendingEventAck
+}
+
+// add starts tracking an event sent to the clients, it returns its ack ID
+func (a *eventAcks) add(clientIDs []string) (string, *pendingEventAck) {
+	pending := &pendingEventAck{
+		remaining: make(map[string]struct{}, len(clientIDs)),
+		done:      make(chan struct{}),
+	}
+	for _, clientID := range clientIDs {
+		pending.remaining[clientID] = struct{}{}
+	}
+	if len(clientIDs) == 0 {
+		close(pending.done)
+	}
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if a.pending == nil {
+		a.pending = make(map[string]*pendingEventAck)
+	}
+	a.nextID++
+	id := strconv.FormatUint(a.nextID, 10)
+	a.pending[id] = pending
+	return id, pending
+}
+
+// acknowledge records the acknowledgement of the event by the client, unknown IDs are ignored
+func (a *eventAcks) acknowledge(id string, clientID string) {
+	a.lock.Lock()
+	pending := a.pending[id]
+	a.lock.Unlock()
+	if pending != nil {
+		pending.acknowledge(clientID)
+	}
+}
+
+// remove stops tracking the event
+func (a *eventAcks) remove(id string) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	delete(a.pending, id)
+}
+
+// isReliableEvent reports whether the event is one of the ReliableEvents, which Notify delivers reliably
+func (d *DevWebServer) isReliableEvent(name string) bool {
+	for _, reliable := range d.appoptions.WebSocket.ReliableEvents {
+		if reliable == name || (runtime.IsEventPattern(reliable) && runtime.MatchEventPattern(reliable, name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// NotifyReliable sends the event to the websocket clients subscribed to it and blocks until all of them
+// acknowledged its receipt. It fails with an EventAckError if some of them did not before the deadline of
+// the context, or EventAckTimeout if it has none. A client disconnecting before its acknowledgement is missing.
+// The event stream clients get the event too, but can't acknowledge it. Unlike Notify, the desktop frontend
+// is not notified.
+func (d *DevWebServer) NotifyReliable(ctx context.Context, name string, data ...interface{}) error {
+	if _, ok := ctx.Deadline(); !ok {
+		timeout := d.appoptions.WebSocket.EventAckTimeout
+		if timeout <= 0 {
+			timeout = defaultEventAckTimeout
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	d.socketMutex.Lock()
+	clients := make(map[*websocket.Conn]*WebsocketInfo, len(d.websocketClients))
+	clientIDs := make([]string, 0, len(d.websocketClients))
+	for conn, info := range d.websocketClients {
+		if info.subscriptions.Matches(name) {
+			clients[conn] = info
+			clientIDs = append(clientIDs, info.id)
+		}
+	}
+	d.socketMutex.Unlock()
+
+	ackID, pending := d.eventAcks.add(clientIDs)
+	defer d.eventAcks.remove(ackID)
+	notification := EventNotify{
+		Name:  name,
+		Data:  data,
+		AckID: ackID,
+	}
+	d.tagEvent(&notification, eventSourceGo)
+	payload, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+	message, err := d.limitEventSize(name, "n"+string(payload))
+	if err != nil {
+		return err
+	}
+	d.eventsBroadcast.Add(1)
+	d.notifyEventStreamClients(name, message, "")
+	for conn, info := range clients {
+		if d.enqueue(conn, info, []byte(message)) {
+			info.subscriptions.Consumed(name)
+		}
+	}
+
+	select {
+	case <-pending.done:
+		return nil
+	case <-ctx.Done():
+		return &EventAckError{Event: name, Clients: pending.missing()}
+	}
+}
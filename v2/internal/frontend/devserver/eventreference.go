@@ -0,0 +1,61 @@
+package devserver
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// eventReferenceTTL is the time clients have to fetch an oversized event
+const eventReferenceTTL = time.Minute
+
+// eventReferences holds the oversized events that clients fetch over HTTP
+type eventReferences struct {
+	lock   sync.Mutex
+	events map[string]eventReference
+}
+
+type eventReference struct {
+	payload []byte
+	expires time.Time
+}
+
+func newEventReferences() *eventReferences {
+	return &eventReferences{
+		events: make(map[string]eventReference),
+	}
+}
+
+// add stores the payload and returns the ID to fetch it with. Expired references are removed.
+func (e *eventReferences) add(payload []byte) (string, error) {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", err
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	now := time.Now()
+	for key, reference := range e.events {
+		if now.After(reference.expires) {
+			delete(e.events, key)
+		}
+	}
+	key := hex.EncodeToString(id[:])
+	e.events[key] = eventReference{
+		payload: payload,
+		expires: now.Add(eventReferenceTTL),
+	}
+	return key, nil
+}
+
+// get returns the payload of the reference if it has not expired
+func (e *eventReferences) get(id string) ([]byte, bool) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	reference, ok := e.events[id]
+	if !ok || time.Now().After(reference.expires) {
+		return nil, false
+	}
+	return reference.payload, true
+}
@@ -0,0 +1,371 @@
+package devserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/wailsapp/wails/v2/internal/binding"
+	"github.com/wailsapp/wails/v2/internal/frontend"
+	"github.com/wailsapp/wails/v2/internal/frontend/dispatcher"
+	"github.com/wailsapp/wails/v2/internal/frontend/runtime"
+	"github.com/wailsapp/wails/v2/internal/logger"
+	pkglogger "github.com/wailsapp/wails/v2/pkg/logger"
+	"github.com/wailsapp/wails/v2/pkg/options"
+)
+
+// IPCHarness runs a DevWebServer in-process to test bindings and event flows without a browser. The IPC
+// websocket is served over in-memory connections, no port is opened and the assets are not served.
+type IPCHarness struct {
+	// Server is the DevWebServer under test
+	Server *DevWebServer
+	// Events are the Go events, they are nil if a custom dispatcher is used
+	Events *runtime.Events
+
+	listener   *pipeListener
+	httpServer *http.Server
+}
+
+// NewIPCHarness creates a harness whose calls are dispatched to the structs in appoptions.Bind
+func NewIPCHarness(appoptions *options.App) *IPCHarness {
+	if appoptions == nil {
+		appoptions = &options.App{}
+	}
+	myLogger := newHarnessLogger(appoptions)
+	ctx := context.Background()
+	appBindings := binding.NewBindings(myLogger, appoptions.Bind, nil, false, appoptions.EnumBind)
+	if err := appBindings.SetCallTimeouts(appoptions.CallTimeouts); err != nil {
+		myLogger.Fatal(err.Error())
+	}
+	events := runtime.NewEvents(myLogger)
+	messageDispatcher := dispatcher.NewDispatcher(ctx, myLogger, appBindings, events, appoptions.ErrorFormatter)
+	messageDispatcher.UseInterceptors(appoptions.Interceptors)
+	messageDispatcher.UseTelemetry(appoptions.Telemetry)
+	messageDispatcher.UseCallLimits(appoptions.CallLimits)
+
+	// The events of the harness get the client lifecycle events
+	ctx = context.WithValue(ctx, "events", events)
+	harness := newIPCHarness(ctx, appoptions, myLogger, appBindings, messageDispatcher)
+	harness.Events = events
+	events.AddFrontend(harness.Server)
+	return harness
+}
+
+// NewIPCHarnessWithDispatcher creates a harness whose messages are processed by the given dispatcher,
+// e.g. a mock of the bound methods
+func NewIPCHarnessWithDispatcher(appoptions *options.App, messageDispatcher frontend.Dispatcher) *IPCHarness {
+	if appoptions == nil {
+		appoptions = &options.App{}
+	}
+	myLogger := newHarnessLogger(appoptions)
+	appBindings := binding.NewBindings(myLogger, nil, nil, false, nil)
+	return newIPCHarness(context.Background(), appoptions, myLogger, appBindings, messageDispatcher)
+}
+
+// newHarnessLogger uses the logger of the options, only logging errors by default
+func newHarnessLogger(appoptions *options.App) *logger.Logger {
+	appLogger := appoptions.Logger
+	if appLogger == nil {
+		appLogger = pkglogger.NewDefaultLogger()
+	}
+	myLogger := logger.New(appLogger)
+	myLogger.SetLogLevel(pkglogger.ERROR)
+	if appoptions.LogLevel != 0 {
+		myLogger.SetLogLevel(appoptions.LogLevel)
+	}
+	return myLogger
+}
+
+func newIPCHarness(ctx context.Context, appoptions *options.App, myLogger *logger.Logger, appBindings *binding.Bindings, messageDispatcher frontend.Dispatcher) *IPCHarness {
+	server := NewFrontend(ctx, appoptions, myLogger, appBindings, messageDispatcher, nil, &harnessFrontend{})
+	server.server.GET("/wails/ipc", server.handleIPCWebSocket)
+
+	harness := &IPCHarness{
+		Server:     server,
+		listener:   newPipeListener(),
+		httpServer: &http.Server{Handler: server.server},
+	}
+	go func() { _ = harness.httpServer.Serve(harness.listener) }()
+	return harness
+}
+
+// DialIPC connects a new client to the IPC websocket, with the AuthToken of the options
+func (h *IPCHarness) DialIPC(ctx context.Context) (*IPCClient, error) {
+	dialer := &websocket.Dialer{
+		NetDialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return h.listener.dial(ctx)
+		},
+	}
+	var header http.Header
+	if token := h.Server.appoptions.WebSocket.AuthToken; token != "" {
+		header = http.Header{"Authorization": {"Bearer " + token}}
+	}
+	conn, _, err := dialer.DialContext(ctx, "ws://wails.localhost/wails/ipc", header)
+	if err != nil {
+		return nil, err
+	}
+	// The first message is the ID of the client
+	_, message, err := conn.ReadMessage()
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	if !strings.HasPrefix(string(message), "id") {
+		_ = conn.Close()
+		return nil, fmt.Errorf("expected the client id, got '%s'", message)
+	}
+
+	// The ID is sent before the client is registered, wait for it to receive the events
+	clientID := string(message[2:])
+	for h.Server.websocketClient(clientID) == nil {
+		select {
+		case <-ctx.Done():
+			_ = conn.Close()
+			return nil, ctx.Err()
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	client := &IPCClient{
+		ID:            clientID,
+		conn:          conn,
+		pending:       make(map[string]chan *dispatcher.CallbackMessage),
+		notifications: make(chan EventNotify, 64),
+		done:          make(chan struct{}),
+	}
+	go client.readLoop()
+	return client, nil
+}
+
+// Close disconnects all clients and stops the harness
+func (h *IPCHarness) Close() error {
+	h.Server.shutdown()
+	return h.httpServer.Close()
+}
+
+// IPCClient is a client of the IPC websocket, sending the same messages as the injected IPC script
+type IPCClient struct {
+	// ID is the ID the server assigned to the client
+	ID string
+
+	conn      *websocket.Conn
+	writeLock sync.Mutex
+
+	lastCallbackID atomic.Uint64
+	pendingLock    sync.Mutex
+	pending        map[string]chan *dispatcher.CallbackMessage
+
+	notifications chan EventNotify
+	done          chan struct{}
+	err           error
+}
+
+// Call calls the bound method and waits for its result. The error is either the error returned by the
+// method or the error of the connection.
+func (c *IPCClient) Call(ctx context.Context, name string, args ...interface{}) (interface{}, error) {
+	if args == nil {
+		args = []interface{}{}
+	}
+	callbackID := name + "-" + strconv.FormatUint(c.lastCallbackID.Add(1), 10)
+	message, err := json.Marshal(struct {
+		Name       string        `json:"name"`
+		Args       []interface{} `json:"args"`
+		CallbackID string        `json:"callbackID"`
+	}{name, args, callbackID})
+	if err != nil {
+		return nil, err
+	}
+
+	reply := make(chan *dispatcher.CallbackMessage, 1)
+	c.pendingLock.Lock()
+	c.pending[callbackID] = reply
+	c.pendingLock.Unlock()
+	defer func() {
+		c.pendingLock.Lock()
+		delete(c.pending, callbackID)
+		c.pendingLock.Unlock()
+	}()
+
+	if err := c.Send("C" + string(message)); err != nil {
+		return nil, err
+	}
+	select {
+	case callbackMessage := <-reply:
+		if callbackMessage.Err != nil {
+			return nil, fmt.Errorf("%v", callbackMessage.Err)
+		}
+		return callbackMessage.Result, nil
+	case <-c.done:
+		return nil, c.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Emit emits the event to the other clients and the Go event listeners
+func (c *IPCClient) Emit(name string, data ...interface{}) error {
+	if data == nil {
+		data = []interface{}{}
+	}
+	message, err := json.Marshal(EventNotify{Name: name, Data: data})
+	if err != nil {
+		return err
+	}
+	return c.Send("EE" + string(message))
+}
+
+// Subscribe subscribes the client to the events. Until the first subscription, the client receives all events.
+func (c *IPCClient) Subscribe(eventNames ...string) error {
+	for _, eventName := range eventNames {
+		if err := c.Send("EB" + eventName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SubscribeMultiple subscribes the client to the event for count deliveries, like EventsOnMultiple.
+// A count of 1 is EventsOnce, a count of zero or less never expires.
+func (c *IPCClient) SubscribeMultiple(eventName string, count int) error {
+	message, err := json.Marshal(subscribeMessage{Name: eventName, Count: count})
+	if err != nil {
+		return err
+	}
+	return c.Send("ES" + string(message))
+}
+
+// Unsubscribe removes the subscription of the event
+func (c *IPCClient) Unsubscribe(eventName string) error {
+	return c.Send("EX" + eventName)
+}
+
+// Send sends a raw IPC message
+func (c *IPCClient) Send(message string) error {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	return c.conn.WriteMessage(websocket.TextMessage, []byte(message))
+}
+
+// Notifications returns the events received by the client, the channel is closed when the connection is closed.
+// Up to 64 events are buffered, further events are dropped until the buffered ones are read.
+func (c *IPCClient) Notifications() <-chan EventNotify {
+	return c.notifications
+}
+
+// Close closes the connection
+func (c *IPCClient) Close() error {
+	return c.conn.Close()
+}
+
+func (c *IPCClient) readLoop() {
+	defer close(c.notifications)
+	defer close(c.done)
+	for {
+		messageType, message, err := c.conn.ReadMessage()
+		if err != nil {
+			c.err = err
+			return
+		}
+		if len(message) < 2 {
+			continue
+		}
+		if messageType == websocket.BinaryMessage {
+			// A []byte result: "c", the callback ID, a zero byte and the bytes
+			if callbackID, data, ok := strings.Cut(string(message[1:]), "\x00"); ok && message[0] == 'c' {
+				c.reply(&dispatcher.CallbackMessage{CallbackID: callbackID, Result: []byte(data)})
+			}
+			continue
+		}
+		switch message[0] {
+		case 'c':
+			var callbackMessage dispatcher.CallbackMessage
+			if json.Unmarshal(message[1:], &callbackMessage) == nil {
+				c.reply(&callbackMessage)
+			}
+		case 'n':
+			var notification EventNotify
+			if json.Unmarshal(message[1:], &notification) == nil {
+				select {
+				case c.notifications <- notification:
+				default:
+				}
+			}
+		}
+	}
+}
+
+func (c *IPCClient) reply(callbackMessage *dispatcher.CallbackMessage) {
+	c.pendingLock.Lock()
+	reply := c.pending[callbackMessage.CallbackID]
+	c.pendingLock.Unlock()
+	if reply != nil {
+		reply <- callbackMessage
+	}
+}
+
+// harnessFrontend is the desktop frontend of the harness, which does nothing
+type harnessFrontend struct {
+	frontend.Frontend
+}
+
+func (f *harnessFrontend) Notify(name string, data ...interface{}) {}
+func (f *harnessFrontend) WindowReload()                           {}
+func (f *harnessFrontend) WindowReloadApp()                        {}
+
+// pipeListener is a net.Listener for in-memory connections
+type pipeListener struct {
+	conns     chan net.Conn
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newPipeListener() *pipeListener {
+	return &pipeListener{
+		conns:  make(chan net.Conn),
+		closed: make(chan struct{}),
+	}
+}
+
+func (l *pipeListener) dial(ctx context.Context) (net.Conn, error) {
+	client, server := net.Pipe()
+	select {
+	case l.conns <- server:
+		return client, nil
+	case <-l.closed:
+		return nil, net.ErrClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (l *pipeListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.closed:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *pipeListener) Close() error {
+	l.closeOnce.Do(func() { close(l.closed) })
+	return nil
+}
+
+func (l *pipeListener) Addr() net.Addr {
+	return pipeAddr{}
+}
+
+type pipeAddr struct{}
+
+func (pipeAddr) Network() string { return "pipe" }
+func (pipeAddr) String() string  { return "ipc-harness" }
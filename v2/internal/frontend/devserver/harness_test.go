@@ -0,0 +1,255 @@
+package devserver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/wailsapp/wails/v2/pkg/options"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+type Greeter struct{}
+
+func (g *Greeter) Greet(name string) string {
+	return "Hello " + name + "!"
+}
+
+func (g *Greeter) Fail() error {
+	return errors.New("failed")
+}
+
+func (g *Greeter) GreetCaller(ctx context.Context, greeting string) string {
+	return greeting + " " + runtime.ClientID(ctx) + "!"
+}
+
+func TestIPCHarnessCalls(t *testing.T) {
+	i := is.New(t)
+	harness := NewIPCHarness(&options.App{Bind: []interface{}{&Greeter{}}})
+	defer harness.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client, err := harness.DialIPC(ctx)
+	i.NoErr(err)
+	defer client.Close()
+	i.Equal(harness.Server.ClientIDs(), []string{client.ID})
+
+	result, err := client.Call(ctx, "devserver.Greeter.Greet", "World")
+	i.NoErr(err)
+	i.Equal(result, "Hello World!")
+
+	_, err = client.Call(ctx, "devserver.Greeter.Fail")
+	i.True(err != nil)
+	i.Equal(err.Error(), "failed")
+
+	// Methods taking a context get the ID of the calling client
+	other, err := harness.DialIPC(ctx)
+	i.NoErr(err)
+	defer other.Close()
+	result, err = client.Call(ctx, "devserver.Greeter.GreetCaller", "Hi")
+	i.NoErr(err)
+	i.Equal(result, "Hi "+client.ID+"!")
+	result, err = other.Call(ctx, "devserver.Greeter.GreetCaller", "Hi")
+	i.NoErr(err)
+	i.Equal(result, "Hi "+other.ID+"!")
+}
+
+func TestIPCHarnessEvents(t *testing.T) {
+	i := is.New(t)
+	harness := NewIPCHarness(&options.App{Bind: []interface{}{&Greeter{}}})
+	defer harness.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	subscriber, err := harness.DialIPC(ctx)
+	i.NoErr(err)
+	defer subscriber.Close()
+	i.NoErr(subscriber.Subscribe("tick"))
+	// The messages of a client are processed in order, so the subscription is active once the call returns
+	_, err = subscriber.Call(ctx, "devserver.Greeter.Greet", "subscriber")
+	i.NoErr(err)
+
+	harness.Events.Emit("other", 0)
+	harness.Events.Emit("tick", 1)
+	select {
+	case notification := <-subscriber.Notifications():
+		i.Equal(notification.Name, "tick")
+		i.Equal(notification.Data, []interface{}{float64(1)})
+	case <-ctx.Done():
+		t.Fatal("event not received")
+	}
+
+	// Events emitted by a client reach the Go listeners and the other clients
+	received := make(chan []interface{}, 1)
+	harness.Events.On("hello", func(data ...interface{}) { received <- data })
+	emitter, err := harness.DialIPC(ctx)
+	i.NoErr(err)
+	defer emitter.Close()
+	listener, err := harness.DialIPC(ctx)
+	i.NoErr(err)
+	defer listener.Close()
+	i.NoErr(emitter.Emit("hello", "world"))
+
+	select {
+	case data := <-received:
+		i.Equal(data, []interface{}{"world"})
+	case <-ctx.Done():
+		t.Fatal("event not received in Go")
+	}
+	select {
+	case notification := <-listener.Notifications():
+		i.Equal(notification.Name, "hello")
+		i.Equal(notification.Sender, emitter.ID)
+	case <-ctx.Done():
+		t.Fatal("event not received by the other client")
+	}
+}
+
+func TestIPCHarnessSubscriptionCounts(t *testing.T) {
+	i := is.New(t)
+	harness := NewIPCHarness(&options.App{Bind: []interface{}{&Greeter{}}})
+	defer harness.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client, err := harness.DialIPC(ctx)
+	i.NoErr(err)
+	defer client.Close()
+	i.NoErr(client.SubscribeMultiple("tick", 2))
+	i.NoErr(client.SubscribeMultiple("done", 1))
+	// Keeps the events filtered once the counted subscriptions expired
+	i.NoErr(client.Subscribe("other"))
+	_, err = client.Call(ctx, "devserver.Greeter.Greet", "client")
+	i.NoErr(err)
+	i.Equal(harness.Server.ClientSubscriptions(client.ID), []string{"done", "other", "tick"})
+
+	for n := 0; n < 3; n++ {
+		harness.Events.Emit("tick", n)
+	}
+	harness.Events.Emit("done")
+	harness.Events.Emit("done")
+
+	// The events are sent concurrently, so they may arrive in any order
+	received := map[string]int{}
+	for n := 0; n < 3; n++ {
+		select {
+		case notification := <-client.Notifications():
+			received[notification.Name]++
+		case <-ctx.Done():
+			t.Fatal("event not received")
+		}
+	}
+	select {
+	case notification := <-client.Notifications():
+		t.Fatalf("unexpected event '%s'", notification.Name)
+	case <-time.After(200 * time.Millisecond):
+	}
+	i.Equal(received, map[string]int{"tick": 2, "done": 1})
+	i.Equal(harness.Server.ClientSubscriptions(client.ID), []string{"other"})
+}
+
+func TestEventsEmitTo(t *testing.T) {
+	i := is.New(t)
+	harness := NewIPCHarness(&options.App{Bind: []interface{}{&Greeter{}}})
+	defer harness.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	target, err := harness.DialIPC(ctx)
+	i.NoErr(err)
+	defer target.Close()
+	other, err := harness.DialIPC(ctx)
+	i.NoErr(err)
+	defer other.Close()
+
+	appContext := context.WithValue(ctx, "frontend", harness.Server)
+	i.NoErr(runtime.EventsEmitTo(appContext, target.ID, "private", "data"))
+	select {
+	case notification := <-target.Notifications():
+		i.Equal(notification.Name, "private")
+		i.Equal(notification.Data, []interface{}{"data"})
+	case <-ctx.Done():
+		t.Fatal("event not received")
+	}
+	select {
+	case notification := <-other.Notifications():
+		t.Fatalf("unexpected event '%s'", notification.Name)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	i.True(runtime.EventsEmitTo(appContext, "unknown", "private") != nil)
+}
+
+func TestClientLifecycleEvents(t *testing.T) {
+	i := is.New(t)
+	harness := NewIPCHarness(nil)
+	defer harness.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	received := make(chan runtime.ClientEvent, 2)
+	harness.Events.On(runtime.EventClientConnected, func(data ...interface{}) { received <- data[0].(runtime.ClientEvent) })
+	harness.Events.On(runtime.EventClientDisconnected, func(data ...interface{}) { received <- data[0].(runtime.ClientEvent) })
+
+	client, err := harness.DialIPC(ctx)
+	i.NoErr(err)
+	select {
+	case event := <-received:
+		i.Equal(event.ID, client.ID)
+		i.Equal(event.Clients, 1)
+		i.Equal(event.RemoteAddr, "pipe")
+		i.Equal(event.UserAgent, "Go-http-client/1.1")
+	case <-ctx.Done():
+		t.Fatal("connect event not received")
+	}
+
+	i.NoErr(client.Close())
+	select {
+	case event := <-received:
+		i.Equal(event.ID, client.ID)
+		i.Equal(event.Clients, 0)
+	case <-ctx.Done():
+		t.Fatal("disconnect event not received")
+	}
+}
+
+func TestBrowserClients(t *testing.T) {
+	i := is.New(t)
+	harness := NewIPCHarness(nil)
+	defer harness.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	appCtx := context.WithValue(ctx, "frontend", harness.Server)
+
+	client, err := harness.DialIPC(ctx)
+	i.NoErr(err)
+	i.NoErr(client.Subscribe("app:*"))
+	for len(harness.Server.ClientSubscriptions(client.ID)) == 0 {
+		select {
+		case <-ctx.Done():
+			t.Fatal("subscription not received")
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	clients := runtime.BrowserClients(appCtx)
+	i.Equal(len(clients), 1)
+	i.Equal(clients[0].ID, client.ID)
+	i.Equal(clients[0].RemoteAddr, "pipe")
+	i.Equal(clients[0].Subscriptions, []string{"app:*"})
+	i.True(!clients[0].ConnectedAt.IsZero())
+
+	// The client is disconnected, its notifications are closed
+	i.NoErr(runtime.DisconnectClient(appCtx, client.ID))
+	select {
+	case _, ok := <-client.Notifications():
+		i.True(!ok)
+	case <-ctx.Done():
+		t.Fatal("client not disconnected")
+	}
+	i.True(waitForClients(harness.Server, 0))
+	i.True(runtime.DisconnectClient(appCtx, client.ID) != nil)
+}
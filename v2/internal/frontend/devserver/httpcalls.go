@@ -0,0 +1,128 @@
+package devserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/wailsapp/wails/v2/internal/binding"
+	"github.com/wailsapp/wails/v2/internal/frontend/dispatcher"
+)
+
+// httpCallReply is the reply to a call over HTTP, which holds either the result or the error of the method
+type httpCallReply struct {
+	Result interface{} `json:"result"`
+	Error  interface{} `json:"error,omitempty"`
+}
+
+// handleHTTPCall calls the bound method with the JSON array of arguments in the body. An empty body calls
+// the method without arguments. The result of a method returning an io.Reader, e.g. a runtime.Download, is
+// the URL it is streamed from.
+func (d *DevWebServer) handleHTTPCall(c echo.Context) error {
+	if !d.isAuthorized(c.Request()) {
+		return c.JSON(http.StatusUnauthorized, httpCallReply{Error: "invalid or missing token"})
+	}
+	method := d.appBindings.DB().GetMethodFromStore(c.Param("package"), c.Param("struct"), c.Param("method"))
+	if method == nil {
+		return c.JSON(http.StatusNotFound, httpCallReply{Error: "method not registered"})
+	}
+
+	var args []json.RawMessage
+	body := http.MaxBytesReader(c.Response(), c.Request().Body, d.maxMessageSize())
+	if err := json.NewDecoder(body).Decode(&args); err != nil && !errors.Is(err, io.EOF) {
+		return c.JSON(http.StatusBadRequest, httpCallReply{Error: "invalid arguments: " + err.Error()})
+	}
+	if args == nil {
+		args = []json.RawMessage{}
+	}
+	parsedArgs, err := method.ParseArgs(args)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, httpCallReply{Error: "error parsing arguments: " + err.Error()})
+	}
+
+	// The method gets the context of the application, cancelled when the request is
+	ctx, cancel := context.WithCancel(d.ctx)
+	defer cancel()
+	requestCtx := c.Request().Context()
+	go func() {
+		select {
+		case <-requestCtx.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	d.ipcCalls.Add(1)
+	d.callsInProgress.Add(1)
+	defer d.callsInProgress.Add(-1)
+	start := time.Now()
+	var result interface{}
+	if caller, ok := d.dispatcher.(methodCaller); ok {
+		// The calls over HTTP share the CallLimits of the dispatcher
+		result, err = caller.Call(ctx, method, parsedArgs)
+	} else {
+		result, err = dispatcher.CallMethod(ctx, d.tracer, d.appoptions.Interceptors, method, parsedArgs)
+	}
+	d.metrics.observeDispatch(start)
+	if err != nil {
+		status := http.StatusInternalServerError
+		var timeoutErr *binding.TimeoutError
+		var busyErr *dispatcher.BusyError
+		if errors.As(err, &timeoutErr) {
+			status = http.StatusGatewayTimeout
+		} else if errors.As(err, &busyErr) {
+			status = http.StatusServiceUnavailable
+		}
+		formatted := binding.ErrorValue(err)
+		if d.appoptions.ErrorFormatter != nil {
+			formatted = d.appoptions.ErrorFormatter(err)
+		}
+		return c.JSON(status, httpCallReply{Error: formatted})
+	}
+	if reader, ok := result.(binding.StreamReader); ok {
+		reader.Cancel()
+		return c.JSON(http.StatusNotImplemented, httpCallReply{Error: "streamed results are only supported over the IPC websocket"})
+	}
+	if reader, ok := result.(io.Reader); ok {
+		// Like over the IPC websocket, the result is the URL the reader is streamed from
+		// The download is only released with a client which proved its session
+		clientID, _ := d.verifiedClient(c.Request())
+		id, err := d.downloads.add(reader, clientID)
+		if err != nil {
+			(&download{reader: reader}).close()
+			return c.JSON(http.StatusInternalServerError, httpCallReply{Error: err.Error()})
+		}
+		result = d.basePath + "/wails/download/" + id
+	}
+	return c.JSON(http.StatusOK, httpCallReply{Result: result})
+}
+
+// methodCaller is implemented by the dispatchers calling the bound methods within their limits
+type methodCaller interface {
+	Call(ctx context.Context, method *binding.BoundMethod, args []interface{}) (interface{}, error)
+}
+
+// handleOpenAPI serves the OpenAPI document of the bound methods, whose paths are below the base path
+func (d *DevWebServer) handleOpenAPI(c echo.Context) error {
+	if !d.isAuthorized(c.Request()) {
+		return c.JSON(http.StatusUnauthorized, httpCallReply{Error: "invalid or missing token"})
+	}
+	openAPI, err := d.appBindings.ToOpenAPI()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, httpCallReply{Error: err.Error()})
+	}
+	if d.basePath == "" {
+		return c.Blob(http.StatusOK, echo.MIMEApplicationJSON, []byte(openAPI))
+	}
+	var document map[string]interface{}
+	if err := json.Unmarshal([]byte(openAPI), &document); err != nil {
+		return c.JSON(http.StatusInternalServerError, httpCallReply{Error: err.Error()})
+	}
+	document["servers"] = []map[string]string{{"url": d.basePath}}
+	return c.JSON(http.StatusOK, document)
+}
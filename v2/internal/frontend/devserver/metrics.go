@@ -0,0 +1,161 @@
+package devserver
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// clientIDContextKey is the key of the ID of the IPC client in the echo context, for the access log
+const clientIDContextKey = "clientid"
+
+// dispatchBuckets are the upper bounds in seconds of the buckets of the dispatch latency histogram
+var dispatchBuckets = []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5}
+
+// metrics are the counters served at /wails/metrics, besides the ones of the stats
+type metrics struct {
+	lock sync.Mutex
+
+	// requests counts the requests by method and status
+	requests map[requestKey]uint64
+
+	// dispatchCounts are the dispatched messages by bucket, the last one is +Inf
+	dispatchCounts []uint64
+	dispatchSum    float64
+}
+
+type requestKey struct {
+	method string
+	status int
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		requests:       make(map[requestKey]uint64),
+		dispatchCounts: make([]uint64, len(dispatchBuckets)+1),
+	}
+}
+
+// countRequest counts a request, a nil metrics counts nothing
+func (m *metrics) countRequest(method string, status int) {
+	if m == nil {
+		return
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.requests[requestKey{method: method, status: status}]++
+}
+
+// observeDispatch records the time since the start of the dispatch of a message, a nil metrics records nothing
+func (m *metrics) observeDispatch(start time.Time) {
+	if m == nil {
+		return
+	}
+	seconds := time.Since(start).Seconds()
+	bucket := sort.SearchFloat64s(dispatchBuckets, seconds)
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.dispatchCounts[bucket]++
+	m.dispatchSum += seconds
+}
+
+// accessLogMiddleware logs the requests if the access log is enabled and counts them for the metrics
+func (d *DevWebServer) accessLogMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		start := time.Now()
+		if err := next(c); err != nil {
+			c.Error(err)
+		}
+		req := c.Request()
+		status := c.Response().Status
+		if c.IsWebSocket() && !c.Response().Committed {
+			// The upgraded connection has been hijacked, the status has been written to it
+			status = http.StatusSwitchingProtocols
+		}
+		d.metrics.countRequest(req.Method, status)
+		if !d.appoptions.DevServer.AccessLog {
+			return nil
+		}
+
+		// The path is logged without the query, which may carry the AuthToken
+		clientID, _ := c.Get(clientIDContextKey).(string)
+		if clientID == "" {
+			clientID = requestedClientID(req)
+		}
+		if clientID != "" {
+			d.logger.Info("[DevWebServer] %s %s %d %s client='%s'", req.Method, req.URL.Path, status, time.Since(start), clientID)
+		} else {
+			d.logger.Info("[DevWebServer] %s %s %d %s", req.Method, req.URL.Path, status, time.Since(start))
+		}
+		return nil
+	}
+}
+
+func (d *DevWebServer) handleMetrics(c echo.Context) error {
+	c.Response().Header().Set(echo.HeaderContentType, "text/plain; version=0.0.4; charset=utf-8")
+	c.Response().WriteHeader(http.StatusOK)
+	return d.writeMetrics(c.Response())
+}
+
+// writeMetrics writes the metrics in the Prometheus text format
+func (d *DevWebServer) writeMetrics(w io.Writer) error {
+	stats := d.Stats()
+	d.sseMutex.Lock()
+	eventStreamClients := len(d.sseClients)
+	d.sseMutex.Unlock()
+
+	var out strings.Builder
+	writeMetric := func(name string, metricType string, help string, value interface{}) {
+		fmt.Fprintf(&out, "# HELP %s %s\n# TYPE %s %s\n%s %v\n", name, help, name, metricType, name, value)
+	}
+
+	m := d.metrics
+	m.lock.Lock()
+	requests := make([]requestKey, 0, len(m.requests))
+	for key := range m.requests {
+		requests = append(requests, key)
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		if requests[i].method != requests[j].method {
+			return requests[i].method < requests[j].method
+		}
+		return requests[i].status < requests[j].status
+	})
+	out.WriteString("# HELP wails_http_requests_total The HTTP requests served, by method and status.\n")
+	out.WriteString("# TYPE wails_http_requests_total counter\n")
+	for _, key := range requests {
+		fmt.Fprintf(&out, "wails_http_requests_total{method=%q,status=\"%d\"} %d\n", key.method, key.status, m.requests[key])
+	}
+
+	out.WriteString("# HELP wails_ipc_dispatch_duration_seconds The time of processing the IPC messages.\n")
+	out.WriteString("# TYPE wails_ipc_dispatch_duration_seconds histogram\n")
+	var count uint64
+	for index, bucketCount := range m.dispatchCounts {
+		count += bucketCount
+		le := "+Inf"
+		if index < len(dispatchBuckets) {
+			le = strconv.FormatFloat(dispatchBuckets[index], 'g', -1, 64)
+		}
+		fmt.Fprintf(&out, "wails_ipc_dispatch_duration_seconds_bucket{le=%q} %d\n", le, count)
+	}
+	fmt.Fprintf(&out, "wails_ipc_dispatch_duration_seconds_sum %g\n", m.dispatchSum)
+	fmt.Fprintf(&out, "wails_ipc_dispatch_duration_seconds_count %d\n", count)
+	m.lock.Unlock()
+
+	writeMetric("wails_websocket_clients", "gauge", "The connected IPC websocket clients.", stats.Clients)
+	writeMetric("wails_eventstream_clients", "gauge", "The connected event stream clients.", eventStreamClients)
+	writeMetric("wails_ipc_calls_total", "counter", "The calls of bound methods.", stats.IPCCalls)
+	writeMetric("wails_events_broadcast_total", "counter", "The events broadcast to the clients.", stats.EventsBroadcast)
+	writeMetric("wails_dropped_messages_total", "counter", "The events dropped from the send queues of slow clients.", stats.DroppedMessages)
+	writeMetric("wails_uptime_seconds", "gauge", "The time since the start of the dev server.", stats.Uptime)
+
+	_, err := io.WriteString(w, out.String())
+	return err
+}
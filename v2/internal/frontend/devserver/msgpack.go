@@ -0,0 +1,399 @@
+package devserver
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// msgpackSubprotocol is negotiated by the clients which want the call results encoded with MessagePack
+const msgpackSubprotocol = "wails.msgpack"
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// marshalMsgpack encodes the value with MessagePack, following the rules of encoding/json: struct fields are
+// named by their json tags and types implementing json.Marshaler are encoded from their JSON. Unlike with
+// JSON, []byte is encoded as binary instead of base64.
+func marshalMsgpack(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := encodeMsgpack(&buf, reflect.ValueOf(v)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func encodeMsgpack(buf *bytes.Buffer, v reflect.Value) error {
+	if !v.IsValid() {
+		buf.WriteByte(0xc0)
+		return nil
+	}
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+		buf.WriteByte(0xc0)
+		return nil
+	}
+	if v.Type().Implements(jsonMarshalerType) {
+		return encodeMsgpackFromJSON(buf, v.Interface().(json.Marshaler))
+	}
+	if v.Type().Implements(textMarshalerType) {
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return err
+		}
+		writeMsgpackString(buf, string(text))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return encodeMsgpack(buf, v.Elem())
+	case reflect.Bool:
+		if v.Bool() {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		writeMsgpackInt(buf, v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		writeMsgpackUint(buf, v.Uint())
+	case reflect.Float32:
+		buf.WriteByte(0xca)
+		_ = binary.Write(buf, binary.BigEndian, math.Float32bits(float32(v.Float())))
+	case reflect.Float64:
+		buf.WriteByte(0xcb)
+		_ = binary.Write(buf, binary.BigEndian, math.Float64bits(v.Float()))
+	case reflect.String:
+		writeMsgpackString(buf, v.String())
+	case reflect.Slice:
+		if v.IsNil() {
+			buf.WriteByte(0xc0)
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			writeMsgpackBinary(buf, v.Bytes())
+			return nil
+		}
+		return encodeMsgpackArray(buf, v)
+	case reflect.Array:
+		return encodeMsgpackArray(buf, v)
+	case reflect.Map:
+		if v.IsNil() {
+			buf.WriteByte(0xc0)
+			return nil
+		}
+		return encodeMsgpackMap(buf, v)
+	case reflect.Struct:
+		return encodeMsgpackStruct(buf, v)
+	default:
+		return fmt.Errorf("msgpack: unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// encodeMsgpackFromJSON encodes the JSON of the value, so custom marshalling is kept
+func encodeMsgpackFromJSON(buf *bytes.Buffer, marshaler json.Marshaler) error {
+	data, err := marshaler.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return err
+	}
+	return encodeMsgpackJSONValue(buf, value)
+}
+
+func encodeMsgpackJSONValue(buf *bytes.Buffer, value interface{}) error {
+	switch value := value.(type) {
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			writeMsgpackInt(buf, i)
+			return nil
+		}
+		f, err := value.Float64()
+		if err != nil {
+			return err
+		}
+		return encodeMsgpack(buf, reflect.ValueOf(f))
+	case []interface{}:
+		writeMsgpackHeader(buf, len(value), 0x90, 16, 0xdc, 0xdd)
+		for _, element := range value {
+			if err := encodeMsgpackJSONValue(buf, element); err != nil {
+				return err
+			}
+		}
+		return nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		writeMsgpackHeader(buf, len(keys), 0x80, 16, 0xde, 0xdf)
+		for _, key := range keys {
+			writeMsgpackString(buf, key)
+			if err := encodeMsgpackJSONValue(buf, value[key]); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return encodeMsgpack(buf, reflect.ValueOf(value))
+	}
+}
+
+func encodeMsgpackArray(buf *bytes.Buffer, v reflect.Value) error {
+	writeMsgpackHeader(buf, v.Len(), 0x90, 16, 0xdc, 0xdd)
+	for i := 0; i < v.Len(); i++ {
+		if err := encodeMsgpack(buf, v.Index(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func encodeMsgpackMap(buf *bytes.Buffer, v reflect.Value) error {
+	type entry struct {
+		key   string
+		value reflect.Value
+	}
+	entries := make([]entry, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		key, err := msgpackMapKey(iter.Key())
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry{key, iter.Value()})
+	}
+	// The keys are sorted like encoding/json does
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+	writeMsgpackHeader(buf, len(entries), 0x80, 16, 0xde, 0xdf)
+	for _, entry := range entries {
+		writeMsgpackString(buf, entry.key)
+		if err := encodeMsgpack(buf, entry.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func msgpackMapKey(key reflect.Value) (string, error) {
+	if key.Kind() == reflect.String {
+		return key.String(), nil
+	}
+	if key.Type().Implements(textMarshalerType) {
+		text, err := key.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err
+	}
+	switch key.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(key.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(key.Uint(), 10), nil
+	}
+	return "", fmt.Errorf("msgpack: unsupported map key type %s", key.Type())
+}
+
+func encodeMsgpackStruct(buf *bytes.Buffer, v reflect.Value) error {
+	fields := msgpackFieldsOf(v.Type())
+	values := make([]reflect.Value, 0, len(fields))
+	encoded := make([]msgpackField, 0, len(fields))
+	for _, field := range fields {
+		value, ok := fieldByIndex(v, field.index)
+		if !ok || (field.omitEmpty && isEmptyValue(value)) {
+			continue
+		}
+		values = append(values, value)
+		encoded = append(encoded, field)
+	}
+	writeMsgpackHeader(buf, len(encoded), 0x80, 16, 0xde, 0xdf)
+	for i, field := range encoded {
+		writeMsgpackString(buf, field.name)
+		if err := encodeMsgpack(buf, values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldByIndex returns the field, it is not ok if it is in a nil embedded struct pointer
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, fieldIndex := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(fieldIndex)
+	}
+	return v, true
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+type msgpackField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+var msgpackFields sync.Map // map[reflect.Type][]msgpackField
+
+// msgpackFieldsOf returns the fields encoding/json would encode. The fields of embedded structs without a
+// name in their json tag are promoted, without the conflict resolution of encoding/json.
+func msgpackFieldsOf(typ reflect.Type) []msgpackField {
+	if fields, ok := msgpackFields.Load(typ); ok {
+		return fields.([]msgpackField)
+	}
+	fields := collectMsgpackFields(typ, nil)
+	msgpackFields.Store(typ, fields)
+	return fields
+}
+
+func collectMsgpackFields(typ reflect.Type, parentIndex []int) []msgpackField {
+	var fields []msgpackField
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		index := append(append([]int{}, parentIndex...), i)
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				fields = append(fields, collectMsgpackFields(embedded, index)...)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, msgpackField{
+			name:      name,
+			index:     index,
+			omitEmpty: strings.Contains(","+options+",", ",omitempty,"),
+		})
+	}
+	return fields
+}
+
+func writeMsgpackInt(buf *bytes.Buffer, i int64) {
+	switch {
+	case i >= 0:
+		writeMsgpackUint(buf, uint64(i))
+	case i >= -32:
+		buf.WriteByte(byte(i))
+	case i >= math.MinInt8:
+		buf.WriteByte(0xd0)
+		buf.WriteByte(byte(i))
+	case i >= math.MinInt16:
+		buf.WriteByte(0xd1)
+		_ = binary.Write(buf, binary.BigEndian, int16(i))
+	case i >= math.MinInt32:
+		buf.WriteByte(0xd2)
+		_ = binary.Write(buf, binary.BigEndian, int32(i))
+	default:
+		buf.WriteByte(0xd3)
+		_ = binary.Write(buf, binary.BigEndian, i)
+	}
+}
+
+func writeMsgpackUint(buf *bytes.Buffer, u uint64) {
+	switch {
+	case u < 128:
+		buf.WriteByte(byte(u))
+	case u <= math.MaxUint8:
+		buf.WriteByte(0xcc)
+		buf.WriteByte(byte(u))
+	case u <= math.MaxUint16:
+		buf.WriteByte(0xcd)
+		_ = binary.Write(buf, binary.BigEndian, uint16(u))
+	case u <= math.MaxUint32:
+		buf.WriteByte(0xce)
+		_ = binary.Write(buf, binary.BigEndian, uint32(u))
+	default:
+		buf.WriteByte(0xcf)
+		_ = binary.Write(buf, binary.BigEndian, u)
+	}
+}
+
+func writeMsgpackString(buf *bytes.Buffer, s string) {
+	if len(s) < 32 {
+		buf.WriteByte(0xa0 | byte(len(s)))
+	} else if len(s) <= math.MaxUint8 {
+		buf.WriteByte(0xd9)
+		buf.WriteByte(byte(len(s)))
+	} else {
+		writeMsgpackLength(buf, len(s), 0xda, 0xdb)
+	}
+	buf.WriteString(s)
+}
+
+func writeMsgpackBinary(buf *bytes.Buffer, data []byte) {
+	if len(data) <= math.MaxUint8 {
+		buf.WriteByte(0xc4)
+		buf.WriteByte(byte(len(data)))
+	} else {
+		writeMsgpackLength(buf, len(data), 0xc5, 0xc6)
+	}
+	buf.Write(data)
+}
+
+// writeMsgpackHeader writes the header of an array or a map, with the fix format for fewer than fixLimit elements
+func writeMsgpackHeader(buf *bytes.Buffer, length int, fixPrefix byte, fixLimit int, prefix16 byte, prefix32 byte) {
+	if length < fixLimit {
+		buf.WriteByte(fixPrefix | byte(length))
+		return
+	}
+	writeMsgpackLength(buf, length, prefix16, prefix32)
+}
+
+func writeMsgpackLength(buf *bytes.Buffer, length int, prefix16 byte, prefix32 byte) {
+	if length <= math.MaxUint16 {
+		buf.WriteByte(prefix16)
+		_ = binary.Write(buf, binary.BigEndian, uint16(length))
+		return
+	}
+	buf.WriteByte(prefix32)
+	_ = binary.Write(buf, binary.BigEndian, uint32(length))
+}
@@ -0,0 +1,68 @@
+package devserver
+
+import (
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+type msgpackEmbedded struct {
+	Inner int `json:"inner"`
+}
+
+type msgpackStruct struct {
+	msgpackEmbedded
+	Name     string            `json:"name"`
+	Skipped  string            `json:"-"`
+	Empty    string            `json:"empty,omitempty"`
+	Data     []byte            `json:"data"`
+	Values   []int             `json:"values"`
+	Labels   map[string]string `json:"labels"`
+	Untagged bool
+	hidden   string
+}
+
+func TestMarshalMsgpack(t *testing.T) {
+	i := is.New(t)
+	tests := []struct {
+		value    interface{}
+		expected string
+	}{
+		{nil, "c0"},
+		{true, "c3"},
+		{5, "05"},
+		{-5, "fb"},
+		{200, "ccc8"},
+		{-200, "d1ff38"},
+		{70000, "ce00011170"},
+		{1.5, "cb3ff8000000000000"},
+		{"hi", "a26869"},
+		{[]byte{1, 2}, "c4020102"},
+		{[]int(nil), "c0"},
+		{map[int]string{2: "b", 1: "a"}, "82a131a161a132a162"},
+		// Types implementing json.Marshaler are encoded from their JSON
+		{time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), "b4323032342d30312d30325430333a30343a30355a"},
+		{
+			msgpackStruct{
+				msgpackEmbedded: msgpackEmbedded{Inner: 1},
+				Name:            "x",
+				Skipped:         "skipped",
+				Data:            []byte{0xff},
+				Values:          []int{1, 2},
+				Labels:          map[string]string{"a": "b"},
+				hidden:          "hidden",
+			},
+			"86a5696e6e657201a46e616d65a178a464617461c401ffa676616c756573920102a66c6162656c7381a161a162a8556e746167676564c2",
+		},
+	}
+	for _, test := range tests {
+		encoded, err := marshalMsgpack(test.value)
+		i.NoErr(err)
+		i.Equal(hex.EncodeToString(encoded), test.expected)
+	}
+
+	_, err := marshalMsgpack(make(chan int))
+	i.True(err != nil)
+}
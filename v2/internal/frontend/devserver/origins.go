@@ -0,0 +1,59 @@
+package devserver
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// checkOrigin decides whether a browser may open the IPC websocket. Without AllowedOrigins any origin is
+// accepted. Otherwise the origin must be listed, or be the dev server itself. Clients which are not browsers
+// don't send an origin and are always accepted.
+func (d *DevWebServer) checkOrigin(req *http.Request) bool {
+	origin := req.Header.Get("Origin")
+	if origin == "" || len(d.appoptions.WebSocket.AllowedOrigins) == 0 {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, req.Host) {
+		return true
+	}
+	return d.isAllowedOrigin(origin)
+}
+
+func (d *DevWebServer) isAllowedOrigin(origin string) bool {
+	for _, allowed := range d.appoptions.WebSocket.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// corsMiddleware allows pages of the AllowedOrigins to fetch the assets and the endpoints of the dev server.
+// Without AllowedOrigins, every origin is allowed.
+func (d *DevWebServer) corsMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		origin := c.Request().Header.Get("Origin")
+		if origin == "" {
+			return next(c)
+		}
+		header := c.Response().Header()
+		header.Add("Vary", "Origin")
+		if len(d.appoptions.WebSocket.AllowedOrigins) > 0 && !d.isAllowedOrigin(origin) {
+			return next(c)
+		}
+		header.Set("Access-Control-Allow-Origin", origin)
+
+		// Answer the preflight requests
+		if c.Request().Method != http.MethodOptions || c.Request().Header.Get("Access-Control-Request-Method") == "" {
+			return next(c)
+		}
+		header.Set("Access-Control-Allow-Methods", "GET, HEAD, OPTIONS")
+		if requested := c.Request().Header.Get("Access-Control-Request-Headers"); requested != "" {
+			header.Set("Access-Control-Allow-Headers", requested)
+		}
+		return c.NoContent(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,38 @@
+package devserver
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/wailsapp/wails/v2/internal/binding"
+)
+
+// progressMessage is sent to the client for a progress update of a call, as "p" and the JSON
+type progressMessage struct {
+	CallbackID string `json:"callbackid"`
+	binding.ProgressUpdate
+}
+
+// withProgress returns the context of the call of the message, whose progress updates are sent to the client.
+// The callback ID is only read from the message once the method reports progress.
+func (d *DevWebServer) withProgress(conn *websocket.Conn, info *WebsocketInfo, message string) context.Context {
+	var once sync.Once
+	var callbackID string
+	return binding.WithProgress(info.ctx, func(update binding.ProgressUpdate) {
+		once.Do(func() {
+			callbackID = callbackIDOf([]byte(message))
+		})
+		if callbackID == "" {
+			return
+		}
+		payload, err := json.Marshal(progressMessage{CallbackID: callbackID, ProgressUpdate: update})
+		if err != nil {
+			return
+		}
+		_ = d.writeMessage(conn, info, websocket.TextMessage, append([]byte("p"), payload...), time.Time{})
+	})
+}
@@ -0,0 +1,146 @@
+package devserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// The IPC websocket protocol has two formats of text messages, the binary messages are the same in both.
+//
+// Version 1, the legacy format of the injected IPC script, prefixes the payload with the type of the message.
+// The clients send:
+//
+//	C{call}               calls a bound method
+//	C[{call},...]         calls the methods of a batch, see batch.go
+//	EE{"name","data"}     emits an event
+//	EB<name>              subscribes to an event or pattern
+//	ES{"name","count"}    subscribes to an event for a number of deliveries
+//	EX<name>              unsubscribes from an event
+//	EA<ack ID>            acknowledges the receipt of a reliable event, see NotifyReliable
+//	X<callback ID>        cancels a call in progress or its stream result
+//	SX<callback ID>       cancels the stream result of a call
+//	D{"query","id"}       queries the diagnostics of the server
+//
+// and the binary message a<reference>\0<bytes>, a binary argument of their next call, see binaryargs.go. They
+// receive:
+//
+//	id<client ID>         the ID of the client, the first message
+//	c{callback}           the result of a call
+//	c[{callback},...]     the results of a batch of calls
+//	n{"name","data",...}  an event
+//	r{"name","url"}       an event too large to be sent, to be fetched from the url
+//	s{"callbackid",...}   the start, a value or the end of the stream result of a call
+//	p{"callbackid",...}   a progress update of a call, see runtime.Progress
+//	d{"id","result"}      the reply to a diagnostics query
+//	b{bindings}           the bindings, after methods have been bound or unbound at runtime
+//	k                     keep-alive
+//
+// besides the reload, reload app and "server-closing" messages.
+//
+// Version 2 wraps the same payloads into an envelope, e.g. {"v":2,"type":"call","payload":{...}}. Clients
+// ask for it with the "protocol=2" query parameter of the handshake, which is confirmed with the
+// X-Wails-Protocol header of the response. The other clients keep getting version 1.
+const (
+	legacyProtocolVersion   = 1
+	envelopeProtocolVersion = 2
+
+	headerProtocol = "X-Wails-Protocol"
+)
+
+// envelope is a message of protocol version 2
+type envelope struct {
+	Version int             `json:"v"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// The prefixes of the client messages by envelope type. The payload of these types is a string.
+var (
+	clientMessagePrefixes = map[string]string{
+		"call":        "C",
+		"emit":        "EE",
+		"subscribe":   "ES",
+		"diagnostics": "D",
+	}
+	clientStringMessagePrefixes = map[string]string{
+		"unsubscribe":  "EX",
+		"ack":          "EA",
+		"cancel":       "X",
+		"cancelstream": "SX",
+	}
+	serverMessageTypes = map[byte]string{
+		'c': "callback",
+		'n': "notify",
+		'r': "reference",
+		's': "stream",
+		'p': "progress",
+		'd': "diagnostics",
+		'b': "bindings",
+	}
+)
+
+// requestedProtocolVersion returns the protocol version the client asked for in the handshake
+func requestedProtocolVersion(req *http.Request) int {
+	version, err := strconv.Atoi(req.URL.Query().Get("protocol"))
+	if err != nil || version < legacyProtocolVersion || version > envelopeProtocolVersion {
+		return legacyProtocolVersion
+	}
+	return version
+}
+
+// decodeEnvelope returns the version 1 message of the envelope
+func decodeEnvelope(message []byte) ([]byte, error) {
+	var e envelope
+	if err := json.Unmarshal(message, &e); err != nil {
+		return nil, fmt.Errorf("invalid envelope: %w", err)
+	}
+	if e.Version != envelopeProtocolVersion {
+		return nil, fmt.Errorf("unsupported protocol version %d", e.Version)
+	}
+	if prefix, ok := clientMessagePrefixes[e.Type]; ok {
+		return append([]byte(prefix), e.Payload...), nil
+	}
+	if prefix, ok := clientStringMessagePrefixes[e.Type]; ok {
+		var payload string
+		if err := json.Unmarshal(e.Payload, &payload); err != nil {
+			return nil, fmt.Errorf("invalid payload of '%s': %w", e.Type, err)
+		}
+		return append([]byte(prefix), payload...), nil
+	}
+	return nil, fmt.Errorf("unknown message type '%s'", e.Type)
+}
+
+// encodeEnvelope wraps the version 1 message sent to the client into an envelope
+func (d *DevWebServer) encodeEnvelope(message []byte) []byte {
+	e := envelope{Version: envelopeProtocolVersion}
+	text := string(message)
+	switch {
+	case text == d.reloadMessage:
+		e.Type = "reload"
+	case text == d.reloadAppMessage:
+		e.Type = "reloadapp"
+	case text == serverClosingMessage:
+		e.Type = serverClosingMessage
+	case text == keepAliveMessage:
+		e.Type = "keepalive"
+	case strings.HasPrefix(text, "id"):
+		e.Type = "id"
+		e.Payload, _ = json.Marshal(text[2:])
+	case len(message) > 0 && serverMessageTypes[message[0]] != "":
+		e.Type = serverMessageTypes[message[0]]
+		e.Payload = json.RawMessage(message[1:])
+	default:
+		e.Type = "message"
+		e.Payload, _ = json.Marshal(text)
+	}
+	result, err := json.Marshal(e)
+	if err != nil {
+		// The payload is not valid JSON, send it as a string
+		e.Payload, _ = json.Marshal(string(e.Payload))
+		result, _ = json.Marshal(e)
+	}
+	return result
+}
@@ -0,0 +1,123 @@
+package devserver
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/wailsapp/wails/v2/internal/logger"
+	"github.com/wailsapp/wails/v2/pkg/options"
+)
+
+const (
+	headerForwardedHost  = "X-Forwarded-Host"
+	headerForwardedProto = "X-Forwarded-Proto"
+)
+
+// setForwardedHeaders sets the X-Forwarded-Host and X-Forwarded-Proto headers, unless a proxy in front
+// of the dev server has already set them
+func setForwardedHeaders(req *http.Request, host string, proto string) {
+	if req.Header.Get(headerForwardedHost) == "" {
+		req.Header.Set(headerForwardedHost, host)
+	}
+	if req.Header.Get(headerForwardedProto) == "" {
+		req.Header.Set(headerForwardedProto, proto)
+	}
+}
+
+// proxyRule is a ProxyRule with its reverse proxy
+type proxyRule struct {
+	prefix string
+	proxy  http.Handler
+}
+
+// proxyRules are sorted by the length of their prefix, longest first
+type proxyRules []proxyRule
+
+// match returns the proxy of the rule with the longest prefix of the path, nil if no rule matches
+func (r proxyRules) match(path string) http.Handler {
+	for _, rule := range r {
+		if strings.HasPrefix(path, rule.prefix) {
+			return rule.proxy
+		}
+	}
+	return nil
+}
+
+// newProxyRules creates the reverse proxies of the ProxyRules
+func (d *DevWebServer) newProxyRules(rules []options.ProxyRule) (proxyRules, error) {
+	result := make(proxyRules, 0, len(rules))
+	for _, rule := range rules {
+		if rule.PathPrefix == "" {
+			return nil, fmt.Errorf("the proxy rule for '%s' has no path prefix", rule.Upstream)
+		}
+		upstream, err := url.Parse(rule.Upstream)
+		if err != nil || upstream.Scheme == "" || upstream.Host == "" {
+			return nil, fmt.Errorf("invalid upstream '%s' of the proxy rule for '%s'", rule.Upstream, rule.PathPrefix)
+		}
+		result = append(result, proxyRule{prefix: rule.PathPrefix, proxy: newRuleProxy(rule, upstream)})
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return len(result[i].prefix) > len(result[j].prefix)
+	})
+	return result, nil
+}
+
+func newRuleProxy(rule options.ProxyRule, upstream *url.URL) *httputil.ReverseProxy {
+	proxy := httputil.NewSingleHostReverseProxy(upstream)
+	baseDirector := proxy.Director
+	proxy.Director = func(req *http.Request) {
+		host := req.Host
+		proto := "http"
+		if req.TLS != nil {
+			proto = "https"
+		}
+		if rule.StripPrefix {
+			req.URL.Path = "/" + strings.TrimPrefix(strings.TrimPrefix(req.URL.Path, rule.PathPrefix), "/")
+			req.URL.RawPath = ""
+		}
+
+		baseDirector(req)
+
+		setForwardedHeaders(req, host, proto)
+		if rule.ChangeOrigin {
+			req.Host = upstream.Host
+		}
+		for name, value := range rule.Headers {
+			if value == "" {
+				req.Header.Del(name)
+			} else {
+				req.Header.Set(name, value)
+			}
+		}
+	}
+	return proxy
+}
+
+// observedTransport reports every round trip to the observer
+type observedTransport struct {
+	transport http.RoundTripper
+	observer  func(req *http.Request, resp *http.Response, duration time.Duration, err error)
+	logger    *logger.Logger
+}
+
+func (t *observedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.transport.RoundTrip(req)
+	t.observe(req, resp, time.Since(start), err)
+	return resp, err
+}
+
+// observe calls the observer, making sure a panic does not break the proxied request
+func (t *observedTransport) observe(req *http.Request, resp *http.Response, duration time.Duration, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			t.logger.Error("ProxyObserver panicked: %v", r)
+		}
+	}()
+	t.observer(req, resp, duration, err)
+}
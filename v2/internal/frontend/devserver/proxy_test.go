@@ -0,0 +1,89 @@
+package devserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/matryer/is"
+	"github.com/wailsapp/wails/v2/pkg/options"
+)
+
+func TestProxyRules(t *testing.T) {
+	i := is.New(t)
+
+	var received *http.Request
+	api := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if websocket.IsWebSocketUpgrade(req) {
+			conn, err := (&websocket.Upgrader{}).Upgrade(rw, req, nil)
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			_, message, err := conn.ReadMessage()
+			if err == nil {
+				_ = conn.WriteMessage(websocket.TextMessage, append([]byte("echo "), message...))
+			}
+			return
+		}
+		received = req
+		_, _ = rw.Write([]byte("api"))
+	}))
+	defer api.Close()
+	v2 := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, _ = rw.Write([]byte("v2 " + req.URL.Path))
+	}))
+	defer v2.Close()
+
+	_, server := runTestServer(t, &options.App{
+		DevServer: options.DevServer{
+			Proxies: []options.ProxyRule{
+				{
+					PathPrefix:   "/api/",
+					Upstream:     api.URL + "/backend",
+					StripPrefix:  true,
+					ChangeOrigin: true,
+					Headers:      map[string]string{"X-Api-Key": "secret", "Cookie": ""},
+				},
+				{PathPrefix: "/api/v2/", Upstream: v2.URL},
+			},
+		},
+	})
+
+	resp, body := get(t, server, "/api/users?id=1", http.Header{"Cookie": {"session=1"}})
+	i.Equal(resp.StatusCode, http.StatusOK)
+	i.Equal(body, "api")
+	i.Equal(received.URL.Path, "/backend/users")
+	i.Equal(received.URL.RawQuery, "id=1")
+	i.Equal(received.Host, strings.TrimPrefix(api.URL, "http://"))
+	i.Equal(received.Header.Get("X-Api-Key"), "secret")
+	i.Equal(received.Header.Get("Cookie"), "")
+	i.Equal(received.Header.Get("X-Forwarded-Host"), strings.TrimPrefix(server.URL, "http://"))
+
+	// The longest prefix wins
+	_, body = get(t, server, "/api/v2/users", nil)
+	i.Equal(body, "v2 /api/v2/users")
+
+	// Other requests are served by the dev server
+	_, body = get(t, server, "/", nil)
+	i.True(strings.Contains(body, "<html>"))
+
+	// Websockets are forwarded
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/api/socket", nil)
+	i.NoErr(err)
+	defer conn.Close()
+	i.NoErr(conn.WriteMessage(websocket.TextMessage, []byte("hello")))
+	_, message, err := conn.ReadMessage()
+	i.NoErr(err)
+	i.Equal(string(message), "echo hello")
+
+	invalid := newTestFrontend(t, withTestAssets(&options.App{
+		DevServer: options.DevServer{
+			Proxies: []options.ProxyRule{{PathPrefix: "/api/", Upstream: "localhost:8080"}},
+		},
+	}))
+	i.True(invalid.Run(context.Background()) != nil)
+}
@@ -0,0 +1,64 @@
+package devserver
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// relayedEvents holds the events emitted by browsers while they are relayed to the desktop frontend. If the
+// desktop frontend notifies the dev server of the same event meanwhile, e.g. because it is the dev webview
+// itself, the notification is an echo of an event that has already been sent to the websocket clients.
+type relayedEvents struct {
+	lock   sync.Mutex
+	events map[string]int
+}
+
+func newRelayedEvents() *relayedEvents {
+	return &relayedEvents{
+		events: make(map[string]int),
+	}
+}
+
+// relayedEventKey identifies an event by its name and data
+func relayedEventKey(name string, data []interface{}) (string, bool) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return "", false
+	}
+	return name + "\x00" + string(payload), true
+}
+
+// relay marks the event as being relayed until the returned function is called
+func (r *relayedEvents) relay(name string, data []interface{}) func() {
+	key, ok := relayedEventKey(name, data)
+	if !ok {
+		return func() {}
+	}
+	r.lock.Lock()
+	r.events[key]++
+	r.lock.Unlock()
+	return func() {
+		r.lock.Lock()
+		defer r.lock.Unlock()
+		if r.events[key]--; r.events[key] <= 0 {
+			delete(r.events, key)
+		}
+	}
+}
+
+// isEcho reports whether the event is currently being relayed
+func (r *relayedEvents) isEcho(name string, data []interface{}) bool {
+	r.lock.Lock()
+	empty := len(r.events) == 0
+	r.lock.Unlock()
+	if empty {
+		return false
+	}
+	key, ok := relayedEventKey(name, data)
+	if !ok {
+		return false
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.events[key] > 0
+}
@@ -0,0 +1,236 @@
+package devserver
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// replayRetention is how long the missed events of a disconnected session are collected
+	replayRetention = time.Minute
+	// defaultSessionTimeout is how long the state of a disconnected session is kept by default
+	defaultSessionTimeout = 10 * time.Minute
+
+	// reservedSessionKeyPrefix is the prefix of the keys the dev server uses in the SessionStore, which the
+	// app can't use with runtime.SessionSave
+	reservedSessionKeyPrefix = "wails:"
+	// sessionStateKey is the key of the sessionState in the SessionStore
+	sessionStateKey = reservedSessionKeyPrefix + "session"
+)
+
+// sessionState is the state of a session kept in the SessionStore, so a client resuming the session gets its
+// subscriptions and missed events back, also from another dev server instance or after a restart
+type sessionState struct {
+	// Disconnected is the time the last client of the session disconnected in Unix nanoseconds, zero while
+	// a client of the session is connected
+	Disconnected int64 `json:"disconnected,omitempty"`
+	// Subscriptions are the listeners of the client by event name or pattern, see SubscriptionManager
+	Subscriptions map[string][]int `json:"subscriptions,omitempty"`
+	// Missed are the events emitted after the client disconnected, replayed until ReplayUntil
+	Missed      []string `json:"missed,omitempty"`
+	Dropped     int      `json:"dropped,omitempty"`
+	ReplayUntil int64    `json:"replayUntil,omitempty"`
+	// Owner is the hash of the secret of the browser owning the session, see sessionOwnerOf
+	Owner string `json:"owner"`
+
+	sessionID string
+	// version orders the states of the session, see sessionWriter
+	version uint64
+}
+
+// sessionWriter stores the states of the sessions in the SessionStore in the order they have been taken,
+// a state taken before the last stored one of the session is dropped
+type sessionWriter struct {
+	lock sync.Mutex
+	// version is the last version taken, the versions increase across the sessions
+	version atomic.Uint64
+	// stored are the last versions stored by session, guarded by lock
+	stored map[string]uint64
+}
+
+func newSessionWriter() *sessionWriter {
+	return &sessionWriter{stored: make(map[string]uint64)}
+}
+
+// eventRing is a bounded buffer of the events missed by a disconnected client, the oldest events are
+// overwritten once it is full. It is guarded by the socketMutex of the server.
+type eventRing struct {
+	messages []string
+	start    int
+	length   int
+	dropped  int
+}
+
+func newEventRing(size int) *eventRing {
+	return &eventRing{messages: make([]string, size)}
+}
+
+func (r *eventRing) push(message string) {
+	if r.length < len(r.messages) {
+		r.messages[(r.start+r.length)%len(r.messages)] = message
+		r.length++
+		return
+	}
+	r.messages[r.start] = message
+	r.start = (r.start + 1) % len(r.messages)
+	r.dropped++
+}
+
+// drain returns the events in the order they have been emitted
+func (r *eventRing) drain() []string {
+	result := make([]string, 0, r.length)
+	for i := 0; i < r.length; i++ {
+		result = append(result, r.messages[(r.start+i)%len(r.messages)])
+	}
+	return result
+}
+
+// retainSession returns the state of the session of the disconnected client, to be stored with storeSession.
+// With a ReplayBufferSize, the events the session subscribed to keep being collected for a replay.
+// The socketMutex must be held.
+func (d *DevWebServer) retainSession(info *WebsocketInfo) sessionState {
+	now := time.Now()
+	info.disconnected = now.UnixNano()
+	if size := d.appoptions.WebSocket.ReplayBufferSize; size > 0 && !d.isClosing() {
+		info.missed = newEventRing(size)
+		info.replayUntil = now.Add(replayRetention).UnixNano()
+		d.disconnectedSessions[info.sessionID] = info
+		time.AfterFunc(replayRetention, func() {
+			d.socketMutex.Lock()
+			defer d.socketMutex.Unlock()
+			if d.disconnectedSessions[info.sessionID] == info {
+				delete(d.disconnectedSessions, info.sessionID)
+			}
+		})
+	}
+	if !d.isClosing() {
+		disconnected := info.disconnected
+		time.AfterFunc(d.sessionTimeout(), func() {
+			d.expireSession(info.sessionID, disconnected)
+		})
+	}
+	return d.disconnectedState(info)
+}
+
+// disconnectedState returns the current state of the session of the disconnected client.
+// The socketMutex must be held.
+func (d *DevWebServer) disconnectedState(info *WebsocketInfo) sessionState {
+	state := sessionState{
+		sessionID:     info.sessionID,
+		Owner:         info.owner,
+		Disconnected:  info.disconnected,
+		Subscriptions: info.subscriptions.snapshot(),
+		ReplayUntil:   info.replayUntil,
+		version:       d.sessionWriter.version.Add(1),
+	}
+	if info.missed != nil {
+		state.Missed = info.missed.drain()
+		state.Dropped = info.missed.dropped
+	}
+	return state
+}
+
+// resumeSession restores the subscriptions of the session into the info of the new client and returns the
+// events the session missed while it was disconnected. The state of a session disconnected from this server
+// is preferred over the stored one, which may be from another server. The socketMutex must be held.
+func (d *DevWebServer) resumeSession(info *WebsocketInfo, stored *sessionState) []string {
+	if previous := d.disconnectedSessions[info.sessionID]; previous != nil {
+		delete(d.disconnectedSessions, info.sessionID)
+		info.subscriptions.restore(previous.subscriptions.snapshot())
+		if previous.missed.dropped > 0 {
+			d.logger.Warning("Session '%s' missed %d more events than can be replayed", info.sessionID, previous.missed.dropped)
+		}
+		return previous.missed.drain()
+	}
+	if stored == nil || stored.Disconnected == 0 {
+		return nil
+	}
+	info.subscriptions.restore(stored.Subscriptions)
+	if time.Now().UnixNano() > stored.ReplayUntil {
+		return nil
+	}
+	if stored.Dropped > 0 {
+		d.logger.Warning("Session '%s' missed %d more events than can be replayed", info.sessionID, stored.Dropped)
+	}
+	return stored.Missed
+}
+
+// connectedState returns the state marking the session as connected
+func (d *DevWebServer) connectedState(info *WebsocketInfo) sessionState {
+	return sessionState{sessionID: info.sessionID, Owner: info.owner, version: d.sessionWriter.version.Add(1)}
+}
+
+// loadSession returns the state of the session in the SessionStore, nil if there is none
+func (d *DevWebServer) loadSession(sessionID string) *sessionState {
+	value, ok, err := d.sessionStore.Load(sessionID, sessionStateKey)
+	if err != nil {
+		d.logger.Error("Unable to load the state of session '%s': %s", sessionID, err.Error())
+		return nil
+	}
+	if !ok {
+		return nil
+	}
+	var state sessionState
+	if err := json.Unmarshal(value, &state); err != nil {
+		d.logger.Error("Unable to load the state of session '%s': %s", sessionID, err.Error())
+		return nil
+	}
+	return &state
+}
+
+// storeSession stores the state of the session in the SessionStore, unless a later state has been stored.
+// The socketMutex must not be held.
+func (d *DevWebServer) storeSession(state sessionState) {
+	sessionID := state.sessionID
+	writer := d.sessionWriter
+	writer.lock.Lock()
+	defer writer.lock.Unlock()
+	if state.version <= writer.stored[sessionID] {
+		return
+	}
+	writer.stored[sessionID] = state.version
+	value, err := json.Marshal(state)
+	if err == nil {
+		err = d.sessionStore.Save(sessionID, sessionStateKey, value)
+	}
+	if err != nil {
+		d.logger.Error("Unable to store the state of session '%s': %s", sessionID, err.Error())
+	}
+}
+
+// expireSession deletes the session from the SessionStore, unless a client resumed it since it has been
+// disconnected at the given time
+func (d *DevWebServer) expireSession(sessionID string, disconnected int64) {
+	d.socketMutex.Lock()
+	connected := d.connectedSessions[sessionID]
+	d.socketMutex.Unlock()
+	if connected {
+		return
+	}
+
+	writer := d.sessionWriter
+	writer.lock.Lock()
+	defer writer.lock.Unlock()
+	if state := d.loadSession(sessionID); state == nil || state.Disconnected != disconnected {
+		return
+	}
+	delete(writer.stored, sessionID)
+	if err := d.sessionStore.Delete(sessionID); err != nil {
+		d.logger.Error("Unable to delete session '%s': %s", sessionID, err.Error())
+	}
+}
+
+func (d *DevWebServer) sessionTimeout() time.Duration {
+	if timeout := d.appoptions.WebSocket.SessionTimeout; timeout > 0 {
+		return timeout
+	}
+	return defaultSessionTimeout
+}
+
+// isReservedSessionKey reports whether the key is used by the dev server in the SessionStore
+func isReservedSessionKey(key string) bool {
+	return strings.HasPrefix(key, reservedSessionKeyPrefix)
+}
@@ -0,0 +1,48 @@
+package devserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// withClientContext returns the request with the ID and the session of the websocket client which sent it in its
+// context, so the Handler of the asset server can tell the clients apart with runtime.ClientID and
+// runtime.SessionID like the bound methods do. The client names itself with the "clientid" query parameter or the
+// X-Wails-Client-ID header and proves it with the session of its handshake, given with the "session" query
+// parameter or the X-Wails-Session header. Requests which are not authorized or don't match a connected client
+// are returned unchanged.
+func (d *DevWebServer) withClientContext(req *http.Request) *http.Request {
+	clientID, sessionID := d.verifiedClient(req)
+	if clientID == "" {
+		return req
+	}
+	ctx := context.WithValue(context.WithValue(req.Context(), "clientid", clientID), "sessionid", sessionID)
+	return req.WithContext(ctx)
+}
+
+// verifiedClient returns the ID and the session of the websocket client which sent the request, see
+// withClientContext. Both are empty if the request is not authorized or doesn't match a connected client.
+func (d *DevWebServer) verifiedClient(req *http.Request) (string, string) {
+	clientID := requestedClientID(req)
+	sessionID := strings.TrimSpace(req.URL.Query().Get("session"))
+	if sessionID == "" {
+		sessionID = strings.TrimSpace(req.Header.Get(headerSessionID))
+	}
+	if clientID == "" || sessionID == "" || !d.isAuthorized(req) {
+		return "", ""
+	}
+
+	d.socketMutex.Lock()
+	defer d.socketMutex.Unlock()
+	for _, info := range d.websocketClients {
+		if info.id == clientID {
+			if subtle.ConstantTimeCompare([]byte(info.sessionID), []byte(sessionID)) == 1 {
+				return clientID, sessionID
+			}
+			break
+		}
+	}
+	return "", ""
+}
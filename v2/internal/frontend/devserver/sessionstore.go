@@ -0,0 +1,47 @@
+package devserver
+
+import (
+	"sync"
+
+	"github.com/wailsapp/wails/v2/pkg/options"
+)
+
+// memorySessionStore is the default options.SessionStore. State is lost when the dev server stops.
+type memorySessionStore struct {
+	lock     sync.RWMutex
+	sessions map[string]map[string][]byte
+}
+
+func newMemorySessionStore() *memorySessionStore {
+	return &memorySessionStore{
+		sessions: make(map[string]map[string][]byte),
+	}
+}
+
+func (m *memorySessionStore) Load(sessionID string, key string) ([]byte, bool, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	value, ok := m.sessions[sessionID][key]
+	return value, ok, nil
+}
+
+func (m *memorySessionStore) Save(sessionID string, key string, value []byte) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	session := m.sessions[sessionID]
+	if session == nil {
+		session = make(map[string][]byte)
+		m.sessions[sessionID] = session
+	}
+	session[key] = value
+	return nil
+}
+
+func (m *memorySessionStore) Delete(sessionID string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.sessions, sessionID)
+	return nil
+}
+
+var _ options.SessionStore = (*memorySessionStore)(nil)
@@ -0,0 +1,309 @@
+package devserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/cookiejar"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/matryer/is"
+
+	"github.com/wailsapp/wails/v2/pkg/options"
+	assetserveroptions "github.com/wailsapp/wails/v2/pkg/options/assetserver"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+func TestMemorySessionStore(t *testing.T) {
+	i := is.New(t)
+	store := newMemorySessionStore()
+
+	_, ok, err := store.Load("session", "key")
+	i.NoErr(err)
+	i.True(!ok)
+
+	i.NoErr(store.Save("session", "key", []byte("value")))
+	value, ok, err := store.Load("session", "key")
+	i.NoErr(err)
+	i.True(ok)
+	i.Equal(string(value), "value")
+
+	i.NoErr(store.Delete("session"))
+	_, ok, err = store.Load("session", "key")
+	i.NoErr(err)
+	i.True(!ok)
+}
+
+// newBrowserDialer returns a dialer keeping the cookies like a browser, so its clients can resume their sessions
+func newBrowserDialer(t *testing.T) *websocket.Dialer {
+	t.Helper()
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &websocket.Dialer{Jar: jar}
+}
+
+func TestSessionAffinity(t *testing.T) {
+	i := is.New(t)
+	d, server := newTestServer(t, nil)
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/wails/ipc"
+	browser := newBrowserDialer(t)
+
+	// A new session is started for clients without one
+	conn, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	i.NoErr(err)
+	receiveClientID(t, conn)
+	i.Equal(len(resp.Header.Get(headerSessionID)), 32)
+	// The browser gets the secret owning its sessions in an HttpOnly cookie
+	cookies := resp.Cookies()
+	i.Equal(len(cookies), 1)
+	i.Equal(cookies[0].Name, sessionCookie)
+	i.True(cookies[0].HttpOnly)
+	i.NoErr(conn.Close())
+	i.True(waitForClients(d, 0))
+
+	// The state of the session is kept across reconnects
+	conn, resp, err = browser.Dial(url+"?session=tab-1", nil)
+	i.NoErr(err)
+	clientID := receiveClientID(t, conn)
+	i.Equal(resp.Header.Get(headerSessionID), "tab-1")
+	i.True(waitForClients(d, 1))
+	i.Equal(d.BrowserClients()[0].SessionID, "tab-1")
+	ctx := context.WithValue(d.websocketClient(clientID).ctx, "frontend", d)
+	i.Equal(runtime.SessionID(ctx), "tab-1")
+	i.NoErr(runtime.SessionSave(ctx, "counter", []byte("1")))
+	i.NoErr(conn.Close())
+	i.True(waitForClients(d, 0))
+
+	conn, resp, err = browser.Dial(url, map[string][]string{headerSessionID: {"tab-1"}})
+	i.NoErr(err)
+	defer conn.Close()
+	clientID = receiveClientID(t, conn)
+	i.Equal(resp.Header.Get(headerSessionID), "tab-1")
+	i.True(waitForClients(d, 1))
+	ctx = context.WithValue(d.websocketClient(clientID).ctx, "frontend", d)
+	value, ok, err := runtime.SessionLoad(ctx, "counter")
+	i.NoErr(err)
+	i.True(ok)
+	i.Equal(string(value), "1")
+
+	// Another client of the browser can't resume the session while it is connected, e.g. a duplicated tab
+	duplicate, resp, err := browser.Dial(url+"?session=tab-1", nil)
+	i.NoErr(err)
+	receiveClientID(t, duplicate)
+	i.Equal(len(resp.Header.Get(headerSessionID)), 32)
+	i.NoErr(duplicate.Close())
+	i.True(waitForClients(d, 1))
+
+	// Other browsers can't resume the session, they start a new one
+	i.NoErr(conn.Close())
+	i.True(waitForClients(d, 0))
+	other, resp, err := websocket.DefaultDialer.Dial(url+"?session=tab-1", nil)
+	i.NoErr(err)
+	defer other.Close()
+	clientID = receiveClientID(t, other)
+	i.True(resp.Header.Get(headerSessionID) != "tab-1")
+	i.True(waitForClients(d, 1))
+	ctx = context.WithValue(d.websocketClient(clientID).ctx, "frontend", d)
+	_, ok, err = runtime.SessionLoad(ctx, "counter")
+	i.NoErr(err)
+	i.True(!ok)
+
+	// Calls of the desktop window have no session
+	_, _, err = runtime.SessionLoad(context.WithValue(context.Background(), "frontend", d), "counter")
+	i.True(err != nil)
+
+	// Session IDs are limited in length
+	_, resp, err = websocket.DefaultDialer.Dial(url+"?session="+strings.Repeat("x", maxSessionIDLength+1), nil)
+	i.True(err != nil)
+	i.Equal(resp.StatusCode, http.StatusBadRequest)
+}
+
+func TestSessionReplay(t *testing.T) {
+	i := is.New(t)
+	d, server := newTestServer(t, &options.App{WebSocket: options.WebSocket{ReplayBufferSize: 2}})
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/wails/ipc"
+	browser := newBrowserDialer(t)
+
+	conn, _, err := browser.Dial(url+"?session=tab-1", nil)
+	i.NoErr(err)
+	receiveClientID(t, conn)
+	i.NoErr(send(conn, "EBwanted"))
+	i.True(waitForClients(d, 1))
+	deadline := time.Now().Add(time.Second)
+	for len(d.ClientSubscriptions(d.ClientIDs()[0])) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	i.NoErr(conn.Close())
+	i.True(waitForClients(d, 0))
+
+	// The last two events the session subscribed to are replayed, in order
+	d.Notify("wanted", 1)
+	d.Notify("unwanted", 2)
+	d.Notify("wanted", 3)
+	d.Notify("wanted", 4)
+
+	conn, _, err = browser.Dial(url+"?session=tab-1", nil)
+	i.NoErr(err)
+	defer conn.Close()
+	receiveClientID(t, conn)
+	msg, err := receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `n{"name":"wanted","data":[3]}`)
+	msg, err = receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `n{"name":"wanted","data":[4]}`)
+
+	// The events are replayed once, other sessions get none
+	other, _, err := websocket.DefaultDialer.Dial(url+"?session=tab-2", nil)
+	i.NoErr(err)
+	defer other.Close()
+	receiveClientID(t, other)
+	i.True(waitForClients(d, 2))
+	d.Notify("wanted", 5)
+	msg, err = receive(other, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `n{"name":"wanted","data":[5]}`)
+}
+
+func TestSharedSessionStore(t *testing.T) {
+	i := is.New(t)
+	store := newMemorySessionStore()
+	appoptions := func() *options.App {
+		return &options.App{WebSocket: options.WebSocket{ReplayBufferSize: 2, SessionStore: store}}
+	}
+	first, firstServer := newTestServer(t, appoptions())
+	second, secondServer := newTestServer(t, appoptions())
+	browser := newBrowserDialer(t)
+
+	conn, _, err := browser.Dial("ws"+strings.TrimPrefix(firstServer.URL, "http")+"/wails/ipc?session=tab-1", nil)
+	i.NoErr(err)
+	receiveClientID(t, conn)
+	i.NoErr(send(conn, `ES{"name":"wanted","count":0}`))
+	i.True(waitForClients(first, 1))
+	deadline := time.Now().Add(time.Second)
+	for len(first.ClientSubscriptions(first.ClientIDs()[0])) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	i.NoErr(conn.Close())
+	i.True(waitForClients(first, 0))
+	first.Notify("wanted", 1)
+	first.Notify("unwanted", 2)
+
+	// The session resumed on another server gets the missed events and its subscriptions back
+	conn, _, err = browser.Dial("ws"+strings.TrimPrefix(secondServer.URL, "http")+"/wails/ipc?session=tab-1", nil)
+	i.NoErr(err)
+	defer conn.Close()
+	clientID := receiveClientID(t, conn)
+	msg, err := receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `n{"name":"wanted","data":[1]}`)
+	i.True(waitForClients(second, 1))
+	i.Equal(second.ClientSubscriptions(clientID), []string{"wanted"})
+	second.Notify("unwanted", 3)
+	second.Notify("wanted", 4)
+	msg, err = receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `n{"name":"wanted","data":[4]}`)
+
+	// The restored subscriptions are replaced by the ones the client sends
+	i.NoErr(send(conn, `ES{"name":"other","count":0}`))
+	deadline = time.Now().Add(time.Second)
+	for second.ClientSubscriptions(clientID)[0] != "other" && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	i.Equal(second.ClientSubscriptions(clientID), []string{"other"})
+
+	// The state is marked as connected, the events are not replayed again
+	state := second.loadSession("tab-1")
+	i.True(state != nil)
+	i.Equal(state.Disconnected, int64(0))
+	i.Equal(len(state.Missed), 0)
+}
+
+func TestSessionTimeout(t *testing.T) {
+	i := is.New(t)
+	d, server := newTestServer(t, &options.App{WebSocket: options.WebSocket{SessionTimeout: 50 * time.Millisecond}})
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/wails/ipc?session=tab-1"
+	browser := newBrowserDialer(t)
+
+	// The keys of the dev server are reserved
+	i.True(d.SaveSessionState("tab-1", sessionStateKey, []byte("{}")) != nil)
+
+	conn, _, err := browser.Dial(url, nil)
+	i.NoErr(err)
+	receiveClientID(t, conn)
+	i.True(waitForClients(d, 1))
+	i.NoErr(d.SaveSessionState("tab-1", "counter", []byte("1")))
+	i.NoErr(conn.Close())
+	i.True(waitForClients(d, 0))
+
+	// The session is deleted once it timed out without a client resuming it
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if _, ok, _ := d.LoadSessionState("tab-1", "counter"); !ok {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	_, ok, err := d.LoadSessionState("tab-1", "counter")
+	i.NoErr(err)
+	i.True(!ok)
+
+	// A resumed session is kept
+	conn, _, err = browser.Dial(url, nil)
+	i.NoErr(err)
+	receiveClientID(t, conn)
+	i.True(waitForClients(d, 1))
+	i.NoErr(d.SaveSessionState("tab-1", "counter", []byte("2")))
+	i.NoErr(conn.Close())
+	i.True(waitForClients(d, 0))
+	conn, _, err = browser.Dial(url, nil)
+	i.NoErr(err)
+	defer conn.Close()
+	receiveClientID(t, conn)
+	time.Sleep(100 * time.Millisecond)
+	value, ok, err := d.LoadSessionState("tab-1", "counter")
+	i.NoErr(err)
+	i.True(ok)
+	i.Equal(string(value), "2")
+}
+
+func TestHandlerRequestContext(t *testing.T) {
+	i := is.New(t)
+	d, server := runTestServer(t, &options.App{
+		WebSocket: options.WebSocket{AuthToken: "secret"},
+		AssetServer: &assetserveroptions.Options{
+			Handler: http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				_, _ = rw.Write([]byte(runtime.ClientID(req.Context()) + "/" + runtime.SessionID(req.Context())))
+			}),
+		},
+	})
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/wails/ipc?token=secret&clientid=alice&session=tab-1"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	i.NoErr(err)
+	defer conn.Close()
+	receiveClientID(t, conn)
+	i.True(waitForClients(d, 1))
+
+	header := http.Header{
+		"Authorization": {"Bearer secret"},
+		headerClientID:  {"alice"},
+		headerSessionID: {"tab-1"},
+	}
+	_, body := get(t, server, "/api", header)
+	i.Equal(body, "alice/tab-1")
+	_, body = get(t, server, "/api?token=secret&clientid=alice&session=tab-1", nil)
+	i.Equal(body, "alice/tab-1")
+
+	// Requests without the token are rejected, the ones with another session or of unknown clients have neither
+	resp, _ := get(t, server, "/api?clientid=alice&session=tab-1", nil)
+	i.Equal(resp.StatusCode, http.StatusUnauthorized)
+	_, body = get(t, server, "/api?token=secret&clientid=alice&session=tab-2", nil)
+	i.Equal(body, "/")
+	_, body = get(t, server, "/api?token=secret&clientid=bob&session=tab-1", nil)
+	i.Equal(body, "/")
+}
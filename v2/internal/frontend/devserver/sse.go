@@ -0,0 +1,179 @@
+package devserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// sseClient is a client of the event stream, the fallback transport for browsers which can't open the
+// IPC websocket, e.g. behind a proxy blocking websockets. It gets the same messages as the websocket
+// clients, its calls are made with the HTTP call endpoint and its events are posted to the event stream.
+type sseClient struct {
+	id string
+
+	// messages queues the messages for the stream until evicted is closed
+	messages  chan string
+	evicted   chan struct{}
+	evictOnce sync.Once
+
+	// subscriptions are the events given with the "events" query parameter, all events if there are none
+	subscriptions *SubscriptionManager
+}
+
+func (s *sseClient) evict() {
+	s.evictOnce.Do(func() { close(s.evicted) })
+}
+
+// handleEventStream streams the messages of the IPC websocket as server-sent events. The first message is
+// the ID of the client, which it passes as the "clientid" query parameter when posting events.
+func (d *DevWebServer) handleEventStream(c echo.Context) error {
+	if !d.isAuthorized(c.Request()) {
+		return c.String(http.StatusUnauthorized, "invalid or missing token")
+	}
+	clientID, err := d.reserveClientID(requestedClientID(c.Request()))
+	if err != nil {
+		return c.String(http.StatusConflict, err.Error())
+	}
+	defer d.releaseClientID(clientID)
+	c.Set(clientIDContextKey, clientID)
+
+	client := &sseClient{
+		id:            clientID,
+		messages:      make(chan string, d.sendQueueSize()),
+		evicted:       make(chan struct{}),
+		subscriptions: NewSubscriptionManager(),
+	}
+	for _, eventName := range strings.Split(c.QueryParam("events"), ",") {
+		if eventName = strings.TrimSpace(eventName); eventName != "" {
+			client.subscriptions.Subscribe(eventName, 0)
+		}
+	}
+
+	response := c.Response()
+	response.Header().Set(echo.HeaderContentType, "text/event-stream")
+	response.Header().Set(echo.HeaderCacheControl, "no-cache")
+	// Keep proxies like nginx from buffering the stream
+	response.Header().Set("X-Accel-Buffering", "no")
+	response.WriteHeader(http.StatusOK)
+	if err := writeServerSentEvent(response, "id"+clientID); err != nil {
+		return nil
+	}
+
+	d.sseMutex.Lock()
+	d.sseClients[client] = struct{}{}
+	d.sseMutex.Unlock()
+	d.LogDebug("Event stream client '%s' connected", clientID)
+	defer func() {
+		d.sseMutex.Lock()
+		delete(d.sseClients, client)
+		d.sseMutex.Unlock()
+		d.LogDebug("Event stream client '%s' disconnected", clientID)
+	}()
+
+	var keepAlive <-chan time.Time
+	if interval := d.appoptions.WebSocket.KeepAliveInterval; interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		keepAlive = ticker.C
+	}
+	for {
+		var err error
+		select {
+		case <-c.Request().Context().Done():
+			return nil
+		case <-d.closing:
+			_ = writeServerSentEvent(response, serverClosingMessage)
+			return nil
+		case <-client.evicted:
+			d.logger.Error("Event stream client '%s' does not keep up with the events, disconnecting", clientID)
+			return nil
+		case <-keepAlive:
+			// A comment, which is ignored by the EventSource
+			_, err = io.WriteString(response, ":"+keepAliveMessage+"\n\n")
+			response.Flush()
+		case message := <-client.messages:
+			err = writeServerSentEvent(response, message)
+		}
+		if err != nil {
+			return nil
+		}
+	}
+}
+
+// writeServerSentEvent writes the message as the data of an event, a line of data for each line of the message
+func writeServerSentEvent(response *echo.Response, message string) error {
+	var event strings.Builder
+	for _, line := range strings.Split(message, "\n") {
+		event.WriteString("data: ")
+		event.WriteString(line)
+		event.WriteString("\n")
+	}
+	event.WriteString("\n")
+	if _, err := io.WriteString(response, event.String()); err != nil {
+		return err
+	}
+	response.Flush()
+	return nil
+}
+
+// handleEventStreamEmit emits the event posted by an event stream client, the body is the same as the one
+// of the "EE" message of the IPC websocket
+func (d *DevWebServer) handleEventStreamEmit(c echo.Context) error {
+	if !d.isAuthorized(c.Request()) {
+		return c.String(http.StatusUnauthorized, "invalid or missing token")
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(c.Response(), c.Request().Body, d.maxMessageSize()))
+	if err != nil {
+		return c.String(http.StatusRequestEntityTooLarge, err.Error())
+	}
+	if !json.Valid(body) {
+		return c.String(http.StatusBadRequest, "invalid event")
+	}
+	senderID := requestedClientID(c.Request())
+	message := "EE" + string(body)
+	d.ipcCalls.Add(1)
+	d.notifyExcludingSender([]byte(message), senderID)
+	ctx := d.ctx
+	if senderID != "" {
+		ctx = context.WithValue(ctx, "clientid", senderID)
+	}
+	start := time.Now()
+	_, err = d.dispatcher.ProcessMessage(ctx, message, d)
+	d.metrics.observeDispatch(start)
+	if err != nil {
+		d.logger.Error(err.Error())
+		return c.String(http.StatusBadRequest, fmt.Sprintf("unable to emit the event: %s", err.Error()))
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// notifyEventStreamClients queues the message for the event stream clients subscribed to the event, except
+// the sender. Messages without an event name, e.g. the reload messages, are sent to all of them.
+func (d *DevWebServer) notifyEventStreamClients(eventName string, message string, senderID string) {
+	d.sseMutex.Lock()
+	defer d.sseMutex.Unlock()
+	for client := range d.sseClients {
+		if senderID != "" && client.id == senderID {
+			continue
+		}
+		if eventName != "" && !client.subscriptions.Matches(eventName) {
+			continue
+		}
+		select {
+		case client.messages <- message:
+			if eventName != "" {
+				client.subscriptions.Consumed(eventName)
+			}
+		default:
+			client.evict()
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package devserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/wailsapp/wails/v2/internal/binding"
+)
+
+// The types of the stream messages. The promise of the call is resolved with an async iterator when the client
+// receives the start of the stream, which yields the values until the end.
+const (
+	streamStart = "start"
+	streamValue = "value"
+	streamEnd   = "end"
+)
+
+// streamMessage is sent to the client for the result of a method which streams, as "s" and the JSON
+type streamMessage struct {
+	CallbackID string      `json:"callbackid"`
+	Type       string      `json:"type"`
+	Value      interface{} `json:"value"`
+	Error      interface{} `json:"error,omitempty"`
+}
+
+// isStreamCancel reports whether the message cancels a stream, "SX" and the callback ID of the call
+func isStreamCancel(message []byte) bool {
+	return len(message) > 2 && string(message[:2]) == "SX"
+}
+
+// isCallCancel reports whether the message cancels a call, "X" and the callback ID of the call
+func isCallCancel(message []byte) bool {
+	return len(message) > 1 && message[0] == 'X'
+}
+
+// cancelStream stops sending the stream of the call to the client
+func (w *WebsocketInfo) cancelStream(callbackID string) {
+	w.streamsLock.Lock()
+	defer w.streamsLock.Unlock()
+	if cancel := w.streams[callbackID]; cancel != nil {
+		cancel()
+	}
+}
+
+// sendStream sends the values of the stream to the client until the stream ends, the client cancels it or
+// disconnects. The values are read one at a time, so a method sending them blocks while the client is slow.
+func (d *DevWebServer) sendStream(conn *websocket.Conn, info *WebsocketInfo, callbackID string, reader binding.StreamReader) {
+	ctx, cancel := context.WithCancel(info.ctx)
+	info.streamsLock.Lock()
+	if _, exists := info.streams[callbackID]; exists {
+		info.streamsLock.Unlock()
+		cancel()
+		reader.Cancel()
+		_ = d.writeStreamMessage(conn, info, streamMessage{CallbackID: callbackID, Type: streamEnd, Error: "a stream with the callback ID exists"})
+		return
+	}
+	if info.streams == nil {
+		info.streams = make(map[string]context.CancelFunc)
+	}
+	info.streams[callbackID] = cancel
+	info.streamsLock.Unlock()
+	defer func() {
+		info.streamsLock.Lock()
+		delete(info.streams, callbackID)
+		info.streamsLock.Unlock()
+		cancel()
+	}()
+
+	if err := d.writeStreamMessage(conn, info, streamMessage{CallbackID: callbackID, Type: streamStart}); err != nil {
+		reader.Cancel()
+		return
+	}
+	for {
+		value, err := reader.Recv(ctx)
+		if ctx.Err() != nil {
+			// The client cancelled the stream or disconnected
+			reader.Cancel()
+			return
+		}
+		message := streamMessage{CallbackID: callbackID, Type: streamValue, Value: value}
+		if errors.Is(err, io.EOF) {
+			message = streamMessage{CallbackID: callbackID, Type: streamEnd}
+		} else if err != nil {
+			message = streamMessage{CallbackID: callbackID, Type: streamEnd, Error: binding.ErrorValue(err)}
+			if d.appoptions.ErrorFormatter != nil {
+				message.Error = d.appoptions.ErrorFormatter(err)
+			}
+		}
+		if err := d.writeStreamMessage(conn, info, message); err != nil || message.Type == streamEnd {
+			reader.Cancel()
+			return
+		}
+	}
+}
+
+// writeStreamMessage writes the stream message to the client. A value which can't be marshalled ends the stream
+// with the error.
+func (d *DevWebServer) writeStreamMessage(conn *websocket.Conn, info *WebsocketInfo, message streamMessage) error {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		d.logger.Error("Unable to marshal a value of stream '%s': %s", message.CallbackID, err.Error())
+		payload, _ = json.Marshal(streamMessage{CallbackID: message.CallbackID, Type: streamEnd, Error: err.Error()})
+		if writeErr := d.writeMessage(conn, info, websocket.TextMessage, append([]byte("s"), payload...), time.Time{}); writeErr != nil {
+			return writeErr
+		}
+		return err
+	}
+	return d.writeMessage(conn, info, websocket.TextMessage, append([]byte("s"), payload...), time.Time{})
+}
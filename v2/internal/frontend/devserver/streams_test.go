@@ -0,0 +1,116 @@
+package devserver
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+
+	"github.com/wailsapp/wails/v2/internal/binding"
+	"github.com/wailsapp/wails/v2/internal/frontend/dispatcher"
+	"github.com/wailsapp/wails/v2/internal/logger"
+	pkglogger "github.com/wailsapp/wails/v2/pkg/logger"
+	"github.com/wailsapp/wails/v2/pkg/options"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+type StreamApp struct {
+	cancelled chan struct{}
+}
+
+func (s *StreamApp) Count(n int) <-chan int {
+	values := make(chan int)
+	go func() {
+		defer close(values)
+		for value := 0; value < n; value++ {
+			values <- value
+		}
+	}()
+	return values
+}
+
+func (s *StreamApp) Fail() *runtime.Stream {
+	stream := runtime.NewStream()
+	go func() {
+		_ = stream.Send("first")
+		stream.Close(errors.New("failed"))
+	}()
+	return stream
+}
+
+func (s *StreamApp) Tail(ctx context.Context) *runtime.Stream {
+	stream := runtime.NewStream()
+	go func() {
+		for line := 0; ; line++ {
+			if err := stream.Send(line); err != nil {
+				<-ctx.Done()
+				close(s.cancelled)
+				return
+			}
+		}
+	}()
+	return stream
+}
+
+func TestStreamedCallResults(t *testing.T) {
+	i := is.New(t)
+	myLogger := logger.New(pkglogger.NewDefaultLogger())
+	myLogger.SetLogLevel(pkglogger.ERROR)
+	app := &StreamApp{cancelled: make(chan struct{})}
+	appBindings := binding.NewBindings(myLogger, []interface{}{app}, nil, false, nil)
+	messageDispatcher := dispatcher.NewDispatcher(context.Background(), myLogger, appBindings, nil, nil)
+	d := NewFrontend(context.Background(), &options.App{}, myLogger, appBindings, messageDispatcher, nil, &mockFrontend{})
+	d.server.GET("/wails/ipc", d.handleIPCWebSocket)
+	server := httptest.NewServer(d.server)
+	defer server.Close()
+	conn := dialIPC(t, server)
+
+	expect := func(messages ...string) {
+		t.Helper()
+		for _, expected := range messages {
+			msg, err := receive(conn, time.Second)
+			i.NoErr(err)
+			i.Equal(msg, expected)
+		}
+	}
+
+	// The values of a channel are sent until it is closed
+	i.NoErr(send(conn, `C{"name":"devserver.StreamApp.Count","args":[3],"callbackID":"count-1"}`))
+	expect(
+		`s{"callbackid":"count-1","type":"start","value":null}`,
+		`s{"callbackid":"count-1","type":"value","value":0}`,
+		`s{"callbackid":"count-1","type":"value","value":1}`,
+		`s{"callbackid":"count-1","type":"value","value":2}`,
+		`s{"callbackid":"count-1","type":"end","value":null}`,
+	)
+
+	// The error a stream is closed with ends it
+	i.NoErr(send(conn, `C{"name":"devserver.StreamApp.Fail","args":[],"callbackID":"fail-1"}`))
+	expect(
+		`s{"callbackid":"fail-1","type":"start","value":null}`,
+		`s{"callbackid":"fail-1","type":"value","value":"first"}`,
+		`s{"callbackid":"fail-1","type":"end","value":null,"error":"failed"}`,
+	)
+
+	// Cancelling a stream stops the method
+	i.NoErr(send(conn, `C{"name":"devserver.StreamApp.Tail","args":[],"callbackID":"tail-1"}`))
+	expect(
+		`s{"callbackid":"tail-1","type":"start","value":null}`,
+		`s{"callbackid":"tail-1","type":"value","value":0}`,
+	)
+	i.NoErr(send(conn, `SXtail-1`))
+	select {
+	case <-app.cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("the stream has not been cancelled")
+	}
+
+	// Frontends which can't send streams fail the calls
+	result, err := messageDispatcher.ProcessMessage(context.Background(), `C{"name":"devserver.StreamApp.Count","args":[1],"callbackID":"desktop-1"}`, nil)
+	i.NoErr(err)
+	i.True(strings.Contains(result, "streamed results are only supported by the browser clients"))
+}
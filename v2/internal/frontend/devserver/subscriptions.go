@@ -0,0 +1,212 @@
+package devserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/wailsapp/wails/v2/internal/frontend/runtime"
+)
+
+// subscribeMessage is sent by the clients with the "ES" prefix to subscribe to an event for a number of
+// deliveries, the remote counterpart of EventsOnMultiple. A count of 1 is EventsOnce, a count of zero
+// or less never expires. "EB<name>" subscribes without a count, "EX<name>" unsubscribes.
+type subscribeMessage struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func parseSubscribeMessage(message []byte) (subscribeMessage, error) {
+	var subscription subscribeMessage
+	if err := json.Unmarshal(message[2:], &subscription); err != nil {
+		return subscription, err
+	}
+	if subscription.Name == "" {
+		return subscription, fmt.Errorf("subscription without an event name")
+	}
+	return subscription, nil
+}
+
+// SubscriptionManager tracks the events a client subscribed to. Until the first subscription, and again
+// once no subscription is left, the client receives all events. Every subscription is a listener, a name
+// or a pattern may have several of them, each with its own count of remaining deliveries.
+type SubscriptionManager struct {
+	lock sync.Mutex
+	// listeners holds the remaining deliveries of each listener by event name or pattern, 0 never expires
+	listeners map[string][]int
+	// patterns are the subscriptions containing a wildcard, see runtime.EventPattern
+	patterns     []subscriptionPattern
+	filterEvents bool
+	// restored is set while the subscriptions are the ones of a resumed session, see restore
+	restored bool
+}
+
+// NewSubscriptionManager creates a SubscriptionManager without subscriptions
+func NewSubscriptionManager() *SubscriptionManager {
+	return &SubscriptionManager{listeners: make(map[string][]int)}
+}
+
+// Subscribe adds a listener for the event or pattern, which expires after count deliveries.
+// A count of zero or less never expires.
+func (s *SubscriptionManager) Subscribe(eventName string, count int) {
+	if count < 0 {
+		count = 0
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.dropRestored()
+	if _, exists := s.listeners[eventName]; !exists && runtime.IsEventPattern(eventName) {
+		s.patterns = append(s.patterns, subscriptionPattern{pattern: eventName, matcher: runtime.CompileEventPattern(eventName)})
+	}
+	s.listeners[eventName] = append(s.listeners[eventName], count)
+	s.filterEvents = true
+}
+
+// Unsubscribe removes all listeners of the event or pattern. Once the client has no subscriptions left,
+// it receives all events again.
+func (s *SubscriptionManager) Unsubscribe(eventName string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.dropRestored()
+	s.remove(eventName)
+}
+
+func (s *SubscriptionManager) remove(eventName string) {
+	delete(s.listeners, eventName)
+	if runtime.IsEventPattern(eventName) {
+		for index, pattern := range s.patterns {
+			if pattern.pattern == eventName {
+				s.patterns = append(s.patterns[:index], s.patterns[index+1:]...)
+				break
+			}
+		}
+	}
+	s.filterEvents = len(s.listeners) > 0
+}
+
+// Subscriptions returns the sorted names and patterns with listeners
+func (s *SubscriptionManager) Subscriptions() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	result := make([]string, 0, len(s.listeners))
+	for eventName := range s.listeners {
+		result = append(result, eventName)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Clear removes all subscriptions, the client then receives all events again
+func (s *SubscriptionManager) Clear() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.clear()
+}
+
+// snapshot returns a copy of the listeners, to keep them with the state of the session
+func (s *SubscriptionManager) snapshot() map[string][]int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	result := make(map[string][]int, len(s.listeners))
+	for eventName, listeners := range s.listeners {
+		result[eventName] = append([]int(nil), listeners...)
+	}
+	return result
+}
+
+// restore replaces the subscriptions with the listeners of a resumed session. They filter the events until
+// the client subscribes or unsubscribes itself, as the runtime replays its subscriptions after reconnecting.
+func (s *SubscriptionManager) restore(listeners map[string][]int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.clear()
+	for eventName, counts := range listeners {
+		if len(counts) == 0 {
+			continue
+		}
+		if runtime.IsEventPattern(eventName) {
+			s.patterns = append(s.patterns, subscriptionPattern{pattern: eventName, matcher: runtime.CompileEventPattern(eventName)})
+		}
+		s.listeners[eventName] = append([]int(nil), counts...)
+	}
+	s.filterEvents = len(s.listeners) > 0
+	s.restored = s.filterEvents
+}
+
+// dropRestored removes the restored subscriptions, the client sends its own ones
+func (s *SubscriptionManager) dropRestored() {
+	if s.restored {
+		s.clear()
+	}
+}
+
+func (s *SubscriptionManager) clear() {
+	s.listeners = make(map[string][]int)
+	s.patterns = nil
+	s.filterEvents = false
+	s.restored = false
+}
+
+// Matches reports whether the event should be sent to the client. The listeners are not counted, the
+// event is counted with Consumed once it has been queued for the client.
+func (s *SubscriptionManager) Matches(eventName string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !s.filterEvents {
+		return true
+	}
+	if _, ok := s.listeners[eventName]; ok {
+		return true
+	}
+	for _, pattern := range s.patterns {
+		if pattern.matcher.Match(eventName) {
+			return true
+		}
+	}
+	return false
+}
+
+// Consumed counts a delivery of the event against the matching listeners, the listeners reaching their
+// count are removed.
+func (s *SubscriptionManager) Consumed(eventName string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !s.filterEvents {
+		return
+	}
+	s.count(eventName)
+	for _, pattern := range append([]subscriptionPattern(nil), s.patterns...) {
+		if pattern.matcher.Match(eventName) {
+			s.count(pattern.pattern)
+		}
+	}
+}
+
+// count counts a delivery against the listeners of the name or pattern
+func (s *SubscriptionManager) count(eventName string) {
+	listeners, ok := s.listeners[eventName]
+	if !ok {
+		return
+	}
+	remaining := listeners[:0]
+	for _, count := range listeners {
+		switch {
+		case count == 0:
+			remaining = append(remaining, 0)
+		case count > 1:
+			remaining = append(remaining, count-1)
+		}
+	}
+	if len(remaining) == 0 {
+		s.remove(eventName)
+	} else {
+		s.listeners[eventName] = remaining
+	}
+}
+
+// subscriptionPattern is a subscription to the events matching a pattern
+type subscriptionPattern struct {
+	pattern string
+	matcher *runtime.EventPattern
+}
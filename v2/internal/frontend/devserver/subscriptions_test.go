@@ -0,0 +1,88 @@
+package devserver
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestSubscriptionManager(t *testing.T) {
+	i := is.New(t)
+	s := NewSubscriptionManager()
+	deliver := func(eventName string) bool {
+		if !s.Matches(eventName) {
+			return false
+		}
+		s.Consumed(eventName)
+		return true
+	}
+
+	// Without subscriptions all events are delivered
+	i.True(deliver("anything"))
+
+	s.Subscribe("once", 1)
+	s.Subscribe("twice", 2)
+	s.Subscribe("always", 0)
+	s.Subscribe("app:*", 1)
+	i.Equal(s.Subscriptions(), []string{"always", "app:*", "once", "twice"})
+	i.True(!deliver("other"))
+
+	// Matching does not count the delivery
+	i.True(s.Matches("once"))
+	i.True(s.Matches("once"))
+
+	i.True(deliver("once"))
+	i.True(!deliver("once"))
+	i.True(deliver("twice"))
+	i.True(deliver("twice"))
+	i.True(!deliver("twice"))
+	i.True(deliver("app:started"))
+	i.True(!deliver("app:stopped"))
+	i.True(deliver("always"))
+	i.True(deliver("always"))
+	i.Equal(s.Subscriptions(), []string{"always"})
+
+	// Every listener of an event has its own count
+	s.Subscribe("shared", 1)
+	s.Subscribe("shared", 2)
+	i.True(deliver("shared"))
+	i.True(deliver("shared"))
+	i.True(!deliver("shared"))
+
+	s.Subscribe("shared", 0)
+	s.Unsubscribe("shared")
+	i.True(!deliver("shared"))
+
+	// A '+' segment matches a single segment
+	s.Subscribe("user.+.updated", 0)
+	i.True(deliver("user.42.updated"))
+	i.True(!deliver("user.42.profile.updated"))
+	s.Unsubscribe("user.+.updated")
+	i.True(!deliver("user.42.updated"))
+
+	s.Clear()
+	i.Equal(s.Subscriptions(), []string{})
+	i.True(deliver("shared"))
+
+	// Once the last listener expired, all events are delivered again
+	s.Subscribe("once", 1)
+	i.True(!deliver("other"))
+	i.True(deliver("once"))
+	i.Equal(s.Subscriptions(), []string{})
+	i.True(deliver("other"))
+
+	// The same after the last subscription has been removed
+	s.Subscribe("some", 0)
+	i.True(!deliver("other"))
+	s.Unsubscribe("some")
+	i.True(deliver("other"))
+
+	// The restored subscriptions of a resumed session are replaced once the client subscribes itself
+	s.restore(map[string][]int{"restored": {0}, "app:*": {2}})
+	i.Equal(s.Subscriptions(), []string{"app:*", "restored"})
+	i.True(deliver("app:started"))
+	i.True(!deliver("other"))
+	s.Subscribe("own", 0)
+	i.Equal(s.Subscriptions(), []string{"own"})
+	i.True(!deliver("restored"))
+}
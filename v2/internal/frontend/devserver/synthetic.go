@@ -0,0 +1,123 @@
+//go:build dev
+// +build dev
+
+package devserver
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/wailsapp/wails/v2/internal/frontend/dispatcher"
+)
+
+// SyntheticMethod handles the calls of a method which is not bound, e.g. to mock a Go method that does not
+// exist yet during frontend development. The args are the JSON decoded arguments of the call.
+type SyntheticMethod func(args []interface{}) (interface{}, error)
+
+// syntheticMethods is the registry of the synthetic methods, which are only available in dev builds
+type syntheticMethods struct {
+	lock    sync.RWMutex
+	methods map[string]SyntheticMethod
+}
+
+func (s *syntheticMethods) get(name string) SyntheticMethod {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.methods[name]
+}
+
+func (s *syntheticMethods) names() []string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	result := make([]string, 0, len(s.methods))
+	for name := range s.methods {
+		result = append(result, name)
+	}
+	return result
+}
+
+// RegisterSyntheticMethod registers a handler for the calls of the method with the given name, it takes
+// precedence over a bound method with the same name. Names of the form "package.Struct.Method" are added to
+// the bindings, so the method is available as `window.go.package.Struct.Method`, connected clients are reloaded.
+func (d *DevWebServer) RegisterSyntheticMethod(name string, method SyntheticMethod) error {
+	d.syntheticMethods.lock.Lock()
+	if d.syntheticMethods.methods == nil {
+		d.syntheticMethods.methods = make(map[string]SyntheticMethod)
+	}
+	d.syntheticMethods.methods[name] = method
+	d.syntheticMethods.lock.Unlock()
+	return d.RefreshBindings()
+}
+
+// UnregisterSyntheticMethod removes the synthetic method, calls are dispatched to the bindings again
+func (d *DevWebServer) UnregisterSyntheticMethod(name string) error {
+	d.syntheticMethods.lock.Lock()
+	delete(d.syntheticMethods.methods, name)
+	d.syntheticMethods.lock.Unlock()
+	return d.RefreshBindings()
+}
+
+// processSyntheticCall handles the message if it is a call of a synthetic method, ok is false otherwise
+func (d *DevWebServer) processSyntheticCall(message string) (callbackMessage *dispatcher.CallbackMessage, ok bool) {
+	if len(message) < 2 || message[0] != 'C' {
+		return nil, false
+	}
+	var call struct {
+		Name       string        `json:"name"`
+		Args       []interface{} `json:"args"`
+		CallbackID string        `json:"callbackID"`
+	}
+	if err := json.Unmarshal([]byte(message[1:]), &call); err != nil {
+		return nil, false
+	}
+	method := d.syntheticMethods.get(call.Name)
+	if method == nil {
+		return nil, false
+	}
+
+	callbackMessage = &dispatcher.CallbackMessage{
+		CallbackID: call.CallbackID,
+	}
+	result, err := method(call.Args)
+	if err != nil {
+		callbackMessage.Err = err.Error()
+	} else {
+		callbackMessage.Result = result
+	}
+	return callbackMessage, true
+}
+
+// bindingsJSON returns the bindings of the app, including the synthetic methods
+func (d *DevWebServer) bindingsJSON() (string, error) {
+	bindingsJSON, err := d.appBindings.ToJSON()
+	if err != nil {
+		return "", err
+	}
+	names := d.syntheticMethods.names()
+	if len(names) == 0 {
+		return bindingsJSON, nil
+	}
+
+	bindings := map[string]map[string]map[string]json.RawMessage{}
+	if err := json.Unmarshal([]byte(bindingsJSON), &bindings); err != nil {
+		return "", err
+	}
+	for _, name := range names {
+		parts := strings.Split(name, ".")
+		if len(parts) != 3 {
+			continue
+		}
+		if bindings[parts[0]] == nil {
+			bindings[parts[0]] = map[string]map[string]json.RawMessage{}
+		}
+		if bindings[parts[0]][parts[1]] == nil {
+			bindings[parts[0]][parts[1]] = map[string]json.RawMessage{}
+		}
+		if _, exists := bindings[parts[0]][parts[1]][parts[2]]; !exists {
+			bindings[parts[0]][parts[1]][parts[2]] = json.RawMessage("{}")
+		}
+	}
+	result, err := json.Marshal(bindings)
+	return string(result), err
+}
@@ -0,0 +1,19 @@
+//go:build !dev
+// +build !dev
+
+package devserver
+
+import "github.com/wailsapp/wails/v2/internal/frontend/dispatcher"
+
+// syntheticMethods is empty, the synthetic methods are only available in dev builds
+type syntheticMethods struct{}
+
+// processSyntheticCall never handles the message, there are no synthetic methods
+func (d *DevWebServer) processSyntheticCall(message string) (callbackMessage *dispatcher.CallbackMessage, ok bool) {
+	return nil, false
+}
+
+// bindingsJSON returns the bindings of the app
+func (d *DevWebServer) bindingsJSON() (string, error) {
+	return d.appBindings.ToJSON()
+}
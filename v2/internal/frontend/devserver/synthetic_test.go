@@ -0,0 +1,192 @@
+//go:build dev
+// +build dev
+
+package devserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/wailsapp/wails/v2/internal/binding"
+	"github.com/wailsapp/wails/v2/internal/frontend/dispatcher"
+	"github.com/wailsapp/wails/v2/internal/logger"
+	pkglogger "github.com/wailsapp/wails/v2/pkg/logger"
+	"github.com/wailsapp/wails/v2/pkg/options"
+	pkgruntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+func TestSyntheticMethods(t *testing.T) {
+	i := is.New(t)
+	d, server := runTestServer(t, nil)
+	i.NoErr(d.RegisterSyntheticMethod("main.App.Greet", func(args []interface{}) (interface{}, error) {
+		return fmt.Sprintf("Hello %v!", args[0]), nil
+	}))
+	i.NoErr(d.RegisterSyntheticMethod("failing", func(args []interface{}) (interface{}, error) {
+		return nil, errors.New("not implemented yet")
+	}))
+
+	// Synthetic methods of the form package.Struct.Method are added to the bindings
+	_, runtimeJS := get(t, server, "/wails/runtime.js", nil)
+	i.True(strings.Contains(runtimeJS, template.JSEscapeString(`{"main":{"App":{"Greet":{}}}}`)))
+
+	conn := dialIPC(t, server)
+	i.NoErr(send(conn, `C{"name":"main.App.Greet","args":["World"],"callbackID":"1"}`))
+	msg, err := receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `c{"result":"Hello World!","error":null,"callbackid":"1"}`)
+
+	i.NoErr(send(conn, `C{"name":"failing","args":[],"callbackID":"2"}`))
+	msg, err = receive(conn, time.Second)
+	i.NoErr(err)
+	i.Equal(msg, `c{"result":null,"error":"not implemented yet","callbackid":"2"}`)
+
+	// Once unregistered, the clients are reloaded and calls are dispatched to the bindings again
+	i.NoErr(d.UnregisterSyntheticMethod("failing"))
+	i.NoErr(send(conn, `C{"name":"failing","args":[],"callbackID":"3"}`))
+	received := map[string]bool{}
+	for n := 0; n < 2; n++ {
+		msg, err = receive(conn, time.Second)
+		i.NoErr(err)
+		received[msg] = true
+	}
+	i.Equal(received, map[string]bool{
+		"reload": true,
+		`c{"name":"failing","args":[],"callbackID":"3"}`: true,
+	})
+}
+
+func TestStreamedDownloads(t *testing.T) {
+	i := is.New(t)
+	d, server := runTestServer(t, nil)
+	pipeReader, pipeWriter := io.Pipe()
+	i.NoErr(d.RegisterSyntheticMethod("download", func(args []interface{}) (interface{}, error) {
+		switch args[0] {
+		case "pipe":
+			return pipeReader, nil
+		case "export":
+			return pkgruntime.Download{
+				Reader:      strings.NewReader("a,b"),
+				Filename:    "export.csv",
+				ContentType: "text/csv",
+				Size:        3,
+			}, nil
+		}
+		return strings.NewReader("file contents"), nil
+	}))
+	conn := dialIPC(t, server)
+
+	// The result of the call is the URL to stream the reader from, it can only be fetched once
+	download := func(callbackID string, arg string) string {
+		i.NoErr(send(conn, `C{"name":"download","args":["`+arg+`"],"callbackID":"`+callbackID+`"}`))
+		msg, err := receive(conn, time.Second)
+		i.NoErr(err)
+		var callback dispatcher.CallbackMessage
+		i.NoErr(json.Unmarshal([]byte(msg[1:]), &callback))
+		i.Equal(callback.Err, nil)
+		url, ok := callback.Result.(string)
+		i.True(ok && strings.HasPrefix(url, "/wails/download/"))
+		return url
+	}
+	url := download("1", "reader")
+	resp, body := get(t, server, url, nil)
+	i.Equal(resp.StatusCode, http.StatusOK)
+	i.Equal(resp.Header.Get("Content-Type"), "application/octet-stream")
+	i.Equal(body, "file contents")
+	resp, _ = get(t, server, url, nil)
+	i.Equal(resp.StatusCode, http.StatusNotFound)
+
+	// The metadata of a Download are sent with the content
+	resp, body = get(t, server, download("3", "export"), nil)
+	i.Equal(resp.StatusCode, http.StatusOK)
+	i.Equal(resp.Header.Get("Content-Type"), "text/csv")
+	i.Equal(resp.Header.Get("Content-Length"), "3")
+	i.Equal(resp.Header.Get("Content-Disposition"), `attachment; filename=export.csv`)
+	i.Equal(body, "a,b")
+
+	// A client going away during the download closes the reader
+	url = download("2", "pipe")
+	resp, err := http.Get(server.URL + url)
+	i.NoErr(err)
+	go func() { _, _ = pipeWriter.Write([]byte("first")) }()
+	first := make([]byte, 5)
+	_, err = io.ReadFull(resp.Body, first)
+	i.NoErr(err)
+	i.Equal(string(first), "first")
+	i.NoErr(resp.Body.Close())
+
+	written := make(chan error, 1)
+	go func() {
+		for {
+			if _, err := pipeWriter.Write([]byte("more")); err != nil {
+				written <- err
+				return
+			}
+		}
+	}()
+	select {
+	case err = <-written:
+		i.True(errors.Is(err, io.ErrClosedPipe))
+	case <-time.After(2 * time.Second):
+		t.Fatal("the reader has not been closed")
+	}
+}
+
+func TestBindingsQuery(t *testing.T) {
+	i := is.New(t)
+	myLogger := logger.New(pkglogger.NewDefaultLogger())
+	myLogger.SetLogLevel(pkglogger.ERROR)
+	appBindings := binding.NewBindings(myLogger, []interface{}{&BinaryApp{}}, nil, false, nil)
+	messageDispatcher := dispatcher.NewDispatcher(context.Background(), myLogger, appBindings, nil, nil)
+	d := NewFrontend(context.Background(), &options.App{}, myLogger, appBindings, messageDispatcher, nil, &mockFrontend{})
+	i.NoErr(d.RegisterSyntheticMethod("main.App.Greet", func(args []interface{}) (interface{}, error) {
+		return "Hello", nil
+	}))
+	d.server.GET("/wails/ipc", d.handleIPCWebSocket)
+	server := httptest.NewServer(d.server)
+	defer server.Close()
+	conn := dialIPC(t, server)
+
+	i.NoErr(send(conn, `D{"query":"bindings","id":"1"}`))
+	msg, err := receive(conn, time.Second)
+	i.NoErr(err)
+	i.True(strings.HasPrefix(msg, "d"))
+	var reply struct {
+		ID     string            `json:"id"`
+		Result []BoundMethodInfo `json:"result"`
+	}
+	i.NoErr(json.Unmarshal([]byte(msg[1:]), &reply))
+	i.Equal(reply.ID, "1")
+	i.Equal(reply.Result, []BoundMethodInfo{
+		{
+			Name:    "devserver.BinaryApp.Data",
+			Inputs:  []ParameterInfo{{Type: "int"}},
+			Outputs: []ParameterInfo{{Type: "[]uint8"}},
+		},
+		{
+			Name:    "devserver.BinaryApp.Sum",
+			Inputs:  []ParameterInfo{{Type: "string"}, {Type: "[]uint8"}},
+			Outputs: []ParameterInfo{{Type: "string"}},
+		},
+		{
+			Name:    "devserver.BinaryApp.Text",
+			Inputs:  []ParameterInfo{},
+			Outputs: []ParameterInfo{{Type: "string"}},
+		},
+		// The synthetic methods have no known signature
+		{
+			Name:    "main.App.Greet",
+			Inputs:  []ParameterInfo{},
+			Outputs: []ParameterInfo{},
+		},
+	})
+}
@@ -0,0 +1,296 @@
+package devserver
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/wailsapp/wails/v2/pkg/options"
+)
+
+// uploadTTL is the time after which an upload without new chunks is discarded
+const uploadTTL = time.Hour
+
+const (
+	tusVersion     = "1.0.0"
+	tusContentType = "application/offset+octet-stream"
+
+	headerTusResumable   = "Tus-Resumable"
+	headerUploadLength   = "Upload-Length"
+	headerUploadOffset   = "Upload-Offset"
+	headerUploadMetadata = "Upload-Metadata"
+)
+
+// uploads holds the incomplete uploads of the clients. An upload is created with a POST with its length,
+// its chunks are sent with PATCH requests at the current offset, which a client resuming the upload
+// gets with a HEAD request.
+type uploads struct {
+	config *options.Uploads
+
+	lock    sync.Mutex
+	uploads map[string]*upload
+}
+
+type upload struct {
+	// lock is held while a chunk is written, concurrent chunks are rejected
+	lock sync.Mutex
+
+	file     *os.File
+	length   int64
+	offset   int64
+	metadata map[string]string
+	clientID string
+	expires  time.Time
+}
+
+// discard closes and removes the file of the upload
+func (u *upload) discard() {
+	_ = u.file.Close()
+	_ = os.Remove(u.file.Name())
+}
+
+func newUploads(config *options.Uploads) (*uploads, error) {
+	if config.OnComplete == nil {
+		return nil, errors.New("the uploads require an OnComplete callback")
+	}
+	return &uploads{
+		config:  config,
+		uploads: make(map[string]*upload),
+	}, nil
+}
+
+// add creates the file of a new upload and returns its ID. Expired uploads are discarded.
+func (u *uploads) add(pending *upload) (string, error) {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", err
+	}
+	file, err := os.CreateTemp(u.config.Dir, "wails-upload-*")
+	if err != nil {
+		return "", err
+	}
+	pending.file = file
+	pending.expires = time.Now().Add(uploadTTL)
+
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	now := time.Now()
+	for key, expired := range u.uploads {
+		if now.After(expired.expires) && expired.lock.TryLock() {
+			expired.discard()
+			delete(u.uploads, key)
+		}
+	}
+	key := hex.EncodeToString(id[:])
+	u.uploads[key] = pending
+	return key, nil
+}
+
+func (u *uploads) get(id string) *upload {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	return u.uploads[id]
+}
+
+func (u *uploads) remove(id string) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	delete(u.uploads, id)
+}
+
+// close discards the incomplete uploads
+func (u *uploads) close() {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	for key, pending := range u.uploads {
+		pending.discard()
+		delete(u.uploads, key)
+	}
+}
+
+// parseUploadMetadata parses the comma separated pairs of a key and a base64 encoded value
+func parseUploadMetadata(header string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	for _, pair := range strings.Split(header, ",") {
+		key, encoded, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			continue
+		}
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid metadata '%s': %w", key, err)
+		}
+		metadata[key] = string(value)
+	}
+	return metadata, nil
+}
+
+func (d *DevWebServer) handleUploadOptions(c echo.Context) error {
+	header := c.Response().Header()
+	header.Set("Tus-Version", tusVersion)
+	header.Set("Tus-Extension", "creation,termination")
+	if maxSize := d.uploads.config.MaxSize; maxSize > 0 {
+		header.Set("Tus-Max-Size", strconv.FormatInt(maxSize, 10))
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// handleUploadCreate creates an upload with the length of the Upload-Length header
+func (d *DevWebServer) handleUploadCreate(c echo.Context) error {
+	if !d.isAuthorized(c.Request()) {
+		return c.String(http.StatusUnauthorized, "invalid or missing token")
+	}
+	c.Response().Header().Set(headerTusResumable, tusVersion)
+	length, err := strconv.ParseInt(c.Request().Header.Get(headerUploadLength), 10, 64)
+	if err != nil || length < 0 {
+		return c.String(http.StatusBadRequest, "invalid or missing Upload-Length")
+	}
+	if maxSize := d.uploads.config.MaxSize; maxSize > 0 && length > maxSize {
+		return c.String(http.StatusRequestEntityTooLarge, fmt.Sprintf("the upload exceeds the maximum size of %d bytes", maxSize))
+	}
+	metadata, err := parseUploadMetadata(c.Request().Header.Get(headerUploadMetadata))
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	// The upload is only attributed to a client which proved its session
+	clientID, _ := d.verifiedClient(c.Request())
+	pending := &upload{length: length, metadata: metadata, clientID: clientID}
+	id, err := d.uploads.add(pending)
+	if err != nil {
+		d.logger.Error("Unable to create the upload: %s", err.Error())
+		return c.String(http.StatusInternalServerError, "unable to create the upload")
+	}
+	c.Response().Header().Set(echo.HeaderLocation, d.basePath+"/wails/upload/"+id)
+	if length == 0 {
+		pending.lock.Lock()
+		defer pending.lock.Unlock()
+		if err := d.completeUpload(id, pending); err != nil {
+			return c.String(http.StatusInternalServerError, err.Error())
+		}
+	}
+	return c.NoContent(http.StatusCreated)
+}
+
+// handleUploadOffset returns the offset of the upload, to resume it
+func (d *DevWebServer) handleUploadOffset(c echo.Context) error {
+	if !d.isAuthorized(c.Request()) {
+		return c.NoContent(http.StatusUnauthorized)
+	}
+	header := c.Response().Header()
+	header.Set(headerTusResumable, tusVersion)
+	header.Set(echo.HeaderCacheControl, "no-store")
+	pending := d.uploads.get(c.Param("id"))
+	if pending == nil {
+		return c.NoContent(http.StatusNotFound)
+	}
+	if !pending.lock.TryLock() {
+		// A chunk is being written, the offset is not known yet
+		return c.NoContent(http.StatusConflict)
+	}
+	defer pending.lock.Unlock()
+	header.Set(headerUploadOffset, strconv.FormatInt(pending.offset, 10))
+	header.Set(headerUploadLength, strconv.FormatInt(pending.length, 10))
+	return c.NoContent(http.StatusOK)
+}
+
+// handleUploadChunk appends the body to the upload at the offset of the Upload-Offset header, the upload is
+// passed to the OnComplete callback with its last chunk
+func (d *DevWebServer) handleUploadChunk(c echo.Context) error {
+	req := c.Request()
+	if !d.isAuthorized(req) {
+		return c.String(http.StatusUnauthorized, "invalid or missing token")
+	}
+	c.Response().Header().Set(headerTusResumable, tusVersion)
+	if req.Header.Get(echo.HeaderContentType) != tusContentType {
+		return c.String(http.StatusUnsupportedMediaType, "the Content-Type must be "+tusContentType)
+	}
+	id := c.Param("id")
+	pending := d.uploads.get(id)
+	if pending == nil {
+		return c.String(http.StatusNotFound, "unknown upload")
+	}
+	if !pending.lock.TryLock() {
+		return c.String(http.StatusConflict, "a chunk of the upload is being written")
+	}
+	defer pending.lock.Unlock()
+	offset, err := strconv.ParseInt(req.Header.Get(headerUploadOffset), 10, 64)
+	if err != nil || offset != pending.offset {
+		return c.String(http.StatusConflict, fmt.Sprintf("the Upload-Offset must be %d", pending.offset))
+	}
+	if req.ContentLength > pending.length-pending.offset {
+		return c.String(http.StatusRequestEntityTooLarge, "the chunk exceeds the Upload-Length")
+	}
+
+	// The data received before an error is kept, the client resumes after it
+	written, err := io.Copy(io.NewOffsetWriter(pending.file, pending.offset), http.MaxBytesReader(c.Response(), req.Body, pending.length-pending.offset))
+	pending.offset += written
+	pending.expires = time.Now().Add(uploadTTL)
+	c.Response().Header().Set(headerUploadOffset, strconv.FormatInt(pending.offset, 10))
+	var maxBytesError *http.MaxBytesError
+	if errors.As(err, &maxBytesError) {
+		return c.String(http.StatusRequestEntityTooLarge, "the chunk exceeds the Upload-Length")
+	}
+	if err != nil {
+		d.LogDebug("Upload '%s' interrupted at %d bytes: %s", id, pending.offset, err.Error())
+		return c.String(http.StatusBadRequest, "unable to read the chunk")
+	}
+
+	if pending.offset == pending.length {
+		if err := d.completeUpload(id, pending); err != nil {
+			return c.String(http.StatusInternalServerError, err.Error())
+		}
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// handleUploadTerminate discards the upload
+func (d *DevWebServer) handleUploadTerminate(c echo.Context) error {
+	if !d.isAuthorized(c.Request()) {
+		return c.String(http.StatusUnauthorized, "invalid or missing token")
+	}
+	c.Response().Header().Set(headerTusResumable, tusVersion)
+	id := c.Param("id")
+	pending := d.uploads.get(id)
+	if pending == nil {
+		return c.NoContent(http.StatusNotFound)
+	}
+	if !pending.lock.TryLock() {
+		return c.String(http.StatusConflict, "a chunk of the upload is being written")
+	}
+	defer pending.lock.Unlock()
+	d.uploads.remove(id)
+	pending.discard()
+	return c.NoContent(http.StatusNoContent)
+}
+
+// completeUpload passes the upload to the OnComplete callback and discards it, the lock of the upload must be held
+func (d *DevWebServer) completeUpload(id string, pending *upload) error {
+	d.uploads.remove(id)
+	defer pending.discard()
+	if _, err := pending.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	err := d.uploads.config.OnComplete(&options.Upload{
+		ID:       id,
+		ClientID: pending.clientID,
+		Metadata: pending.metadata,
+		Size:     pending.length,
+		File:     pending.file,
+	})
+	if err != nil {
+		d.logger.Error("Upload '%s' failed: %s", id, err.Error())
+	}
+	return err
+}
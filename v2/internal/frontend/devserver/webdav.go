@@ -0,0 +1,86 @@
+package devserver
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/net/webdav"
+
+	"github.com/wailsapp/wails/v2/pkg/options"
+)
+
+const defaultWebDAVPrefix = "/wails/webdav"
+
+// webDAVMethods are all methods of WebDAV, webDAVReadMethods the ones which don't change files
+var (
+	webDAVMethods = []string{
+		http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete,
+		"PROPFIND", "PROPPATCH", "MKCOL", "COPY", "MOVE", "LOCK", "UNLOCK",
+	}
+	webDAVReadMethods = map[string]bool{
+		http.MethodGet: true, http.MethodHead: true, http.MethodOptions: true, "PROPFIND": true,
+	}
+)
+
+// registerWebDAV serves the directory of the options over WebDAV below its prefix
+func (d *DevWebServer) registerWebDAV(routes *echo.Group, config *options.WebDAV) error {
+	if config.Username == "" || config.Password == "" {
+		return fmt.Errorf("the WebDAV server requires a Username and a Password")
+	}
+	if info, err := os.Stat(config.Root); err != nil || !info.IsDir() {
+		return fmt.Errorf("the WebDAV root '%s' is not a directory", config.Root)
+	}
+
+	prefix := "/" + strings.Trim(config.Prefix, "/")
+	if prefix == "/" {
+		prefix = defaultWebDAVPrefix
+	}
+	// The file managers of the OS can't send the AuthToken, the Username and Password are required instead
+	d.webDAVPrefix = d.basePath + prefix
+	handler := &webdav.Handler{
+		Prefix:     d.basePath + prefix,
+		FileSystem: webdav.Dir(config.Root),
+		LockSystem: webdav.NewMemLS(),
+		Logger: func(req *http.Request, err error) {
+			if err != nil && !os.IsNotExist(err) {
+				d.logger.Error("WebDAV %s %s: %s", req.Method, req.URL.Path, err.Error())
+			}
+		},
+	}
+	serve := func(c echo.Context) error {
+		req := c.Request()
+		username, password, ok := req.BasicAuth()
+		if !ok || !secureCompare(username, config.Username) || !secureCompare(password, config.Password) {
+			c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Basic realm="Wails WebDAV"`)
+			return c.String(http.StatusUnauthorized, "invalid or missing credentials")
+		}
+		if config.ReadOnly && !webDAVReadMethods[req.Method] {
+			return c.String(http.StatusForbidden, "the WebDAV server is read-only")
+		}
+		handler.ServeHTTP(c.Response(), req)
+		return nil
+	}
+	routes.Match(webDAVMethods, prefix, serve)
+	routes.Match(webDAVMethods, prefix+"/*", serve)
+	return nil
+}
+
+// isWebDAVRequest reports whether the request is for the WebDAV directory, which authMiddleware leaves to the
+// basic authentication of registerWebDAV
+func (d *DevWebServer) isWebDAVRequest(req *http.Request) bool {
+	if d.webDAVPrefix == "" {
+		return false
+	}
+	requestPath := path.Clean(req.URL.Path)
+	return requestPath == d.webDAVPrefix || strings.HasPrefix(requestPath, d.webDAVPrefix+"/")
+}
+
+// secureCompare compares the credentials in constant time
+func secureCompare(given string, expected string) bool {
+	return subtle.ConstantTimeCompare([]byte(given), []byte(expected)) == 1
+}
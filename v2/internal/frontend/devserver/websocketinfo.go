@@ -0,0 +1,428 @@
+package devserver
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var lastClientID uint64
+
+// WebsocketInfo holds the state of a connected IPC websocket client
+type WebsocketInfo struct {
+	// id identifies the client in the admin methods of the DevWebServer
+	id string
+
+	// lock serialises the writes to the connection
+	lock sync.Mutex
+
+	// outbound queues the events for the writer of the client until closed is closed on disconnect
+	outbound        chan outboundMessage
+	closed          chan struct{}
+	droppedMessages atomic.Uint64
+	evicted         atomic.Bool
+
+	// subscriptions are the events the client subscribed to
+	subscriptions *SubscriptionManager
+
+	// callLimiter and eventLimiter are nil when rate limiting is disabled
+	callLimiter   *tokenBucket
+	eventLimiter  *tokenBucket
+	maxDropped    int
+	droppedMutex  sync.Mutex
+	droppedByRate int
+
+	// ctx is passed to the bound methods the client calls. It holds the ID of the client and is
+	// cancelled when the client disconnects.
+	ctx context.Context
+
+	// The parameters negotiated in the handshake, these don't change for the life of the connection
+	connectedAt time.Time
+	subprotocol string
+	compression bool
+	remoteAddr  string
+	userAgent   string
+	// protocolVersion is the version of the IPC protocol the client speaks, see protocol.go
+	protocolVersion int
+	// sessionID identifies the browser tab across reloads and reconnects, unlike the ID of the client
+	sessionID string
+	// owner is the hash of the secret of the browser owning the session, see sessionOwnerOf
+	owner string
+	// missed holds the events emitted after the client disconnected, see replay.go
+	missed *eventRing
+	// disconnected and replayUntil are the times the client disconnected and the missed events are replayed
+	// until in Unix nanoseconds, guarded by the socketMutex of the server
+	disconnected int64
+	replayUntil  int64
+
+	// streams cancel the streams sent to the client by the callback IDs of their calls, see streams.go
+	streamsLock sync.Mutex
+	streams     map[string]context.CancelFunc
+
+	// binaryArguments are the binary arguments received for the next calls, see binaryargs.go
+	binaryArguments binaryArguments
+}
+
+// outboundMessage is a message queued for the writer of a client. The writer sends the result of the write
+// to written, if it is set, see broadcastSync.
+type outboundMessage struct {
+	data []byte
+	// deadline is the write deadline of the message, zero for none
+	deadline time.Time
+	written  chan error
+}
+
+// ClientInfo describes a connected IPC websocket client
+type ClientInfo struct {
+	ID          string
+	ConnectedAt time.Time
+	// Subprotocol is the negotiated subprotocol, empty if none has been negotiated
+	Subprotocol string
+	// Compression reports whether permessage-deflate has been negotiated
+	Compression bool
+	RemoteAddr  string
+	UserAgent   string
+	// ProtocolVersion is the version of the IPC protocol, 2 for clients using envelopes
+	ProtocolVersion int
+	// SessionID is kept by the client across reloads and reconnects
+	SessionID string
+}
+
+func (w *WebsocketInfo) clientInfo() ClientInfo {
+	return ClientInfo{
+		ID:              w.id,
+		ConnectedAt:     w.connectedAt,
+		Subprotocol:     w.subprotocol,
+		Compression:     w.compression,
+		RemoteAddr:      w.remoteAddr,
+		UserAgent:       w.userAgent,
+		ProtocolVersion: w.protocolVersion,
+		SessionID:       w.sessionID,
+	}
+}
+
+// negotiatedCompression reports whether permessage-deflate is used for the connection, which is the
+// case if the server enabled compression and the client offered the extension in the handshake
+func negotiatedCompression(req *http.Request, enabled bool) bool {
+	if !enabled {
+		return false
+	}
+	for _, header := range req.Header.Values("Sec-Websocket-Extensions") {
+		for _, extension := range strings.Split(header, ",") {
+			name, _, _ := strings.Cut(extension, ";")
+			if strings.EqualFold(strings.TrimSpace(name), "permessage-deflate") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+const (
+	// maxClientIDLength is the maximum length of a client provided ID
+	maxClientIDLength = 128
+
+	headerClientID = "X-Wails-Client-ID"
+
+	// maxSessionIDLength is the maximum length of a client provided session ID
+	maxSessionIDLength = 128
+
+	headerSessionID = "X-Wails-Session"
+
+	// sessionCookie holds the secret of the browser owning the sessions, see sessionOwnerOf
+	sessionCookie       = "wails_session"
+	sessionSecretLength = 32
+)
+
+// requestedClientID returns the ID the client asked for in the handshake, if any
+func requestedClientID(req *http.Request) string {
+	clientID := req.URL.Query().Get("clientid")
+	if clientID == "" {
+		clientID = req.Header.Get(headerClientID)
+	}
+	return strings.TrimSpace(clientID)
+}
+
+// sessionIDOf returns the session the client asks to resume, given with the "session" query parameter or the
+// X-Wails-Session header of the handshake, empty if it did not give one
+func sessionIDOf(req *http.Request) (string, error) {
+	sessionID := req.URL.Query().Get("session")
+	if sessionID == "" {
+		sessionID = req.Header.Get(headerSessionID)
+	}
+	sessionID = strings.TrimSpace(sessionID)
+	if len(sessionID) > maxSessionIDLength {
+		return "", fmt.Errorf("session id exceeds %d characters", maxSessionIDLength)
+	}
+	return sessionID, nil
+}
+
+func newSessionID() (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(random), nil
+}
+
+// sessionOwnerOf returns the owner of the sessions of the client, the hash of the secret the server issued to
+// its browser in the HttpOnly session cookie. The session IDs are chosen by the clients, the secret keeps other
+// browsers from resuming them. A new secret is issued with the returned cookie if the client sent none.
+func (d *DevWebServer) sessionOwnerOf(req *http.Request) (string, *http.Cookie, error) {
+	if cookie, err := req.Cookie(sessionCookie); err == nil {
+		if secret, err := hex.DecodeString(cookie.Value); err == nil && len(secret) == sessionSecretLength {
+			return sessionOwner(secret), nil, nil
+		}
+	}
+	secret := make([]byte, sessionSecretLength)
+	if _, err := rand.Read(secret); err != nil {
+		return "", nil, err
+	}
+	cookie := &http.Cookie{
+		Name:     sessionCookie,
+		Value:    hex.EncodeToString(secret),
+		Path:     d.cookiePath(),
+		HttpOnly: true,
+		Secure:   d.usesTLS(),
+		SameSite: http.SameSiteStrictMode,
+	}
+	return sessionOwner(secret), cookie, nil
+}
+
+func sessionOwner(secret []byte) string {
+	hash := sha256.Sum256(secret)
+	return hex.EncodeToString(hash[:])
+}
+
+// reserveSession reserves the requested session for a new client and returns it. A new session is started
+// instead if the client did not request one, if the session is owned by another browser or if another client
+// of its owner is connected to it, e.g. a duplicated tab.
+func (d *DevWebServer) reserveSession(requested string, owner string, stored *sessionState) (string, error) {
+	d.socketMutex.Lock()
+	defer d.socketMutex.Unlock()
+	if requested != "" && !d.connectedSessions[requested] && d.ownsSession(requested, owner, stored) {
+		d.connectedSessions[requested] = true
+		return requested, nil
+	}
+	for {
+		sessionID, err := newSessionID()
+		if err != nil {
+			return "", err
+		}
+		if !d.connectedSessions[sessionID] {
+			d.connectedSessions[sessionID] = true
+			return sessionID, nil
+		}
+	}
+}
+
+// ownsSession reports whether the session is unknown or owned by the owner. The socketMutex must be held.
+func (d *DevWebServer) ownsSession(sessionID string, owner string, stored *sessionState) bool {
+	var previousOwner string
+	switch previous := d.disconnectedSessions[sessionID]; {
+	case previous != nil:
+		previousOwner = previous.owner
+	case stored != nil:
+		previousOwner = stored.Owner
+	default:
+		return true
+	}
+	return subtle.ConstantTimeCompare([]byte(previousOwner), []byte(owner)) == 1
+}
+
+// releaseSession releases the session of a client which did not connect
+func (d *DevWebServer) releaseSession(sessionID string) {
+	d.socketMutex.Lock()
+	defer d.socketMutex.Unlock()
+	delete(d.connectedSessions, sessionID)
+}
+
+// reserveClientID reserves an ID for a new client. Without a requested ID, a numeric ID is assigned.
+// A requested ID already in use is either rejected or suffixed, depending on RejectDuplicateClientIDs.
+func (d *DevWebServer) reserveClientID(requested string) (string, error) {
+	if len(requested) > maxClientIDLength {
+		return "", fmt.Errorf("client id exceeds %d characters", maxClientIDLength)
+	}
+	d.socketMutex.Lock()
+	defer d.socketMutex.Unlock()
+	clientID := requested
+	switch {
+	case clientID == "":
+		for clientID == "" || d.clientIDs[clientID] {
+			clientID = strconv.FormatUint(atomic.AddUint64(&lastClientID, 1), 10)
+		}
+	case d.clientIDs[clientID]:
+		if d.appoptions.WebSocket.RejectDuplicateClientIDs {
+			return "", fmt.Errorf("client id '%s' is already in use", requested)
+		}
+		for suffix := 2; d.clientIDs[clientID]; suffix++ {
+			clientID = requested + "-" + strconv.Itoa(suffix)
+		}
+	}
+	d.clientIDs[clientID] = true
+	return clientID, nil
+}
+
+func (d *DevWebServer) releaseClientID(clientID string) {
+	d.socketMutex.Lock()
+	defer d.socketMutex.Unlock()
+	delete(d.clientIDs, clientID)
+}
+
+func (d *DevWebServer) newWebsocketInfo(clientID string) *WebsocketInfo {
+	opts := d.appoptions.WebSocket
+	info := &WebsocketInfo{
+		id:            clientID,
+		subscriptions: NewSubscriptionManager(),
+		outbound:      make(chan outboundMessage, d.sendQueueSize()),
+		closed:        make(chan struct{}),
+		maxDropped:    opts.RateLimitDisconnectThreshold,
+	}
+	info.binaryArguments.maxSize = maxPendingBinaryBytes
+	if maxMessageSize := d.maxMessageSize(); maxMessageSize > info.binaryArguments.maxSize {
+		info.binaryArguments.maxSize = maxMessageSize
+	}
+	if opts.RateLimit > 0 {
+		info.callLimiter = newTokenBucket(opts.RateLimit, opts.RateLimitBurst)
+	}
+	if opts.EventRateLimit > 0 {
+		info.eventLimiter = newTokenBucket(opts.EventRateLimit, opts.EventRateLimitBurst)
+	} else {
+		// Events share the bucket of the calls if no separate limit has been set
+		info.eventLimiter = info.callLimiter
+	}
+	return info
+}
+
+// allow reports whether the message is within the rate limits of the client
+func (w *WebsocketInfo) allow(message []byte) bool {
+	limiter := w.callLimiter
+	if len(message) > 1 && message[0] == 'E' {
+		limiter = w.eventLimiter
+	}
+	if limiter == nil || limiter.take() {
+		return true
+	}
+	w.droppedMutex.Lock()
+	w.droppedByRate++
+	w.droppedMutex.Unlock()
+	return false
+}
+
+// exceededDropThreshold reports whether the client had more messages dropped than allowed
+func (w *WebsocketInfo) exceededDropThreshold() bool {
+	if w.maxDropped <= 0 {
+		return false
+	}
+	w.droppedMutex.Lock()
+	defer w.droppedMutex.Unlock()
+	return w.droppedByRate >= w.maxDropped
+}
+
+// rateLimitedReply returns the error callback for a rate limited call, so the promise in the
+// frontend is rejected instead of waiting for a timeout. Other messages get no reply.
+func rateLimitedReply(message []byte) string {
+	return errorReply(message, "rate limit exceeded")
+}
+
+// errorReply returns the error callback for a call which could not be dispatched, or an empty string if
+// the message is not a call
+func errorReply(message []byte, errorMessage string) string {
+	callbackID := callbackIDOf(message)
+	if callbackID == "" {
+		return ""
+	}
+	reply, err := json.Marshal(struct {
+		Err        string `json:"error"`
+		CallbackID string `json:"callbackid"`
+	}{
+		Err:        errorMessage,
+		CallbackID: callbackID,
+	})
+	if err != nil {
+		return ""
+	}
+	return "c" + string(reply)
+}
+
+// callbackIDOf returns the callback ID of a call message, or an empty string if the message
+// is not a call or the ID can't be read, e.g. because the call is encrypted
+func callbackIDOf(message []byte) string {
+	if len(message) < 2 || (message[0] != 'C' && message[0] != 'c') {
+		return ""
+	}
+	var call struct {
+		CallbackID string `json:"callbackID"`
+	}
+	if err := json.Unmarshal(message[1:], &call); err != nil {
+		return ""
+	}
+	return call.CallbackID
+}
+
+// tokenBucket is a simple token bucket rate limiter
+type tokenBucket struct {
+	lock     sync.Mutex
+	rate     float64
+	capacity float64
+	tokens   float64
+	last     time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	capacity := float64(burst)
+	if capacity < 1 {
+		capacity = rate
+	}
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &tokenBucket{
+		rate:     rate,
+		capacity: capacity,
+		tokens:   capacity,
+		last:     time.Now(),
+	}
+}
+
+// wait removes a token from the bucket, waiting until one is available or the context is done
+func (t *tokenBucket) wait(ctx context.Context) error {
+	interval := time.Duration(float64(time.Second) / t.rate)
+	for !t.take() {
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	return nil
+}
+
+// take removes a token from the bucket, it returns false if the bucket is empty
+func (t *tokenBucket) take() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	now := time.Now()
+	t.tokens += now.Sub(t.last).Seconds() * t.rate
+	if t.tokens > t.capacity {
+		t.tokens = t.capacity
+	}
+	t.last = now
+	if t.tokens < 1 {
+		return false
+	}
+	t.tokens--
+	return true
+}
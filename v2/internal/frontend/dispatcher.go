@@ -1,5 +1,9 @@
 package frontend
 
+import "context"
+
 type Dispatcher interface {
-	ProcessMessage(message string, sender Frontend) (string, error)
+	// ProcessMessage processes a message of the sender. Bound methods taking a context.Context as their
+	// first parameter are called with ctx, e.g. to identify the client of the call.
+	ProcessMessage(ctx context.Context, message string, sender Frontend) (string, error)
 }
@@ -1,24 +1,66 @@
 package dispatcher
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 
+	"github.com/wailsapp/wails/v2/internal/binding"
 	"github.com/wailsapp/wails/v2/internal/frontend"
 )
 
+// errStreamUnsupported fails the calls of methods returning a stream from frontends which can only send the
+// result at once, like the desktop window
+var errStreamUnsupported = errors.New("streamed results are only supported by the browser clients")
+
 type callMessage struct {
 	Name       string            `json:"name"`
 	Args       []json.RawMessage `json:"args"`
 	CallbackID string            `json:"callbackID"`
 }
 
-func (d *Dispatcher) processCallMessage(message string, sender frontend.Frontend) (string, error) {
+func (d *Dispatcher) processCallMessage(ctx context.Context, message string, sender frontend.Frontend) (string, error) {
+	callbackMessage, err := d.ProcessCall(ctx, message, sender)
+	if err != nil {
+		if callbackMessage == nil {
+			return "", err
+		}
+		// The frontends pass the result of a failed call to the callback as-is, without the "c" prefix
+		result, _ := d.NewErrorCallback(err.Error(), callbackMessage.CallbackID)
+		return result, err
+	}
+	cancelStream(callbackMessage)
+
+	messageData, err := json.Marshal(callbackMessage)
+	d.log.Trace("json call result data: %+v\n", string(messageData))
+	if err != nil {
+		// what now?
+		d.log.Fatal(err.Error())
+	}
+
+	return "c" + string(messageData), nil
+}
+
+// cancelStream replaces a stream result with an error, for the frontends which don't support streams
+func cancelStream(callbackMessage *CallbackMessage) {
+	if reader, ok := callbackMessage.Result.(binding.StreamReader); ok {
+		reader.Cancel()
+		callbackMessage.Result = nil
+		callbackMessage.Err = errStreamUnsupported.Error()
+	}
+}
+
+// ProcessCall processes a call message and returns the callback message before it is marshalled, so
+// frontends can send some results in another format, e.g. []byte results as binary.
+// The result of a method returning a stream is a binding.StreamReader, which the frontend must read or cancel.
+// If the callback message is nil, no reply should be sent.
+func (d *Dispatcher) ProcessCall(ctx context.Context, message string, sender frontend.Frontend) (*CallbackMessage, error) {
 	var payload callMessage
 	err := json.Unmarshal([]byte(message[1:]), &payload)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	var result interface{}
@@ -33,16 +75,24 @@ func (d *Dispatcher) processCallMessage(message string, sender frontend.Frontend
 
 		// Check we have it
 		if registeredMethod == nil {
-			return "", fmt.Errorf("method '%s' not registered", payload.Name)
+			return nil, fmt.Errorf("method '%s' not registered", payload.Name)
 		}
 
 		args, err2 := registeredMethod.ParseArgs(payload.Args)
 		if err2 != nil {
 			errmsg := fmt.Errorf("error parsing arguments: %s", err2.Error())
-			result, _ := d.NewErrorCallback(errmsg.Error(), payload.CallbackID)
-			return result, errmsg
+			return &CallbackMessage{
+				Err:        errmsg.Error(),
+				CallbackID: payload.CallbackID,
+			}, errmsg
 		}
-		result, err = registeredMethod.Call(args)
+		if !registeredMethod.Streams() {
+			// The frontend may cancel the call, the context of a stream is cancelled with the stream
+			callCtx, done := d.calls.start(ctx, payload.CallbackID)
+			defer done()
+			ctx = callCtx
+		}
+		result, err = d.Call(ctx, registeredMethod, args)
 	}
 
 	callbackMessage := &CallbackMessage{
@@ -53,19 +103,12 @@ func (d *Dispatcher) processCallMessage(message string, sender frontend.Frontend
 		if d.errfmt != nil {
 			callbackMessage.Err = d.errfmt(err)
 		} else {
-			callbackMessage.Err = err.Error()
+			callbackMessage.Err = binding.ErrorValue(err)
 		}
 	} else {
 		callbackMessage.Result = result
 	}
-	messageData, err := json.Marshal(callbackMessage)
-	d.log.Trace("json call result data: %+v\n", string(messageData))
-	if err != nil {
-		// what now?
-		d.log.Fatal(err.Error())
-	}
-
-	return "c" + string(messageData), nil
+	return callbackMessage, nil
 }
 
 // CallbackMessage defines a message that contains the result of a call
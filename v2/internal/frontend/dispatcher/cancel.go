@@ -0,0 +1,69 @@
+package dispatcher
+
+import (
+	"context"
+	"sync"
+)
+
+// inflightCalls holds the cancel functions of the calls in progress, so the frontend can cancel the context of
+// a call with the "X" message and the callback ID of the call. The calls are kept by the ID of the client too,
+// as the browser clients of the dev server share the dispatcher and choose the callback IDs themselves.
+type inflightCalls struct {
+	lock    sync.Mutex
+	cancels map[callKey]context.CancelFunc
+}
+
+type callKey struct {
+	clientID   string
+	callbackID string
+}
+
+func newInflightCalls() *inflightCalls {
+	return &inflightCalls{cancels: make(map[callKey]context.CancelFunc)}
+}
+
+// callKeyOf returns the key of the call of the client the context belongs to
+func callKeyOf(ctx context.Context, callbackID string) callKey {
+	var clientID string
+	if ctx != nil {
+		clientID, _ = ctx.Value("clientid").(string)
+	}
+	return callKey{clientID: clientID, callbackID: callbackID}
+}
+
+// start returns the context of the call, which is cancelled by the frontend or by done
+func (c *inflightCalls) start(ctx context.Context, callbackID string) (callCtx context.Context, done func()) {
+	key := callKeyOf(ctx, callbackID)
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	callCtx, cancel := context.WithCancel(ctx)
+	c.lock.Lock()
+	c.cancels[key] = cancel
+	c.lock.Unlock()
+	return callCtx, func() {
+		c.lock.Lock()
+		delete(c.cancels, key)
+		c.lock.Unlock()
+		cancel()
+	}
+}
+
+// cancel cancels the context of the call if it is in progress
+func (c *inflightCalls) cancel(ctx context.Context, callbackID string) {
+	c.lock.Lock()
+	cancel := c.cancels[callKeyOf(ctx, callbackID)]
+	c.lock.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// processCancelMessage cancels the context of the call with the callback ID of the message, a call which has
+// ended is ignored
+func (d *Dispatcher) processCancelMessage(ctx context.Context, message string) (string, error) {
+	if len(message) > 1 {
+		d.calls.cancel(ctx, message[1:])
+	}
+	return "", nil
+}
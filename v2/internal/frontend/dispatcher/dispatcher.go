@@ -17,6 +17,11 @@ type Dispatcher struct {
 	bindingsDB *binding.DB
 	ctx        context.Context
 	errfmt     options.ErrorFormatter
+	calls      *inflightCalls
+
+	interceptors []options.Interceptor
+	tracer       options.Tracer
+	limiter      *callLimiter
 }
 
 func NewDispatcher(ctx context.Context, log *logger.Logger, bindings *binding.Bindings, events frontend.Events, errfmt options.ErrorFormatter) *Dispatcher {
@@ -27,22 +32,27 @@ func NewDispatcher(ctx context.Context, log *logger.Logger, bindings *binding.Bi
 		bindingsDB: bindings.DB(),
 		ctx:        ctx,
 		errfmt:     errfmt,
+		calls:      newInflightCalls(),
 	}
 }
 
-func (d *Dispatcher) ProcessMessage(message string, sender frontend.Frontend) (string, error) {
+func (d *Dispatcher) ProcessMessage(ctx context.Context, message string, sender frontend.Frontend) (result string, err error) {
 	if message == "" {
 		return "", errors.New("No message to process")
 	}
+	ctx, end := startMessageSpan(ctx, d.tracer, message)
+	defer func() { end(err) }()
 	switch message[0] {
 	case 'L':
 		return d.processLogMessage(message)
 	case 'E':
 		return d.processEventMessage(message, sender)
 	case 'C':
-		return d.processCallMessage(message, sender)
+		return d.processCallMessage(ctx, message, sender)
 	case 'c':
-		return d.processSecureCallMessage(message, sender)
+		return d.processSecureCallMessage(ctx, message, sender)
+	case 'X':
+		return d.processCancelMessage(ctx, message)
 	case 'W':
 		return d.processWindowMessage(message, sender)
 	case 'B':
@@ -0,0 +1,40 @@
+package dispatcher
+
+import (
+	"context"
+
+	"github.com/wailsapp/wails/v2/internal/binding"
+	"github.com/wailsapp/wails/v2/pkg/options"
+)
+
+// UseInterceptors sets the interceptors called around the calls of bound methods
+func (d *Dispatcher) UseInterceptors(interceptors []options.Interceptor) {
+	d.interceptors = interceptors
+}
+
+// CallMethod calls the bound method between the Before and After hooks of the interceptors. If a Before hook
+// fails, the method is not called and the hooks are not called after it. The call is traced in a span of the
+// tracer, unless it is nil.
+func CallMethod(ctx context.Context, tracer options.Tracer, interceptors []options.Interceptor, method *binding.BoundMethod, args []interface{}) (result interface{}, err error) {
+	ctx, end := startCallSpan(ctx, tracer, method.Name)
+	defer func() { end(err) }()
+	if len(interceptors) == 0 {
+		return method.Call(ctx, args)
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	call := &options.CallInfo{Method: method.Name, Args: args}
+	call.ClientID, _ = ctx.Value("clientid").(string)
+	call.SessionID, _ = ctx.Value("sessionid").(string)
+	for _, interceptor := range interceptors {
+		if err := interceptor.Before(ctx, call); err != nil {
+			return nil, err
+		}
+	}
+	result, err = method.Call(ctx, call.Args)
+	for index := len(interceptors) - 1; index >= 0; index-- {
+		interceptors[index].After(ctx, call, result, err)
+	}
+	return result, err
+}
@@ -0,0 +1,173 @@
+package dispatcher
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/wailsapp/wails/v2/internal/binding"
+	"github.com/wailsapp/wails/v2/pkg/options"
+)
+
+// BusyError is the error of a call which could not be processed within the CallLimits. It is a CodedError with
+// the code "busy" and the method and the limit which has been reached, "server" or "client", as data.
+type BusyError struct {
+	Method string
+	Limit  string
+}
+
+func (e *BusyError) Error() string {
+	return fmt.Sprintf("call of '%s' rejected: the %s is busy", e.Method, e.Limit)
+}
+
+func (e *BusyError) ErrorCode() string {
+	return "busy"
+}
+
+func (e *BusyError) ErrorData() map[string]interface{} {
+	return map[string]interface{}{
+		"method": e.Method,
+		"limit":  e.Limit,
+	}
+}
+
+// UseCallLimits bounds the number of calls of bound methods which are processed concurrently
+func (d *Dispatcher) UseCallLimits(limits options.CallLimits) {
+	d.limiter = newCallLimiter(limits)
+}
+
+// Call calls the bound method within the CallLimits, between the interceptors and in a span of the telemetry
+func (d *Dispatcher) Call(ctx context.Context, method *binding.BoundMethod, args []interface{}) (interface{}, error) {
+	release, err := d.limiter.acquire(ctx, method.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return CallMethod(ctx, d.tracer, d.interceptors, method, args)
+}
+
+// callLimiter holds the slots of the calls in progress, in total and by client. A call waits in the queue while
+// the slots are taken, it is rejected with a BusyError once the queue is full or it waited for the QueueTimeout.
+// The calls of the desktop window have no client ID, they are only limited in total.
+type callLimiter struct {
+	limits options.CallLimits
+	server chan struct{}
+	queued atomic.Int64
+
+	lock    sync.Mutex
+	clients map[string]*clientSlots
+}
+
+// clientSlots are the slots of a client, they are removed once none of its calls is in progress or waiting
+type clientSlots struct {
+	slots chan struct{}
+	users int
+}
+
+// newCallLimiter returns the limiter of the limits, nil if there are none
+func newCallLimiter(limits options.CallLimits) *callLimiter {
+	if limits.MaxConcurrent <= 0 && limits.MaxConcurrentPerClient <= 0 {
+		return nil
+	}
+	limiter := &callLimiter{limits: limits, clients: make(map[string]*clientSlots)}
+	if limits.MaxConcurrent > 0 {
+		limiter.server = make(chan struct{}, limits.MaxConcurrent)
+	}
+	return limiter
+}
+
+// acquire takes the slots of the call, the returned function releases them once the call has returned
+func (l *callLimiter) acquire(ctx context.Context, method string) (release func(), err error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var deadline <-chan time.Time
+	if l.limits.QueueTimeout > 0 {
+		timer := time.NewTimer(l.limits.QueueTimeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	// The slot of the client is taken first, so a client waiting for its own calls does not hold a slot of the server
+	clientID, _ := ctx.Value("clientid").(string)
+	client := l.client(clientID)
+	if client != nil {
+		if err := l.wait(ctx, client.slots, deadline, &BusyError{Method: method, Limit: "client"}); err != nil {
+			l.releaseClient(clientID, false)
+			return nil, err
+		}
+	}
+	if l.server != nil {
+		if err := l.wait(ctx, l.server, deadline, &BusyError{Method: method, Limit: "server"}); err != nil {
+			if client != nil {
+				l.releaseClient(clientID, true)
+			}
+			return nil, err
+		}
+	}
+	return func() {
+		if l.server != nil {
+			<-l.server
+		}
+		if client != nil {
+			l.releaseClient(clientID, true)
+		}
+	}, nil
+}
+
+// wait takes a slot, waiting in the queue if they are all taken
+func (l *callLimiter) wait(ctx context.Context, slots chan struct{}, deadline <-chan time.Time, busy *BusyError) error {
+	select {
+	case slots <- struct{}{}:
+		return nil
+	default:
+	}
+	if queued := l.queued.Add(1); l.limits.MaxQueued > 0 && queued > int64(l.limits.MaxQueued) {
+		l.queued.Add(-1)
+		return busy
+	}
+	defer l.queued.Add(-1)
+	select {
+	case slots <- struct{}{}:
+		return nil
+	case <-deadline:
+		return busy
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// client returns the slots of the client, nil if the calls of clients are not limited or the client has no ID
+func (l *callLimiter) client(clientID string) *clientSlots {
+	if l.limits.MaxConcurrentPerClient <= 0 || clientID == "" {
+		return nil
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	client := l.clients[clientID]
+	if client == nil {
+		client = &clientSlots{slots: make(chan struct{}, l.limits.MaxConcurrentPerClient)}
+		l.clients[clientID] = client
+	}
+	client.users++
+	return client
+}
+
+// releaseClient releases the slot of the client if it has been taken, and the client if it has no calls anymore
+func (l *callLimiter) releaseClient(clientID string, taken bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	client := l.clients[clientID]
+	if taken {
+		<-client.slots
+	}
+	client.users--
+	if client.users == 0 {
+		delete(l.clients, clientID)
+	}
+}
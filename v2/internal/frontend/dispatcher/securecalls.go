@@ -1,9 +1,11 @@
 package dispatcher
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
+	"github.com/wailsapp/wails/v2/internal/binding"
 	"github.com/wailsapp/wails/v2/internal/frontend"
 )
 
@@ -13,7 +15,7 @@ type secureCallMessage struct {
 	CallbackID string            `json:"callbackID"`
 }
 
-func (d *Dispatcher) processSecureCallMessage(message string, sender frontend.Frontend) (string, error) {
+func (d *Dispatcher) processSecureCallMessage(ctx context.Context, message string, sender frontend.Frontend) (string, error) {
 	var payload secureCallMessage
 	err := json.Unmarshal([]byte(message[1:]), &payload)
 	if err != nil {
@@ -36,16 +38,22 @@ func (d *Dispatcher) processSecureCallMessage(message string, sender frontend.Fr
 		result, _ := d.NewErrorCallback(errmsg.Error(), payload.CallbackID)
 		return result, errmsg
 	}
-	result, err = registeredMethod.Call(args)
+	if !registeredMethod.Streams() {
+		callCtx, done := d.calls.start(ctx, payload.CallbackID)
+		defer done()
+		ctx = callCtx
+	}
+	result, err = d.Call(ctx, registeredMethod, args)
 
 	callbackMessage := &CallbackMessage{
 		CallbackID: payload.CallbackID,
 	}
 	if err != nil {
-		callbackMessage.Err = err.Error()
+		callbackMessage.Err = binding.ErrorValue(err)
 	} else {
 		callbackMessage.Result = result
 	}
+	cancelStream(callbackMessage)
 	messageData, err := json.Marshal(callbackMessage)
 	d.log.Trace("json call result data: %+v\n", string(messageData))
 	if err != nil {
@@ -0,0 +1,113 @@
+package dispatcher
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/wailsapp/wails/v2/pkg/options"
+)
+
+// instrumentationName is the name of the tracer of the spans
+const instrumentationName = "github.com/wailsapp/wails/v2"
+
+// messageTypes are the types of the messages of the frontends in the spans, by their prefix
+var messageTypes = map[byte]string{
+	'L': "log",
+	'E': "event",
+	'C': "call",
+	'c': "securecall",
+	'X': "cancel",
+	'W': "window",
+	'B': "browser",
+	'Q': "quit",
+	'S': "show",
+	'H': "hide",
+}
+
+// NewTracer returns the tracer of the telemetry options, nil if the telemetry is disabled
+func NewTracer(telemetry *options.Telemetry) options.Tracer {
+	if telemetry == nil {
+		return nil
+	}
+	if telemetry.Tracer != nil {
+		return telemetry.Tracer
+	}
+	return otelTracer{tracer: otel.GetTracerProvider().Tracer(instrumentationName)}
+}
+
+// UseTelemetry traces the messages of the frontends and the calls of bound methods with OpenTelemetry spans
+func (d *Dispatcher) UseTelemetry(telemetry *options.Telemetry) {
+	d.tracer = NewTracer(telemetry)
+}
+
+// otelTracer adapts an OpenTelemetry tracer to the Tracer of the options
+type otelTracer struct {
+	tracer trace.Tracer
+}
+
+func (t otelTracer) Start(ctx context.Context, name string, kind options.SpanKind, attributes []options.SpanAttribute) (context.Context, options.Span) {
+	spanKind := trace.SpanKindInternal
+	if kind == options.SpanKindServer {
+		spanKind = trace.SpanKindServer
+	}
+	keyValues := make([]attribute.KeyValue, 0, len(attributes))
+	for _, a := range attributes {
+		keyValues = append(keyValues, attribute.String(a.Key, a.Value))
+	}
+	ctx, span := t.tracer.Start(ctx, name, trace.WithSpanKind(spanKind), trace.WithAttributes(keyValues...))
+	return ctx, otelSpan{span: span}
+}
+
+type otelSpan struct {
+	span trace.Span
+}
+
+func (s otelSpan) End(err error) {
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+	s.span.End()
+}
+
+// startMessageSpan starts the span of the message of a frontend, the returned function ends it with the error of
+// the message. Nothing is traced if the tracer is nil.
+func startMessageSpan(ctx context.Context, tracer options.Tracer, message string) (context.Context, func(error)) {
+	if tracer == nil {
+		return ctx, func(error) {}
+	}
+	messageType := messageTypes[message[0]]
+	if messageType == "" {
+		messageType = "unknown"
+	}
+	return startSpan(ctx, tracer, "wails.message "+messageType, options.SpanKindServer,
+		options.SpanAttribute{Key: "wails.message.type", Value: messageType})
+}
+
+// startCallSpan starts the span of the call of a bound method, which is the parent of the spans of the method
+func startCallSpan(ctx context.Context, tracer options.Tracer, method string) (context.Context, func(error)) {
+	if tracer == nil {
+		return ctx, func(error) {}
+	}
+	return startSpan(ctx, tracer, method, options.SpanKindInternal,
+		options.SpanAttribute{Key: "rpc.system", Value: "wails"},
+		options.SpanAttribute{Key: "rpc.method", Value: method})
+}
+
+func startSpan(ctx context.Context, tracer options.Tracer, name string, kind options.SpanKind, attributes ...options.SpanAttribute) (context.Context, func(error)) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if clientID, _ := ctx.Value("clientid").(string); clientID != "" {
+		attributes = append(attributes, options.SpanAttribute{Key: "wails.client_id", Value: clientID})
+	}
+	if sessionID, _ := ctx.Value("sessionid").(string); sessionID != "" {
+		attributes = append(attributes, options.SpanAttribute{Key: "wails.session_id", Value: sessionID})
+	}
+	ctx, span := tracer.Start(ctx, name, kind, attributes)
+	return ctx, span.End
+}
@@ -140,3 +140,8 @@ type Frontend interface {
 	ClipboardGetText() (string, error)
 	ClipboardSetText(text string) error
 }
+
+// ClientNotifier is implemented by frontends serving several clients, which can notify a single one of them
+type ClientNotifier interface {
+	NotifyClient(clientID string, name string, data ...interface{}) error
+}
@@ -1,9 +1,8 @@
-//go:build !dev
-
 package runtime
 
 var RuntimeAssetsBundle = &RuntimeAssets{
 	desktopIPC:       DesktopIPC,
+	websocketIPC:     WebsocketIPC,
 	runtimeDesktopJS: RuntimeDesktopJS,
 }
 
@@ -0,0 +1,72 @@
+package runtime
+
+import (
+	"regexp"
+	"strings"
+)
+
+// eventSeparators separate the segments of the event names matched by a '+' wildcard
+const eventSeparators = ".:/"
+
+// EventPattern matches the event names subscribed to with a pattern. A '*' matches any sequence of characters,
+// e.g. "download:*" matches all events starting with "download:". A '+' forming a whole segment, delimited by
+// '.', ':' or '/', matches exactly one non-empty segment, e.g. "user.+.updated" matches "user.42.updated" but
+// not "user.42.profile.updated".
+type EventPattern struct {
+	regexp *regexp.Regexp
+}
+
+// IsEventPattern reports whether the event name is a pattern, i.e. it has a wildcard
+func IsEventPattern(eventName string) bool {
+	if strings.Contains(eventName, "*") {
+		return true
+	}
+	for index := range eventName {
+		if isSegmentWildcard(eventName, index) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSegmentWildcard reports whether the character at the index of the pattern is a '+' forming a whole segment
+func isSegmentWildcard(pattern string, index int) bool {
+	return pattern[index] == '+' &&
+		(index == 0 || strings.IndexByte(eventSeparators, pattern[index-1]) >= 0) &&
+		(index == len(pattern)-1 || strings.IndexByte(eventSeparators, pattern[index+1]) >= 0)
+}
+
+// CompileEventPattern returns the matcher of the pattern. The patterns are translated to regular expressions,
+// which match in linear time, as the patterns of the browser clients can't be trusted.
+func CompileEventPattern(pattern string) *EventPattern {
+	var expression strings.Builder
+	expression.WriteString(`(?s)^`)
+	literal := 0
+	for index := 0; index < len(pattern); index++ {
+		var wildcard string
+		switch {
+		case pattern[index] == '*':
+			wildcard = `.*`
+		case isSegmentWildcard(pattern, index):
+			wildcard = `[^` + regexp.QuoteMeta(eventSeparators) + `]+`
+		default:
+			continue
+		}
+		expression.WriteString(regexp.QuoteMeta(pattern[literal:index]))
+		expression.WriteString(wildcard)
+		literal = index + 1
+	}
+	expression.WriteString(regexp.QuoteMeta(pattern[literal:]))
+	expression.WriteString(`$`)
+	return &EventPattern{regexp: regexp.MustCompile(expression.String())}
+}
+
+// Match reports whether the event name matches the pattern
+func (p *EventPattern) Match(eventName string) bool {
+	return p.regexp.MatchString(eventName)
+}
+
+// MatchEventPattern reports whether the event name matches the pattern, see EventPattern
+func MatchEventPattern(pattern string, eventName string) bool {
+	return CompileEventPattern(pattern).Match(eventName)
+}
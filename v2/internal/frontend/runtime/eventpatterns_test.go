@@ -0,0 +1,54 @@
+package runtime_test
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/wailsapp/wails/v2/internal/frontend/runtime"
+)
+
+func TestMatchEventPattern(t *testing.T) {
+	tests := []struct {
+		pattern   string
+		eventName string
+		want      bool
+	}{
+		{"*", "", true},
+		{"*", "anything", true},
+		{"app:*", "app:", true},
+		{"app:*", "app:started", true},
+		{"app:*", "application", false},
+		{"*:changed", "user:changed", true},
+		{"*:changed", "user:changed:again", false},
+		{"app:*:changed", "app:user:changed", true},
+		{"app:*:changed", "app:changed", false},
+		{"a*b*c", "abc", true},
+		{"a*b*c", "axxbyyc", true},
+		{"a*b*c", "acb", false},
+		{"user.+.updated", "user.42.updated", true},
+		{"user.+.updated", "user..updated", false},
+		{"user.+.updated", "user.42.profile.updated", false},
+		{"+:progress", "download:progress", true},
+		{"download/+", "download/42", true},
+		{"download/+", "download/", false},
+		{"+.*", "user.42.updated", true},
+		{"a.b", "a.b", true},
+		{"a.b", "axb", false},
+		{"c++", "c++", true},
+		{"c++", "cxx", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.eventName, func(t *testing.T) {
+			is.New(t).Equal(runtime.MatchEventPattern(tt.pattern, tt.eventName), tt.want)
+		})
+	}
+}
+
+func TestIsEventPattern(t *testing.T) {
+	i := is.New(t)
+	i.True(runtime.IsEventPattern("download:*"))
+	i.True(runtime.IsEventPattern("user.+.updated"))
+	i.True(runtime.IsEventPattern("+"))
+	i.True(!runtime.IsEventPattern("c++"))
+	i.True(!runtime.IsEventPattern("user.updated"))
+}
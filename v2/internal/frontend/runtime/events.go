@@ -29,6 +29,9 @@ type Events struct {
 	// Go event listeners
 	listeners  map[string][]*eventListener
 	notifyLock sync.RWMutex
+
+	// patterns are the event names of the listeners which are patterns, see EventPattern
+	patterns map[string]*EventPattern
 }
 
 func (e *Events) Notify(sender frontend.Frontend, name string, data ...interface{}) {
@@ -69,6 +72,9 @@ func (e *Events) OffAll() {
 	for eventName := range e.listeners {
 		delete(e.listeners, eventName)
 	}
+	for pattern := range e.patterns {
+		delete(e.patterns, pattern)
+	}
 	e.notifyLock.Unlock()
 }
 
@@ -77,11 +83,13 @@ func NewEvents(log Logger) *Events {
 	result := &Events{
 		log:       log,
 		listeners: make(map[string][]*eventListener),
+		patterns:  make(map[string]*EventPattern),
 	}
 	return result
 }
 
-// registerListener provides a means of subscribing to events of type "eventName"
+// registerListener provides a means of subscribing to events of type "eventName", or to the events matching
+// it if it is a pattern, e.g. "download:*"
 func (e *Events) registerListener(eventName string, callback func(...interface{}), counter int) func() {
 	// Create new eventListener
 	thisListener := &eventListener{
@@ -92,6 +100,9 @@ func (e *Events) registerListener(eventName string, callback func(...interface{}
 	e.notifyLock.Lock()
 	// Append the new listener to the listeners slice
 	e.listeners[eventName] = append(e.listeners[eventName], thisListener)
+	if _, exists := e.patterns[eventName]; !exists && IsEventPattern(eventName) {
+		e.patterns[eventName] = CompileEventPattern(eventName)
+	}
 	e.notifyLock.Unlock()
 	return func() {
 		e.notifyLock.Lock()
@@ -111,19 +122,32 @@ func (e *Events) unRegisterListener(eventName string) {
 	e.notifyLock.Lock()
 	// Clear the listeners
 	delete(e.listeners, eventName)
+	delete(e.patterns, eventName)
 	e.notifyLock.Unlock()
 }
 
-// Notify backend for the given event name
+// Notify backend for the given event name, the listeners of the event and of the patterns matching it
 func (e *Events) notifyBackend(eventName string, data ...interface{}) {
 	e.notifyLock.Lock()
 	defer e.notifyLock.Unlock()
 
+	notified := e.notifyListeners(eventName, data)
+	for pattern, matcher := range e.patterns {
+		if pattern != eventName && matcher.Match(eventName) && e.notifyListeners(pattern, data) {
+			notified = true
+		}
+	}
+	if !notified {
+		e.log.Trace("No listeners for event '%s'", eventName)
+	}
+}
+
+// notifyListeners calls the listeners of the event name or pattern, it reports whether there are any
+func (e *Events) notifyListeners(eventName string, data []interface{}) bool {
 	// Get list of event listeners
 	listeners := e.listeners[eventName]
 	if listeners == nil {
-		e.log.Trace("No listeners for event '%s'", eventName)
-		return
+		return false
 	}
 
 	// We have a dirty flag to indicate that there are items to delete
@@ -161,8 +185,10 @@ func (e *Events) notifyBackend(eventName string, data ...interface{}) {
 			e.listeners[eventName] = newListeners
 		} else {
 			delete(e.listeners, eventName)
+			delete(e.patterns, eventName)
 		}
 	}
+	return true
 }
 
 func (e *Events) AddFrontend(appFrontend frontend.Frontend) {
@@ -36,3 +36,34 @@ func Test_EventsOn(t *testing.T) {
 	i.Equal(1, counter)
 
 }
+
+func Test_EventsOnPattern(t *testing.T) {
+	i := is.New(t)
+	l := &mockLogger{}
+	manager := runtime.NewEvents(l)
+
+	var lock sync.Mutex
+	var received []string
+	var wg sync.WaitGroup
+	manager.On("user.+.updated", func(args ...interface{}) {
+		lock.Lock()
+		received = append(received, args[0].(string))
+		lock.Unlock()
+		wg.Done()
+	})
+	wg.Add(1)
+	manager.Emit("user.42.updated", "user.42.updated")
+	manager.Emit("user.42.profile.updated", "user.42.profile.updated")
+	wg.Wait()
+	i.Equal(received, []string{"user.42.updated"})
+
+	// A listener of a pattern expires like the others
+	wg.Add(1)
+	manager.Once("download:*", func(args ...interface{}) {
+		wg.Done()
+	})
+	manager.Emit("download:progress")
+	wg.Wait()
+	manager.Emit("download:done")
+	i.Equal(l.Log, "No listeners for event 'download:done'")
+}
@@ -1,6 +1,3 @@
-//go:build dev
-// +build dev
-
 package runtime
 
 import _ "embed"
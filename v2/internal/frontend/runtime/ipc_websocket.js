@@ -632,7 +632,42 @@
         var ne = {}
             , nt = null
             , j = [];
+        // The event subscriptions of this client by event name or pattern, they are sent again after reconnecting.
+        // Every listener of the runtime subscribes with its own count.
+        var subscriptions = {};
+        function trackSubscription(t) {
+            switch (t.slice(0, 2)) {
+                case "EB":
+                    subscriptions[t.slice(2)] = [t];
+                    return true;
+                case "ES":
+                    var name;
+                    try {
+                        name = JSON.parse(t.slice(2)).name;
+                    } catch (e) {
+                        return false;
+                    }
+                    (subscriptions[name] = subscriptions[name] || []).push(t);
+                    return true;
+                case "EX":
+                    delete subscriptions[t.slice(2)];
+                    return true;
+            }
+            return false
+        }
         window.WailsInvoke = t=>{
+            if (typeof t !== "string") {
+                // A binary argument, sent in order with the calls
+                nt ? nt(t) : j.push(t);
+                return
+            }
+            if (t[0] === "C") {
+                lastCallID = callIDOf(t);
+            }
+            if (trackSubscription(t) && !nt) {
+                // Sent with the other subscriptions once connected
+                return
+            }
             if (!nt) {
                 console.log("Queueing: " + t),
                     j.push(t);
@@ -641,6 +676,37 @@
             nt(t)
         }
         ;
+        // The runtime sends no subscriptions, the desktop window gets all events. Its listeners are subscribed
+        // here instead, each with its own count, 0 for no limit. The runtime is loaded after this script, so its
+        // functions are wrapped once it is set, before the scripts of the page register their listeners.
+        function subscribeListeners(runtime) {
+            if (!runtime || !runtime.EventsOnMultiple || runtime.EventsOnMultiple.subscribes) {
+                return runtime;
+            }
+            var eventsOnMultiple = runtime.EventsOnMultiple;
+            runtime.EventsOnMultiple = function(eventName, callback, maxCallbacks) {
+                var off = eventsOnMultiple(eventName, callback, maxCallbacks);
+                window.WailsInvoke("ES" + JSON.stringify({name: eventName, count: maxCallbacks > 0 ? maxCallbacks : 0}));
+                return off;
+            };
+            runtime.EventsOnMultiple.subscribes = true;
+            runtime.EventsOn = function(eventName, callback) {
+                return runtime.EventsOnMultiple(eventName, callback, -1);
+            };
+            runtime.EventsOnce = function(eventName, callback) {
+                return runtime.EventsOnMultiple(eventName, callback, 1);
+            };
+            return runtime;
+        }
+        var loadedRuntime = subscribeListeners(window.runtime);
+        Object.defineProperty(window, "runtime", {
+            configurable: true,
+            enumerable: true,
+            get: ()=>loadedRuntime,
+            set: runtime=>{
+                loadedRuntime = subscribeListeners(runtime);
+            }
+        });
         window.addEventListener("DOMContentLoaded", ()=>{
                 ne.overlay = new Ct({
                     target: document.body,
@@ -649,6 +715,10 @@
             }
         );
         var d = null, kt;
+        // Reconnect with an exponential backoff, from half a second up to ten seconds between the attempts
+        var minReconnectDelay = 500
+            , maxReconnectDelay = 10000
+            , reconnectDelay = minReconnectDelay;
         window.onbeforeunload = function() {
             d && (d.onclose = function() {}
                 ,
@@ -662,22 +732,31 @@
                 d.send(t)
             }
             ;
+            for (const t in subscriptions)
+                subscriptions[t].forEach(nt);
             for (let t = 0; t < j.length; t++)
                 console.log("sending queued message: " + j[t]),
                     window.WailsInvoke(j[t]);
-            j = []
+            j = [];
+            wrapBindings()
         }
         function oe() {
             D("Connected to backend"),
                 $t(),
                 ie(),
-                clearInterval(kt),
+                clearTimeout(kt),
+                reconnectDelay = minReconnectDelay,
+                failedConnections = 0,
                 d.onclose = re,
                 d.onmessage = se
         }
         function re() {
             D("Disconnected from backend"),
+                endStreams("Disconnected from backend"),
+                cancelledCalls = {},
+                progressHandlers = {},
                 d = null,
+                nt = null,
                 xt(),
                 It()
         }
@@ -685,18 +764,196 @@
         var host = null;
         function Et() {
             get_host();
-            d == null && (d = new WebSocket((protocol.indexOf("https") > -1 ? "wss://" : "ws://") + host + "/wails/ipc"),
+            var protocols = (window.wailsipcconfig || {}).codec === "msgpack" ? ["wails.msgpack"] : [];
+            d == null && (d = new WebSocket((protocol.indexOf("https") > -1 ? "wss://" : "ws://") + host + ipcURL("/wails/ipc", "session=" + encodeURIComponent(sessionID()) + "&apiversion=" + encodeURIComponent((window.wailsipcconfig || {}).apiversion || "")), protocols),
+                    d.binaryType = "arraybuffer",
                     d.onopen = oe,
                     d.onerror = function(t) {
                         return t.stopImmediatePropagation(),
                             t.stopPropagation(),
                             t.preventDefault(),
                             d = null,
+                            failedConnections++,
+                            failedConnections >= eventStreamAfterFailures && (window.wailsipcconfig || {}).eventstream && openEventStream(),
                             !1
                     }
             )
         }
 
+        // The session survives reloads of the tab, which lets the server re-associate the state of the session.
+        // It is kept in the session storage, so that every tab has its own.
+        var sessionKey = "wails-session";
+        function sessionID() {
+            var id = null;
+            try {
+                id = window.sessionStorage.getItem(sessionKey);
+                if (!id) {
+                    id = window.crypto && window.crypto.randomUUID ? window.crypto.randomUUID() : Date.now().toString(36) + Math.random().toString(36).slice(2);
+                    window.sessionStorage.setItem(sessionKey, id)
+                }
+            } catch (e) {
+                // The storage is not available, e.g. in private windows, the server starts a new session
+            }
+            return id || ""
+        }
+
+        // The event stream is the fallback if the websocket can't connect, e.g. because a proxy blocks it. The
+        // messages are received as server-sent events, the calls and events are posted over HTTP.
+        var failedConnections = 0
+            , eventStreamAfterFailures = 3
+            , eventStream = null;
+        function ipcURL(path, query) {
+            var config = window.wailsipcconfig || {};
+            var params = [];
+            query && params.push(query);
+            return (config.basepath || "") + path + (params.length ? "?" + params.join("&") : "")
+        }
+        function openEventStream() {
+            if (eventStream || typeof EventSource === "undefined") {
+                return
+            }
+            clearTimeout(kt);
+            D("Unable to connect the websocket, falling back to the event stream");
+            eventStream = new EventSource(ipcURL("/wails/events"));
+            eventStream.onopen = function() {
+                D("Connected to backend (event stream)"),
+                    $t(),
+                    nt = sendOverHTTP;
+                for (let t = 0; t < j.length; t++)
+                    window.WailsInvoke(j[t]);
+                j = []
+            }
+            ;
+            eventStream.onerror = function() {
+                // The EventSource reconnects by itself
+                nt = null,
+                    xt()
+            }
+            ;
+            eventStream.onmessage = function(t) {
+                se({
+                    data: t.data
+                })
+            }
+        }
+        function sendOverHTTP(t) {
+            switch (t.slice(0, 2)) {
+                case "EE":
+                    fetch(ipcURL("/wails/events", "clientid=" + encodeURIComponent(window.wailsipcclientid || "")), {
+                        method: "POST",
+                        body: t.slice(2)
+                    }).catch(function(e) {
+                        D("Unable to emit the event: " + e)
+                    });
+                    return;
+            }
+            if (t[0] !== "C") {
+                // Subscriptions and diagnostics are not supported by the event stream
+                return
+            }
+            var call = JSON.parse(t.slice(1));
+            var reply = function(result, error) {
+                window.wails.Callback(JSON.stringify({
+                    result: result,
+                    error: error,
+                    callbackid: call.callbackID
+                }))
+            };
+            fetch(ipcURL("/wails/call/" + call.name.split(".").map(encodeURIComponent).join("/")), {
+                method: "POST",
+                headers: {
+                    "Content-Type": "application/json"
+                },
+                body: JSON.stringify(call.args || [])
+            }).then(function(r) {
+                return r.json()
+            }).then(function(r) {
+                reply(r.result, r.error)
+            }).catch(function(e) {
+                reply(null, "" + e)
+            })
+        }
+
+        // upload sends the file in chunks to the upload endpoint, resuming after failed chunks. It resolves with
+        // the ID of the upload once the app has processed it.
+        function upload(file, options) {
+            options = options || {};
+            var chunkSize = options.chunkSize || 8 << 20;
+            var metadata = Object.assign({
+                filename: file.name || ""
+            }, options.metadata || {});
+            var encodedMetadata = Object.keys(metadata).map(function(key) {
+                return key + " " + btoa(unescape(encodeURIComponent(String(metadata[key]))))
+            }).join(",");
+            var headers = function(extra) {
+                return Object.assign({
+                    "Tus-Resumable": "1.0.0"
+                }, extra || {})
+            };
+            var failed = function(r) {
+                return r.text().then(function(text) {
+                    throw new Error("Upload failed (" + r.status + "): " + text)
+                })
+            };
+            // The session proves the ID of the client, see the Upload option
+            return fetch(ipcURL("/wails/upload", "clientid=" + encodeURIComponent(window.wailsipcclientid || "") + "&session=" + encodeURIComponent(sessionID())), {
+                method: "POST",
+                headers: headers({
+                    "Upload-Length": String(file.size),
+                    "Upload-Metadata": encodedMetadata
+                })
+            }).then(function(r) {
+                if (r.status !== 201) {
+                    return failed(r)
+                }
+                var id = r.headers.get("Location").split("/").pop();
+                var uploadURL = ipcURL("/wails/upload/" + id);
+                var retries = 0;
+                function resume(error) {
+                    if (++retries > 3) {
+                        throw error
+                    }
+                    return new Promise(function(resolve) {
+                        setTimeout(resolve, 1000 * retries)
+                    }).then(function() {
+                        return fetch(uploadURL, {
+                            method: "HEAD",
+                            headers: headers()
+                        })
+                    }).then(function(r) {
+                        if (!r.ok) {
+                            throw error
+                        }
+                        return send(Number(r.headers.get("Upload-Offset")))
+                    })
+                }
+                function send(offset) {
+                    options.onProgress && options.onProgress(offset, file.size);
+                    if (offset >= file.size) {
+                        return id
+                    }
+                    return fetch(uploadURL, {
+                        method: "PATCH",
+                        headers: headers({
+                            "Upload-Offset": String(offset),
+                            "Content-Type": "application/offset+octet-stream"
+                        }),
+                        body: file.slice(offset, offset + chunkSize)
+                    }).then(function(r) {
+                        if (r.status === 204) {
+                            retries = 0;
+                            return send(Number(r.headers.get("Upload-Offset")))
+                        }
+                        if (r.status === 409 || r.status === 400) {
+                            return resume(new Error("Upload failed (" + r.status + ")"))
+                        }
+                        return failed(r)
+                    }, resume)
+                }
+                return send(0)
+            })
+        }
+
         function get_host() {
             if (host) {
                 return
@@ -717,24 +974,598 @@
             }
         }
         function It() {
+            if (eventStream) {
+                return
+            }
             Et(),
-                kt = setInterval(Et, 500)
+                kt = setTimeout(It, reconnectDelay),
+                reconnectDelay = Math.min(reconnectDelay * 2, maxReconnectDelay)
+        }
+        // The listeners of patterns, e.g. EventsOn("download:*"), get the events matching them, which the server
+        // only sends if the client subscribed to them. A '*' matches any characters, a '+' segment delimited by
+        // '.', ':' or '/' matches a single segment, e.g. "user.+.updated" matches "user.42.updated".
+        var eventSeparators = ".:/";
+        var eventPatterns = {};
+        function isSegmentWildcard(pattern, index) {
+            return pattern[index] === "+" &&
+                (index === 0 || eventSeparators.includes(pattern[index - 1])) &&
+                (index === pattern.length - 1 || eventSeparators.includes(pattern[index + 1]));
+        }
+        function isEventPattern(name) {
+            for (let index = 0; index < name.length; index++) {
+                if (name[index] === "*" || isSegmentWildcard(name, index)) {
+                    return true;
+                }
+            }
+            return false;
+        }
+        function eventPattern(pattern) {
+            if (!eventPatterns[pattern]) {
+                let expression = "";
+                for (let index = 0; index < pattern.length; index++) {
+                    if (pattern[index] === "*") {
+                        expression += ".*";
+                    } else if (isSegmentWildcard(pattern, index)) {
+                        expression += "[^.:/]+";
+                    } else {
+                        expression += pattern[index].replace(/[.*+?^${}()|[\]\\\/]/g, "\\$&");
+                    }
+                }
+                eventPatterns[pattern] = new RegExp("^" + expression + "$", "s");
+            }
+            return eventPatterns[pattern];
+        }
+        // The reliable events have an "ackid", the client acknowledges their receipt with "EA<ackid>", even if
+        // they are stale, see runtime.EventsEmitReliable
+        function notifyEvent(data) {
+            let event;
+            try {
+                event = JSON.parse(data);
+            } catch (e) {
+                window.wails.EventsNotify(data);
+                return;
+            }
+            if (event.ackid) {
+                window.WailsInvoke("EA" + event.ackid);
+            }
+            if (isStaleEvent(event)) {
+                return;
+            }
+            window.wails.EventsNotify(data);
+            const listeners = window.wails.eventListeners;
+            for (const pattern in listeners) {
+                if (pattern === event.name || !isEventPattern(pattern) || !eventPattern(pattern).test(event.name)) {
+                    continue;
+                }
+                const remaining = listeners[pattern].filter(listener => !listener.Callback(event.data));
+                if (remaining.length === 0) {
+                    window.runtime.EventsOff(pattern);
+                } else {
+                    listeners[pattern] = remaining;
+                }
+            }
         }
+        var lastEventTimestamps = {};
+        function isStaleEvent(event) {
+            if (!event.lww || !event.timestamp) {
+                return false;
+            }
+            if (lastEventTimestamps[event.name] > event.timestamp) {
+                return true;
+            }
+            lastEventTimestamps[event.name] = event.timestamp;
+            return false;
+        }
+        function fetchEventReference(data) {
+            let reference;
+            try {
+                reference = JSON.parse(data);
+            } catch (e) {
+                D("Invalid event reference: " + data);
+                return;
+            }
+            fetch(protocol + "//" + host + reference.url).then(r => {
+                if (!r.ok) {
+                    throw new Error(r.status + " " + r.statusText);
+                }
+                return r.text();
+            }).then(event => {
+                notifyEvent(event);
+            }).catch(e => D("Unable to fetch event '" + reference.name + "': " + e));
+        }
+        // decodeMsgpack decodes the MessagePack encoded results of the calls, binary data is decoded to an ArrayBuffer
+        function decodeMsgpack(bytes) {
+            const view = new DataView(bytes.buffer, bytes.byteOffset, bytes.byteLength);
+            const textDecoder = new TextDecoder();
+            let offset = 0;
+            function str(length) {
+                const value = textDecoder.decode(bytes.subarray(offset, offset + length));
+                offset += length;
+                return value;
+            }
+            function bin(length) {
+                const value = bytes.slice(offset, offset + length).buffer;
+                offset += length;
+                return value;
+            }
+            function array(length) {
+                const value = new Array(length);
+                for (let i = 0; i < length; i++) {
+                    value[i] = next();
+                }
+                return value;
+            }
+            function map(length) {
+                const value = {};
+                for (let i = 0; i < length; i++) {
+                    const key = next();
+                    value[key] = next();
+                }
+                return value;
+            }
+            function read(size, getter) {
+                const value = view[getter](offset);
+                offset += size;
+                return value;
+            }
+            function next() {
+                const type = bytes[offset++];
+                if (type < 0x80) return type;
+                if (type < 0x90) return map(type & 0x0f);
+                if (type < 0xa0) return array(type & 0x0f);
+                if (type < 0xc0) return str(type & 0x1f);
+                if (type >= 0xe0) return type - 0x100;
+                switch (type) {
+                    case 0xc0: return null;
+                    case 0xc2: return false;
+                    case 0xc3: return true;
+                    case 0xc4: return bin(read(1, "getUint8"));
+                    case 0xc5: return bin(read(2, "getUint16"));
+                    case 0xc6: return bin(read(4, "getUint32"));
+                    case 0xca: return read(4, "getFloat32");
+                    case 0xcb: return read(8, "getFloat64");
+                    case 0xcc: return read(1, "getUint8");
+                    case 0xcd: return read(2, "getUint16");
+                    case 0xce: return read(4, "getUint32");
+                    case 0xcf: return Number(read(8, "getBigUint64"));
+                    case 0xd0: return read(1, "getInt8");
+                    case 0xd1: return read(2, "getInt16");
+                    case 0xd2: return read(4, "getInt32");
+                    case 0xd3: return Number(read(8, "getBigInt64"));
+                    case 0xd9: return str(read(1, "getUint8"));
+                    case 0xda: return str(read(2, "getUint16"));
+                    case 0xdb: return str(read(4, "getUint32"));
+                    case 0xdc: return array(read(2, "getUint16"));
+                    case 0xdd: return array(read(4, "getUint32"));
+                    case 0xde: return map(read(2, "getUint16"));
+                    case 0xdf: return map(read(4, "getUint32"));
+                }
+                throw new Error("Unsupported MessagePack type 0x" + type.toString(16));
+            }
+            return next();
+        }
+        function msgpackCallback(bytes) {
+            let message;
+            try {
+                message = decodeMsgpack(bytes);
+            } catch (e) {
+                D("Invalid MessagePack callback: " + e.message);
+                return;
+            }
+            const callbackData = window.wails.callbacks[message.callbackid];
+            if (!callbackData) {
+                D("Callback '" + message.callbackid + "' not registered");
+                return;
+            }
+            clearTimeout(callbackData.timeoutHandle);
+            delete window.wails.callbacks[message.callbackid];
+            message.error ? callbackData.reject(message.error) : callbackData.resolve(message.result);
+        }
+        function binaryCallback(data) {
+            // "c", the callback ID, a zero byte and the result of the call
+            const bytes = new Uint8Array(data);
+            if (bytes[0] === 77) {
+                // "M" and the MessagePack encoded callback message
+                msgpackCallback(bytes.subarray(1));
+                return;
+            }
+            const separator = bytes.indexOf(0, 1);
+            if (bytes[0] !== 99 || separator < 0) {
+                D("Unknown binary message");
+                return;
+            }
+            const callbackID = new TextDecoder().decode(bytes.subarray(1, separator));
+            const callbackData = window.wails.callbacks[callbackID];
+            if (!callbackData) {
+                D("Callback '" + callbackID + "' not registered");
+                return;
+            }
+            clearTimeout(callbackData.timeoutHandle);
+            delete window.wails.callbacks[callbackID];
+            callbackData.resolve(data.slice(separator + 1));
+        }
+        // Calls are cancelled with the "X" message and their callback ID, which cancels the context of the bound
+        // method. The callback ID of a call is taken from its message, which the runtime sends when the call starts.
+        var lastCallID = null;
+        var cancelledCalls = {};
+        function callIDOf(message) {
+            const key = '"callbackID":"';
+            const start = message.lastIndexOf(key);
+            if (start < 0) {
+                return null;
+            }
+            const end = message.indexOf('"', start + key.length);
+            return end < 0 ? null : message.slice(start + key.length, end);
+        }
+        function cancelCall(callbackID) {
+            if (streams[callbackID]) {
+                streams[callbackID].iterator.return();
+                return;
+            }
+            const callbackData = window.wails.callbacks[callbackID];
+            if (!callbackData) {
+                return;
+            }
+            clearTimeout(callbackData.timeoutHandle);
+            delete window.wails.callbacks[callbackID];
+            cancelledCalls[callbackID] = true;
+            window.WailsInvoke("X" + callbackID);
+            callbackData.reject(new Error("Call cancelled"));
+        }
+        // cancellable wraps a bound method, e.g. window.go.main.App.Search, so the promises of its calls can be
+        // cancelled with cancel(). Cancelling a stream result stops the stream.
+        function cancellable(method) {
+            return function() {
+                lastCallID = null;
+                const promise = method.apply(this, arguments);
+                const callbackID = lastCallID;
+                promise.cancel = function() {
+                    callbackID !== null && cancelCall(callbackID);
+                };
+                return promise;
+            };
+        }
+        // isCancelledCallReply reports whether the reply is the one of a cancelled call, which is dropped
+        function isCancelledCallReply(data) {
+            for (const callbackID in cancelledCalls) {
+                if (data.indexOf('"callbackid":"' + callbackID + '"') >= 0) {
+                    delete cancelledCalls[callbackID];
+                    return true;
+                }
+            }
+            return false;
+        }
+        // The stream results of calls by callback ID. The promise of the call is resolved with an async iterator
+        // at the start of the stream, which yields the values until the end, e.g. for await (const v of await Go())
+        var streams = {};
+        function openStream(callbackID) {
+            const stream = {values: [], waiting: [], ended: false, error: null};
+            stream.settle = function() {
+                while (stream.waiting.length > 0) {
+                    if (stream.values.length > 0) {
+                        stream.waiting.shift().resolve({value: stream.values.shift(), done: false});
+                    } else if (stream.error !== null) {
+                        stream.waiting.shift().reject(stream.error);
+                        stream.error = null;
+                    } else if (stream.ended) {
+                        stream.waiting.shift().resolve({value: undefined, done: true});
+                    } else {
+                        return;
+                    }
+                }
+            };
+            streams[callbackID] = stream;
+            return stream.iterator = {
+                next: function() {
+                    return new Promise((resolve, reject) => {
+                        stream.waiting.push({resolve: resolve, reject: reject});
+                        stream.settle();
+                    });
+                },
+                return: function() {
+                    // Breaking out of the loop cancels the stream
+                    if (!stream.ended) {
+                        stream.ended = true;
+                        delete streams[callbackID];
+                        window.WailsInvoke("SX" + callbackID);
+                    }
+                    stream.values = [];
+                    stream.settle();
+                    return Promise.resolve({value: undefined, done: true});
+                },
+                [Symbol.asyncIterator]: function() {
+                    return this;
+                }
+            };
+        }
+        function streamMessage(data) {
+            let message;
+            try {
+                message = JSON.parse(data);
+            } catch (e) {
+                D("Invalid stream message: " + data);
+                return;
+            }
+            if (message.type === "start") {
+                const callbackData = window.wails.callbacks[message.callbackid];
+                if (!callbackData) {
+                    cancelledCalls[message.callbackid] || D("Callback '" + message.callbackid + "' not registered");
+                    delete cancelledCalls[message.callbackid];
+                    window.WailsInvoke("SX" + message.callbackid);
+                    return;
+                }
+                clearTimeout(callbackData.timeoutHandle);
+                delete window.wails.callbacks[message.callbackid];
+                callbackData.resolve(openStream(message.callbackid));
+                return;
+            }
+            const stream = streams[message.callbackid];
+            if (!stream) {
+                return;
+            }
+            if (message.type === "value") {
+                stream.values.push(message.value);
+            } else {
+                stream.ended = true;
+                stream.error = message.error ? message.error : null;
+                delete streams[message.callbackid];
+            }
+            stream.settle();
+        }
+        function endStreams(error) {
+            for (const callbackID in streams) {
+                streams[callbackID].ended = true;
+                streams[callbackID].error = error;
+                streams[callbackID].settle();
+            }
+            streams = {};
+        }
+        // The bindings are pushed by the server when methods are bound or unbound at runtime. The methods which
+        // are not bound anymore are removed from window.go, the new ones are added.
+        var bindingCallID = 0;
+        function callBinding(name, args) {
+            return new Promise((resolve, reject) => {
+                const callbackID = name + "-bound-" + (++bindingCallID);
+                window.wails.callbacks[callbackID] = {resolve: resolve, reject: reject};
+                window.WailsInvoke("C" + JSON.stringify({name: name, args: args, callbackID: callbackID}));
+            });
+        }
+        function setBindings(data) {
+            let bindings;
+            try {
+                bindings = JSON.parse(data);
+            } catch (e) {
+                D("Invalid bindings: " + data);
+                return;
+            }
+            window.go = window.go || {};
+            for (const packageName in window.go) {
+                for (const structName in window.go[packageName]) {
+                    for (const methodName in window.go[packageName][structName]) {
+                        const bound = bindings[packageName] && bindings[packageName][structName];
+                        if (!bound || !bound[methodName]) {
+                            delete window.go[packageName][structName][methodName];
+                        }
+                    }
+                }
+            }
+            for (const packageName in bindings) {
+                window.go[packageName] = window.go[packageName] || {};
+                for (const structName in bindings[packageName]) {
+                    window.go[packageName][structName] = window.go[packageName][structName] || {};
+                    for (const methodName in bindings[packageName][structName]) {
+                        if (window.go[packageName][structName][methodName]) {
+                            continue;
+                        }
+                        const name = [packageName, structName, methodName].join(".");
+                        window.go[packageName][structName][methodName] = function() {
+                            return callBinding(name, [].slice.call(arguments));
+                        };
+                    }
+                }
+            }
+            wrapBindings();
+        }
+        // The Uint8Array and ArrayBuffer arguments of the bound methods are sent as binary messages before the
+        // call, which references them with {"$binary":"<reference>"}. This avoids the overhead of base64 for e.g.
+        // images passed to a method taking a []byte. Only the arguments themselves are sent this way, binary data
+        // nested in objects is still encoded in JSON.
+        var binaryArgumentID = 0;
+        function binaryArgument(arg) {
+            let bytes;
+            if (arg instanceof ArrayBuffer) {
+                bytes = new Uint8Array(arg);
+            } else if (ArrayBuffer.isView(arg)) {
+                bytes = new Uint8Array(arg.buffer, arg.byteOffset, arg.byteLength);
+            } else {
+                return arg;
+            }
+            const reference = String(++binaryArgumentID);
+            const prefix = new TextEncoder().encode("a" + reference + "\0");
+            const message = new Uint8Array(prefix.length + bytes.length);
+            message.set(prefix);
+            message.set(bytes, prefix.length);
+            window.WailsInvoke(message);
+            return {"$binary": reference};
+        }
+        // The progress updates of a call, reported by a method taking a runtime.Progress, are passed to the
+        // handler given to onProgress of its promise, e.g. window.go.main.App.Import(paths).onProgress(cb).
+        var progressHandlers = {};
+        function wrapMethod(method) {
+            if (method.wailsWrapped) {
+                return method;
+            }
+            const wrapped = function() {
+                lastCallID = null;
+                const promise = method.apply(this, [].slice.call(arguments).map(binaryArgument));
+                const callbackID = lastCallID;
+                if (promise && callbackID !== null) {
+                    promise.onProgress = function(handler) {
+                        progressHandlers[callbackID] = handler;
+                        const done = () => delete progressHandlers[callbackID];
+                        promise.then(done, done);
+                        return promise;
+                    };
+                }
+                return promise;
+            };
+            wrapped.wailsWrapped = true;
+            return wrapped;
+        }
+        function progressMessage(data) {
+            let update;
+            try {
+                update = JSON.parse(data);
+            } catch (e) {
+                D("Invalid progress update: " + data);
+                return;
+            }
+            const handler = progressHandlers[update.callbackid];
+            delete update.callbackid;
+            handler && handler(update);
+        }
+        // window.go.$batch calls the methods in one message and resolves with their results once all have
+        // returned, e.g. window.go.$batch([["main.App.Stats"], ["main.App.User", 7]]). It fails if one of the
+        // calls fails, like Promise.all.
+        var batchCallID = 0;
+        function batch(calls) {
+            const promises = [];
+            const payload = calls.map(call => {
+                const callbackID = "batch-" + (++batchCallID);
+                promises.push(new Promise((resolve, reject) => {
+                    window.wails.callbacks[callbackID] = {resolve: resolve, reject: reject};
+                }));
+                return {name: call[0], args: call.slice(1), callbackID: callbackID};
+            });
+            window.WailsInvoke("C" + JSON.stringify(payload));
+            return Promise.all(promises);
+        }
+        function batchCallback(data) {
+            let callbacks;
+            try {
+                callbacks = JSON.parse(data);
+            } catch (e) {
+                D("Invalid batch results: " + data);
+                return;
+            }
+            for (const callback of callbacks) {
+                window.wails.Callback(JSON.stringify(callback));
+            }
+        }
+        function wrapBindings() {
+            window.go = window.go || {};
+            Object.defineProperty(window.go, "$batch", {value: batch, configurable: true});
+            const bindings = window.go;
+            for (const packageName in bindings) {
+                for (const structName in bindings[packageName]) {
+                    const methods = bindings[packageName][structName];
+                    for (const methodName in methods) {
+                        if (typeof methods[methodName] === "function") {
+                            methods[methodName] = wrapMethod(methods[methodName]);
+                        }
+                    }
+                }
+            }
+        }
+        window.addEventListener("DOMContentLoaded", wrapBindings);
+        // Diagnostics queries are answered by the dev server itself, e.g. window.wailsdevserver.version()
+        var diagnosticsID = 0;
+        var diagnosticsQueries = {};
+        function diagnosticsQuery(query) {
+            return new Promise((resolve, reject) => {
+                const id = String(++diagnosticsID);
+                diagnosticsQueries[id] = {resolve: resolve, reject: reject};
+                window.WailsInvoke("D" + JSON.stringify({query: query, id: id}));
+            });
+        }
+        function diagnosticsReply(data) {
+            let reply;
+            try {
+                reply = JSON.parse(data);
+            } catch (e) {
+                D("Invalid diagnostics reply: " + data);
+                return;
+            }
+            const query = diagnosticsQueries[reply.id];
+            if (!query) {
+                return;
+            }
+            delete diagnosticsQueries[reply.id];
+            reply.error ? query.reject(new Error(reply.error)) : query.resolve(reply.result);
+        }
+        window.wailsdevserver = {
+            version: () => diagnosticsQuery("version"),
+            bindings: () => diagnosticsQuery("bindings"),
+            upload: upload,
+            cancellable: cancellable
+        };
+        // runtime.Bindings() returns the catalogue of the bound methods, with the names and the Go types of their
+        // parameters and results, e.g. for admin UIs and debugging consoles. The runtime is loaded after this
+        // script, so it is added to it once the page has been loaded.
+        window.addEventListener("DOMContentLoaded", () => {
+            if (window.runtime) {
+                window.runtime.Bindings = window.wailsdevserver.bindings;
+            }
+        });
+        var ipcConfig = window.wailsipcconfig || {};
+        var reloadMessage = ipcConfig.reload || "reload";
+        var reloadAppMessage = ipcConfig.reloadapp || "reloadapp";
         function se(t) {
-            if (t.data === "reload") {
+            if (t.data instanceof ArrayBuffer) {
+                binaryCallback(t.data);
+                return
+            }
+            if (t.data === reloadMessage) {
                 window.runtime.WindowReload();
                 return
             }
-            if (t.data === "reloadapp") {
+            if (t.data === reloadAppMessage) {
                 window.runtime.WindowReloadApp();
                 return
             }
+            if (t.data === "server-closing") {
+                D("The server is shutting down");
+                return
+            }
             switch (t.data[0]) {
                 case "n":
-                    window.wails.EventsNotify(t.data.slice(1));
+                    notifyEvent(t.data.slice(1));
+                    break;
+                case "r":
+                    fetchEventReference(t.data.slice(1));
+                    break;
+                case "k":
+                    // Keep-alive, nothing to do
+                    break;
+                case "d":
+                    diagnosticsReply(t.data.slice(1));
+                    break;
+                case "s":
+                    streamMessage(t.data.slice(1));
+                    break;
+                case "p":
+                    progressMessage(t.data.slice(1));
+                    break;
+                case "b":
+                    setBindings(t.data.slice(1));
+                    break;
+                case "i":
+                    if (t.data.startsWith("id")) {
+                        // The ID of this connection, the "sender" of the events emitted by this client
+                        window.wailsipcclientid = t.data.slice(2);
+                        break;
+                    }
+                    D("Unknown message: " + t.data);
                     break;
                 case "c":
                     let e = t.data.slice(1);
+                    if (e[0] === "[") {
+                        batchCallback(e);
+                        break;
+                    }
+                    if (isCancelledCallReply(e)) {
+                        break;
+                    }
                     window.wails.Callback(e);
                     break;
                 default:
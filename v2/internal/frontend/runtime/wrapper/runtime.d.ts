@@ -32,6 +32,20 @@ export interface EnvironmentInfo {
     arch: string;
 }
 
+// A parameter or a result of a bound method, with its Go type
+export interface BindingParameter {
+    name?: string;
+    type: string;
+}
+
+// A bound method, as it is called: "<package>.<struct>.<method>"
+export interface BoundMethod {
+    name: string;
+    inputs: BindingParameter[];
+    outputs: BindingParameter[];
+    comments?: string;
+}
+
 // [EventsEmit](https://wails.io/docs/reference/runtime/events#eventsemit)
 // emits the given event. Optional data may be passed with the event.
 // This will trigger any event listeners.
@@ -233,3 +247,8 @@ export function ClipboardGetText(): Promise<string>;
 // [ClipboardSetText](https://wails.io/docs/reference/runtime/clipboard#clipboardsettext)
 // Sets a text on the clipboard
 export function ClipboardSetText(text: string): Promise<boolean>;
+
+// Bindings
+// Returns the catalogue of the bound methods, with the Go types of their parameters and results.
+// Only available in the browser, when the app is served by the dev server, the promise is rejected otherwise.
+export function Bindings(): Promise<BoundMethod[]>;
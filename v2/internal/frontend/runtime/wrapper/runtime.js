@@ -203,4 +203,11 @@ export function ClipboardGetText() {
 
 export function ClipboardSetText(text) {
     return window.runtime.ClipboardSetText(text);
-}
\ No newline at end of file
+}
+export function Bindings() {
+    // Only the IPC of the dev server implements it, the desktop runtime does not know the Go types
+    if (!window.runtime.Bindings) {
+        return Promise.reject(new Error("Bindings is only available in the browser, when the app is served by the dev server"));
+    }
+    return window.runtime.Bindings();
+}
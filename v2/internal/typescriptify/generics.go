@@ -0,0 +1,83 @@
+package typescriptify
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+var (
+	// typeQualifierRegex matches the import path and the package of a qualified type name, e.g. "example.com/app/models."
+	typeQualifierRegex = regexp.MustCompile(`(?:[\w.\-]+/)*\w+\.`)
+	nonIdentifierRegex = regexp.MustCompile(`\W+`)
+)
+
+// GenericTypeName returns the type name with the instantiated generic types monomorphized, as TS classes can't
+// have the type arguments of Go in their name: the type arguments are appended to the name of the generic type
+// without their package, e.g. "models.Page[example.com/app/models.User]" is "models.Page_User" and
+// "Pair[string,[]int]" is "Pair_string_Arrayint". Other type names are returned as is.
+func GenericTypeName(typeName string) string {
+	for {
+		open := typeArgumentsStart(typeName)
+		if open < 0 {
+			return typeName
+		}
+		end := matchingBracket(typeName, open)
+		if end < 0 {
+			return typeName
+		}
+		args := typeQualifierRegex.ReplaceAllString(typeName[open+1:end], "")
+		args = strings.ReplaceAll(args, "[]", "Array")
+		args = strings.Trim(nonIdentifierRegex.ReplaceAllString(args, "_"), "_")
+		typeName = typeName[:open] + "_" + args + typeName[end+1:]
+	}
+}
+
+// typeName returns the name of the type, monomorphized if it is an instantiated generic type
+func typeName(typ reflect.Type) string {
+	return GenericTypeName(typ.Name())
+}
+
+// typeString returns the qualified name of the type, monomorphized if it is an instantiated generic type
+func typeString(typ reflect.Type) string {
+	return GenericTypeName(typ.String())
+}
+
+// typeArgumentsStart returns the index of the bracket opening the type arguments of a generic type, the
+// brackets of slices, arrays and maps are skipped. It is -1 if there is none.
+func typeArgumentsStart(typeName string) int {
+	for index := 1; index < len(typeName); index++ {
+		if typeName[index] != '[' || !isIdentifierChar(typeName[index-1]) {
+			continue
+		}
+		start := index
+		for start > 0 && isIdentifierChar(typeName[start-1]) {
+			start--
+		}
+		if typeName[start:index] != "map" {
+			return index
+		}
+	}
+	return -1
+}
+
+// matchingBracket returns the index of the bracket closing the one at open, -1 if there is none
+func matchingBracket(typeName string, open int) int {
+	depth := 0
+	for index := open; index < len(typeName); index++ {
+		switch typeName[index] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return index
+			}
+		}
+	}
+	return -1
+}
+
+func isIdentifierChar(c byte) bool {
+	return c == '_' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -20,6 +20,7 @@ import (
 const (
 	tsTransformTag      = "ts_transform"
 	tsType              = "ts_type"
+	wailsTag            = "wails"
 	tsConvertValuesFunc = `convertValues(a: any, classs: any, asMap: boolean = false): any {
 	if (!a) {
 		return a;
@@ -156,15 +157,19 @@ func (t *TypeScriptify) deepFields(typeOf reflect.Type) []reflect.StructField {
 		f := typeOf.Field(i)
 		kind := f.Type.Kind()
 		isPointer := kind == reflect.Ptr && f.Type.Elem().Kind() == reflect.Struct
-		if f.Anonymous && kind == reflect.Struct {
+		// Like encoding/json, embedded structs with a name in their json tag are not flattened
+		jsonTag := t.getJSONFieldName(f, isPointer)
+		embedded := f.Anonymous && (f.Tag.Get("json") == "" || strings.HasPrefix(f.Tag.Get("json"), ","))
+		if f.Tag.Get("json") == "-" {
+			continue
+		} else if embedded && kind == reflect.Struct {
 			// fmt.Println(v.Interface())
 			fields = append(fields, t.deepFields(f.Type)...)
-		} else if f.Anonymous && isPointer {
+		} else if embedded && isPointer {
 			// fmt.Println(v.Interface())
 			fields = append(fields, t.deepFields(f.Type.Elem())...)
 		} else {
 			// Check we have a json tag
-			jsonTag := t.getJSONFieldName(f, isPointer)
 			if jsonTag != "" {
 				fields = append(fields, f)
 			}
@@ -261,18 +266,18 @@ func (t *TypeScriptify) AddType(typeOf reflect.Type) *TypeScriptify {
 func (t *typeScriptClassBuilder) AddMapField(fieldName string, field reflect.StructField) {
 	keyType := field.Type.Key()
 	valueType := field.Type.Elem()
-	valueTypeName := valueType.Name()
+	valueTypeName := typeName(valueType)
 	if name, ok := t.types[valueType.Kind()]; ok {
 		valueTypeName = name
 	}
 	if valueType.Kind() == reflect.Array || valueType.Kind() == reflect.Slice {
-		valueTypeName = valueType.Elem().Name() + "[]"
+		valueTypeName = typeName(valueType.Elem()) + "[]"
 	}
 	if valueType.Kind() == reflect.Ptr {
-		valueTypeName = valueType.Elem().Name()
+		valueTypeName = typeName(valueType.Elem())
 	}
 	if valueType.Kind() == reflect.Struct && differentNamespaces(t.namespace, valueType) {
-		valueTypeName = valueType.String()
+		valueTypeName = typeString(valueType)
 	}
 	strippedFieldName := strings.ReplaceAll(fieldName, "?", "")
 	isOptional := strings.HasSuffix(fieldName, "?")
@@ -551,36 +556,40 @@ func (t *TypeScriptify) getFieldOptions(structType reflect.Type, field reflect.S
 	return opts
 }
 
+// getJSONFieldName returns the name of the field in the JSON of the struct, with a "?" suffix if it is optional.
+// It is empty for the fields without a json tag and the fields ignored with `json:"-"`.
 func (t *TypeScriptify) getJSONFieldName(field reflect.StructField, isPtr bool) string {
-	jsonFieldName := ""
 	jsonTag := field.Tag.Get("json")
-	if len(jsonTag) > 0 {
-		jsonTagParts := strings.Split(jsonTag, ",")
-		if len(jsonTagParts) > 0 {
-			jsonFieldName = strings.Trim(jsonTagParts[0], t.Indent)
-		}
-		hasOmitEmpty := false
-		ignored := false
-		for _, t := range jsonTagParts {
-			if t == "" {
-				break
-			}
-			if t == "omitempty" {
-				hasOmitEmpty = true
-				break
-			}
-			if t == "-" {
-				ignored = true
-				break
-			}
-		}
-		if !ignored && isPtr || hasOmitEmpty {
-			jsonFieldName = fmt.Sprintf("%s?", jsonFieldName)
+	if len(jsonTag) == 0 || jsonTag == "-" {
+		return ""
+	}
+	jsonTagParts := strings.Split(jsonTag, ",")
+	jsonFieldName := strings.Trim(jsonTagParts[0], t.Indent)
+	if jsonFieldName == "" {
+		jsonFieldName = field.Name
+	}
+	hasOmitEmpty := false
+	for _, option := range jsonTagParts[1:] {
+		if option == "omitempty" {
+			hasOmitEmpty = true
 		}
 	}
+	if isPtr || hasOmitEmpty {
+		jsonFieldName = fmt.Sprintf("%s?", jsonFieldName)
+	}
 	return jsonFieldName
 }
 
+// isReadonly returns true if the field is tagged `wails:"readonly"`, its property is readonly in the model
+func isReadonly(field reflect.StructField) bool {
+	for _, option := range strings.Split(field.Tag.Get(wailsTag), ",") {
+		if option == "readonly" {
+			return true
+		}
+	}
+	return false
+}
+
 func (t *TypeScriptify) convertType(depth int, typeOf reflect.Type, customCode map[string]string) (string, error) {
 	if _, found := t.alreadyConverted[typeOf.String()]; found { // Already converted
 		return "", nil
@@ -596,7 +605,7 @@ func (t *TypeScriptify) convertType(depth int, typeOf reflect.Type, customCode m
 
 	t.alreadyConverted[typeOf.String()] = true
 
-	entityName := t.Prefix + typeOf.Name() + t.Suffix
+	entityName := t.Prefix + typeName(typeOf) + t.Suffix
 
 	if typeClashWithReservedKeyword(entityName) {
 		warnAboutTypesClash(entityName)
@@ -625,9 +634,10 @@ func (t *TypeScriptify) convertType(depth int, typeOf reflect.Type, customCode m
 			field.Type = field.Type.Elem()
 		}
 		jsonFieldName := t.getJSONFieldName(field, isPtr)
-		if len(jsonFieldName) == 0 || jsonFieldName == "-" {
+		if len(jsonFieldName) == 0 {
 			continue
 		}
+		builder.readonly = isReadonly(field)
 
 		var err error
 		fldOpts := t.getFieldOptions(typeOf, field)
@@ -794,6 +804,8 @@ type typeScriptClassBuilder struct {
 	constructorBody      []string
 	prefix, suffix       string
 	namespace            string
+	// readonly is set for the fields tagged `wails:"readonly"`
+	readonly bool
 }
 
 func (t *typeScriptClassBuilder) AddSimpleArrayField(fieldName string, field reflect.StructField, arrayDepth int, opts TypeOptions) error {
@@ -841,7 +853,7 @@ func (t *typeScriptClassBuilder) AddSimpleField(fieldName string, field reflect.
 }
 
 func (t *typeScriptClassBuilder) AddEnumField(fieldName string, field reflect.StructField) {
-	fieldType := field.Type.Name()
+	fieldType := typeName(field.Type)
 	t.addField(fieldName, t.prefix+fieldType+t.suffix, false)
 	strippedFieldName := strings.ReplaceAll(fieldName, "?", "")
 	t.addInitializerFieldLine(strippedFieldName, fmt.Sprintf("source[\"%s\"]", strippedFieldName))
@@ -851,7 +863,7 @@ func (t *typeScriptClassBuilder) AddStructField(fieldName string, field reflect.
 	strippedFieldName := strings.ReplaceAll(fieldName, "?", "")
 	classname := "null"
 	namespace := strings.Split(field.Type.String(), ".")[0]
-	fqname := t.prefix + field.Type.Name() + t.suffix
+	fqname := t.prefix + typeName(field.Type) + t.suffix
 	if namespace != t.namespace {
 		fqname = namespace + "." + fqname
 	}
@@ -870,9 +882,9 @@ func (t *typeScriptClassBuilder) AddStructField(fieldName string, field reflect.
 }
 
 func (t *typeScriptClassBuilder) AddArrayOfStructsField(fieldName string, field reflect.StructField, arrayDepth int) {
-	fieldType := field.Type.Elem().Name()
+	fieldType := typeName(field.Type.Elem())
 	if differentNamespaces(t.namespace, field.Type.Elem()) {
-		fieldType = field.Type.Elem().String()
+		fieldType = typeString(field.Type.Elem())
 	}
 	strippedFieldName := strings.ReplaceAll(fieldName, "?", "")
 	t.addField(fieldName, fmt.Sprint(t.prefix+fieldType+t.suffix, strings.Repeat("[]", arrayDepth)), false)
@@ -899,6 +911,9 @@ func (t *typeScriptClassBuilder) addField(fld, fldType string, isAnyType bool) {
 			fld += "?"
 		}
 	}
+	if t.readonly {
+		fld = "readonly " + fld
+	}
 	if isAnyType {
 		fldType = strings.Split(fldType, ".")[0]
 		t.fields = append(t.fields, fmt.Sprint(t.indent, "// Go type: ", fldType, "\n", t.indent, fld, ": any;"))
@@ -916,7 +931,7 @@ func indentLines(str string, i int) string {
 }
 
 func getStructFQN(in string) string {
-	result := strings.ReplaceAll(in, "[]", "")
+	result := strings.ReplaceAll(GenericTypeName(in), "[]", "")
 	result = strings.ReplaceAll(result, "*", "")
 	return result
 }
@@ -0,0 +1,66 @@
+package assetserver
+
+import (
+	"net/http"
+	"time"
+)
+
+// UseAccessLog logs every request with the method, path, status and duration
+func (d *AssetServer) UseAccessLog(enabled bool) {
+	d.accessLog = enabled
+}
+
+// logAccess logs the request at the Info level, or at the Debug level if the Logger has no Info level
+func (d *AssetServer) logAccess(req *http.Request, status int, duration time.Duration) {
+	if d.logger == nil {
+		return
+	}
+	// The path is logged without the query, which may carry a token
+	const message = "[AssetServer] %s %s %d %s"
+	if infoLogger, ok := d.logger.(interface {
+		Info(message string, args ...interface{})
+	}); ok {
+		infoLogger.Info(message, req.Method, req.URL.Path, status, duration)
+	} else {
+		d.logger.Debug(message, req.Method, req.URL.Path, status, duration)
+	}
+}
+
+// statusRecorder records the status of the response for the access log
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(buf []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(buf)
+}
+
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the ResponseWriter for http.ResponseController
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// Status returns the status of the response, 200 if nothing has been written
+func (r *statusRecorder) Status() int {
+	if r.status == 0 {
+		return http.StatusOK
+	}
+	return r.status
+}
@@ -10,7 +10,6 @@ import (
 	"net/http"
 	"os"
 	"path"
-	"strconv"
 	"strings"
 
 	"github.com/wailsapp/wails/v2/pkg/options/assetserver"
@@ -34,41 +33,46 @@ type assetHandler struct {
 
 	logger Logger
 
-	retryMissingFiles bool
-}
+	// etags holds the content hashes of the files, which are sent as ETag to serve conditional requests
+	etags *etagCache
 
-func NewAssetHandler(options assetserver.Options, log Logger) (http.Handler, error) {
-	vfs := options.Assets
-	if vfs != nil {
-		if _, err := vfs.Open("."); err != nil {
-			return nil, err
-		}
+	// compression is nil if the files are served uncompressed
+	compression *compression
 
-		subDir, err := FindPathToFile(vfs, indexHTML)
-		if err != nil {
-			if errors.Is(err, os.ErrNotExist) {
-				msg := "no `index.html` could be found in your Assets fs.FS"
-				if embedFs, isEmbedFs := vfs.(embed.FS); isEmbedFs {
-					rootFolder, _ := FindEmbedRootPath(embedFs)
-					msg += fmt.Sprintf(", please make sure the embedded directory '%s' is correct and contains your assets", rootFolder)
-				}
+	// mimeTypes determines the Content-Type of the files
+	mimeTypes *mimeTypes
 
-				return nil, fmt.Errorf(msg)
-			}
+	// spaFallback is the file served for navigations to missing files, empty if there is none
+	spaFallback     string
+	notFoundHandler http.Handler
 
-			return nil, err
-		}
+	retryMissingFiles bool
+}
 
-		vfs, err = iofs.Sub(vfs, path.Clean(subDir))
-		if err != nil {
-			return nil, err
-		}
+func NewAssetHandler(options assetserver.Options, log Logger) (http.Handler, error) {
+	vfs, err := assetsFS(options.Assets)
+	if err != nil {
+		return nil, err
+	}
+
+	spaFallback := options.SPAFallback
+	if spaFallback != "" {
+		spaFallback = path.Clean(strings.TrimPrefix(spaFallback, "/"))
 	}
 
 	var result http.Handler = &assetHandler{
-		fs:      vfs,
-		handler: options.Handler,
-		logger:  log,
+		fs:              vfs,
+		handler:         options.Handler,
+		logger:          log,
+		etags:           newETagCache(),
+		compression:     newCompression(options.Compression),
+		mimeTypes:       newMimeTypes(options.MimeTypes, options.MimeSniffing),
+		spaFallback:     spaFallback,
+		notFoundHandler: options.NotFoundHandler,
+	}
+
+	if len(options.Mounts) > 0 {
+		result = newMountHandler(options, result, log)
 	}
 
 	if middleware := options.Middleware; middleware != nil {
@@ -78,6 +82,35 @@ func NewAssetHandler(options assetserver.Options, log Logger) (http.Handler, err
 	return result, nil
 }
 
+// assetsFS returns the part of the assets which is served, the directory containing the index.html.
+// It returns nil if no assets are defined.
+func assetsFS(vfs iofs.FS) (iofs.FS, error) {
+	if vfs == nil {
+		return nil, nil
+	}
+
+	if _, err := vfs.Open("."); err != nil {
+		return nil, err
+	}
+
+	subDir, err := FindPathToFile(vfs, indexHTML)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			msg := "no `index.html` could be found in your Assets fs.FS"
+			if embedFs, isEmbedFs := vfs.(embed.FS); isEmbedFs {
+				rootFolder, _ := FindEmbedRootPath(embedFs)
+				msg += fmt.Sprintf(", please make sure the embedded directory '%s' is correct and contains your assets", rootFolder)
+			}
+
+			return nil, fmt.Errorf(msg)
+		}
+
+		return nil, err
+	}
+
+	return iofs.Sub(vfs, path.Clean(subDir))
+}
+
 func (d *assetHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 	url := req.URL.Path
 	handler := d.handler
@@ -85,12 +118,23 @@ func (d *assetHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 		filename := path.Clean(strings.TrimPrefix(url, "/"))
 
 		d.logDebug("Handling request '%s' (file='%s')", url, filename)
-		if err := d.serveFSFile(rw, req, filename); err != nil {
+		err := d.serveFSFile(rw, req, filename)
+		if os.IsNotExist(err) && d.spaFallback != "" && isNavigation(req) {
+			d.logDebug("File '%s' not found, serving the SPA fallback '%s'", filename, d.spaFallback)
+			fallbackReq := req.Clone(req.Context())
+			fallbackReq.URL.Path = "/" + d.spaFallback
+			err = d.serveFSFile(rw, fallbackReq, d.spaFallback)
+		}
+		if err != nil {
 			if os.IsNotExist(err) {
 				if handler != nil {
 					d.logDebug("File '%s' not found, serving '%s' by AssetHandler", filename, url)
 					handler.ServeHTTP(rw, req)
 					err = nil
+				} else if d.notFoundHandler != nil {
+					d.logDebug("File '%s' not found, serving '%s' by NotFoundHandler", filename, url)
+					d.notFoundHandler.ServeHTTP(rw, req)
+					err = nil
 				} else {
 					rw.WriteHeader(http.StatusNotFound)
 					err = nil
@@ -164,32 +208,40 @@ func (d *assetHandler) serveFSFile(rw http.ResponseWriter, req *http.Request, fi
 
 		// Do the custom MimeType sniffing even though http.ServeContent would do it in case
 		// of an io.ReadSeeker. We would like to have a consistent behaviour in both cases.
-		if contentType := GetMimetype(filename, buf[:n]); contentType != "" {
+		if contentType := d.mimeTypes.mimetype(filename, buf[:n]); contentType != "" {
 			rw.Header().Set(HeaderContentType, contentType)
 		}
 	}
 
-	if fileSeeker, _ := file.(io.ReadSeeker); fileSeeker != nil {
-		if _, err := fileSeeker.Seek(0, io.SeekStart); err != nil {
-			return fmt.Errorf("seeker can't seek")
+	etag, err := d.etags.etag(d.fs, filename, statInfo)
+	if err != nil {
+		return err
+	}
+	// Ranges are served from the uncompressed file
+	if req.Header.Get(HeaderRange) == "" && d.compression.compresses(rw.Header().Get(HeaderContentType), statInfo.Size()) {
+		served, err := d.serveCompressed(rw, req, filename, etag, io.MultiReader(bytes.NewReader(buf[:n]), file))
+		if served || err != nil {
+			return err
 		}
-
-		http.ServeContent(rw, req, statInfo.Name(), statInfo.ModTime(), fileSeeker)
+	}
+	rw.Header().Set(HeaderETag, etag)
+	if ifNoneMatch := req.Header.Get(HeaderIfNoneMatch); ifNoneMatch != "" && etagMatches(ifNoneMatch, etag) {
+		rw.WriteHeader(http.StatusNotModified)
 		return nil
 	}
 
-	size := strconv.FormatInt(statInfo.Size(), 10)
-	rw.Header().Set(HeaderContentLength, size)
-
-	// Write the first 512 bytes used for MimeType sniffing
-	_, err = io.Copy(rw, bytes.NewReader(buf[:n]))
+	// http.ServeContent serves the Range requests, e.g. of video and audio elements seeking in the media
+	content, err := seekableContent(file, statInfo.Size(), buf[:n])
 	if err != nil {
 		return err
 	}
-
-	// Copy the remaining content of the file
-	_, err = io.Copy(rw, file)
-	return err
+	if _, sequential := content.(*forwardSeeker); sequential && strings.Contains(req.Header.Get(HeaderRange), ",") {
+		// Multiple ranges may be in any order, which can't be served by reading the file once
+		req = req.Clone(req.Context())
+		req.Header.Del(HeaderRange)
+	}
+	http.ServeContent(rw, req, statInfo.Name(), statInfo.ModTime(), content)
+	return nil
 }
 
 func (d *assetHandler) logDebug(message string, args ...interface{}) {
@@ -13,12 +13,17 @@ import (
 	"github.com/wailsapp/wails/v2/pkg/options/assetserver"
 )
 
-func NewExternalAssetsHandler(logger Logger, options assetserver.Options, url *url.URL) http.Handler {
+// NewExternalAssetsHandler proxies the requests to the external frontend dev server. If multiple URLs are
+// given, the next one is tried if the connection to an upstream fails.
+func NewExternalAssetsHandler(logger Logger, options assetserver.Options, urls ...*url.URL) http.Handler {
 	baseHandler := options.Handler
 
 	errSkipProxy := fmt.Errorf("skip proxying")
 
-	proxy := httputil.NewSingleHostReverseProxy(url)
+	proxy := httputil.NewSingleHostReverseProxy(urls[0])
+	if len(urls) > 1 {
+		proxy.Transport = NewFailoverTransport(logger, urls, nil)
+	}
 	baseDirector := proxy.Director
 	proxy.Director = func(r *http.Request) {
 		baseDirector(r)
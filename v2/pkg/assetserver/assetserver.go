@@ -3,9 +3,12 @@ package assetserver
 import (
 	"bytes"
 	"fmt"
+	iofs "io/fs"
 	"math/rand"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
 
 	"golang.org/x/net/html"
 	"html/template"
@@ -31,9 +34,13 @@ type RuntimeHandler interface {
 }
 
 type AssetServer struct {
-	handler   http.Handler
-	runtimeJS []byte
-	ipcJS     func(*http.Request) []byte
+	handler http.Handler
+	ipcJS   func(*http.Request) []byte
+
+	runtimeJSLock sync.RWMutex
+	bindingsJSON  string
+	apiVersion    string
+	runtimeJS     []byte
 
 	logger  Logger
 	runtime RuntimeAssets
@@ -47,6 +54,53 @@ type AssetServer struct {
 	// plugin scripts
 	pluginScripts map[string]string
 
+	// scriptBasePath is the prefix of the paths of the scripts injected into index.html
+	scriptBasePath string
+
+	// websocketIPCConfig is made available to the websocket IPC script as window.wailsipcconfig
+	websocketIPCConfig map[string]interface{}
+
+	// desktopIPCPredicate decides whether a request gets the desktop IPC in dev mode, nil uses the User-Agent
+	desktopIPCPredicate func(*http.Request) bool
+
+	// runtimeModules are the injected runtime modules, nil means all
+	runtimeModules map[options.RuntimeModule]bool
+
+	// middlewares wrap the AssetServer, nil if there are none
+	middlewares http.Handler
+
+	// overlay serves the files of the overlay FS in place of the ones of the handler, nil if there is none
+	overlayLock sync.RWMutex
+	overlay     http.Handler
+	overlayFS   iofs.FS
+
+	// manifest lists the files of the manifestAssets and the overlay FS, nil if it is not served
+	manifestLock   sync.RWMutex
+	manifestAssets iofs.FS
+	manifest       *Manifest
+
+	// mountPrefixes are the prefixes of the mounts, whose pages get no runtime injected
+	mountPrefixes []string
+
+	// accessLog logs every request
+	accessLog bool
+
+	// mimeTypes determines the Content-Type of the served files, nil uses the defaults
+	mimeTypes *mimeTypes
+
+	// compression and notFoundHandler are the ones of the handler, used for the overlay FS too
+	compression     *assetserver.Compression
+	notFoundHandler http.Handler
+
+	// indexTemplate renders the index.html before the injection, nil if it is served as it is
+	indexTemplate *assetserver.IndexTemplate
+
+	// securityHeaders are added to all responses, nil if there are none
+	securityHeaders *assetserver.SecurityHeaders
+
+	// spaFallback injects the runtime into all pages served for navigations, which may be the SPA fallback
+	spaFallback bool
+
 	assetServerWebView
 }
 
@@ -64,21 +118,32 @@ func NewAssetServer(bindingsJSON string, options assetserver.Options, servingFro
 		return nil, err
 	}
 
-	return NewAssetServerWithHandler(handler, bindingsJSON, servingFromDisk, logger, runtime)
+	result, err := NewAssetServerWithHandler(handler, bindingsJSON, servingFromDisk, logger, runtime)
+	if err != nil {
+		return nil, err
+	}
+	result.UseMiddlewares(options.Middlewares...)
+	result.UseSPAFallback(options.SPAFallback)
+	result.UseSecurityHeaders(options.SecurityHeaders)
+	result.UseIndexTemplate(options.IndexTemplate)
+	result.UseMimeTypes(options.MimeTypes, options.MimeSniffing)
+	result.UseCompression(options.Compression)
+	result.UseNotFoundHandler(options.NotFoundHandler)
+	result.UseAccessLog(options.AccessLog)
+	result.UseMounts(options.Mounts)
+	if options.EnableManifest {
+		if err := result.UseManifest(options.Assets); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
 }
 
 func NewAssetServerWithHandler(handler http.Handler, bindingsJSON string, servingFromDisk bool, logger Logger, runtime RuntimeAssets) (*AssetServer, error) {
 
-	var buffer bytes.Buffer
-	if bindingsJSON != "" {
-		escapedBindingsJSON := template.JSEscapeString(bindingsJSON)
-		buffer.WriteString(`window.wailsbindings='` + escapedBindingsJSON + `';` + "\n")
-	}
-	buffer.Write(runtime.RuntimeDesktopJS())
-
 	result := &AssetServer{
-		handler:   handler,
-		runtimeJS: buffer.Bytes(),
+		handler:      handler,
+		bindingsJSON: bindingsJSON,
 
 		// Check if we have been given a directory to serve assets from.
 		// If so, this means we are in dev mode and are serving assets off disk.
@@ -88,14 +153,122 @@ func NewAssetServerWithHandler(handler http.Handler, bindingsJSON string, servin
 		logger:          logger,
 		runtime:         runtime,
 	}
+	result.runtimeJS = result.buildRuntimeJS()
 
 	return result, nil
 }
 
+// UseMimeTypes sets the Content-Type of the files by extension, overriding the builtin types, and the sniffing
+// of the files with unknown extensions, for the files served by the AssetServer itself and the overlay FS.
+func (d *AssetServer) UseMimeTypes(byExt map[string]string, sniffing assetserver.MimeSniffing) {
+	d.mimeTypes = newMimeTypes(byExt, sniffing)
+}
+
+// UseCompression sets the compression of the files of the overlay FS, like the one of the handler
+func (d *AssetServer) UseCompression(compression *assetserver.Compression) {
+	d.compression = compression
+}
+
+// UseNotFoundHandler sets the handler of the requests for files missing from the overlay FS and the handler
+func (d *AssetServer) UseNotFoundHandler(handler http.Handler) {
+	d.notFoundHandler = handler
+}
+
+// SetBindingsJSON replaces the bindings which are injected with the runtime. Pages which have already
+// been loaded only pick up the new bindings after a reload.
+func (d *AssetServer) SetBindingsJSON(bindingsJSON string) {
+	d.runtimeJSLock.Lock()
+	defer d.runtimeJSLock.Unlock()
+	d.bindingsJSON = bindingsJSON
+	d.runtimeJS = d.buildRuntimeJS()
+}
+
+// UseAPIVersion sets the API version of the app which is injected with the bindings as window.wailsapiversion.
+// The websocket IPC script sends it when it connects, so that the dev server detects the pages which have been
+// loaded from another version.
+func (d *AssetServer) UseAPIVersion(version string) {
+	d.runtimeJSLock.Lock()
+	defer d.runtimeJSLock.Unlock()
+	d.apiVersion = version
+	d.runtimeJS = d.buildRuntimeJS()
+	d.SetWebsocketIPCConfig("apiversion", version)
+}
+
+func (d *AssetServer) getRuntimeJS() []byte {
+	d.runtimeJSLock.RLock()
+	defer d.runtimeJSLock.RUnlock()
+	return d.runtimeJS
+}
+
+func (d *AssetServer) buildRuntimeJS() []byte {
+	var buffer bytes.Buffer
+	if d.bindingsJSON != "" && d.hasRuntimeModule(options.RuntimeModuleBindings) {
+		escapedBindingsJSON := template.JSEscapeString(d.bindingsJSON)
+		buffer.WriteString(`window.wailsbindings='` + escapedBindingsJSON + `';` + "\n")
+		if d.apiVersion != "" {
+			buffer.WriteString(`window.wailsapiversion='` + template.JSEscapeString(d.apiVersion) + `';` + "\n")
+		}
+	}
+	buffer.Write(d.runtime.RuntimeDesktopJS())
+	return buffer.Bytes()
+}
+
+// UseRuntimeModules limits the runtime modules which are injected into index.html. The IPC module
+// is always injected and requires the runtime module.
+func (d *AssetServer) UseRuntimeModules(modules []options.RuntimeModule) error {
+	d.runtimeJSLock.Lock()
+	defer d.runtimeJSLock.Unlock()
+
+	if len(modules) == 0 {
+		d.runtimeModules = nil
+		d.runtimeJS = d.buildRuntimeJS()
+		return nil
+	}
+
+	runtimeModules := map[options.RuntimeModule]bool{
+		options.RuntimeModuleIPC: true,
+	}
+	for _, module := range modules {
+		switch module {
+		case options.RuntimeModuleIPC, options.RuntimeModuleRuntime, options.RuntimeModuleBindings, options.RuntimeModulePlugins:
+			runtimeModules[module] = true
+		default:
+			return fmt.Errorf("unknown runtime module '%s'", module)
+		}
+	}
+	// The IPC script delivers the calls and events through window.runtime and window.wails
+	if !runtimeModules[options.RuntimeModuleRuntime] {
+		return fmt.Errorf("runtime module '%s' requires the '%s' module", options.RuntimeModuleIPC, options.RuntimeModuleRuntime)
+	}
+
+	d.runtimeModules = runtimeModules
+	d.runtimeJS = d.buildRuntimeJS()
+	return nil
+}
+
+func (d *AssetServer) hasRuntimeModule(module options.RuntimeModule) bool {
+	return d.runtimeModules == nil || d.runtimeModules[module]
+}
+
 func (d *AssetServer) UseRuntimeHandler(handler RuntimeHandler) {
 	d.runtimeHandler = handler
 }
 
+// UseMiddlewares wraps all responses of the AssetServer with the middlewares, the first one is the outermost
+func (d *AssetServer) UseMiddlewares(middlewares ...assetserver.Middleware) {
+	if len(middlewares) == 0 {
+		d.middlewares = nil
+		return
+	}
+	d.middlewares = assetserver.ChainMiddleware(middlewares...)(http.HandlerFunc(d.serveHTTP))
+}
+
+// UseSPAFallback tells the AssetServer that its handler serves the fallback page for navigations to missing
+// files, which then gets the runtime injected like the index.html
+func (d *AssetServer) UseSPAFallback(fallback string) {
+	d.spaFallback = fallback != ""
+}
+
 func (d *AssetServer) AddPluginScript(pluginName string, script string) {
 	if d.pluginScripts == nil {
 		d.pluginScripts = make(map[string]string)
@@ -107,6 +280,21 @@ func (d *AssetServer) AddPluginScript(pluginName string, script string) {
 }
 
 func (d *AssetServer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if d.accessLog {
+		recorder := &statusRecorder{ResponseWriter: rw}
+		rw = recorder
+		defer func(start time.Time) {
+			d.logAccess(req, recorder.Status(), time.Since(start))
+		}(time.Now())
+	}
+	if d.middlewares != nil {
+		d.middlewares.ServeHTTP(rw, req)
+		return
+	}
+	d.serveHTTP(rw, req)
+}
+
+func (d *AssetServer) serveHTTP(rw http.ResponseWriter, req *http.Request) {
 	if isWebSocket(req) {
 		// WebSockets are not supported by the AssetServer
 		rw.WriteHeader(http.StatusNotImplemented)
@@ -116,8 +304,9 @@ func (d *AssetServer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 	if d.servingFromDisk {
 		rw.Header().Add(HeaderCacheControl, "no-cache")
 	}
+	d.writeSecurityHeaders(rw)
 
-	handler := d.handler
+	handler := d.assetHandler()
 	if req.Method != http.MethodGet {
 		handler.ServeHTTP(rw, req)
 		return
@@ -125,7 +314,7 @@ func (d *AssetServer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 
 	path := req.URL.Path
 	if path == runtimeJSPath {
-		d.writeBlob(rw, path, d.runtimeJS)
+		d.writeBlob(rw, path, d.getRuntimeJS())
 	} else if path == runtimePath && d.runtimeHandler != nil {
 		d.runtimeHandler.HandleRuntimeCall(rw, req)
 	} else if path == ipcJSPath {
@@ -135,9 +324,11 @@ func (d *AssetServer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 		}
 		d.writeBlob(rw, path, content)
 
+	} else if path == manifestPath && d.hasManifest() {
+		d.serveManifest(rw)
 	} else if script, ok := d.pluginScripts[path]; ok {
 		d.writeBlob(rw, path, []byte(script))
-	} else if d.isRuntimeInjectionMatch(path) {
+	} else if !d.isMounted(path) && (d.isRuntimeInjectionMatch(path) || (d.spaFallback && isNavigation(req))) {
 		recorder := &bodyRecorder{
 			ResponseWriter: rw,
 			doRecord: func(code int, h http.Header) bool {
@@ -153,6 +344,12 @@ func (d *AssetServer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 			},
 		}
 
+		// The page is changed by the injection, so it is neither served from the cache of the browser nor
+		// validated with the ETag of the original file
+		req = req.Clone(req.Context())
+		req.Header.Del(HeaderIfNoneMatch)
+		req.Header.Del(HeaderIfModifiedSince)
+		req.Header.Del(HeaderAcceptEncoding)
 		handler.ServeHTTP(recorder, req)
 
 		body := recorder.Body()
@@ -164,11 +361,23 @@ func (d *AssetServer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 		code := recorder.Code()
 		switch code {
 		case http.StatusOK:
-			content, err := d.processIndexHTML(body.Bytes())
+			nonce, err := d.allowInjectedScripts(rw, req)
+			if err != nil {
+				d.serveError(rw, err, "Unable to create the nonce of the scripts")
+				return
+			}
+			page, err := d.renderIndexTemplate(req, body.Bytes(), nonce)
+			if err != nil {
+				d.serveError(rw, err, "Unable to render the index template")
+				return
+			}
+			content, err := d.processIndexHTML(page, nonce)
 			if err != nil {
 				d.serveError(rw, err, "Unable to processIndexHTML")
 				return
 			}
+			rw.Header().Del(HeaderETag)
+			rw.Header().Del(HeaderLastModified)
 			d.writeBlob(rw, indexHTML, content)
 
 		case http.StatusNotFound:
@@ -184,7 +393,8 @@ func (d *AssetServer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 	}
 }
 
-func (d *AssetServer) processIndexHTML(indexHTML []byte) ([]byte, error) {
+// processIndexHTML injects the scripts into the page, the nonce is added to the scripts unless it is empty
+func (d *AssetServer) processIndexHTML(indexHTML []byte, nonce string) ([]byte, error) {
 	htmlNode, err := getHTMLNode(indexHTML)
 	if err != nil {
 		return nil, err
@@ -197,18 +407,20 @@ func (d *AssetServer) processIndexHTML(indexHTML []byte) ([]byte, error) {
 		}
 	}
 
-	if err := insertScriptInHead(htmlNode, runtimeJSPath); err != nil {
+	if err := insertScriptInHead(htmlNode, d.scriptBasePath+runtimeJSPath, nonce); err != nil {
 		return nil, err
 	}
 
-	if err := insertScriptInHead(htmlNode, ipcJSPath); err != nil {
+	if err := insertScriptInHead(htmlNode, d.scriptBasePath+ipcJSPath, nonce); err != nil {
 		return nil, err
 	}
 
 	// Inject plugins
-	for scriptName := range d.pluginScripts {
-		if err := insertScriptInHead(htmlNode, scriptName); err != nil {
-			return nil, err
+	if d.hasRuntimeModule(options.RuntimeModulePlugins) {
+		for scriptName := range d.pluginScripts {
+			if err := insertScriptInHead(htmlNode, d.scriptBasePath+scriptName, nonce); err != nil {
+				return nil, err
+			}
 		}
 	}
 
@@ -221,7 +433,7 @@ func (d *AssetServer) processIndexHTML(indexHTML []byte) ([]byte, error) {
 }
 
 func (d *AssetServer) writeBlob(rw http.ResponseWriter, filename string, blob []byte) {
-	err := serveFile(rw, filename, blob)
+	err := serveFile(rw, d.mimeTypes, filename, blob)
 	if err != nil {
 		d.serveError(rw, err, "Unable to write content %s", filename)
 	}
@@ -245,7 +457,7 @@ func (d *AssetServer) logError(message string, args ...interface{}) {
 	}
 }
 
-func (AssetServer) isRuntimeInjectionMatch(path string) bool {
+func (d *AssetServer) isRuntimeInjectionMatch(path string) bool {
 	if path == "" {
 		path = "/"
 	}
@@ -0,0 +1,122 @@
+package assetserver
+
+import (
+    "bytes"
+    "encoding/json"
+    "net/http"
+    "net/url"
+    "strings"
+)
+
+const (
+    // ipcOverrideQuery and ipcOverrideHeader select the injected IPC explicitly, with the value
+    // ipcDesktop or ipcWebsocket
+    ipcOverrideQuery  = "_wails_ipc"
+    ipcOverrideHeader = "X-Wails-IPC"
+
+    ipcDesktop   = "desktop"
+    ipcWebsocket = "websocket"
+)
+
+/*
+The assetserver for the browsers, used by dev builds and by production builds with the WebServer enabled.
+Depending on the UserAgent it injects a websocket based IPC script into `index.html` or the default desktop IPC. The
+default desktop IPC is injected when the webview accesses the devserver. The detection can be overridden per request,
+see useDesktopIPC.
+*/
+func NewDevAssetServer(handler http.Handler, bindingsJSON string, servingFromDisk bool, logger Logger, runtime RuntimeAssets) (*AssetServer, error) {
+    result, err := NewAssetServerWithHandler(handler, bindingsJSON, servingFromDisk, logger, runtime)
+    if err != nil {
+        return nil, err
+    }
+
+    result.appendSpinnerToBody = true
+    result.ipcJS = func(req *http.Request) []byte {
+        if result.useDesktopIPC(req) {
+            return runtime.DesktopIPC()
+        }
+        ipc := runtime.WebsocketIPC()
+        if len(result.websocketIPCConfig) > 0 {
+            config, err := json.Marshal(result.websocketIPCConfig)
+            if err != nil {
+                result.logError("Unable to marshal the websocket IPC config: %s", err)
+            } else {
+                var buffer bytes.Buffer
+                buffer.WriteString("window.wailsipcconfig=" + string(config) + ";\n")
+                buffer.Write(ipc)
+                ipc = buffer.Bytes()
+            }
+        }
+
+        //if address, ok := os.LookupEnv("websocket_address"); ok {
+        //    ipc = bytes.ReplaceAll(ipc, []byte("window.location.host"), []byte(fmt.Sprintf(`"%s"`, address)))
+        //}
+        //if protocol, ok := os.LookupEnv("websocket_protocol"); ok {
+        //    ipc = bytes.ReplaceAll(ipc, []byte(`window.location.protocol.indexOf("https")`), []byte(fmt.Sprintf(`"%s".indexOf("wss")`, protocol)))
+        //}
+
+        return ipc
+    }
+
+    return result, nil
+}
+
+// SetWebsocketIPCConfig sets a value which is made available to the websocket IPC script as
+// `window.wailsipcconfig[key]`. This keeps the injected script in sync with the dev server settings.
+func (d *AssetServer) SetWebsocketIPCConfig(key string, value interface{}) {
+    if d.websocketIPCConfig == nil {
+        d.websocketIPCConfig = make(map[string]interface{})
+    }
+    d.websocketIPCConfig[key] = value
+}
+
+// UseBasePath sets the prefix the dev server is served under, e.g. "/myapp". The scripts injected into
+// index.html and the IPC websocket use it, the requests reaching the AssetServer must not contain it.
+func (d *AssetServer) UseBasePath(basePath string) {
+    d.scriptBasePath = basePath
+    if basePath != "" {
+        d.SetWebsocketIPCConfig("basepath", basePath)
+    }
+}
+
+// UseSpinner controls whether the loading spinner is appended to the body of the served pages. It is enabled
+// by default in dev mode and can be disabled if the frontend dev server renders its own loading UI.
+func (d *AssetServer) UseSpinner(enabled bool) {
+    d.appendSpinnerToBody = enabled
+}
+
+// UseDesktopIPCPredicate replaces the User-Agent check deciding whether a request gets the desktop IPC
+// or the websocket IPC. A nil predicate restores the User-Agent check.
+func (d *AssetServer) UseDesktopIPCPredicate(predicate func(req *http.Request) bool) {
+    d.desktopIPCPredicate = predicate
+}
+
+// useDesktopIPC reports whether the desktop IPC should be injected for the request. An explicit
+// `_wails_ipc` query parameter, on the request or the page referring to it, or an `X-Wails-IPC` header
+// takes precedence over the predicate.
+func (d *AssetServer) useDesktopIPC(req *http.Request) bool {
+    switch ipcOverride(req) {
+    case ipcDesktop:
+        return true
+    case ipcWebsocket:
+        return false
+    }
+    if d.desktopIPCPredicate != nil {
+        return d.desktopIPCPredicate(req)
+    }
+    return strings.Contains(req.UserAgent(), WailsUserAgentValue)
+}
+
+func ipcOverride(req *http.Request) string {
+    if value := req.URL.Query().Get(ipcOverrideQuery); value != "" {
+        return value
+    }
+    if value := req.Header.Get(ipcOverrideHeader); value != "" {
+        return value
+    }
+    // The IPC script is loaded by index.html, so the query parameter is usually set on the page
+    if referer, err := url.Parse(req.Referer()); err == nil {
+        return referer.Query().Get(ipcOverrideQuery)
+    }
+    return ""
+}
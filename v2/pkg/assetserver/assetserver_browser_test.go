@@ -0,0 +1,143 @@
+package assetserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/wailsapp/wails/v2/pkg/options/assetserver"
+)
+
+func TestDevIPCSelection(t *testing.T) {
+	tests := []struct {
+		name      string
+		path      string
+		header    http.Header
+		predicate func(*http.Request) bool
+		want      string
+	}{
+		{
+			name: "browser",
+			path: "/wails/ipc.js",
+			want: "websocketipc",
+		},
+		{
+			name:   "webview user agent",
+			path:   "/wails/ipc.js",
+			header: http.Header{"User-Agent": []string{"Mozilla/5.0 " + WailsUserAgentValue}},
+			want:   "desktopipc",
+		},
+		{
+			name:   "header overrides user agent",
+			path:   "/wails/ipc.js",
+			header: http.Header{"User-Agent": []string{WailsUserAgentValue}, "X-Wails-Ipc": []string{"websocket"}},
+			want:   "websocketipc",
+		},
+		{
+			name:   "header selects desktop",
+			path:   "/wails/ipc.js",
+			header: http.Header{"X-Wails-Ipc": []string{"desktop"}},
+			want:   "desktopipc",
+		},
+		{
+			name:   "query overrides user agent",
+			path:   "/wails/ipc.js?_wails_ipc=websocket",
+			header: http.Header{"User-Agent": []string{WailsUserAgentValue}},
+			want:   "websocketipc",
+		},
+		{
+			name:   "query of the referer",
+			path:   "/wails/ipc.js",
+			header: http.Header{"Referer": []string{"http://localhost:34115/?_wails_ipc=desktop"}},
+			want:   "desktopipc",
+		},
+		{
+			name:      "predicate",
+			path:      "/wails/ipc.js",
+			header:    http.Header{"X-Embedded": []string{"1"}},
+			predicate: func(req *http.Request) bool { return req.Header.Get("X-Embedded") != "" },
+			want:      "desktopipc",
+		},
+		{
+			name:      "override takes precedence over the predicate",
+			path:      "/wails/ipc.js?_wails_ipc=websocket",
+			predicate: func(*http.Request) bool { return true },
+			want:      "websocketipc",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := is.New(t)
+			server, err := NewDevAssetServer(http.NotFoundHandler(), `{}`, false, nil, mockRuntimeAssets{})
+			i.NoErr(err)
+			server.UseDesktopIPCPredicate(tt.predicate)
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			for key, values := range tt.header {
+				req.Header[key] = values
+			}
+			rec := httptest.NewRecorder()
+			server.ServeHTTP(rec, req)
+			i.Equal(rec.Body.String(), tt.want)
+		})
+	}
+}
+
+func TestDevSpinner(t *testing.T) {
+	handler := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set(HeaderContentType, "text/html; charset=utf-8")
+		_, _ = rw.Write([]byte("<html><head></head><body></body></html>"))
+	})
+	for _, enabled := range []bool{true, false} {
+		i := is.New(t)
+		server, err := NewDevAssetServer(handler, `{}`, false, nil, mockRuntimeAssets{})
+		i.NoErr(err)
+		if !enabled {
+			server.UseSpinner(false)
+		}
+
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		body := rec.Body.String()
+		i.Equal(strings.Contains(body, "wails-spinner"), enabled)
+		i.True(strings.Contains(body, `<script src="/wails/ipc.js"></script>`))
+	}
+}
+
+func TestDevAPIVersion(t *testing.T) {
+	i := is.New(t)
+	server, err := NewDevAssetServer(http.NotFoundHandler(), `{}`, false, nil, mockRuntimeAssets{})
+	i.NoErr(err)
+	server.UseAPIVersion("2'")
+
+	// The version is injected with the bindings and passed to the IPC script, which sends it when it connects
+	i.Equal(serve(server, runtimeJSPath), "window.wailsbindings='{}';\nwindow.wailsapiversion='2\\'';\nruntime")
+	i.Equal(serve(server, ipcJSPath+"?_wails_ipc=websocket"), `window.wailsipcconfig={"apiversion":"2'"};`+"\nwebsocketipc")
+}
+
+func TestDevSecurityHeaders(t *testing.T) {
+	i := is.New(t)
+	handler := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set(HeaderContentType, "text/html; charset=utf-8")
+		_, _ = rw.Write([]byte("<html><head></head><body></body></html>"))
+	})
+	server, err := NewDevAssetServer(handler, `{}`, false, nil, mockRuntimeAssets{})
+	i.NoErr(err)
+	server.UseSecurityHeaders(&assetserver.SecurityHeaders{ContentSecurityPolicy: "default-src 'none'"})
+
+	// The websocket IPC connects to the dev server
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://localhost:34115/", nil))
+	policy := rec.Header().Get(HeaderContentSecurityPolicy)
+	i.True(strings.Contains(policy, "connect-src 'self' ws://localhost:34115 wss://localhost:34115"))
+	i.True(strings.Contains(policy, "script-src 'nonce-"))
+
+	// The desktop IPC does not
+	req := httptest.NewRequest(http.MethodGet, "http://localhost:34115/", nil)
+	req.Header.Set("User-Agent", WailsUserAgentValue)
+	rec = httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	i.True(!strings.Contains(rec.Header().Get(HeaderContentSecurityPolicy), "connect-src"))
+}
@@ -0,0 +1,78 @@
+//go:build dev
+// +build dev
+
+package assetserver
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/matryer/is"
+	"github.com/wailsapp/wails/v2/pkg/options/assetserver"
+)
+
+func TestAssetsTarball(t *testing.T) {
+	i := is.New(t)
+	assets := fstest.MapFS{
+		"frontend/dist/index.html":      &fstest.MapFile{Data: []byte("<html></html>")},
+		"frontend/dist/assets/main.js":  &fstest.MapFile{Data: []byte("console.log(1)")},
+		"frontend/dist/assets/main.css": &fstest.MapFile{Data: []byte("body{}")},
+	}
+	handler, err := NewAssetsTarballHandler(assetserver.Options{Assets: assets}, nil)
+	i.NoErr(err)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/wails/assets.tar.gz", nil))
+	i.Equal(rec.Code, http.StatusOK)
+	i.Equal(rec.Header().Get(HeaderContentType), "application/gzip")
+
+	gzipReader, err := gzip.NewReader(rec.Body)
+	i.NoErr(err)
+	tarReader := tar.NewReader(gzipReader)
+	files := map[string]string{}
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		i.NoErr(err)
+		content, err := io.ReadAll(tarReader)
+		i.NoErr(err)
+		files[header.Name] = string(content)
+	}
+	// The tarball contains the served files, relative to the index.html
+	i.Equal(files, map[string]string{
+		"assets/":         "",
+		"assets/main.css": "body{}",
+		"assets/main.js":  "console.log(1)",
+		"index.html":      "<html></html>",
+	})
+
+	// Assets served by a handler can not be captured
+	handler, err = NewAssetsTarballHandler(assetserver.Options{Handler: http.NotFoundHandler()}, nil)
+	i.NoErr(err)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/wails/assets.tar.gz", nil))
+	i.Equal(rec.Code, http.StatusNotFound)
+}
+
+func TestParseFrontendDevServerURLs(t *testing.T) {
+	i := is.New(t)
+
+	urls, err := ParseFrontendDevServerURLs("http://localhost:5173, http://127.0.0.1:5174")
+	i.NoErr(err)
+	i.Equal(len(urls), 2)
+	i.Equal(urls[0].Host, "localhost:5173")
+	i.Equal(urls[1].Host, "127.0.0.1:5174")
+
+	_, err = ParseFrontendDevServerURLs("localhost:5173")
+	i.True(err != nil)
+
+	_, err = ParseFrontendDevServerURLs(" , ")
+	i.True(err != nil)
+}
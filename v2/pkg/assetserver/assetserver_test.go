@@ -0,0 +1,760 @@
+package assetserver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	iofs "io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/options"
+	"github.com/wailsapp/wails/v2/pkg/options/assetserver"
+)
+
+type mockRuntimeAssets struct{}
+
+func (mockRuntimeAssets) DesktopIPC() []byte       { return []byte("desktopipc") }
+func (mockRuntimeAssets) WebsocketIPC() []byte     { return []byte("websocketipc") }
+func (mockRuntimeAssets) RuntimeDesktopJS() []byte { return []byte("runtime") }
+
+func newTestAssetServer(t *testing.T) *AssetServer {
+	t.Helper()
+	handler := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set(HeaderContentType, "text/html; charset=utf-8")
+		_, _ = rw.Write([]byte("<html><head></head><body></body></html>"))
+	})
+	server, err := NewAssetServerWithHandler(handler, `{"go":{}}`, false, nil, mockRuntimeAssets{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.AddPluginScript("plugin", "plugin")
+	return server
+}
+
+func serve(server *AssetServer, path string) string {
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec.Body.String()
+}
+
+func TestUseRuntimeModules(t *testing.T) {
+	tests := []struct {
+		name         string
+		modules      []options.RuntimeModule
+		wantErr      bool
+		wantRuntime  bool
+		wantBindings bool
+		wantPlugins  bool
+	}{
+		{"default", nil, false, true, true, true},
+		{"ipc requires runtime", []options.RuntimeModule{options.RuntimeModuleIPC}, true, false, false, false},
+		{"runtime without bindings", []options.RuntimeModule{options.RuntimeModuleRuntime}, false, true, false, false},
+		{"runtime and plugins", []options.RuntimeModule{options.RuntimeModuleRuntime, options.RuntimeModulePlugins}, false, true, false, true},
+		{"bindings require runtime", []options.RuntimeModule{options.RuntimeModuleBindings}, true, false, false, false},
+		{"plugins require runtime", []options.RuntimeModule{options.RuntimeModulePlugins}, true, false, false, false},
+		{"unknown module", []options.RuntimeModule{"unknown"}, true, false, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestAssetServer(t)
+			err := server.UseRuntimeModules(tt.modules)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UseRuntimeModules() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			index := serve(server, "/")
+			if !strings.Contains(index, ipcJSPath) {
+				t.Errorf("ipc script not injected: %s", index)
+			}
+			if got := strings.Contains(index, runtimeJSPath); got != tt.wantRuntime {
+				t.Errorf("runtime injected = %v, want %v", got, tt.wantRuntime)
+			}
+			if got := strings.Contains(index, "/plugin_"); got != tt.wantPlugins {
+				t.Errorf("plugins injected = %v, want %v", got, tt.wantPlugins)
+			}
+			if got := strings.Contains(serve(server, runtimeJSPath), "window.wailsbindings"); got != tt.wantBindings {
+				t.Errorf("bindings injected = %v, want %v", got, tt.wantBindings)
+			}
+		})
+	}
+}
+
+func TestUseMiddlewares(t *testing.T) {
+	server := newTestAssetServer(t)
+	var calls []string
+	middleware := func(name string) assetserver.Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				calls = append(calls, name+" "+req.URL.Path)
+				rw.Header().Set("X-Middleware", name)
+				next.ServeHTTP(rw, req)
+			})
+		}
+	}
+	server.UseMiddlewares(middleware("outer"), middleware("inner"))
+
+	// The middlewares wrap the injected scripts as well as the assets
+	for _, path := range []string{"/", runtimeJSPath, ipcJSPath} {
+		calls = nil
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s: status = %d", path, rec.Code)
+		}
+		if got, want := strings.Join(calls, ","), "outer "+path+",inner "+path; got != want {
+			t.Errorf("%s: calls = %s, want %s", path, got, want)
+		}
+		if got := rec.Header().Get("X-Middleware"); got != "inner" {
+			t.Errorf("%s: header = %s, want inner", path, got)
+		}
+	}
+
+	// A middleware can reject the request
+	server.UseMiddlewares(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		})
+	})
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, runtimeJSPath, nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestAssetETags(t *testing.T) {
+	assets := fstest.MapFS{
+		"index.html": {Data: []byte("<html><head></head><body></body></html>")},
+		"style.css":  {Data: []byte("body {}"), ModTime: time.Unix(1000, 0)},
+	}
+	server, err := NewAssetServer("", assetserver.Options{Assets: assets}, true, nil, mockRuntimeAssets{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func(path string, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if ifNoneMatch != "" {
+			req.Header.Set(HeaderIfNoneMatch, ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("/style.css", "")
+	etag := rec.Header().Get(HeaderETag)
+	if rec.Code != http.StatusOK || etag == "" {
+		t.Fatalf("status = %d, etag = %s", rec.Code, etag)
+	}
+	if rec := get("/style.css", etag); rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusNotModified)
+	}
+	if rec := get("/style.css", `"other", W/`+etag); rec.Code != http.StatusNotModified {
+		t.Errorf("status = %d with a list of etags, want %d", rec.Code, http.StatusNotModified)
+	}
+
+	// A changed file gets a new ETag
+	assets["style.css"] = &fstest.MapFile{Data: []byte("body { margin: 0 }"), ModTime: time.Unix(2000, 0)}
+	rec = get("/style.css", etag)
+	if rec.Code != http.StatusOK || rec.Header().Get(HeaderETag) == etag {
+		t.Errorf("status = %d, etag = %s after the file changed", rec.Code, rec.Header().Get(HeaderETag))
+	}
+
+	// The index.html is changed by the injection of the runtime, it has no ETag
+	rec = get("/", `"any"`)
+	if rec.Code != http.StatusOK || rec.Header().Get(HeaderETag) != "" {
+		t.Errorf("index: status = %d, etag = %s", rec.Code, rec.Header().Get(HeaderETag))
+	}
+	if rec := get("/", "*"); rec.Code != http.StatusOK {
+		t.Errorf("index: status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestAssetCompression(t *testing.T) {
+	script := strings.Repeat("console.log('wails');\n", 100)
+	assets := fstest.MapFS{
+		"index.html":   {Data: []byte("<html></html>")},
+		"app.js":       {Data: []byte(script)},
+		"app.js.br":    {Data: []byte("brotli")},
+		"vendor.js":    {Data: []byte(script)},
+		"vendor.js.gz": {Data: []byte("gzip")},
+		"small.js":     {Data: []byte("console.log('wails');")},
+		"logo.png":     {Data: append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 2048)...)},
+	}
+	handler, err := NewAssetHandler(assetserver.Options{Assets: assets, Compression: &assetserver.Compression{}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func(path string, acceptEncoding string, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set(HeaderAcceptEncoding, acceptEncoding)
+		req.Header.Set(HeaderIfNoneMatch, ifNoneMatch)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	tests := []struct {
+		name           string
+		path           string
+		acceptEncoding string
+		wantEncoding   string
+		wantBody       string
+	}{
+		{"brotli sidecar", "/app.js", "gzip, deflate, br", "br", "brotli"},
+		{"gzip sidecar", "/vendor.js", "gzip, br", "gzip", "gzip"},
+		{"gzip", "/app.js", "gzip", "gzip", script},
+		{"refused gzip", "/app.js", "br;q=0, gzip;q=0", "", script},
+		{"not accepted", "/app.js", "", "", script},
+		{"too small", "/small.js", "gzip", "", "console.log('wails');"},
+		{"not compressed type", "/logo.png", "gzip", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := get(tt.path, tt.acceptEncoding, "")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d", rec.Code)
+			}
+			if got := rec.Header().Get(HeaderContentEncoding); got != tt.wantEncoding {
+				t.Fatalf("encoding = %s, want %s", got, tt.wantEncoding)
+			}
+			if !strings.HasPrefix(rec.Header().Get(HeaderContentType), "text/javascript") && tt.path != "/logo.png" {
+				t.Errorf("content type = %s", rec.Header().Get(HeaderContentType))
+			}
+			if tt.wantBody == "" {
+				return
+			}
+			body := rec.Body.String()
+			if tt.wantEncoding == "gzip" && tt.wantBody == script {
+				reader, err := gzip.NewReader(rec.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				content, err := io.ReadAll(reader)
+				if err != nil {
+					t.Fatal(err)
+				}
+				body = string(content)
+			}
+			if body != tt.wantBody {
+				t.Errorf("body = %q, want %q", body, tt.wantBody)
+			}
+		})
+	}
+
+	// The encoded files have their own ETag
+	rec := get("/app.js", "br", "")
+	etag := rec.Header().Get(HeaderETag)
+	if !strings.HasSuffix(etag, `-br"`) || rec.Header().Get(HeaderVary) != HeaderAcceptEncoding {
+		t.Fatalf("etag = %s, vary = %s", etag, rec.Header().Get(HeaderVary))
+	}
+	if rec := get("/app.js", "br", etag); rec.Code != http.StatusNotModified {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusNotModified)
+	}
+	if rec := get("/app.js", "", etag); rec.Code != http.StatusOK {
+		t.Errorf("status = %d for the uncompressed file, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+// sequentialFS hides the Seek and ReadAt methods of the files, which can then only be read sequentially
+type sequentialFS struct {
+	iofs.FS
+}
+
+func (s sequentialFS) Open(name string) (iofs.File, error) {
+	file, err := s.FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	if statInfo, err := file.Stat(); err == nil && statInfo.IsDir() {
+		return file, nil
+	}
+	return struct{ iofs.File }{file}, nil
+}
+
+func TestAssetRanges(t *testing.T) {
+	video := make([]byte, 4096)
+	for i := range video {
+		video[i] = byte(i)
+	}
+	assets := fstest.MapFS{
+		"index.html": {Data: []byte("<html></html>")},
+		"video.mp4":  {Data: video},
+		"app.js":     {Data: []byte(strings.Repeat("console.log('wails');\n", 100))},
+	}
+	for name, vfs := range map[string]iofs.FS{"seekable": assets, "sequential": sequentialFS{assets}} {
+		t.Run(name, func(t *testing.T) {
+			handler, err := NewAssetHandler(assetserver.Options{Assets: vfs, Compression: &assetserver.Compression{}}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			get := func(path string, ranges string) *httptest.ResponseRecorder {
+				req := httptest.NewRequest(http.MethodGet, path, nil)
+				req.Header.Set(HeaderRange, ranges)
+				req.Header.Set(HeaderAcceptEncoding, "gzip")
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				return rec
+			}
+
+			rec := get("/video.mp4", "bytes=1000-1999")
+			if rec.Code != http.StatusPartialContent {
+				t.Fatalf("status = %d, want %d", rec.Code, http.StatusPartialContent)
+			}
+			if got := rec.Header().Get("Content-Range"); got != "bytes 1000-1999/4096" {
+				t.Errorf("content range = %s", got)
+			}
+			if !bytes.Equal(rec.Body.Bytes(), video[1000:2000]) {
+				t.Errorf("wrong content of the range")
+			}
+
+			rec = get("/video.mp4", "bytes=-96")
+			if rec.Code != http.StatusPartialContent || !bytes.Equal(rec.Body.Bytes(), video[4000:]) {
+				t.Errorf("suffix range: status = %d", rec.Code)
+			}
+
+			rec = get("/video.mp4", "bytes=5000-")
+			if rec.Code != http.StatusRequestedRangeNotSatisfiable {
+				t.Errorf("unsatisfiable range: status = %d", rec.Code)
+			}
+
+			// Ranges of compressible files are served from the uncompressed file
+			rec = get("/app.js", "bytes=0-6")
+			if rec.Code != http.StatusPartialContent || rec.Body.String() != "console" || rec.Header().Get(HeaderContentEncoding) != "" {
+				t.Errorf("compressible file: status = %d, body = %s", rec.Code, rec.Body.String())
+			}
+
+			rec = get("/video.mp4", "")
+			if rec.Code != http.StatusOK || !bytes.Equal(rec.Body.Bytes(), video) || rec.Header().Get("Accept-Ranges") != "bytes" {
+				t.Errorf("full file: status = %d", rec.Code)
+			}
+		})
+	}
+
+	// Multiple ranges in any order can't be served from a sequential file, it is sent completely
+	handler, err := NewAssetHandler(assetserver.Options{Assets: sequentialFS{assets}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/video.mp4", nil)
+	req.Header.Set(HeaderRange, "bytes=2000-2099,0-99")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !bytes.Equal(rec.Body.Bytes(), video) {
+		t.Errorf("multiple ranges: status = %d", rec.Code)
+	}
+}
+
+func TestSPAFallback(t *testing.T) {
+	assets := fstest.MapFS{
+		"index.html": {Data: []byte("<html><head></head><body>app</body></html>")},
+		"app.js":     {Data: []byte("console.log('wails');")},
+	}
+	notFound := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		http.Error(rw, "custom not found", http.StatusNotFound)
+	})
+	get := func(server *AssetServer, path string, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set(HeaderAccept, accept)
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec
+	}
+
+	server, err := NewAssetServer("", assetserver.Options{Assets: assets, SPAFallback: "index.html", NotFoundHandler: notFound}, false, nil, mockRuntimeAssets{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		path     string
+		accept   string
+		wantCode int
+		wantBody string
+	}{
+		{"deep link", "/users/42", "text/html,application/xhtml+xml", http.StatusOK, runtimeJSPath},
+		{"deep link with a trailing slash", "/users/42/", "text/html", http.StatusOK, runtimeJSPath},
+		{"existing file", "/app.js", "text/html", http.StatusOK, "console.log('wails');"},
+		{"missing file", "/missing.js", "*/*", http.StatusNotFound, "custom not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := get(server, tt.path, tt.accept)
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want it to contain %s", rec.Body.String(), tt.wantBody)
+			}
+		})
+	}
+
+	// Without the fallback, deep links are not found
+	server, err = NewAssetServer("", assetserver.Options{Assets: assets, NotFoundHandler: notFound}, false, nil, mockRuntimeAssets{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec := get(server, "/users/42", "text/html"); rec.Code != http.StatusNotFound || !strings.Contains(rec.Body.String(), "custom not found") {
+		t.Errorf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+
+	if err := (assetserver.Options{Handler: notFound, SPAFallback: "index.html"}).Validate(); err == nil {
+		t.Errorf("a SPAFallback without Assets is valid")
+	}
+}
+
+func TestExtendContentSecurityPolicy(t *testing.T) {
+	tests := []struct {
+		name           string
+		policy         string
+		connectSources []string
+		want           string
+	}{
+		{"script-src", "script-src 'self'", nil, "script-src 'self' 'nonce-abc'"},
+		{"default-src", "default-src 'self'; img-src *", nil, "default-src 'self'; img-src *; script-src 'self' 'nonce-abc'"},
+		{"none", "default-src 'none'", nil, "default-src 'none'; script-src 'nonce-abc'"},
+		{"unsafe-inline", "script-src 'unsafe-inline'", nil, "script-src 'unsafe-inline' 'self'"},
+		{"script-src-elem", "script-src 'self'; script-src-elem https://cdn.example.com", nil, "script-src 'self' 'nonce-abc'; script-src-elem https://cdn.example.com 'nonce-abc'"},
+		{"unrestricted scripts", "img-src 'self'", []string{"ws://localhost"}, "img-src 'self'"},
+		{"connect-src", "default-src 'self'", []string{"'self'", "ws://localhost"}, "default-src 'self'; script-src 'self' 'nonce-abc'; connect-src 'self' ws://localhost"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extendContentSecurityPolicy(tt.policy, "abc", tt.connectSources); got != tt.want {
+				t.Errorf("extendContentSecurityPolicy() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	assets := fstest.MapFS{
+		"index.html": {Data: []byte("<html><head></head><body></body></html>")},
+	}
+	server, err := NewAssetServer("", assetserver.Options{
+		Assets: assets,
+		SecurityHeaders: &assetserver.SecurityHeaders{
+			ContentSecurityPolicy: "default-src 'self'",
+			FrameOptions:          "DENY",
+			ReferrerPolicy:        "no-referrer",
+			Custom:                map[string]string{"Permissions-Policy": "camera=()"},
+		},
+	}, false, nil, mockRuntimeAssets{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"/", runtimeJSPath} {
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		for name, want := range map[string]string{HeaderFrameOptions: "DENY", HeaderReferrerPolicy: "no-referrer", "Permissions-Policy": "camera=()"} {
+			if got := rec.Header().Get(name); got != want {
+				t.Errorf("%s: %s = %s, want %s", path, name, got, want)
+			}
+		}
+		policy := rec.Header().Get(HeaderContentSecurityPolicy)
+		if path == runtimeJSPath {
+			if policy != "default-src 'self'" {
+				t.Errorf("%s: policy = %s", path, policy)
+			}
+			continue
+		}
+
+		// The injected scripts get the nonce allowed by the policy of the page
+		_, nonce, ok := strings.Cut(policy, "'nonce-")
+		if !ok {
+			t.Fatalf("no nonce in the policy %s", policy)
+		}
+		nonce = strings.TrimSuffix(nonce, "'")
+		if !strings.Contains(rec.Body.String(), `<script src="/wails/ipc.js" nonce="`+nonce+`"></script>`) {
+			t.Errorf("scripts without the nonce: %s", rec.Body.String())
+		}
+	}
+}
+
+func TestIndexTemplate(t *testing.T) {
+	assets := fstest.MapFS{
+		"index.html": {Data: []byte(`<html><head><script nonce="[[cspNonce]]">window.version="[[.Version]]"</script></head><body>[[upper .Flag]]</body></html>`)},
+	}
+	server, err := NewAssetServer("", assetserver.Options{
+		Assets:          assets,
+		SecurityHeaders: &assetserver.SecurityHeaders{ContentSecurityPolicy: "default-src 'self'"},
+		IndexTemplate: &assetserver.IndexTemplate{
+			Data: func(req *http.Request) (interface{}, error) {
+				return map[string]string{"Version": "v1.2.3", "Flag": req.URL.Query().Get("flag")}, nil
+			},
+			Funcs:  template.FuncMap{"upper": strings.ToUpper},
+			Delims: [2]string{"[[", "]]"},
+		},
+	}, false, nil, mockRuntimeAssets{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?flag=beta", nil))
+	_, nonce, _ := strings.Cut(rec.Header().Get(HeaderContentSecurityPolicy), "'nonce-")
+	nonce = strings.TrimSuffix(nonce, "'")
+	body := rec.Body.String()
+	for _, want := range []string{
+		`<script nonce="` + nonce + `">window.version="v1.2.3"</script>`,
+		`<script src="/wails/ipc.js" nonce="` + nonce + `"></script>`,
+		`<body>BETA</body>`,
+	} {
+		if nonce == "" || !strings.Contains(body, want) {
+			t.Errorf("%s not in %s", want, body)
+		}
+	}
+
+	// Errors of the data are not served as the page
+	server.UseIndexTemplate(&assetserver.IndexTemplate{
+		Data: func(req *http.Request) (interface{}, error) { return nil, iofs.ErrPermission },
+	})
+	rec = httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+}
+
+func TestMimeTypes(t *testing.T) {
+	assets := fstest.MapFS{
+		"index.html":  {Data: []byte("<html><head></head><body></body></html>")},
+		"model.glb":   {Data: []byte("glTF")},
+		"app.WASM":    {Data: []byte("\x00asm")},
+		"notes.dat":   {Data: []byte("plain text")},
+		"bundle.js":   {Data: []byte("export {}")},
+		"overlay.glb": {Data: []byte("glTF")},
+	}
+	server, err := NewAssetServer("", assetserver.Options{
+		Assets:       assets,
+		MimeTypes:    map[string]string{"glb": "model/gltf-binary", ".js": "application/javascript"},
+		MimeSniffing: assetserver.MimeSniffingNone,
+	}, false, nil, mockRuntimeAssets{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.SetOverlayFS(fstest.MapFS{"overlay.glb": {Data: []byte("glTF")}})
+
+	for path, want := range map[string]string{
+		"/model.glb":        "model/gltf-binary",
+		"/overlay.glb":      "model/gltf-binary",
+		"/app.WASM":         "application/wasm",
+		"/notes.dat":        "application/octet-stream",
+		"/bundle.js":        "application/javascript",
+		"/wails/runtime.js": "application/javascript",
+	} {
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if got := rec.Header().Get(HeaderContentType); got != want {
+			t.Errorf("%s: Content-Type = %s, want %s", path, got, want)
+		}
+	}
+}
+
+// infoLogger records the messages logged at the Info level
+type infoLogger struct {
+	messages []string
+}
+
+func (l *infoLogger) Debug(message string, args ...interface{}) {}
+func (l *infoLogger) Error(message string, args ...interface{}) {}
+func (l *infoLogger) Info(message string, args ...interface{}) {
+	l.messages = append(l.messages, fmt.Sprintf(message, args...))
+}
+
+func TestAccessLog(t *testing.T) {
+	assets := fstest.MapFS{
+		"index.html": {Data: []byte("<html><head></head><body></body></html>")},
+	}
+	log := &infoLogger{}
+	server, err := NewAssetServer("", assetserver.Options{Assets: assets, AccessLog: true}, false, log, mockRuntimeAssets{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	serve(server, "/")
+	serve(server, "/missing.js?token=secret")
+
+	if len(log.messages) != 2 {
+		t.Fatalf("messages = %v", log.messages)
+	}
+	for index, want := range []string{"[AssetServer] GET / 200 ", "[AssetServer] GET /missing.js 404 "} {
+		if !strings.HasPrefix(log.messages[index], want) {
+			t.Errorf("message = %s, want %s...", log.messages[index], want)
+		}
+	}
+}
+
+func TestManifest(t *testing.T) {
+	assets := fstest.MapFS{
+		"frontend/dist/index.html":    {Data: []byte("<html><head></head><body></body></html>")},
+		"frontend/dist/assets/app.js": {Data: []byte("app")},
+	}
+	server, err := NewAssetServer("", assetserver.Options{Assets: assets, EnableManifest: true}, false, nil, mockRuntimeAssets{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	getManifest := func() Manifest {
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, manifestPath, nil))
+		var manifest Manifest
+		if err := json.Unmarshal(rec.Body.Bytes(), &manifest); err != nil {
+			t.Fatalf("invalid manifest %s: %s", rec.Body.String(), err)
+		}
+		return manifest
+	}
+	hash := func(content string) string {
+		sum := sha256.Sum256([]byte(content))
+		return hex.EncodeToString(sum[:])
+	}
+
+	manifest := getManifest()
+	if len(manifest.Files) != 2 || manifest.Files["/assets/app.js"] != hash("app") || manifest.Files["/index.html"] == "" {
+		t.Errorf("files = %v", manifest.Files)
+	}
+	if manifest.Version == "" {
+		t.Errorf("no version")
+	}
+
+	// The files of the overlay replace the assets
+	server.SetOverlayFS(fstest.MapFS{"assets/app.js": {Data: []byte("patched")}})
+	patched := getManifest()
+	if patched.Files["/assets/app.js"] != hash("patched") || patched.Version == manifest.Version {
+		t.Errorf("manifest with the overlay = %v", patched)
+	}
+	if server.Manifest().Files["/assets/app.js"] != hash("patched") {
+		t.Errorf("Manifest() = %v", server.Manifest())
+	}
+
+	// Without the option there is no manifest
+	server, err = NewAssetServer("", assetserver.Options{Assets: assets}, false, nil, mockRuntimeAssets{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if server.Manifest() != nil {
+		t.Errorf("unexpected manifest")
+	}
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, manifestPath, nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestMounts(t *testing.T) {
+	assets := fstest.MapFS{
+		"index.html": {Data: []byte("<html><head></head><body>app</body></html>")},
+		"style.css":  {Data: []byte("app")},
+	}
+	server, err := NewAssetServer("", assetserver.Options{
+		Assets: assets,
+		Mounts: []assetserver.Mount{
+			{Prefix: "/docs/", Assets: fstest.MapFS{
+				"index.html": {Data: []byte("<html><head></head><body>docs {{.}}</body></html>")},
+				"style.css":  {Data: []byte("docs")},
+			}},
+			{Prefix: "docs/api", Assets: fstest.MapFS{"style.css": {Data: []byte("api")}}},
+		},
+		IndexTemplate: &assetserver.IndexTemplate{},
+	}, false, nil, mockRuntimeAssets{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for path, want := range map[string]string{
+		"/style.css":          "app",
+		"/docs/style.css":     "docs",
+		"/docs/api/style.css": "api",
+		"/docsstyle.css":      "",
+		"/docs/":              "<html><head></head><body>docs {{.}}</body></html>",
+		"/docs":               "<html><head></head><body>docs {{.}}</body></html>",
+	} {
+		if got := serve(server, path); got != want {
+			t.Errorf("%s: body = %s, want %s", path, got, want)
+		}
+	}
+	if body := serve(server, "/"); !strings.Contains(body, `<script src="/wails/ipc.js">`) {
+		t.Errorf("no runtime injected into the index.html of the assets: %s", body)
+	}
+}
+
+func TestSetOverlayFS(t *testing.T) {
+	assets := fstest.MapFS{
+		"index.html": {Data: []byte("<html><head></head><body>base</body></html>")},
+		"style.css":  {Data: []byte("base")},
+	}
+	server, err := NewAssetServer("", assetserver.Options{Assets: assets}, false, nil, mockRuntimeAssets{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.SetOverlayFS(fstest.MapFS{
+		"style.css":        {Data: []byte("overlay")},
+		"themes/dark.css":  {Data: []byte("dark")},
+		"plugins/index.js": {Data: []byte("plugin")},
+	})
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/style.css", "overlay"},
+		{"/themes/dark.css", "dark"},
+		{"/plugins/index.js", "plugin"},
+		{"/", "base"},
+	}
+	for _, tt := range tests {
+		if got := serve(server, tt.path); !strings.Contains(got, tt.want) {
+			t.Errorf("%s: body = %s, want %s", tt.path, got, tt.want)
+		}
+	}
+	if got := serve(server, "/"); !strings.Contains(got, ipcJSPath) {
+		t.Errorf("runtime not injected with an overlay: %s", got)
+	}
+
+	server.SetOverlayFS(nil)
+	if got := serve(server, "/style.css"); got != "base" {
+		t.Errorf("body = %s after removing the overlay, want base", got)
+	}
+}
+
+func TestSetOverlayFSCompression(t *testing.T) {
+	script := strings.Repeat("console.log('overlay');\n", 100)
+	assets := fstest.MapFS{"index.html": {Data: []byte("<html></html>")}}
+	server, err := NewAssetServer("", assetserver.Options{Assets: assets, Compression: &assetserver.Compression{}}, false, nil, mockRuntimeAssets{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.SetOverlayFS(fstest.MapFS{"app.js": {Data: []byte(script)}})
+
+	req := httptest.NewRequest(http.MethodGet, "/app.js", nil)
+	req.Header.Set(HeaderAcceptEncoding, "gzip")
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	if got := rec.Header().Get(HeaderContentEncoding); got != "gzip" {
+		t.Fatalf("encoding = %s, want gzip", got)
+	}
+	reader, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != script {
+		t.Errorf("body = %q, want the overlay file", content)
+	}
+}
@@ -124,6 +124,8 @@ func (d *AssetServer) processWebViewRequestInternal(r webview.Request) {
 	}
 
 	req.Header = header
+	// The webviews don't decode compressed responses of the asset server, which is in-process anyway
+	req.Header.Del(HeaderAcceptEncoding)
 
 	if req.RemoteAddr == "" {
 		// 192.0.2.0/24 is "TEST-NET" in RFC 5737
@@ -0,0 +1,84 @@
+//go:build dev
+// +build dev
+
+package assetserver
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	iofs "io/fs"
+	"net/http"
+
+	"github.com/wailsapp/wails/v2/pkg/options/assetserver"
+)
+
+// NewAssetsTarballHandler returns a handler streaming the served assets as a gzipped tarball, to
+// capture a snapshot of what the dev server is serving. It responds with 404 if the assets are not
+// served from an fs.FS, e.g. when they are served by a frontend dev server.
+func NewAssetsTarballHandler(options assetserver.Options, log Logger) (http.Handler, error) {
+	vfs, err := assetsFS(options.Assets)
+	if err != nil {
+		return nil, err
+	}
+
+	// The tarball contains the assets, so it is wrapped by the same middlewares as the AssetServer
+	return assetserver.ChainMiddleware(options.Middlewares...)(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if vfs == nil {
+			http.Error(rw, "the assets are not served from a filesystem", http.StatusNotFound)
+			return
+		}
+
+		rw.Header().Set(HeaderContentType, "application/gzip")
+		rw.Header().Set("Content-Disposition", `attachment; filename="assets.tar.gz"`)
+		if err := writeTarball(rw, vfs); err != nil && log != nil {
+			// The response has already been started, so only log the error
+			log.Error("Unable to write the assets tarball: %s", err)
+		}
+	})), nil
+}
+
+func writeTarball(rw http.ResponseWriter, vfs iofs.FS) error {
+	gzipWriter := gzip.NewWriter(rw)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	err := iofs.WalkDir(vfs, ".", func(path string, entry iofs.DirEntry, err error) error {
+		if err != nil || path == "." {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() && !info.Mode().IsRegular() {
+			// Symlinks and other special files are not part of the snapshot
+			return nil
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = path
+		if entry.IsDir() {
+			header.Name += "/"
+		}
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		content, err := iofs.ReadFile(vfs, path)
+		if err != nil {
+			return err
+		}
+		_, err = tarWriter.Write(content)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if err := tarWriter.Close(); err != nil {
+		return err
+	}
+	return gzipWriter.Close()
+}
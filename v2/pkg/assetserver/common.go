@@ -38,15 +38,16 @@ const (
 	HeaderUserAgent     = "User-Agent"
 	HeaderCacheControl  = "Cache-Control"
 	HeaderUpgrade       = "Upgrade"
+	HeaderAccept        = "Accept"
 
 	WailsUserAgentValue = "wails.io"
 )
 
-func serveFile(rw http.ResponseWriter, filename string, blob []byte) error {
+func serveFile(rw http.ResponseWriter, mimeTypes *mimeTypes, filename string, blob []byte) error {
 	header := rw.Header()
 	header.Set(HeaderContentLength, strconv.Itoa(len(blob)))
 	if mimeType := header.Get(HeaderContentType); mimeType == "" {
-		mimeType = GetMimetype(filename, blob)
+		mimeType = mimeTypes.mimetype(filename, blob)
 		header.Set(HeaderContentType, mimeType)
 	}
 
@@ -55,8 +56,8 @@ func serveFile(rw http.ResponseWriter, filename string, blob []byte) error {
 	return err
 }
 
-func createScriptNode(scriptName string) *html.Node {
-	return &html.Node{
+func createScriptNode(scriptName string, nonce string) *html.Node {
+	node := &html.Node{
 		Type: html.ElementNode,
 		Data: "script",
 		Attr: []html.Attribute{
@@ -66,6 +67,10 @@ func createScriptNode(scriptName string) *html.Node {
 			},
 		},
 	}
+	if nonce != "" {
+		node.Attr = append(node.Attr, html.Attribute{Key: "nonce", Val: nonce})
+	}
+	return node
 }
 
 func createDivNode(id string) *html.Node {
@@ -82,12 +87,12 @@ func createDivNode(id string) *html.Node {
 	}
 }
 
-func insertScriptInHead(htmlNode *html.Node, scriptName string) error {
+func insertScriptInHead(htmlNode *html.Node, scriptName string, nonce string) error {
 	headNode := findFirstTag(htmlNode, "head")
 	if headNode == nil {
 		return errors.New("cannot find head in HTML")
 	}
-	scriptNode := createScriptNode(scriptName)
+	scriptNode := createScriptNode(scriptName, nonce)
 	if headNode.FirstChild != nil {
 		headNode.InsertBefore(scriptNode, headNode.FirstChild)
 	} else {
@@ -133,3 +138,8 @@ func isWebSocket(req *http.Request) bool {
 	upgrade := req.Header.Get(HeaderUpgrade)
 	return strings.EqualFold(upgrade, "websocket")
 }
+
+// isNavigation reports whether the request is a page navigation, which accepts HTML
+func isNavigation(req *http.Request) bool {
+	return strings.Contains(req.Header.Get(HeaderAccept), "text/html")
+}
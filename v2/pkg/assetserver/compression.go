@@ -0,0 +1,151 @@
+package assetserver
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/wailsapp/wails/v2/pkg/options/assetserver"
+)
+
+const (
+	HeaderAcceptEncoding  = "Accept-Encoding"
+	HeaderContentEncoding = "Content-Encoding"
+	HeaderVary            = "Vary"
+
+	defaultCompressionMinSize = 1024
+)
+
+var defaultCompressedMimeTypes = []string{"text/*", "application/javascript", "application/json", "application/wasm", "image/svg+xml"}
+
+// sidecarEncodings are the encodings of the pre-compressed files, in the order of preference
+var sidecarEncodings = []struct {
+	encoding  string
+	extension string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// compression decides which files are compressed, it is nil if the compression is disabled
+type compression struct {
+	mimeTypes []string
+	minSize   int64
+}
+
+func newCompression(options *assetserver.Compression) *compression {
+	if options == nil {
+		return nil
+	}
+	result := &compression{
+		mimeTypes: options.MimeTypes,
+		minSize:   options.MinSize,
+	}
+	if len(result.mimeTypes) == 0 {
+		result.mimeTypes = defaultCompressedMimeTypes
+	}
+	if result.minSize <= 0 {
+		result.minSize = defaultCompressionMinSize
+	}
+	return result
+}
+
+// compresses reports whether a file of the content type and size is compressed
+func (c *compression) compresses(contentType string, size int64) bool {
+	if c == nil || size < c.minSize {
+		return false
+	}
+	mimeType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, compressed := range c.mimeTypes {
+		if prefix, ok := strings.CutSuffix(compressed, "*"); ok && strings.HasPrefix(mimeType, prefix) {
+			return true
+		}
+		if compressed == mimeType {
+			return true
+		}
+	}
+	return false
+}
+
+// serveCompressed serves the pre-compressed sidecar of the file or compresses the content with gzip, depending
+// on the encodings the client accepts. It reports whether the file has been served.
+func (d *assetHandler) serveCompressed(rw http.ResponseWriter, req *http.Request, filename string, etag string, content io.Reader) (bool, error) {
+	// Caches must not serve the compressed file to clients not accepting it, and vice versa
+	rw.Header().Add(HeaderVary, HeaderAcceptEncoding)
+	acceptEncoding := req.Header.Get(HeaderAcceptEncoding)
+
+	for _, sidecar := range sidecarEncodings {
+		if !acceptsEncoding(acceptEncoding, sidecar.encoding) {
+			continue
+		}
+		file, err := d.fs.Open(filename + sidecar.extension)
+		if err != nil {
+			continue
+		}
+		defer file.Close()
+		statInfo, err := file.Stat()
+		if err != nil || statInfo.IsDir() {
+			continue
+		}
+		rw.Header().Set(HeaderContentLength, strconv.FormatInt(statInfo.Size(), 10))
+		if !d.writeEncodedHeader(rw, req, sidecar.encoding, etag) {
+			return true, nil
+		}
+		_, err = io.Copy(rw, file)
+		return true, err
+	}
+
+	if !acceptsEncoding(acceptEncoding, "gzip") {
+		return false, nil
+	}
+	rw.Header().Del(HeaderContentLength)
+	if !d.writeEncodedHeader(rw, req, "gzip", etag) {
+		return true, nil
+	}
+	gzipWriter := gzip.NewWriter(rw)
+	if _, err := io.Copy(gzipWriter, content); err != nil {
+		return true, err
+	}
+	return true, gzipWriter.Close()
+}
+
+// writeEncodedHeader writes the header of the encoded file, whose ETag differs from the one of the file. It
+// reports whether the body should be written, it is not for a 304 Not Modified.
+func (d *assetHandler) writeEncodedHeader(rw http.ResponseWriter, req *http.Request, encoding string, etag string) bool {
+	etag = strings.TrimSuffix(etag, `"`) + "-" + encoding + `"`
+	header := rw.Header()
+	header.Set(HeaderETag, etag)
+	if ifNoneMatch := req.Header.Get(HeaderIfNoneMatch); ifNoneMatch != "" && etagMatches(ifNoneMatch, etag) {
+		header.Del(HeaderContentLength)
+		rw.WriteHeader(http.StatusNotModified)
+		return false
+	}
+	header.Set(HeaderContentEncoding, encoding)
+	rw.WriteHeader(http.StatusOK)
+	return true
+}
+
+// acceptsEncoding reports whether the Accept-Encoding header accepts the encoding, an encoding with a
+// quality of zero is refused
+func acceptsEncoding(acceptEncoding string, encoding string) bool {
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.TrimSpace(name)
+		if !strings.EqualFold(name, encoding) && name != "*" {
+			continue
+		}
+		if quality, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if q, err := strconv.ParseFloat(quality, 64); err == nil && q == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package assetserver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	iofs "io/fs"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	HeaderETag            = "ETag"
+	HeaderIfNoneMatch     = "If-None-Match"
+	HeaderLastModified    = "Last-Modified"
+	HeaderIfModifiedSince = "If-Modified-Since"
+)
+
+// etagCache holds the content hashes of the asset files. The hash of an embedded file is computed once, the
+// one of a file on disk again after its modification time or size changed, e.g. when it is rebuilt in dev mode.
+type etagCache struct {
+	lock    sync.Mutex
+	entries map[string]etagEntry
+}
+
+type etagEntry struct {
+	modTime time.Time
+	size    int64
+	etag    string
+}
+
+func newETagCache() *etagCache {
+	return &etagCache{entries: make(map[string]etagEntry)}
+}
+
+// etag returns the strong ETag of the file, the quoted hash of its content
+func (c *etagCache) etag(fsys iofs.FS, filename string, statInfo iofs.FileInfo) (string, error) {
+	c.lock.Lock()
+	entry, ok := c.entries[filename]
+	c.lock.Unlock()
+	if ok && entry.modTime.Equal(statInfo.ModTime()) && entry.size == statInfo.Size() {
+		return entry.etag, nil
+	}
+
+	file, err := fsys.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	etag := `"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+
+	c.lock.Lock()
+	c.entries[filename] = etagEntry{modTime: statInfo.ModTime(), size: statInfo.Size(), etag: etag}
+	c.lock.Unlock()
+	return etag, nil
+}
+
+// etagMatches reports whether the If-None-Match header matches the ETag, using the weak comparison
+func etagMatches(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+//go:build dev
+// +build dev
+
+package assetserver
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// upstreamCooldown is the time an upstream which failed to connect is skipped, before it is tried again
+const upstreamCooldown = 5 * time.Second
+
+// ParseFrontendDevServerURLs parses a comma-separated list of frontend dev server URLs, in the order in which
+// they should be tried.
+func ParseFrontendDevServerURLs(value string) ([]*url.URL, error) {
+	var result []*url.URL
+	for _, rawURL := range strings.Split(value, ",") {
+		rawURL = strings.TrimSpace(rawURL)
+		if rawURL == "" {
+			continue
+		}
+		upstream, err := url.Parse(rawURL)
+		if err != nil {
+			return nil, err
+		}
+		if upstream.Host == "" {
+			return nil, fmt.Errorf("Invalid frontend:dev:serverUrl '%s' missing protocol scheme?", rawURL)
+		}
+		result = append(result, upstream)
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no frontend:dev:serverUrl defined")
+	}
+	return result, nil
+}
+
+// FailoverTransport sends the requests to the first of the upstreams which accepts the connection. Upstreams
+// failing to connect are skipped for a short time, after which they are tried again, so a restarted primary
+// upstream is picked up lazily without active health checks.
+// Only requests without a body are retried on another upstream.
+type FailoverTransport struct {
+	upstreams []*url.URL
+	transport http.RoundTripper
+	logger    Logger
+
+	lock      sync.Mutex
+	downUntil map[int]time.Time
+}
+
+// NewFailoverTransport creates a FailoverTransport using the given transport for the requests, if the
+// transport is nil http.DefaultTransport is used.
+func NewFailoverTransport(logger Logger, upstreams []*url.URL, transport http.RoundTripper) *FailoverTransport {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &FailoverTransport{
+		upstreams: upstreams,
+		transport: transport,
+		logger:    logger,
+		downUntil: make(map[int]time.Time),
+	}
+}
+
+func (f *FailoverTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var err error
+	for _, index := range f.candidates() {
+		upstream := f.upstreams[index]
+		outreq := req.Clone(req.Context())
+		outreq.URL.Scheme = upstream.Scheme
+		outreq.URL.Host = upstream.Host
+		if req.Host == req.URL.Host {
+			outreq.Host = upstream.Host
+		}
+
+		var resp *http.Response
+		resp, err = f.transport.RoundTrip(outreq)
+		if err == nil {
+			f.markUp(index)
+			if f.logger != nil {
+				f.logger.Debug("[FrontendDevServer] '%s' served by %s", req.URL.Path, upstream.Host)
+			}
+			return resp, nil
+		}
+		if !isConnectError(err) || req.Body != nil && req.Body != http.NoBody {
+			return nil, err
+		}
+		f.markDown(index)
+		if f.logger != nil {
+			f.logger.Debug("[FrontendDevServer] Unable to connect to %s: %s", upstream.Host, err)
+		}
+	}
+	return nil, err
+}
+
+// candidates returns the indexes of the upstreams to try, the ones which are not down first
+func (f *FailoverTransport) candidates() []int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	now := time.Now()
+	var up, down []int
+	for index := range f.upstreams {
+		if now.Before(f.downUntil[index]) {
+			down = append(down, index)
+		} else {
+			up = append(up, index)
+		}
+	}
+	return append(up, down...)
+}
+
+func (f *FailoverTransport) markDown(index int) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.downUntil[index] = time.Now().Add(upstreamCooldown)
+}
+
+func (f *FailoverTransport) markUp(index int) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	delete(f.downUntil, index)
+}
+
+// isConnectError reports whether the error happened while connecting, so the request has not been sent
+func isConnectError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
@@ -0,0 +1,46 @@
+package assetserver
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+
+	"github.com/wailsapp/wails/v2/pkg/options/assetserver"
+)
+
+// UseIndexTemplate renders the index.html as a html/template before the runtime is injected, nil serves it as it is
+func (d *AssetServer) UseIndexTemplate(indexTemplate *assetserver.IndexTemplate) {
+	d.indexTemplate = indexTemplate
+}
+
+// renderIndexTemplate renders the page with the data for the request. The template is parsed for every request,
+// so that changes of the index.html are picked up in dev mode.
+func (d *AssetServer) renderIndexTemplate(req *http.Request, page []byte, nonce string) ([]byte, error) {
+	indexTemplate := d.indexTemplate
+	if indexTemplate == nil {
+		return page, nil
+	}
+
+	tmpl := template.New(indexHTML).Funcs(indexTemplate.Funcs).Funcs(template.FuncMap{
+		"cspNonce": func() string { return nonce },
+	})
+	if delims := indexTemplate.Delims; delims[0] != "" || delims[1] != "" {
+		tmpl = tmpl.Delims(delims[0], delims[1])
+	}
+	tmpl, err := tmpl.Parse(string(page))
+	if err != nil {
+		return nil, err
+	}
+
+	var data interface{}
+	if indexTemplate.Data != nil {
+		if data, err = indexTemplate.Data(req); err != nil {
+			return nil, err
+		}
+	}
+	var buffer bytes.Buffer
+	if err := tmpl.Execute(&buffer, data); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
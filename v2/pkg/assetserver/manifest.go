@@ -0,0 +1,126 @@
+package assetserver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	iofs "io/fs"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+const manifestPath = "/wails/manifest.json"
+
+// Manifest lists the asset files with the hashes of their content, e.g. for cache-busting or to precache the
+// assets in a service worker
+type Manifest struct {
+	// Version is the hash of all files, it changes whenever a file changes
+	Version string `json:"version"`
+	// Files maps the URL paths of the files, e.g. "/assets/app.js", to the hex encoded hashes of their content
+	Files map[string]string `json:"files"`
+}
+
+// UseManifest builds the manifest of the assets, which is served at /wails/manifest.json. Like the Assets of the
+// options, the files are served from the directory containing the index.html. The files of the overlay FS are
+// listed in place of the assets with the same path. Nil assets remove the manifest.
+func (d *AssetServer) UseManifest(assets iofs.FS) error {
+	vfs, err := assetsFS(assets)
+	if err != nil {
+		return err
+	}
+	d.manifestLock.Lock()
+	defer d.manifestLock.Unlock()
+	d.manifestAssets = vfs
+	return d.buildManifest()
+}
+
+// Manifest returns a copy of the manifest of the assets, nil if there is none
+func (d *AssetServer) Manifest() *Manifest {
+	d.manifestLock.RLock()
+	defer d.manifestLock.RUnlock()
+	if d.manifest == nil {
+		return nil
+	}
+	files := make(map[string]string, len(d.manifest.Files))
+	for path, hash := range d.manifest.Files {
+		files[path] = hash
+	}
+	return &Manifest{Version: d.manifest.Version, Files: files}
+}
+
+// buildManifest hashes the files of the assets and of the overlay, the manifestLock must be held
+func (d *AssetServer) buildManifest() error {
+	if d.manifestAssets == nil {
+		d.manifest = nil
+		return nil
+	}
+	d.overlayLock.RLock()
+	overlay := d.overlayFS
+	d.overlayLock.RUnlock()
+
+	files := make(map[string]string)
+	for _, fsys := range []iofs.FS{d.manifestAssets, overlay} {
+		if fsys == nil {
+			continue
+		}
+		if err := hashFiles(fsys, files); err != nil {
+			return err
+		}
+	}
+
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	version := sha256.New()
+	for _, path := range paths {
+		version.Write([]byte(path + " " + files[path] + "\n"))
+	}
+	d.manifest = &Manifest{
+		Version: hex.EncodeToString(version.Sum(nil)[:16]),
+		Files:   files,
+	}
+	return nil
+}
+
+// hashFiles adds the hashes of all files of the FS by their URL path
+func hashFiles(fsys iofs.FS, files map[string]string) error {
+	return iofs.WalkDir(fsys, ".", func(path string, entry iofs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		file, err := fsys.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		hash := sha256.New()
+		if _, err := io.Copy(hash, file); err != nil {
+			return err
+		}
+		files["/"+strings.TrimPrefix(path, "./")] = hex.EncodeToString(hash.Sum(nil))
+		return nil
+	})
+}
+
+func (d *AssetServer) hasManifest() bool {
+	d.manifestLock.RLock()
+	defer d.manifestLock.RUnlock()
+	return d.manifest != nil
+}
+
+func (d *AssetServer) serveManifest(rw http.ResponseWriter) {
+	d.manifestLock.RLock()
+	content, err := json.Marshal(d.manifest)
+	d.manifestLock.RUnlock()
+	if err != nil {
+		d.serveError(rw, err, "Unable to marshal the manifest")
+		return
+	}
+	// The manifest changes with the overlay, it is always validated
+	rw.Header().Set(HeaderCacheControl, "no-cache")
+	d.writeBlob(rw, manifestPath, content)
+}
@@ -3,15 +3,15 @@ package assetserver
 import (
 	"net/http"
 	"path/filepath"
+	"strings"
 	"sync"
 
 	"github.com/wailsapp/mimetype"
+
+	"github.com/wailsapp/wails/v2/pkg/options/assetserver"
 )
 
 var (
-	mimeCache = map[string]string{}
-	mimeMutex sync.Mutex
-
 	// The list of builtin mime-types by extension as defined by
 	// the golang standard lib package "mime"
 	// The standard lib also takes into account mime type definitions from
@@ -35,33 +35,83 @@ var (
 		".webp": "image/webp",
 		".xml":  "text/xml; charset=utf-8",
 	}
+
+	defaultMimeTypes = newMimeTypes(nil, assetserver.MimeSniffingDetect)
 )
 
 func GetMimetype(filename string, data []byte) string {
-	mimeMutex.Lock()
-	defer mimeMutex.Unlock()
+	return defaultMimeTypes.mimetype(filename, data)
+}
+
+// mimeTypes determines the Content-Type of the files by their extension, sniffing the content of files with
+// unknown extensions. The sniffed types are cached by filename.
+type mimeTypes struct {
+	byExt    map[string]string
+	sniffing assetserver.MimeSniffing
 
-	result := mimeTypesByExt[filepath.Ext(filename)]
+	lock  sync.Mutex
+	cache map[string]string
+}
+
+// newMimeTypes creates the mimeTypes with the custom types by extension, which override the builtin ones
+func newMimeTypes(custom map[string]string, sniffing assetserver.MimeSniffing) *mimeTypes {
+	byExt := make(map[string]string, len(mimeTypesByExt)+len(custom))
+	for ext, mimeType := range mimeTypesByExt {
+		byExt[ext] = mimeType
+	}
+	for ext, mimeType := range custom {
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		byExt[strings.ToLower(ext)] = mimeType
+	}
+	return &mimeTypes{
+		byExt:    byExt,
+		sniffing: sniffing,
+		cache:    map[string]string{},
+	}
+}
+
+// mimetype returns the Content-Type of the file, a nil mimeTypes uses the defaults
+func (m *mimeTypes) mimetype(filename string, data []byte) string {
+	if m == nil {
+		m = defaultMimeTypes
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	ext := filepath.Ext(filename)
+	result := m.byExt[ext]
+	if result == "" {
+		result = m.byExt[strings.ToLower(ext)]
+	}
 	if result != "" {
 		return result
 	}
 
-	result = mimeCache[filename]
+	result = m.cache[filename]
 	if result != "" {
 		return result
 	}
 
-	detect := mimetype.Detect(data)
-	if detect == nil {
+	switch m.sniffing {
+	case assetserver.MimeSniffingStandard:
 		result = http.DetectContentType(data)
-	} else {
-		result = detect.String()
+	case assetserver.MimeSniffingNone:
+	default:
+		detect := mimetype.Detect(data)
+		if detect == nil {
+			result = http.DetectContentType(data)
+		} else {
+			result = detect.String()
+		}
 	}
 
 	if result == "" {
 		result = "application/octet-stream"
 	}
 
-	mimeCache[filename] = result
+	m.cache[filename] = result
 	return result
 }
@@ -0,0 +1,82 @@
+package assetserver
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/wailsapp/wails/v2/pkg/options/assetserver"
+)
+
+// mountHandler serves the requests below the prefixes of the mounts from their assets, all other requests
+// are served by the handler of the assets
+type mountHandler struct {
+	// mounts are sorted by the length of their prefix, the longest first
+	mounts  []mount
+	handler http.Handler
+}
+
+type mount struct {
+	prefix  string
+	handler http.Handler
+}
+
+func newMountHandler(options assetserver.Options, handler http.Handler, log Logger) http.Handler {
+	result := &mountHandler{handler: handler}
+	for _, m := range options.Mounts {
+		prefix := mountPrefix(m)
+		result.mounts = append(result.mounts, mount{
+			prefix: prefix,
+			handler: http.StripPrefix(prefix, &assetHandler{
+				fs:              m.Assets,
+				logger:          log,
+				etags:           newETagCache(),
+				compression:     newCompression(options.Compression),
+				mimeTypes:       newMimeTypes(options.MimeTypes, options.MimeSniffing),
+				notFoundHandler: options.NotFoundHandler,
+			}),
+		})
+	}
+	sort.SliceStable(result.mounts, func(i, j int) bool {
+		return len(result.mounts[i].prefix) > len(result.mounts[j].prefix)
+	})
+	return result
+}
+
+func (h *mountHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	for _, m := range h.mounts {
+		if isBelowPrefix(req.URL.Path, m.prefix) {
+			m.handler.ServeHTTP(rw, req)
+			return
+		}
+	}
+	h.handler.ServeHTTP(rw, req)
+}
+
+// UseMounts tells the AssetServer the prefixes its handler serves the mounts at, the runtime is not injected
+// into their pages
+func (d *AssetServer) UseMounts(mounts []assetserver.Mount) {
+	d.mountPrefixes = nil
+	for _, m := range mounts {
+		d.mountPrefixes = append(d.mountPrefixes, mountPrefix(m))
+	}
+}
+
+// isMounted reports whether the path is served by a mount
+func (d *AssetServer) isMounted(path string) bool {
+	for _, prefix := range d.mountPrefixes {
+		if isBelowPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// mountPrefix returns the prefix of the mount with a leading and without a trailing slash, e.g. "/docs"
+func mountPrefix(m assetserver.Mount) string {
+	return "/" + strings.Trim(m.Prefix, "/")
+}
+
+func isBelowPrefix(path string, prefix string) bool {
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
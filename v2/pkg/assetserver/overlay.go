@@ -0,0 +1,47 @@
+package assetserver
+
+import (
+	iofs "io/fs"
+	"net/http"
+)
+
+// SetOverlayFS layers the files of the overlay over the assets while the app is running, e.g. themes, plugins or
+// downloaded updates. A file of the overlay is served in place of the asset with the same path, all other
+// requests are served as before. The paths of the overlay are relative to the directory of the index.html of
+// the assets. A nil overlay removes it.
+//
+// Pages which have already been loaded only pick up the overlay after a reload.
+func (d *AssetServer) SetOverlayFS(overlay iofs.FS) {
+	var handler http.Handler
+	if overlay != nil {
+		handler = &assetHandler{
+			fs:              overlay,
+			handler:         d.handler,
+			logger:          d.logger,
+			etags:           newETagCache(),
+			compression:     newCompression(d.compression),
+			mimeTypes:       d.mimeTypes,
+			notFoundHandler: d.notFoundHandler,
+		}
+	}
+	d.overlayLock.Lock()
+	d.overlay = handler
+	d.overlayFS = overlay
+	d.overlayLock.Unlock()
+
+	d.manifestLock.Lock()
+	defer d.manifestLock.Unlock()
+	if err := d.buildManifest(); err != nil {
+		d.logError("Unable to build the manifest of the overlay: %s", err)
+	}
+}
+
+// assetHandler returns the handler of the requests, which serves the overlay if there is one
+func (d *AssetServer) assetHandler() http.Handler {
+	d.overlayLock.RLock()
+	defer d.overlayLock.RUnlock()
+	if d.overlay != nil {
+		return d.overlay
+	}
+	return d.handler
+}
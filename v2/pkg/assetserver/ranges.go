@@ -0,0 +1,71 @@
+package assetserver
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	iofs "io/fs"
+)
+
+const HeaderRange = "Range"
+
+// seekableContent returns the content of the file for http.ServeContent, which needs an io.ReadSeeker to
+// serve Range requests. The first bytes of the file may already have been read to sniff its type.
+func seekableContent(file iofs.File, size int64, sniffed []byte) (io.ReadSeeker, error) {
+	if seeker, ok := file.(io.ReadSeeker); ok {
+		if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("seeker can't seek")
+		}
+		return seeker, nil
+	}
+	if readerAt, ok := file.(io.ReaderAt); ok {
+		return io.NewSectionReader(readerAt, 0, size), nil
+	}
+	return &forwardSeeker{reader: io.MultiReader(bytes.NewReader(sniffed), file), size: size}, nil
+}
+
+// forwardSeeker makes a file which can only be read sequentially seekable, as long as it is not read
+// backwards. Seeking only moves the position, the skipped content is discarded with the next read.
+type forwardSeeker struct {
+	reader io.Reader
+	size   int64
+
+	// offset is the position of the reader, position the one seeked to
+	offset   int64
+	position int64
+}
+
+func (s *forwardSeeker) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += s.position
+	case io.SeekEnd:
+		offset += s.size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	s.position = offset
+	return offset, nil
+}
+
+func (s *forwardSeeker) Read(p []byte) (int, error) {
+	if s.position < s.offset {
+		return 0, errors.New("the file can't be read backwards")
+	}
+	if skip := s.position - s.offset; skip > 0 {
+		skipped, err := io.CopyN(io.Discard, s.reader, skip)
+		s.offset += skipped
+		if err != nil {
+			return 0, err
+		}
+	}
+	n, err := s.reader.Read(p)
+	s.offset += int64(n)
+	s.position = s.offset
+	return n, err
+}
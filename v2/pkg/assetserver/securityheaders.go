@@ -0,0 +1,159 @@
+package assetserver
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"strings"
+
+	"github.com/wailsapp/wails/v2/pkg/options/assetserver"
+)
+
+const (
+	HeaderContentSecurityPolicy = "Content-Security-Policy"
+	HeaderFrameOptions          = "X-Frame-Options"
+	HeaderReferrerPolicy        = "Referrer-Policy"
+)
+
+// UseSecurityHeaders adds the security headers to all responses, nil removes them
+func (d *AssetServer) UseSecurityHeaders(headers *assetserver.SecurityHeaders) {
+	d.securityHeaders = headers
+}
+
+func (d *AssetServer) writeSecurityHeaders(rw http.ResponseWriter) {
+	headers := d.securityHeaders
+	if headers == nil {
+		return
+	}
+	header := rw.Header()
+	for name, value := range headers.Custom {
+		if value != "" {
+			header.Set(name, value)
+		}
+	}
+	for name, value := range map[string]string{
+		HeaderContentSecurityPolicy: headers.ContentSecurityPolicy,
+		HeaderFrameOptions:          headers.FrameOptions,
+		HeaderReferrerPolicy:        headers.ReferrerPolicy,
+	} {
+		if value != "" {
+			header.Set(name, value)
+		}
+	}
+}
+
+// allowInjectedScripts extends the Content-Security-Policy of the page to allow the injected scripts and, in dev
+// mode, the connection of the websocket IPC. It returns the nonce of the scripts, which is empty if the page
+// has no policy.
+func (d *AssetServer) allowInjectedScripts(rw http.ResponseWriter, req *http.Request) (string, error) {
+	policy := rw.Header().Get(HeaderContentSecurityPolicy)
+	if policy == "" {
+		return "", nil
+	}
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	nonce := base64.StdEncoding.EncodeToString(random)
+
+	var connectSources []string
+	if d.ipcJS != nil && !d.useDesktopIPC(req) {
+		connectSources = []string{"'self'", "ws://" + req.Host, "wss://" + req.Host}
+	}
+	rw.Header().Set(HeaderContentSecurityPolicy, extendContentSecurityPolicy(policy, nonce, connectSources))
+	return nonce, nil
+}
+
+// extendContentSecurityPolicy allows the scripts with the nonce and the connections to the sources. Directives
+// which are not restricted, neither by themselves nor by default-src, are left as they are.
+func extendContentSecurityPolicy(policy string, nonce string, connectSources []string) string {
+	var directives [][]string
+	for _, directive := range strings.Split(policy, ";") {
+		if fields := strings.Fields(directive); len(fields) > 0 {
+			directives = append(directives, fields)
+		}
+	}
+
+	scriptDirectives := []string{"script-src", "script-src-elem"}
+	for _, name := range scriptDirectives {
+		sources := directiveSources(directives, name)
+		if sources == nil {
+			continue
+		}
+		// A nonce disables 'unsafe-inline', the scripts are allowed by their origin instead
+		source := "'nonce-" + nonce + "'"
+		if containsSource(sources, "'unsafe-inline'") {
+			source = "'self'"
+		}
+		directives = allowSources(directives, name, source)
+	}
+	if len(connectSources) > 0 {
+		directives = allowSources(directives, "connect-src", connectSources...)
+	}
+
+	result := make([]string, 0, len(directives))
+	for _, directive := range directives {
+		result = append(result, strings.Join(directive, " "))
+	}
+	return strings.Join(result, "; ")
+}
+
+// directiveSources returns the sources of the directive, which falls back to default-src except for
+// script-src-elem. It returns nil if the directive is not restricted.
+func directiveSources(directives [][]string, name string) []string {
+	for _, directive := range directives {
+		if strings.EqualFold(directive[0], name) {
+			return directive[1:]
+		}
+	}
+	if name == "script-src-elem" {
+		// It falls back to script-src, which is extended separately
+		return nil
+	}
+	for _, directive := range directives {
+		if strings.EqualFold(directive[0], "default-src") {
+			return directive[1:]
+		}
+	}
+	return nil
+}
+
+// allowSources adds the sources to the directive, which is created from default-src if it does not exist
+func allowSources(directives [][]string, name string, sources ...string) [][]string {
+	for index, directive := range directives {
+		if strings.EqualFold(directive[0], name) {
+			directives[index] = addSources(directive[:1], directive[1:], sources)
+			return directives
+		}
+	}
+	defaultSources := directiveSources(directives, name)
+	if defaultSources == nil {
+		return directives
+	}
+	return append(directives, addSources([]string{name}, defaultSources, sources))
+}
+
+// addSources returns the directive with the sources added to the existing ones, 'none' is dropped
+func addSources(directive []string, existing []string, sources []string) []string {
+	result := append([]string(nil), directive...)
+	for _, source := range existing {
+		if !strings.EqualFold(source, "'none'") {
+			result = append(result, source)
+		}
+	}
+	for _, source := range sources {
+		if !containsSource(result[1:], source) {
+			result = append(result, source)
+		}
+	}
+	return result
+}
+
+func containsSource(sources []string, source string) bool {
+	for _, s := range sources {
+		if strings.EqualFold(s, source) {
+			return true
+		}
+	}
+	return false
+}
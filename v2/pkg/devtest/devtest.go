@@ -0,0 +1,98 @@
+// Package devtest runs the IPC of the dev server in-process, to test the bindings and event flows of an app
+// without a browser. The IPC websocket is served over in-memory connections, no port is opened.
+package devtest
+
+import (
+	"context"
+
+	"github.com/wailsapp/wails/v2/internal/frontend/devserver"
+	"github.com/wailsapp/wails/v2/pkg/options"
+)
+
+// Event is an event received by a Client
+type Event = devserver.EventNotify
+
+// Harness runs the dev server of the app options in-process
+type Harness struct {
+	harness *devserver.IPCHarness
+}
+
+// New creates a harness whose calls are dispatched to the structs in appoptions.Bind
+func New(appoptions *options.App) *Harness {
+	return &Harness{harness: devserver.NewIPCHarness(appoptions)}
+}
+
+// Dial connects a new client to the IPC websocket, with the AuthToken of the options
+func (h *Harness) Dial(ctx context.Context) (*Client, error) {
+	client, err := h.harness.DialIPC(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{ID: client.ID, client: client}, nil
+}
+
+// EventsOn registers a Go listener for the event, like runtime.EventsOn. The returned function removes it.
+func (h *Harness) EventsOn(eventName string, callback func(optionalData ...interface{})) func() {
+	return h.harness.Events.On(eventName, callback)
+}
+
+// EventsEmit emits the event to the Go listeners and the clients, like runtime.EventsEmit
+func (h *Harness) EventsEmit(eventName string, optionalData ...interface{}) {
+	h.harness.Events.Emit(eventName, optionalData...)
+}
+
+// ClientIDs returns the IDs of the connected clients
+func (h *Harness) ClientIDs() []string {
+	return h.harness.Server.ClientIDs()
+}
+
+// Close disconnects all clients and stops the harness
+func (h *Harness) Close() error {
+	return h.harness.Close()
+}
+
+// Client is a client of the IPC websocket, sending the same messages as the injected IPC script
+type Client struct {
+	// ID is the ID the dev server gave the client
+	ID string
+
+	client *devserver.IPCClient
+}
+
+// Call calls the bound method, e.g. "main.App.Greet", and waits for its result. The error is either the
+// error returned by the method or the error of the connection.
+func (c *Client) Call(ctx context.Context, name string, args ...interface{}) (interface{}, error) {
+	return c.client.Call(ctx, name, args...)
+}
+
+// Emit emits the event to the other clients and the Go event listeners
+func (c *Client) Emit(name string, data ...interface{}) error {
+	return c.client.Emit(name, data...)
+}
+
+// Subscribe subscribes the client to the events. Until the first subscription, the client receives all events.
+func (c *Client) Subscribe(eventNames ...string) error {
+	return c.client.Subscribe(eventNames...)
+}
+
+// SubscribeMultiple subscribes the client to the event for count deliveries, like EventsOnMultiple.
+// A count of 1 is EventsOnce, a count of zero or less never expires.
+func (c *Client) SubscribeMultiple(eventName string, count int) error {
+	return c.client.SubscribeMultiple(eventName, count)
+}
+
+// Unsubscribe removes the subscription of the event
+func (c *Client) Unsubscribe(eventName string) error {
+	return c.client.Unsubscribe(eventName)
+}
+
+// Events returns the events received by the client, the channel is closed when the connection is closed.
+// Up to 64 events are buffered, further events are dropped until the buffered ones are read.
+func (c *Client) Events() <-chan Event {
+	return c.client.Notifications()
+}
+
+// Close closes the connection
+func (c *Client) Close() error {
+	return c.client.Close()
+}
@@ -0,0 +1,59 @@
+package devtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+
+	"github.com/wailsapp/wails/v2/pkg/options"
+)
+
+type Greeter struct{}
+
+func (g *Greeter) Greet(name string) string {
+	return "Hello " + name + "!"
+}
+
+func TestHarness(t *testing.T) {
+	i := is.New(t)
+	harness := New(&options.App{Bind: []interface{}{&Greeter{}}})
+	defer harness.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client, err := harness.Dial(ctx)
+	i.NoErr(err)
+	defer client.Close()
+	i.Equal(harness.ClientIDs(), []string{client.ID})
+
+	result, err := client.Call(ctx, "devtest.Greeter.Greet", "World")
+	i.NoErr(err)
+	i.Equal(result, "Hello World!")
+
+	// Events flow between the clients and the Go listeners
+	received := make(chan []interface{}, 1)
+	harness.EventsOn("hello", func(data ...interface{}) { received <- data })
+	i.NoErr(client.Emit("hello", "from the client"))
+	select {
+	case data := <-received:
+		i.Equal(data, []interface{}{"from the client"})
+	case <-ctx.Done():
+		t.Fatal("the Go listener did not receive the event")
+	}
+
+	i.NoErr(client.Subscribe("tick"))
+	// The messages of a client are processed in order, so the subscription is active once the call returns
+	_, err = client.Call(ctx, "devtest.Greeter.Greet", "subscriber")
+	i.NoErr(err)
+	harness.EventsEmit("other")
+	harness.EventsEmit("tick", 1)
+	select {
+	case event := <-client.Events():
+		i.Equal(event.Name, "tick")
+		i.Equal(event.Data, []interface{}{float64(1)})
+	case <-ctx.Done():
+		t.Fatal("the client did not receive the event")
+	}
+}
@@ -2,8 +2,10 @@ package assetserver
 
 import (
 	"fmt"
+	"html/template"
 	"io/fs"
 	"net/http"
+	"strings"
 )
 
 // Options defines the configuration of the AssetServer.
@@ -21,6 +23,9 @@ type Options struct {
 	// If not defined, the result is the following in cases where the Handler would have been called:
 	//   GET request:   `http.StatusNotFound`
 	//   Other request: `http.StatusMethodNotAllowed`
+	//
+	// Served by the dev server, the requests of a connected browser client which send its ID and session have them
+	// in their context, see runtime.ClientID and runtime.SessionID.
 	Handler http.Handler
 
 	// Middleware is a HTTP Middleware which allows to hook into the AssetServer request chain. It allows to skip the default
@@ -33,12 +38,150 @@ type Options struct {
 	// Multiple Middlewares can be chained together with:
 	//   ChainMiddleware(middleware ...Middleware) Middleware
 	Middleware Middleware
+
+	// Middlewares wrap all responses of the AssetServer, including the injected runtime scripts, e.g. to add
+	// authentication, logging or headers. The first Middleware is the outermost one. Unlike Middleware they
+	// can't replace the default request handler, they always wrap the complete AssetServer.
+	Middlewares []Middleware
+
+	// Compression compresses the asset files for the clients accepting it, e.g. to speed up loading large
+	// JavaScript bundles in remote browsers. If not defined, the files are served uncompressed.
+	Compression *Compression
+
+	// SPAFallback is the file of the Assets served for page navigations to paths without a file, e.g. "index.html",
+	// so that deep links of apps with client-side routing don't 404. Navigations are requests accepting
+	// "text/html", they get the fallback before being passed to the Handler. The runtime is injected into the
+	// fallback page the same as into the index.html.
+	//
+	// If not defined, these requests are handled like the requests of any other missing file.
+	SPAFallback string
+
+	// NotFoundHandler serves the GET requests of files which can't be found in the Assets, if there is neither
+	// a Handler nor a SPAFallback for them, e.g. to render a custom error page.
+	//
+	// If not defined, the result is `http.StatusNotFound`.
+	NotFoundHandler http.Handler
+
+	// SecurityHeaders are added to all responses of the AssetServer. The Content-Security-Policy is extended to
+	// allow the injected runtime and IPC scripts, with a nonce generated for each page.
+	//
+	// If not defined, no security headers are added.
+	SecurityHeaders *SecurityHeaders
+
+	// IndexTemplate renders the index.html as a Go html/template before the runtime is injected, e.g. to fill in
+	// the build version or feature flags.
+	//
+	// If not defined, the index.html is served as it is.
+	IndexTemplate *IndexTemplate
+
+	// MimeTypes maps file extensions like ".wasm" to the Content-Type of the files, overriding the builtin types.
+	MimeTypes map[string]string
+
+	// MimeSniffing determines the Content-Type of the files whose extension has no known type.
+	// Default MimeSniffingDetect.
+	MimeSniffing MimeSniffing
+
+	// AccessLog logs every request of the webview with the Logger of the app, at the Info level: the method,
+	// path, status and duration. The requests of browsers are logged with DevServer.AccessLog instead.
+	AccessLog bool
+
+	// EnableManifest lists the asset files with the hashes of their content at /wails/manifest.json, e.g. for
+	// cache-busting or to precache the assets in a service worker. The manifest is built when the AssetServer
+	// starts, which reads all files once. It is also available with runtime.AssetManifest.
+	EnableManifest bool
+
+	// Mounts serve further asset trees below URL prefixes besides the Assets, e.g. the bundle of a plugin or
+	// the documentation. The mount with the longest matching prefix serves the request, with the prefix removed
+	// from the path. The runtime is not injected into the pages of the mounts, nor are they rendered with the
+	// IndexTemplate.
+	Mounts []Mount
+}
+
+// Mount serves the files of Assets below the URL Prefix
+type Mount struct {
+	// Prefix is the URL path the files are served at, e.g. "/docs" serves "index.html" at "/docs/"
+	Prefix string
+
+	// Assets are the files of the mount, served from its root
+	Assets fs.FS
+}
+
+// MimeSniffing is the strategy to determine the Content-Type of files from their content
+type MimeSniffing int
+
+const (
+	// MimeSniffingDetect detects the type from the content, falling back to http.DetectContentType
+	MimeSniffingDetect MimeSniffing = iota
+	// MimeSniffingStandard only uses http.DetectContentType, which knows fewer types
+	MimeSniffingStandard
+	// MimeSniffingNone serves the files as "application/octet-stream"
+	MimeSniffingNone
+)
+
+// IndexTemplate defines the rendering of the index.html as a html/template. Besides the Funcs, the template
+// has the function "cspNonce", which returns the nonce of the scripts allowed by the SecurityHeaders.
+type IndexTemplate struct {
+	// Data returns the data of the template for the request
+	Data func(req *http.Request) (interface{}, error)
+
+	// Funcs are added to the functions of the template
+	Funcs template.FuncMap
+
+	// Delims are the left and right delimiters of the actions, e.g. to not clash with the syntax of a frontend
+	// framework. Default "{{" and "}}".
+	Delims [2]string
+}
+
+// SecurityHeaders defines the security headers of the AssetServer responses, empty headers are not sent
+type SecurityHeaders struct {
+	// ContentSecurityPolicy is the Content-Security-Policy, e.g. "default-src 'self'"
+	ContentSecurityPolicy string
+
+	// FrameOptions is the X-Frame-Options header, e.g. "DENY"
+	FrameOptions string
+
+	// ReferrerPolicy is the Referrer-Policy header, e.g. "no-referrer"
+	ReferrerPolicy string
+
+	// Custom are further headers by name, e.g. "Permissions-Policy"
+	Custom map[string]string
+}
+
+// Compression defines which asset files are compressed. A pre-compressed sidecar file next to the asset,
+// e.g. "app.js.br" or "app.js.gz", is served if there is one, otherwise the file is compressed with gzip.
+// Brotli is only used for sidecar files.
+type Compression struct {
+	// MimeTypes are the compressed MIME types, a trailing "*" matches any subtype, e.g. "text/*".
+	// Default: text/*, application/javascript, application/json, application/wasm and image/svg+xml
+	MimeTypes []string
+
+	// MinSize is the size in bytes below which files are not compressed. Default 1024.
+	MinSize int64
 }
 
 // Validate the options
 func (o Options) Validate() error {
-	if o.Assets == nil && o.Handler == nil && o.Middleware == nil {
-		return fmt.Errorf("AssetServer options invalid: either Assets, Handler or Middleware must be set")
+	if o.Assets == nil && o.Handler == nil && o.Middleware == nil && len(o.Mounts) == 0 {
+		return fmt.Errorf("AssetServer options invalid: either Assets, Handler, Middleware or Mounts must be set")
+	}
+
+	prefixes := make(map[string]bool, len(o.Mounts))
+	for _, mount := range o.Mounts {
+		prefix := "/" + strings.Trim(mount.Prefix, "/")
+		if prefix == "/" {
+			return fmt.Errorf("AssetServer options invalid: the Prefix of a mount must not be the root, use Assets instead")
+		}
+		if mount.Assets == nil {
+			return fmt.Errorf("AssetServer options invalid: the mount '%s' has no Assets", prefix)
+		}
+		if prefixes[prefix] {
+			return fmt.Errorf("AssetServer options invalid: the prefix '%s' is mounted twice", prefix)
+		}
+		prefixes[prefix] = true
+	}
+
+	if o.SPAFallback != "" && o.Assets == nil {
+		return fmt.Errorf("AssetServer options invalid: the SPAFallback must be one of the Assets")
 	}
 
 	return nil
@@ -0,0 +1,102 @@
+package options
+
+import (
+	"net/http"
+	"time"
+)
+
+// DevServer options which are taken into account in dev builds.
+type DevServer struct {
+	// ProxyDirector is called for every request proxied to the frontend dev server, after the default
+	// director has been applied. It can be used to add headers, rewrite the path or set the Host.
+	ProxyDirector func(req *http.Request)
+
+	// ProxyObserver is called for every request proxied to the frontend dev server, once the response
+	// headers have been received or the request failed. req is the outgoing request, its URL holds the
+	// upstream. resp is nil if err is set. Panics of the observer are recovered and logged.
+	ProxyObserver func(req *http.Request, resp *http.Response, duration time.Duration, err error)
+
+	// RuntimeModules limits the parts of the runtime which are injected into the served pages.
+	// The IPC module is always injected, it requires RuntimeModuleRuntime. Defaults to all modules.
+	RuntimeModules []RuntimeModule
+
+	// ReloadMessage and ReloadAppMessage are the control messages sent to the browsers to reload the page
+	// or the app. The injected IPC script is configured with the same values. Defaults "reload" and "reloadapp".
+	ReloadMessage    string
+	ReloadAppMessage string
+
+	// DisableSpinner disables the loading spinner that is appended to the body of the served pages
+	DisableSpinner bool
+
+	// ServeAssetsTarball serves the assets at /wails/assets.tar.gz as a gzipped tarball, to capture a snapshot
+	// of what is being served. Only available in dev builds and if the assets are served from an fs.FS.
+	ServeAssetsTarball bool
+
+	// UseDesktopIPC decides whether a request for the IPC script gets the desktop IPC instead of the
+	// websocket IPC. Defaults to checking whether the User-Agent is the one of the Wails webview. A
+	// "_wails_ipc=desktop|websocket" query parameter or "X-Wails-IPC" header always takes precedence.
+	UseDesktopIPC func(req *http.Request) bool
+
+	// BasePath is the URL prefix the dev server is served under, e.g. "/myapp" when it is mounted behind
+	// a reverse proxy at a sub-path. All routes are registered under the prefix and the injected scripts
+	// use it. The prefix is stripped from the requests proxied to the frontend dev server.
+	BasePath string
+
+	// ShutdownTimeout is how long the dev server waits for the running requests and calls to complete when
+	// the app exits, before the connected clients are sent the "server-closing" message and disconnected.
+	// Default 5 seconds.
+	ShutdownTimeout time.Duration
+
+	// Proxies forward the requests below a path prefix to another server, e.g. the backend API of the app,
+	// so the frontend can reach it from the origin of the dev server. Websockets are forwarded too.
+	Proxies []ProxyRule
+
+	// AccessLog logs every request of the dev server with the Logger of the app, at the Info level: the method,
+	// path, status, duration and the ID of the IPC client if there is one. Websocket and event stream connections
+	// are logged when they are closed.
+	AccessLog bool
+
+	// EnableMetrics serves the request counts, the number of connected clients and the latency of the IPC
+	// dispatch at /wails/metrics, in the Prometheus text format.
+	EnableMetrics bool
+
+	// ReloadOnAPIVersionMismatch reloads the browser clients whose page has been loaded from another APIVersion
+	// of the app, after they have been sent the "wails:api-version-mismatch" event
+	ReloadOnAPIVersionMismatch bool
+}
+
+// ProxyRule forwards the requests whose path starts with PathPrefix to Upstream
+type ProxyRule struct {
+	// PathPrefix selects the requests to forward, e.g. "/api/". It is matched below the BasePath,
+	// the rule with the longest matching prefix is used.
+	PathPrefix string
+
+	// Upstream is the URL of the server, e.g. "http://localhost:8080". Its path is prepended to the
+	// path of the forwarded requests.
+	Upstream string
+
+	// StripPrefix removes the PathPrefix from the path of the forwarded requests
+	StripPrefix bool
+
+	// ChangeOrigin sets the Host header of the forwarded requests to the host of the Upstream,
+	// which servers using virtual hosts require
+	ChangeOrigin bool
+
+	// Headers are set on the forwarded requests, replacing the ones sent by the browser.
+	// An empty value removes the header.
+	Headers map[string]string
+}
+
+// RuntimeModule is a part of the runtime that is injected into the served pages
+type RuntimeModule string
+
+const (
+	// RuntimeModuleIPC is the IPC bridge to the backend. It is always injected and requires RuntimeModuleRuntime.
+	RuntimeModuleIPC RuntimeModule = "ipc"
+	// RuntimeModuleRuntime is the JS runtime (window.runtime and window.wails)
+	RuntimeModuleRuntime RuntimeModule = "runtime"
+	// RuntimeModuleBindings are the generated bindings of the bound methods
+	RuntimeModuleBindings RuntimeModule = "bindings"
+	// RuntimeModulePlugins are the scripts of the registered plugins
+	RuntimeModulePlugins RuntimeModule = "plugins"
+)
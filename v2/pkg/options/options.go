@@ -2,12 +2,15 @@ package options
 
 import (
     "context"
+    "crypto/tls"
     "html"
     "io/fs"
+    "net"
     "net/http"
     "os"
     "path/filepath"
     "runtime"
+    "time"
 
     "github.com/wailsapp/wails/v2/pkg/options/assetserver"
     "github.com/wailsapp/wails/v2/pkg/options/linux"
@@ -33,8 +36,261 @@ type Experimental struct{}
 type WebSocket struct {
     Server *http.Server
     WsOnly bool
+
+    // Listener serves the UI and the IPC websocket on the listener instead of the dev server address, e.g. a
+    // unix socket behind a local reverse proxy, so no TCP port is opened. The TLS options still apply.
+    Listener net.Listener
+
+    // TLSConfig serves the UI and the IPC websocket over HTTPS, the injected IPC script then uses wss://.
+    // It must hold the certificates. Ignored if Server is set, whose TLSConfig is used instead.
+    TLSConfig *tls.Config
+
+    // TLSCertFile and TLSKeyFile are the paths of the PEM encoded certificate and key to serve HTTPS with,
+    // as an alternative to TLSConfig.
+    TLSCertFile string
+    TLSKeyFile  string
+
+    // MaxMessageSize is the maximum size in bytes of a single message received over the IPC websocket,
+    // after decompression. Clients sending larger messages are disconnected. Default 4MB.
+    MaxMessageSize int64
+
+    // MaxConcurrentCalls is the maximum number of calls of a single IPC websocket client which are processed
+    // concurrently. Further calls wait until one of them has returned. Default 16.
+    MaxConcurrentCalls int
+
+    // RateLimit is the number of messages per second a single IPC websocket client may send, with bursts of up
+    // to RateLimitBurst messages. Messages exceeding the limit are dropped. Zero disables rate limiting.
+    RateLimit      float64
+    RateLimitBurst int
+
+    // EventRateLimit and EventRateLimitBurst limit the event messages (emit, subscribe and unsubscribe)
+    // separately from the calls. When zero, events count towards RateLimit.
+    EventRateLimit      float64
+    EventRateLimitBurst int
+
+    // RateLimitDisconnectThreshold disconnects a client once this many of its messages have been dropped
+    // by the rate limiter. Zero never disconnects.
+    RateLimitDisconnectThreshold int
+
+    // BroadcastRateLimit caps the number of events per second which are broadcast to the IPC websocket clients.
+    // Events exceeding it wait, which keeps the dev server responsive under pathological emit rates.
+    // Zero disables the limit.
+    BroadcastRateLimit float64
+
+    // BroadcastYieldEvery yields the processor to other goroutines after the event has been handed to this many
+    // clients during a broadcast. Zero never yields.
+    BroadcastYieldEvery int
+
+    // EventPolicy controls how events emitted concurrently by browsers and Go are reconciled.
+    // Default EventPolicyNone.
+    EventPolicy EventPolicy
+
+    // EnableCompression enables negotiation of per-message compression (permessage-deflate) with the
+    // IPC websocket clients. Messages are only compressed for clients that negotiated it.
+    EnableCompression bool
+
+    // CompressionLevel is the flate compression level of the messages sent to clients that negotiated
+    // compression, from -2 (Huffman only) to 9 (best compression). Zero uses the default level of 1.
+    CompressionLevel int
+
+    // EnableMessagePack lets IPC websocket clients negotiate the "wails.msgpack" subprotocol, which sends
+    // them the results of calls encoded with MessagePack instead of JSON. The injected IPC script requests it,
+    // other clients keep getting JSON. Events are always sent as JSON.
+    EnableMessagePack bool
+
+    // SessionStore stores the session and subscription state of the IPC websocket clients.
+    // Defaults to an in-memory store.
+    SessionStore SessionStore
+
+    // MaxEventSize is the maximum size in bytes of an event notification sent to the IPC websocket clients.
+    // Larger events are handled according to OversizedEventPolicy. Zero means no limit.
+    MaxEventSize int
+
+    // OversizedEventPolicy defines what happens with events larger than MaxEventSize.
+    // Default OversizedEventDrop.
+    OversizedEventPolicy OversizedEventPolicy
+
+    // ReliableEvents are the events, or patterns like "order:*", which runtime.EventsEmit delivers reliably to the
+    // IPC websocket clients: it returns once every client the event is sent to acknowledged its receipt, or after
+    // EventAckTimeout. runtime.EventsEmitReliable does the same for any event and reports the missing clients.
+    ReliableEvents []string
+
+    // EventAckTimeout is how long a reliable event waits for the acknowledgements of the clients. Default 5 seconds.
+    EventAckTimeout time.Duration
+
+    // EmptyCallResultPolicy defines what happens if processing a call yields no reply, e.g. because the
+    // method is not bound. Default EmptyCallResultAcknowledge.
+    EmptyCallResultPolicy EmptyCallResultPolicy
+
+    // KeepAliveInterval is the interval at which a no-op message is sent to every IPC websocket client, to keep
+    // proxies from closing idle connections. The injected IPC script ignores these messages. Zero disables it.
+    // If ReadTimeout is set, a ping is sent along with the message.
+    KeepAliveInterval time.Duration
+
+    // ReadTimeout disconnects IPC websocket clients which send neither a message nor a pong within the timeout,
+    // to free the resources of stalled connections. Browsers only send pongs in reply to the pings sent with
+    // the keep-alive messages, so KeepAliveInterval should be shorter than the timeout. Zero disables it.
+    ReadTimeout time.Duration
+
+    // SendQueueSize is the number of events queued for each IPC websocket client. A client whose queue is
+    // full, because it does not read the events as fast as they are sent, is handled according to
+    // SlowClientPolicy. Default 256.
+    SendQueueSize int
+
+    // SlowClientPolicy defines what happens with clients whose send queue is full. Default SlowClientDisconnect.
+    SlowClientPolicy SlowClientPolicy
+
+    // ReplayBufferSize is the number of events kept for each session whose client has disconnected. When a
+    // client of the session reconnects within a minute, the events it missed are replayed in order, the
+    // oldest ones are dropped if more have been emitted. Zero disables it.
+    ReplayBufferSize int
+
+    // SessionTimeout is how long the state of a session is kept in the SessionStore after its last client
+    // disconnected, including the values of runtime.SessionSave. A client resuming the session within it gets
+    // its event subscriptions back. Only the browser which started the session can resume it, it is bound to
+    // the HttpOnly cookie the dev server sets on the first connection, and only while no other client of the
+    // session is connected, e.g. of a duplicated tab. Other clients start a new session. Default 10 minutes.
+    SessionTimeout time.Duration
+
+    // WriteTimeout is the maximum time a write to an IPC websocket client may take. A client whose write
+    // times out, e.g. because it stopped reading, is disconnected. Zero disables it.
+    WriteTimeout time.Duration
+
+    // RejectDuplicateClientIDs rejects IPC websocket clients requesting an ID that is already in use,
+    // with 409 Conflict. By default such a client gets a numbered suffix instead, e.g. "ts-generator-2".
+    // Clients request an ID with the "clientid" query parameter or the "X-Wails-Client-ID" header.
+    RejectDuplicateClientIDs bool
+
+    // AllowedOrigins are the origins of the pages which may open the IPC websocket, e.g. "https://example.com".
+    // Pages served by the dev server itself are always allowed, "*" allows any origin. Empty allows any origin.
+    AllowedOrigins []string
+
+    // EnableCORS allows pages of the AllowedOrigins to fetch the assets, by adding CORS headers to the responses
+    // and answering preflight requests. With no AllowedOrigins, every origin may fetch them.
+    EnableCORS bool
+
+    // AuthToken is required from all requests to the dev server, the pages as well as the IPC websocket and
+    // the other IPC routes, either as a bearer token or with the "token" query parameter. Requests without it
+    // are rejected with 401 Unauthorized. Browsers open the UI once with "?token=<AuthToken>", which sets an
+    // HttpOnly cookie they send with the following requests. The WebDAV directory requires its own credentials
+    // instead. Empty accepts any client.
+    AuthToken string
+
+    // EnableHTTPCalls serves the bound methods at POST /wails/call/{package}/{struct}/{method} besides the IPC
+    // websocket, for scripts and clients like curl. The body is the JSON array of arguments, the reply holds the
+    // "result" or the "error" of the method. The AuthToken is required from these requests too. The OpenAPI
+    // document of the methods is served at GET /wails/openapi.json, for tools and clients in other languages.
+    EnableHTTPCalls bool
+
+    // EnableEventStream serves the events as server-sent events at GET /wails/events, for networks blocking
+    // websockets. The injected IPC script falls back to it if the websocket can't connect, posting its events
+    // to the same URL and making its calls over HTTP, which requires EnableHTTPCalls.
+    EnableEventStream bool
+
+    // Uploads accepts resumable uploads of large files from the browser clients at /wails/upload, which the
+    // injected IPC script sends with window.wailsdevserver.upload(file). Nil disables them.
+    Uploads *Uploads
+
+    // OnClientConnect is called with the ID of an IPC websocket client after it has connected.
+    // It is safe to send events from the callback, e.g. to push the initial state to the client.
+    OnClientConnect func(clientID string)
+
+    // OnClientDisconnect is called with the ID of an IPC websocket client after it has disconnected.
+    OnClientDisconnect func(clientID string)
+}
+
+// SessionStore is used to persist per-session state of IPC websocket clients, so that it can be
+// shared between dev server instances or survive a restart. Values are opaque to the store.
+// The keys starting with "wails:" are used by the dev server for the subscriptions and missed events of the
+// session, Delete is called once the session timed out, see WebSocket.SessionTimeout.
+type SessionStore interface {
+    // Load returns the value stored for the given session and key
+    Load(sessionID string, key string) (value []byte, ok bool, err error)
+    // Save stores the value for the given session and key
+    Save(sessionID string, key string, value []byte) error
+    // Delete removes all values stored for the given session
+    Delete(sessionID string) error
+}
+
+// Uploads configures the resumable uploads of the browser clients. The protocol is the core of tus 1.0 with
+// the creation and termination extensions, the AuthToken is required from the clients.
+type Uploads struct {
+    // Dir is the directory the uploads are written to until they are complete, default the temp directory
+    Dir string
+
+    // MaxSize is the maximum size of an upload in bytes. Zero means no limit.
+    MaxSize int64
+
+    // OnComplete is called with every completed upload. The file is closed and removed after it returns, so it
+    // has to be copied or moved to keep it. An error fails the upload, it is sent to the client.
+    OnComplete func(upload *Upload) error
 }
 
+// Upload is a file uploaded by a browser client
+type Upload struct {
+    ID string
+
+    // ClientID is the ID of the IPC websocket client which sent the file, given with the "clientid" and the
+    // "session" of its handshake. It is empty if it is unknown or doesn't match a connected client.
+    ClientID string
+
+    // Metadata are the values the client sent along with the file, e.g. the "filename"
+    Metadata map[string]string
+
+    // Size is the size of the file in bytes
+    Size int64
+
+    // File holds the uploaded content, it is positioned at the start
+    File *os.File
+}
+
+// EventPolicy defines how events relayed over the IPC websocket are reconciled
+type EventPolicy int
+
+const (
+    // EventPolicyNone delivers every event as-is, in the order it is sent
+    EventPolicyNone EventPolicy = iota
+    // EventPolicyTagSource tags every event notification with its source ("go" or "browser")
+    // and a timestamp, so clients can decide how to reconcile them
+    EventPolicyTagSource
+    // EventPolicyLastWriterWins tags notifications like EventPolicyTagSource and drops, in the browser,
+    // any notification that is older than the last one received for the same event name
+    EventPolicyLastWriterWins
+)
+
+// OversizedEventPolicy defines how event notifications exceeding WebSocket.MaxEventSize are handled
+type OversizedEventPolicy int
+
+const (
+    // OversizedEventDrop drops the event and logs an error
+    OversizedEventDrop OversizedEventPolicy = iota
+    // OversizedEventReference sends the clients a reference to the event instead, which they fetch over HTTP.
+    // References expire after a minute.
+    OversizedEventReference
+)
+
+// SlowClientPolicy defines how IPC websocket clients whose send queue is full are handled
+type SlowClientPolicy int
+
+const (
+    // SlowClientDisconnect disconnects the client and logs an error
+    SlowClientDisconnect SlowClientPolicy = iota
+    // SlowClientDropOldest drops the oldest queued event to make room for the new one. The client misses
+    // events but stays connected.
+    SlowClientDropOldest
+)
+
+// EmptyCallResultPolicy defines how calls of IPC websocket clients without a reply from the dispatcher are handled
+type EmptyCallResultPolicy int
+
+const (
+    // EmptyCallResultAcknowledge replies to the call with an empty result, or with the error of the call if
+    // there has been one, so the promise in the frontend is settled
+    EmptyCallResultAcknowledge EmptyCallResultPolicy = iota
+    // EmptyCallResultIgnore sends no reply, the promise in the frontend is never settled
+    EmptyCallResultIgnore
+)
+
 // App contains options for creating the App
 type App struct {
     Title             string
@@ -68,13 +324,41 @@ type App struct {
     OnDomReady         func(ctx context.Context)                `json:"-"`
     OnShutdown         func(ctx context.Context)                `json:"-"`
     OnBeforeClose      func(ctx context.Context) (prevent bool) `json:"-"`
+    // Bind are the struct pointers whose methods are bound, available in the frontend as
+    // window.go.package.Struct.Method. Two structs exposing the same name fail the startup, BindAs binds a
+    // struct under another name, BindInterface binds the methods of an interface.
     Bind               []interface{}
     EnumBind           []interface{}
     WindowStartState   WindowStartState
 
-    // ErrorFormatter overrides the formatting of errors returned by backend methods
+    // ErrorFormatter overrides the formatting of errors returned by backend methods. Without it, errors with the
+    // methods ErrorCode() string and ErrorData() map[string]interface{} are passed to the frontend as an object
+    // {code, message, data}, the other errors as their message.
     ErrorFormatter ErrorFormatter
 
+    // CallTimeouts are the deadlines of the calls of bound methods by their qualified name, e.g. "main.App.Search".
+    // The timeout of the "*" key applies to the methods without one. A call exceeding its timeout gets a cancelled
+    // context and fails right away with an error object {code: "timeout", message, data: {method, timeout}} in
+    // the frontend, with the timeout in milliseconds. Methods returning a stream have no timeout.
+    CallTimeouts map[string]time.Duration
+
+    // Interceptors are called around every call of a bound method, e.g. for per-method ACLs, auditing or the
+    // validation of the arguments of the browser clients
+    Interceptors []Interceptor
+
+    // CallLimits bound the number of calls of bound methods processed concurrently, e.g. to protect the app from
+    // browser clients making thousands of calls at once. There are no limits by default.
+    CallLimits CallLimits
+
+    // Telemetry traces the messages of the frontends and the calls of bound methods with OpenTelemetry spans,
+    // e.g. to trace the issues of the browser clients of the web server. Nil disables it.
+    Telemetry *Telemetry
+
+    // APIVersion is the version of the API of the bound methods, defined by the app, e.g. "2". It is served with
+    // the bindings, so a browser client whose page has been loaded from another version, e.g. a stale tab, gets
+    // the "wails:api-version-mismatch" event when it connects. Empty disables the check.
+    APIVersion string
+
     // CSS property to test for draggable elements. Default "--wails-draggable"
     CSSDragProperty string
 
@@ -101,10 +385,160 @@ type App struct {
 
     // Debug options for debug builds. These options will be ignored in a production build.
     Debug Debug
+
+    // DevServer options for dev builds. These options will be ignored in a production build,
+    // unless the WebServer is enabled.
+    DevServer DevServer
+
+    // WebServer serves the UI and the websocket IPC to browsers in production builds
+    WebServer WebServer
+}
+
+// WebServer options for production builds. Dev builds always serve the UI to browsers.
+type WebServer struct {
+    // Enabled serves the UI and the websocket IPC at Addr, in addition to the desktop window.
+    // On Windows, set WebSocket.WsOnly to not show the window. The DevServer options apply to the served UI,
+    // the reload and stats endpoints, the assets tarball and the synthetic methods are only available in dev builds.
+    Enabled bool
+
+    // Addr is the address the server listens on, default "localhost:34115".
+    // Use e.g. "0.0.0.0:34115" to serve remote browsers.
+    Addr string
+
+    // WebDAV serves a directory of the app over WebDAV, with the dev server and the web server. Nil disables it.
+    WebDAV *WebDAV
+}
+
+// WebDAV serves a directory to browsers and the file managers of the OS, e.g. for apps managing files.
+// The clients are required to authenticate with the Username and Password. The file managers can't send the
+// AuthToken of the WebSocket options, so it is not required below the Prefix.
+type WebDAV struct {
+    // Root is the served directory, the clients can't access the files outside of it
+    Root string
+
+    // Prefix is the URL path the directory is served at, default "/wails/webdav". It is below the BasePath
+    // of the DevServer.
+    Prefix string
+
+    // Username and Password are required from the clients with basic authentication, both must be set
+    Username string
+    Password string
+
+    // ReadOnly rejects the requests changing files or directories with 403 Forbidden
+    ReadOnly bool
 }
 
 type ErrorFormatter func(error) any
 
+// CallInfo describes a call of a bound method for the Interceptors
+type CallInfo struct {
+    // Method is the qualified name of the method, e.g. "main.App.Search"
+    Method string
+    // Args are the arguments of the call, parsed into the types of the parameters of the method
+    Args []interface{}
+    // ClientID and SessionID identify the browser client of the dev or web server which made the call, both
+    // are empty for the calls of the desktop window
+    ClientID  string
+    SessionID string
+}
+
+// Interceptor is called around the calls of bound methods. The interceptors are called in order before a call
+// and in reverse order after it.
+type Interceptor interface {
+    // Before is called before the method, an error fails the call with the error without calling the method
+    Before(ctx context.Context, call *CallInfo) error
+    // After is called once the method returned, with its result and error
+    After(ctx context.Context, call *CallInfo, result interface{}, err error)
+}
+
+// CallLimits bound the calls of bound methods processed concurrently. A call waits in a queue while the limits
+// are reached. It fails with an error object {code: "busy", message, data: {method, limit}} in the frontend once
+// the queue is full or it has waited for the QueueTimeout, the limit is "server" or "client".
+type CallLimits struct {
+    // MaxConcurrent is the maximum number of calls processed at once, of all the clients and the window. Zero
+    // for no limit.
+    MaxConcurrent int
+
+    // MaxConcurrentPerClient is the maximum number of calls of a single browser client processed at once. Zero
+    // for no limit.
+    MaxConcurrentPerClient int
+
+    // MaxQueued is the maximum number of calls waiting for the limits, further calls fail right away. Zero for
+    // no limit.
+    MaxQueued int
+
+    // QueueTimeout is the maximum time a call waits for the limits. Zero waits until the call is cancelled.
+    QueueTimeout time.Duration
+}
+
+// Telemetry configures the tracing of the app. A call of a bound method is a span named after the
+// method, e.g. "main.App.Search", with the ID of the browser client and the error of the call. It is the parent
+// of the spans the method starts with its context.Context. The messages of the frontends are spans too, named
+// e.g. "wails.message call".
+type Telemetry struct {
+    // Tracer starts the spans, default a tracer of the global provider of otel.SetTracerProvider
+    Tracer Tracer
+}
+
+// SpanKind is the role of a span, as the span kinds of OpenTelemetry
+type SpanKind string
+
+const (
+    // SpanKindServer is the kind of the spans of the messages of the frontends
+    SpanKindServer SpanKind = "server"
+    // SpanKindInternal is the kind of the spans of the calls of bound methods
+    SpanKindInternal SpanKind = "internal"
+)
+
+// SpanAttribute is an attribute of a span, e.g. "rpc.method"
+type SpanAttribute struct {
+    Key   string
+    Value string
+}
+
+// Tracer starts the spans of the Telemetry, e.g. to trace with another OpenTelemetry provider than the global
+// one or with another tracing library
+type Tracer interface {
+    // Start starts a span, the returned context carries it so it is the parent of the spans started with it
+    Start(ctx context.Context, name string, kind SpanKind, attributes []SpanAttribute) (context.Context, Span)
+}
+
+// Span is a span started by a Tracer
+type Span interface {
+    // End ends the span with the error of the message or call, nil if it succeeded
+    End(err error)
+}
+
+// NamespacedBinding is a struct pointer bound under a namespace, see BindAs
+type NamespacedBinding struct {
+    Namespace string
+    Struct    interface{}
+}
+
+// BindAs binds the methods of the struct pointer under the namespace of the form "group.Name" instead of
+// "package.Struct", e.g. Bind: []interface{}{options.BindAs("plugins.Spell", spell)} makes them available as
+// window.go.plugins.Spell.Method
+func BindAs(namespace string, structPtr interface{}) NamespacedBinding {
+    return NamespacedBinding{Namespace: namespace, Struct: structPtr}
+}
+
+// InterfaceBinding binds the methods of an interface, see BindInterface
+type InterfaceBinding struct {
+    // Interface is a nil pointer to the interface, e.g. (*store.Store)(nil)
+    Interface interface{}
+    // Implementation is the value whose methods are called, it can be replaced with runtime.SetImplementation
+    Implementation interface{}
+}
+
+// BindInterface binds the methods of the interface instead of the ones of the struct implementing it, e.g.
+// Bind: []interface{}{options.BindInterface((*store.Store)(nil), &store.SQLStore{})} makes them available as
+// window.go.store.Store.Method. The bindings of the frontend are generated from the interface, so another
+// implementation, e.g. a mock, can be selected at startup or with runtime.SetImplementation without changing
+// the frontend.
+func BindInterface(iface interface{}, implementation interface{}) InterfaceBinding {
+    return InterfaceBinding{Interface: iface, Implementation: implementation}
+}
+
 type RGBA struct {
     R uint8 `json:"r"`
     G uint8 `json:"g"`
@@ -0,0 +1,39 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+)
+
+// assetOverlay is implemented by the frontends serving the assets
+type assetOverlay interface {
+	SetAssetOverlay(overlay fs.FS) error
+}
+
+// SetAssetOverlay layers the files of the overlay over the assets of the running app, e.g. themes, plugins or
+// downloaded updates, and reloads the window and the browser clients to pick them up. A file of the overlay
+// is served in place of the asset with the same path. A nil overlay removes it.
+func SetAssetOverlay(ctx context.Context, overlay fs.FS) error {
+	frontend, ok := getFrontend(ctx).(assetOverlay)
+	if !ok {
+		return errors.New("the app does not serve its assets")
+	}
+	return frontend.SetAssetOverlay(overlay)
+}
+
+// assetManifest is implemented by the frontends serving the assets
+type assetManifest interface {
+	AssetManifest() (map[string]string, error)
+}
+
+// AssetManifest returns the hex encoded hashes of the content of the asset files by their URL path, e.g.
+// "/assets/app.js", including the files of the asset overlay. It requires AssetServer.EnableManifest. The same
+// manifest is served to the frontend at /wails/manifest.json.
+func AssetManifest(ctx context.Context) (map[string]string, error) {
+	frontend, ok := getFrontend(ctx).(assetManifest)
+	if !ok {
+		return nil, errors.New("the app does not serve its assets")
+	}
+	return frontend.AssetManifest()
+}
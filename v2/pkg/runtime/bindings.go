@@ -0,0 +1,103 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+
+	"github.com/wailsapp/wails/v2/internal/binding"
+)
+
+// bindingsPusher is implemented by the frontends serving browser clients
+type bindingsPusher interface {
+	PushBindings() error
+}
+
+// windowBindingsScript updates window.go in the window from the bindings JSON appended to it, like the
+// bindings pushed to the browser clients
+const windowBindingsScript = `(function(bindings) {
+	window.go = window.go || {};
+	for (const p in window.go) for (const s in window.go[p]) for (const m in window.go[p][s]) {
+		if (!bindings[p] || !bindings[p][s] || !bindings[p][s][m]) delete window.go[p][s][m];
+	}
+	for (const p in bindings) for (const s in bindings[p]) for (const m in bindings[p][s]) {
+		window.go[p] = window.go[p] || {};
+		window.go[p][s] = window.go[p][s] || {};
+		if (window.go[p][s][m]) continue;
+		const name = [p, s, m].join(".");
+		window.go[p][s][m] = function() {
+			const args = [].slice.call(arguments);
+			return new Promise((resolve, reject) => {
+				const callbackID = name + "-bound-" + Math.random();
+				window.wails.callbacks[callbackID] = {resolve: resolve, reject: reject};
+				window.WailsInvoke("C" + JSON.stringify({name: name, args: args, callbackID: callbackID}));
+			});
+		};
+	}
+})`
+
+func getBindings(ctx context.Context) (*binding.Bindings, error) {
+	if ctx == nil {
+		return nil, errors.New(contextError)
+	}
+	appBindings, ok := ctx.Value("bindings").(*binding.Bindings)
+	if !ok {
+		return nil, errors.New(contextError)
+	}
+	return appBindings, nil
+}
+
+// BindFunc binds a function or the methods of a struct pointer after startup, e.g. for plugins loaded at
+// runtime. A function is bound with a name of the form "package.Struct.Method", available in the frontend as
+// window.go.package.Struct.Method, the methods of a struct pointer with a name of the form "package.Struct".
+// The window and the browser clients get the updated bindings right away; the window of an obfuscated build
+// does not. The methods bound at runtime are not in the generated bindings of the frontend.
+func BindFunc(ctx context.Context, name string, fn interface{}) error {
+	appBindings, err := getBindings(ctx)
+	if err != nil {
+		return err
+	}
+	if err := appBindings.Bind(name, fn); err != nil {
+		return err
+	}
+	return updateBindings(ctx, appBindings)
+}
+
+// Unbind removes a method bound with BindFunc, or all the methods of "package.Struct", from the backend and
+// from window.go in the frontends. The calls in progress are not affected.
+func Unbind(ctx context.Context, name string) error {
+	appBindings, err := getBindings(ctx)
+	if err != nil {
+		return err
+	}
+	if err := appBindings.Unbind(name); err != nil {
+		return err
+	}
+	return updateBindings(ctx, appBindings)
+}
+
+// updateBindings sends the bindings to the window and to the browser clients
+func updateBindings(ctx context.Context, appBindings *binding.Bindings) error {
+	frontend := getFrontend(ctx)
+	if obfuscated, _ := ctx.Value("obfuscated").(bool); !obfuscated {
+		bindingsJSON, err := appBindings.ToJSON()
+		if err != nil {
+			return err
+		}
+		frontend.ExecJS(windowBindingsScript + "(" + bindingsJSON + ");")
+	}
+	if pusher, ok := frontend.(bindingsPusher); ok {
+		return pusher.PushBindings()
+	}
+	return nil
+}
+
+// SetImplementation replaces the implementation of an interface bound with options.BindInterface, named
+// "package.Interface", e.g. to switch between a mock and the real service while the app is running. The
+// bindings of the frontends are those of the interface, so they are not affected.
+func SetImplementation(ctx context.Context, name string, implementation interface{}) error {
+	appBindings, err := getBindings(ctx)
+	if err != nil {
+		return err
+	}
+	return appBindings.SetImplementation(name, implementation)
+}
@@ -0,0 +1,45 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ClientInfo describes a browser client connected to the IPC websocket
+type ClientInfo struct {
+	ID          string    `json:"id"`
+	RemoteAddr  string    `json:"remoteAddr"`
+	UserAgent   string    `json:"userAgent"`
+	ConnectedAt time.Time `json:"connectedAt"`
+	// SessionID is kept by the browser tab across reloads, see SessionID
+	SessionID string `json:"sessionId"`
+	// Subscriptions are the events the client subscribed to, it receives all events if there are none
+	Subscriptions []string `json:"subscriptions"`
+}
+
+// clientManager is implemented by the frontends serving browser clients
+type clientManager interface {
+	BrowserClients() []ClientInfo
+	DisconnectClient(clientID string) error
+}
+
+// BrowserClients returns the browser clients connected to the app, sorted by ID. It is empty if the app
+// does not serve browsers.
+func BrowserClients(ctx context.Context) []ClientInfo {
+	manager, ok := getFrontend(ctx).(clientManager)
+	if !ok {
+		return nil
+	}
+	return manager.BrowserClients()
+}
+
+// DisconnectClient closes the connection of the browser client. It fails if the client is not connected or
+// the app does not serve browsers.
+func DisconnectClient(ctx context.Context, clientID string) error {
+	manager, ok := getFrontend(ctx).(clientManager)
+	if !ok {
+		return errors.New("the app does not serve browser clients")
+	}
+	return manager.DisconnectClient(clientID)
+}
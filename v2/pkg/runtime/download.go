@@ -0,0 +1,27 @@
+package runtime
+
+import "io"
+
+// Download is returned by a bound method to stream a large result, e.g. a multi-gigabyte export, to a browser
+// client. Instead of the content, the client receives a URL it fetches the content from once, within a minute.
+// A bound method may return any io.Reader the same way, Download adds the metadata of the file.
+type Download struct {
+	io.Reader
+
+	// Filename is suggested to the browser for saving the file, default the name of the Reader if it is a file
+	Filename string
+
+	// ContentType is the MIME type of the content, default "application/octet-stream"
+	ContentType string
+
+	// Size is the length of the content in bytes, zero if it is unknown
+	Size int64
+}
+
+// Close closes the Reader if it is an io.Closer, it is called once the download has been served or expired
+func (d Download) Close() error {
+	if closer, ok := d.Reader.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
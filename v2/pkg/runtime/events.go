@@ -2,9 +2,14 @@ package runtime
 
 import (
 	"context"
+	"errors"
+
+	"github.com/wailsapp/wails/v2/internal/frontend"
 )
 
-// EventsOn registers a listener for the given event name. It returns a function to cancel the listener
+// EventsOn registers a listener for the given event name. It returns a function to cancel the listener.
+// The name may be a pattern: '*' matches any characters, e.g. "download:*", and a '+' segment delimited by
+// '.', ':' or '/' matches a single segment, e.g. "user.+.updated".
 func EventsOn(ctx context.Context, eventName string, callback func(optionalData ...interface{})) func() {
 	events := getEvents(ctx)
 	return events.On(eventName, callback)
@@ -47,3 +52,69 @@ func EventsEmit(ctx context.Context, eventName string, optionalData ...interface
 	events := getEvents(ctx)
 	events.Emit(eventName, optionalData...)
 }
+
+// reliableNotifier is implemented by the frontends serving browser clients
+type reliableNotifier interface {
+	NotifyReliable(ctx context.Context, name string, data ...interface{}) error
+}
+
+// EventsEmitReliable emits the event like EventsEmit, but returns once all the browser clients it is sent to
+// acknowledged its receipt, for the workflows where losing an event corrupts the state of the UI. It fails
+// with the IDs of the clients which did not acknowledge it before the deadline of ctx, or
+// options.WebSocket.EventAckTimeout if it has none. The window does not acknowledge the events.
+func EventsEmitReliable(ctx context.Context, eventName string, optionalData ...interface{}) error {
+	events := getEvents(ctx)
+	appFrontend := getFrontend(ctx)
+	notifier, ok := appFrontend.(reliableNotifier)
+	if !ok {
+		// The app does not serve browsers, there is nothing to acknowledge
+		events.Emit(eventName, optionalData...)
+		return nil
+	}
+	// The Go listeners and the window are notified as usual, the browser clients reliably
+	events.Notify(appFrontend, eventName, optionalData...)
+	return notifier.NotifyReliable(ctx, eventName, optionalData...)
+}
+
+// EventsEmitTo sends the event to a single browser client, given its ID, e.g. one returned by ClientID.
+// The Go listeners and the other clients are not notified. It fails if the client is not connected or
+// the app does not serve browsers.
+func EventsEmitTo(ctx context.Context, clientID string, eventName string, optionalData ...interface{}) error {
+	appFrontend := getFrontend(ctx)
+	notifier, ok := appFrontend.(frontend.ClientNotifier)
+	if !ok {
+		return errors.New("the app does not serve browser clients")
+	}
+	return notifier.NotifyClient(clientID, eventName, optionalData...)
+}
+
+const (
+	// EventClientConnected is emitted with a ClientEvent after a browser client connected
+	EventClientConnected = "wails:client-connected"
+	// EventClientDisconnected is emitted with a ClientEvent after a browser client disconnected
+	EventClientDisconnected = "wails:client-disconnected"
+	// EventAPIVersionMismatch is emitted with an APIVersionMismatch when a browser client connects whose page
+	// has been loaded from another options.App.APIVersion. The client gets it too.
+	EventAPIVersionMismatch = "wails:api-version-mismatch"
+)
+
+// ClientEvent describes the browser client of EventClientConnected and EventClientDisconnected
+type ClientEvent struct {
+	ID         string `json:"id"`
+	RemoteAddr string `json:"remoteAddr"`
+	UserAgent  string `json:"userAgent"`
+	SessionID  string `json:"sessionId"`
+	// Clients is the number of browser clients connected after the event, 0 once the last one has left
+	Clients int `json:"clients"`
+}
+
+// APIVersionMismatch describes the browser client of EventAPIVersionMismatch
+type APIVersionMismatch struct {
+	ClientID string `json:"clientId"`
+	// ClientVersion is the API version of the page of the client, empty if it has been loaded without one
+	ClientVersion string `json:"clientVersion"`
+	// ServerVersion is the API version of the app
+	ServerVersion string `json:"serverVersion"`
+	// Reload is true if the client is reloaded, see options.DevServer.ReloadOnAPIVersionMismatch
+	Reload bool `json:"reload"`
+}
@@ -0,0 +1,24 @@
+package runtime
+
+import "github.com/wailsapp/wails/v2/internal/binding"
+
+// Progress reports the progress of a long-running bound method to the client calling it, which gets the updates
+// with `call.onProgress(update => ...)` on the promise of the call. A bound method gets it by taking a
+// *runtime.Progress parameter, after the context.Context if it has one. It is not an argument of the method in
+// the frontend.
+//
+//	func (a *App) Import(ctx context.Context, progress *runtime.Progress, paths []string) error {
+//		for index, path := range paths {
+//			progress.Report(int64(index), int64(len(paths)), path)
+//			...
+//		}
+//		progress.Report(int64(len(paths)), int64(len(paths)), "")
+//		return nil
+//	}
+//
+// The updates are only sent to the browser clients of the dev and web server, they are dropped in the desktop
+// window.
+type Progress = binding.Progress
+
+// ProgressUpdate is the progress sent to the client, Current out of Total
+type ProgressUpdate = binding.ProgressUpdate
@@ -105,3 +105,27 @@ func Environment(ctx context.Context) EnvironmentInfo {
 	result.Arch = goruntime.GOARCH
 	return result
 }
+
+// ClientID returns the ID of the browser client which called the bound method, given the context the method
+// has been called with. Bound methods get the context if their first parameter is a context.Context.
+// It is empty for calls of the desktop window. The context of the HTTP requests a browser client sends to the
+// asset server Handler has it too, if the requests give the ID and the session of the client.
+func ClientID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	clientID, _ := ctx.Value("clientid").(string)
+	return clientID
+}
+
+// SessionID returns the session of the browser client which called the bound method. Unlike the ID of the
+// client, the session is kept by the browser tab across reloads and reconnects, so per-session state
+// can be keyed by it. Only the browser which started the session can resume it. It is empty for calls of the
+// desktop window.
+func SessionID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	sessionID, _ := ctx.Value("sessionid").(string)
+	return sessionID
+}
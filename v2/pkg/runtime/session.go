@@ -0,0 +1,45 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+)
+
+// sessionStateStore is implemented by the frontends keeping the state of browser sessions
+type sessionStateStore interface {
+	LoadSessionState(sessionID string, key string) ([]byte, bool, error)
+	SaveSessionState(sessionID string, key string, value []byte) error
+}
+
+// SessionLoad returns the value stored for the key in the session of the browser client which called the
+// bound method, see SessionID. The value is kept across reloads of the browser tab, until the session timed
+// out, see options.WebSocket.SessionTimeout.
+func SessionLoad(ctx context.Context, key string) ([]byte, bool, error) {
+	store, sessionID, err := sessionState(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	return store.LoadSessionState(sessionID, key)
+}
+
+// SessionSave stores the value for the key in the session of the browser client which called the bound
+// method, see SessionID
+func SessionSave(ctx context.Context, key string, value []byte) error {
+	store, sessionID, err := sessionState(ctx)
+	if err != nil {
+		return err
+	}
+	return store.SaveSessionState(sessionID, key, value)
+}
+
+func sessionState(ctx context.Context) (sessionStateStore, string, error) {
+	sessionID := SessionID(ctx)
+	if sessionID == "" {
+		return nil, "", errors.New("the method has not been called by a browser client")
+	}
+	store, ok := getFrontend(ctx).(sessionStateStore)
+	if !ok {
+		return nil, "", errors.New("the app does not keep browser sessions")
+	}
+	return store, sessionID, nil
+}
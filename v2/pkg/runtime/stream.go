@@ -0,0 +1,111 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+)
+
+var (
+	// ErrStreamCancelled is returned by Stream.Send once the client stopped iterating the stream
+	ErrStreamCancelled = errors.New("the stream has been cancelled")
+	// ErrStreamClosed is returned by Stream.Send after the stream has been closed
+	ErrStreamClosed = errors.New("the stream has been closed")
+)
+
+// Stream is returned by a bound method to send a sequence of values to a browser client, e.g. the progress of a
+// task or the lines of a log. The method returns the stream right away and sends the values from a goroutine,
+// the client iterates them with `for await (const value of await Method())`. A bound method may return a
+// receive channel the same way, Stream adds the error ending it and the cancellation by the client.
+//
+//	func (a *App) Tail(ctx context.Context, path string) *runtime.Stream {
+//		stream := runtime.NewStream()
+//		go func() {
+//			for line := range lines(ctx, path) {
+//				if err := stream.Send(line); err != nil {
+//					return
+//				}
+//			}
+//			stream.Close(nil)
+//		}()
+//		return stream
+//	}
+//
+// Streams are only supported by the browser clients of the dev and web server, the calls of the desktop window
+// fail.
+type Stream struct {
+	values    chan interface{}
+	closed    chan struct{}
+	cancelled chan struct{}
+	err       error
+
+	closeOnce  sync.Once
+	cancelOnce sync.Once
+}
+
+// NewStream returns a stream. Send blocks until the value has been taken to be sent to the client.
+func NewStream() *Stream {
+	return &Stream{
+		values:    make(chan interface{}),
+		closed:    make(chan struct{}),
+		cancelled: make(chan struct{}),
+	}
+}
+
+// Send sends the value to the client. It returns ErrStreamCancelled if the client stopped iterating the stream
+// or disconnected, and ErrStreamClosed if the stream has been closed.
+func (s *Stream) Send(value interface{}) error {
+	select {
+	case <-s.closed:
+		return ErrStreamClosed
+	case <-s.cancelled:
+		return ErrStreamCancelled
+	default:
+	}
+	select {
+	case s.values <- value:
+		return nil
+	case <-s.closed:
+		return ErrStreamClosed
+	case <-s.cancelled:
+		return ErrStreamCancelled
+	}
+}
+
+// Close ends the stream after the values which have been sent. A non-nil err fails the iteration of the client
+// with the error. Only the first call has an effect.
+func (s *Stream) Close(err error) {
+	s.closeOnce.Do(func() {
+		s.err = err
+		close(s.closed)
+	})
+}
+
+// Done is closed when the client stopped iterating the stream or disconnected
+func (s *Stream) Done() <-chan struct{} {
+	return s.cancelled
+}
+
+// Recv returns the next value of the stream, io.EOF once it has been closed or the error it has been closed
+// with. It is called by the frontend sending the values to the client.
+func (s *Stream) Recv(ctx context.Context) (interface{}, error) {
+	select {
+	case value := <-s.values:
+		return value, nil
+	case <-s.closed:
+		if s.err != nil {
+			return nil, s.err
+		}
+		return nil, io.EOF
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Cancel stops the stream, it is called by the frontend when the client stopped iterating it
+func (s *Stream) Cancel() {
+	s.cancelOnce.Do(func() {
+		close(s.cancelled)
+	})
+}